@@ -6,12 +6,13 @@ package drawing
 
 import (
 	"fmt"
+	"image"
 	"os"
-	"syscall"
 	"unsafe"
 )
 
 import (
+	. "walk/winapi"
 	. "walk/winapi/gdi32"
 	. "walk/winapi/gdiplus"
 	. "walk/winapi/kernel32"
@@ -58,35 +59,98 @@ type Bitmap struct {
 	hBmp       HBITMAP
 	hPackedDIB HGLOBAL
 	size       Size
+	// img holds the pixels of a bitmap created by NewBitmapFromImage, until
+	// realize moves them into a GDI bitmap.
+	img *image.RGBA
 }
 
-func newBitmapFromHBITMAP(hBmp HBITMAP) (bmp *Bitmap, err os.Error) {
+// dibFromHBITMAP returns a header describing the pixels of hBmp along with a
+// copy of them. The pixels of a DIB section are copied as they are. Device
+// dependent bitmaps, for which GetObject reports no bits, are converted to 32
+// bits per pixel using GetDIBits.
+func dibFromHBITMAP(hBmp HBITMAP) (bmih BITMAPINFOHEADER, pixels []byte, err os.Error) {
 	var dib DIBSECTION
 	if GetObject(HGDIOBJ(hBmp), unsafe.Sizeof(dib), unsafe.Pointer(&dib)) == 0 {
-		return nil, newError("GetObject failed")
+		return bmih, nil, newError("GetObject failed")
 	}
 
-	bmih := &dib.DsBmih
+	height := dib.DsBm.BmHeight
+	if height < 0 {
+		height = -height
+	}
 
-	bmihSize := uintptr(unsafe.Sizeof(*bmih))
-	pixelsSize := uintptr(int(bmih.BiBitCount)*bmih.BiWidth*bmih.BiHeight) / 8
+	if dib.DsBm.BmBits != nil {
+		// The rows of a DIB section are DWORD aligned, BmWidthBytes includes
+		// the padding.
+		pixelsSize := dib.DsBm.BmWidthBytes * height
 
-	totalSize := uintptr(bmihSize + pixelsSize)
+		bmih = dib.DsBmih
+		bmih.BiSize = uint(unsafe.Sizeof(bmih))
+		bmih.BiSizeImage = uint(pixelsSize)
 
-	hPackedDIB := GlobalAlloc(GHND, totalSize)
+		pixels = make([]byte, pixelsSize)
+		if pixelsSize > 0 {
+			MoveMemory(unsafe.Pointer(&pixels[0]), dib.DsBm.BmBits, uintptr(pixelsSize))
+		}
+
+		return bmih, pixels, nil
+	}
+
+	var bmi BITMAPINFO
+	hdr := &bmi.BmiHeader
+	hdr.BiSize = uint(unsafe.Sizeof(*hdr))
+	hdr.BiWidth = dib.DsBm.BmWidth
+	hdr.BiHeight = height
+	hdr.BiPlanes = 1
+	hdr.BiBitCount = 32
+	hdr.BiCompression = BI_RGB
+	hdr.BiSizeImage = uint(dib.DsBm.BmWidth * 4 * height)
+
+	pixels = make([]byte, hdr.BiSizeImage)
+	if len(pixels) > 0 {
+		err = withCompatibleDC(func(hdc HDC) os.Error {
+			if GetDIBits(hdc, hBmp, 0, uint(height), unsafe.Pointer(&pixels[0]), &bmi, DIB_RGB_COLORS) == 0 {
+				return newError("GetDIBits failed")
+			}
+
+			return nil
+		})
+		if err != nil {
+			return bmih, nil, err
+		}
+	}
+
+	return *hdr, pixels, nil
+}
+
+func newBitmapFromHBITMAP(hBmp HBITMAP) (bmp *Bitmap, err os.Error) {
+	bmih, pixels, err := dibFromHBITMAP(hBmp)
+	if err != nil {
+		return nil, err
+	}
+
+	bmihSize := uintptr(unsafe.Sizeof(bmih))
+	pixelsSize := uintptr(len(pixels))
+
+	hPackedDIB := GlobalAlloc(GHND, bmihSize+pixelsSize)
+	if hPackedDIB == 0 {
+		return nil, newError("GlobalAlloc failed")
+	}
 	dest := GlobalLock(hPackedDIB)
 	defer GlobalUnlock(hPackedDIB)
 
-	src := unsafe.Pointer(&dib.DsBmih)
+	MoveMemory(dest, unsafe.Pointer(&bmih), bmihSize)
 
-	MoveMemory(dest, src, bmihSize)
-
-	dest = unsafe.Pointer(uintptr(dest) + bmihSize)
-	src = dib.DsBm.BmBits
+	if pixelsSize > 0 {
+		MoveMemory(unsafe.Pointer(uintptr(dest)+bmihSize), unsafe.Pointer(&pixels[0]), pixelsSize)
+	}
 
-	MoveMemory(dest, src, pixelsSize)
+	height := bmih.BiHeight
+	if height < 0 {
+		height = -height
+	}
 
-	return &Bitmap{hBmp: hBmp, hPackedDIB: hPackedDIB, size: Size{bmih.BiWidth, bmih.BiHeight}}, nil
+	return &Bitmap{hBmp: hBmp, hPackedDIB: hPackedDIB, size: Size{bmih.BiWidth, height}}, nil
 }
 
 func NewBitmap(size Size) (bmp *Bitmap, err os.Error) {
@@ -115,7 +179,7 @@ func NewBitmap(size Size) (bmp *Bitmap, err os.Error) {
 
 func NewBitmapFromFile(filePath string) (*Bitmap, os.Error) {
 	var gpBmp *GpBitmap
-	if status := GdipCreateBitmapFromFile(syscall.StringToUTF16Ptr(filePath), &gpBmp); status != Ok {
+	if status := GdipCreateBitmapFromFile(StringToUTF16Ptr(filePath), &gpBmp); status != Ok {
 		return nil, newError(fmt.Sprintf("GdipCreateBitmapFromFile failed with status '%s' for file '%s'", status, filePath))
 	}
 	defer GdipDisposeImage((*GpImage)(gpBmp))
@@ -128,7 +192,191 @@ func NewBitmapFromFile(filePath string) (*Bitmap, os.Error) {
 	return newBitmapFromHBITMAP(hBmp)
 }
 
+// NewBitmapFromImage returns a new Bitmap with a copy of the pixels of img.
+//
+// The GDI bitmap is only created once the Bitmap is drawn on a GDISurface or
+// its Handle is used, so a RasterSurface can draw it where GDI is not
+// available.
+func NewBitmapFromImage(img image.Image) (*Bitmap, os.Error) {
+	if img == nil {
+		return nil, newError("img cannot be nil")
+	}
+
+	rgba := copyImage(img)
+	r := rgba.Bounds()
+
+	return &Bitmap{img: rgba, size: Size{r.Dx(), r.Dy()}}, nil
+}
+
+// copyImage returns a copy of img, moved to the origin.
+func copyImage(img image.Image) *image.RGBA {
+	r := img.Bounds()
+	rgba := image.NewRGBA(r.Dx(), r.Dy())
+
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			rgba.Set(x-r.Min.X, y-r.Min.Y, img.At(x, y))
+		}
+	}
+
+	return rgba
+}
+
+// NewBitmapFromPackedDIB returns a new Bitmap with a copy of the pixels of
+// data, a packed DIB as e.g. found on the clipboard in CF_DIB format. Only
+// uncompressed DIBs with 24 or 32 bits per pixel are supported.
+func NewBitmapFromPackedDIB(data []byte) (bmp *Bitmap, err os.Error) {
+	var bmi BITMAPINFO
+	hdr := &bmi.BmiHeader
+	hdrSize := int(unsafe.Sizeof(*hdr))
+
+	if len(data) < hdrSize {
+		return nil, newError("packed DIB too short")
+	}
+
+	bmih := (*BITMAPINFOHEADER)(unsafe.Pointer(&data[0]))
+
+	if bmih.BiBitCount != 24 && bmih.BiBitCount != 32 {
+		return nil, newError("unsupported bit count")
+	}
+
+	offset := int(bmih.BiSize) + int(bmih.BiClrUsed)*4
+
+	switch bmih.BiCompression {
+	case BI_RGB:
+
+	case BI_BITFIELDS:
+		// The color masks follow a BITMAPINFOHEADER, newer headers include
+		// them. Only the default masks are used in practice.
+		if int(bmih.BiSize) == hdrSize {
+			offset += 12
+		}
+
+	default:
+		return nil, newError("unsupported compression")
+	}
+
+	height := bmih.BiHeight
+	if height < 0 {
+		height = -height
+	}
+
+	stride := (bmih.BiWidth*int(bmih.BiBitCount)/8 + 3) &^ 3
+	pixelsSize := stride * height
+
+	if len(data) < offset+pixelsSize {
+		return nil, newError("packed DIB too short")
+	}
+
+	hdr.BiSize = uint(hdrSize)
+	hdr.BiWidth = bmih.BiWidth
+	hdr.BiHeight = bmih.BiHeight
+	hdr.BiPlanes = 1
+	hdr.BiBitCount = bmih.BiBitCount
+	hdr.BiCompression = BI_RGB
+
+	err = withCompatibleDC(func(hdc HDC) os.Error {
+		var bits unsafe.Pointer
+
+		hBmp := CreateDIBSection(hdc, &bmi, DIB_RGB_COLORS, &bits, 0, 0)
+		switch hBmp {
+		case 0, ERROR_INVALID_PARAMETER:
+			return newError("CreateDIBSection failed")
+		}
+
+		if pixelsSize > 0 {
+			MoveMemory(bits, unsafe.Pointer(&data[offset]), uintptr(pixelsSize))
+		}
+
+		bmp, err = newBitmapFromHBITMAP(hBmp)
+		return err
+	})
+
+	return
+}
+
+// PackedDIB returns a copy of the bitmap as packed DIB, a BITMAPINFOHEADER
+// followed by the pixels, e.g. to put it on the clipboard in CF_DIB format.
+func (bmp *Bitmap) PackedDIB() ([]byte, os.Error) {
+	if bmp.img != nil {
+		return packedDIBFromRGBA(bmp.img), nil
+	}
+
+	bmih, pixels, err := dibFromHBITMAP(bmp.hBmp)
+	if err != nil {
+		return nil, err
+	}
+
+	hdrSize := int(unsafe.Sizeof(bmih))
+
+	data := make([]byte, hdrSize+len(pixels))
+
+	MoveMemory(unsafe.Pointer(&data[0]), unsafe.Pointer(&bmih), uintptr(hdrSize))
+	copy(data[hdrSize:], pixels)
+
+	return data, nil
+}
+
+// packedDIBFromRGBA returns the pixels of img as a bottom-up packed DIB with 24
+// bits per pixel.
+func packedDIBFromRGBA(img *image.RGBA) []byte {
+	r := img.Bounds()
+	width, height := r.Dx(), r.Dy()
+
+	var bmih BITMAPINFOHEADER
+	hdrSize := int(unsafe.Sizeof(bmih))
+	stride := (width*3 + 3) &^ 3
+
+	bmih.BiSize = uint(hdrSize)
+	bmih.BiWidth = width
+	bmih.BiHeight = height
+	bmih.BiPlanes = 1
+	bmih.BiBitCount = 24
+	bmih.BiCompression = BI_RGB
+	bmih.BiSizeImage = uint(stride * height)
+
+	data := make([]byte, hdrSize+stride*height)
+	*(*BITMAPINFOHEADER)(unsafe.Pointer(&data[0])) = bmih
+
+	for y := 0; y < height; y++ {
+		row := data[hdrSize+(height-1-y)*stride:]
+
+		for x := 0; x < width; x++ {
+			c := colorFromImageColor(img.At(r.Min.X+x, r.Min.Y+y))
+
+			row[x*3] = c.B()
+			row[x*3+1] = c.G()
+			row[x*3+2] = c.R()
+		}
+	}
+
+	return data
+}
+
+// realize creates the GDI bitmap of a bitmap created by NewBitmapFromImage.
+// From then on, the GDI bitmap holds the pixels.
+func (bmp *Bitmap) realize() os.Error {
+	if bmp.img == nil {
+		return nil
+	}
+
+	realized, err := NewBitmapFromPackedDIB(packedDIBFromRGBA(bmp.img))
+	if err != nil {
+		return err
+	}
+
+	bmp.hBmp = realized.hBmp
+	bmp.hPackedDIB = realized.hPackedDIB
+	bmp.img = nil
+
+	return nil
+}
+
 func (bmp *Bitmap) withSelectedIntoMemDC(f func(hdcMem HDC) os.Error) os.Error {
+	if err := bmp.realize(); err != nil {
+		return err
+	}
+
 	return withCompatibleDC(func(hdcMem HDC) os.Error {
 		hBmpOld := SelectObject(hdcMem, HGDIOBJ(bmp.hBmp))
 		if hBmpOld == 0 {
@@ -164,11 +412,80 @@ func (bmp *Bitmap) drawStretched(hdc HDC, bounds Rectangle) os.Error {
 	})
 }
 
+// toRGBA returns a copy of the current pixels of the bitmap. The image of a
+// bitmap that has not been realized is returned as it is, without using GDI.
+func (bmp *Bitmap) toRGBA() (*image.RGBA, os.Error) {
+	if bmp.img != nil {
+		return bmp.img, nil
+	}
+
+	bmih, pixels, err := dibFromHBITMAP(bmp.hBmp)
+	if err != nil {
+		return nil, err
+	}
+
+	bytesPerPixel := int(bmih.BiBitCount) / 8
+	if bytesPerPixel != 3 && bytesPerPixel != 4 {
+		return nil, newError("unsupported bit count")
+	}
+
+	height := bmih.BiHeight
+	if height < 0 {
+		height = -height
+	}
+
+	stride := 0
+	if height > 0 {
+		stride = len(pixels) / height
+	}
+
+	return rgbaFromDIBPixels(pixels, bmih.BiWidth, height, stride, bytesPerPixel, bmih.BiHeight > 0), nil
+}
+
+// rgbaFromDIBPixels converts the BGR(A) pixels of a DIB with rows of stride
+// bytes into an image. DIBs are stored bottom-up unless their height is
+// negative.
+func rgbaFromDIBPixels(pixels []byte, width, height, stride, bytesPerPixel int, bottomUp bool) *image.RGBA {
+	img := image.NewRGBA(width, height)
+
+	for y := 0; y < height; y++ {
+		row := y
+		if bottomUp {
+			row = height - 1 - y
+		}
+
+		for x := 0; x < width; x++ {
+			i := row*stride + x*bytesPerPixel
+
+			img.Set(x, y, image.RGBAColor{pixels[i+2], pixels[i+1], pixels[i], 0xff})
+		}
+	}
+
+	return img
+}
+
+// ToImage returns a copy of the pixels of the bitmap. Like toRGBA, it only
+// needs GDI for bitmaps that were not created by NewBitmapFromImage.
+func (bmp *Bitmap) ToImage() (*image.RGBA, os.Error) {
+	if bmp.img != nil {
+		return copyImage(bmp.img), nil
+	}
+
+	return bmp.toRGBA()
+}
+
+// Handle returns the GDI bitmap, or 0 if it cannot be created.
 func (bmp *Bitmap) Handle() HBITMAP {
+	if err := bmp.realize(); err != nil {
+		return 0
+	}
+
 	return bmp.hBmp
 }
 
 func (bmp *Bitmap) Dispose() {
+	bmp.img = nil
+
 	if bmp.hBmp != 0 {
 		DeleteObject(HGDIOBJ(bmp.hBmp))
 
@@ -23,6 +23,11 @@ const (
 	HatchDiagonalCross    HatchStyle = HS_DIAGCROSS
 )
 
+// Brush is implemented by the brushes of this package.
+//
+// Brushes create their GDI brush on first use by a GDISurface, so they can be
+// used with a RasterSurface where GDI is not available. If the GDI brush
+// cannot be created, drawing with the brush fails.
 type Brush interface {
 	Dispose()
 	handle() HBRUSH
@@ -33,17 +38,6 @@ type nullBrush struct {
 	hBrush HBRUSH
 }
 
-func newNullBrush() *nullBrush {
-	lb := &LOGBRUSH{LbStyle: BS_NULL}
-
-	hBrush := CreateBrushIndirect(lb)
-	if hBrush == 0 {
-		panic("failed to create null brush")
-	}
-
-	return &nullBrush{hBrush: hBrush}
-}
-
 func (b *nullBrush) Dispose() {
 	if b.hBrush != 0 {
 		DeleteObject(HGDIOBJ(b.hBrush))
@@ -53,6 +47,10 @@ func (b *nullBrush) Dispose() {
 }
 
 func (b *nullBrush) handle() HBRUSH {
+	if b.hBrush == 0 {
+		b.hBrush = CreateBrushIndirect(b.logbrush())
+	}
+
 	return b.hBrush
 }
 
@@ -60,7 +58,7 @@ func (b *nullBrush) logbrush() *LOGBRUSH {
 	return &LOGBRUSH{LbStyle: BS_NULL}
 }
 
-var nullBrushSingleton Brush = newNullBrush()
+var nullBrushSingleton Brush = new(nullBrush)
 
 func NullBrush() Brush {
 	return nullBrushSingleton
@@ -72,14 +70,7 @@ type SolidColorBrush struct {
 }
 
 func NewSolidColorBrush(color Color) (*SolidColorBrush, os.Error) {
-	lb := &LOGBRUSH{LbStyle: BS_SOLID, LbColor: COLORREF(color)}
-
-	hBrush := CreateBrushIndirect(lb)
-	if hBrush == 0 {
-		return nil, newError("CreateBrushIndirect failed")
-	}
-
-	return &SolidColorBrush{hBrush: hBrush, color: color}, nil
+	return &SolidColorBrush{color: color}, nil
 }
 
 func (b *SolidColorBrush) Color() Color {
@@ -95,6 +86,10 @@ func (b *SolidColorBrush) Dispose() {
 }
 
 func (b *SolidColorBrush) handle() HBRUSH {
+	if b.hBrush == 0 {
+		b.hBrush = CreateBrushIndirect(b.logbrush())
+	}
+
 	return b.hBrush
 }
 
@@ -109,14 +104,7 @@ type HatchBrush struct {
 }
 
 func NewHatchBrush(color Color, style HatchStyle) (*HatchBrush, os.Error) {
-	lb := &LOGBRUSH{LbStyle: BS_HATCHED, LbColor: COLORREF(color), LbHatch: uintptr(style)}
-
-	hBrush := CreateBrushIndirect(lb)
-	if hBrush == 0 {
-		return nil, newError("CreateBrushIndirect failed")
-	}
-
-	return &HatchBrush{hBrush: hBrush, color: color, style: style}, nil
+	return &HatchBrush{color: color, style: style}, nil
 }
 
 func (b *HatchBrush) Color() Color {
@@ -132,6 +120,10 @@ func (b *HatchBrush) Dispose() {
 }
 
 func (b *HatchBrush) handle() HBRUSH {
+	if b.hBrush == 0 {
+		b.hBrush = CreateBrushIndirect(b.logbrush())
+	}
+
 	return b.hBrush
 }
 
@@ -153,14 +145,7 @@ func NewBitmapBrush(bitmap *Bitmap) (*BitmapBrush, os.Error) {
 		return nil, newError("bitmap cannot be nil")
 	}
 
-	lb := &LOGBRUSH{LbStyle: BS_DIBPATTERN, LbColor: DIB_RGB_COLORS, LbHatch: uintptr(bitmap.hPackedDIB)}
-
-	hBrush := CreateBrushIndirect(lb)
-	if hBrush == 0 {
-		return nil, newError("CreateBrushIndirect failed")
-	}
-
-	return &BitmapBrush{hBrush: hBrush, bitmap: bitmap}, nil
+	return &BitmapBrush{bitmap: bitmap}, nil
 }
 
 func (b *BitmapBrush) Dispose() {
@@ -172,10 +157,16 @@ func (b *BitmapBrush) Dispose() {
 }
 
 func (b *BitmapBrush) handle() HBRUSH {
+	if b.hBrush == 0 {
+		b.hBrush = CreateBrushIndirect(b.logbrush())
+	}
+
 	return b.hBrush
 }
 
 func (b *BitmapBrush) logbrush() *LOGBRUSH {
+	b.bitmap.realize()
+
 	return &LOGBRUSH{LbStyle: BS_DIBPATTERN, LbColor: DIB_RGB_COLORS, LbHatch: uintptr(b.bitmap.hPackedDIB)}
 }
 
@@ -6,7 +6,6 @@ package drawing
 
 import (
 	"os"
-	"syscall"
 )
 
 import (
@@ -26,17 +25,18 @@ const (
 	FontStrikeOut FontStyle = 0x08
 )
 
-var (
-	screenDPIX int
-	screenDPIY int
-)
+var screenDPIY int
+
+// screenDPI returns the vertical DPI of the screen. It is retrieved on first
+// use, so the package can be initialized without a display.
+func screenDPI() int {
+	if screenDPIY == 0 {
+		hDC := GetDC(0)
+		defer ReleaseDC(0, hDC)
+		screenDPIY = GetDeviceCaps(hDC, LOGPIXELSY)
+	}
 
-func init() {
-	// Retrieve screen DPI
-	hDC := GetDC(0)
-	defer ReleaseDC(0, hDC)
-	screenDPIX = GetDeviceCaps(hDC, LOGPIXELSX)
-	screenDPIY = GetDeviceCaps(hDC, LOGPIXELSY)
+	return screenDPIY
 }
 
 // Font represents a typographic typeface that is used for text drawing
@@ -49,6 +49,8 @@ type Font struct {
 }
 
 // NewFont returns a new Font with the specified attributes.
+//
+// The os resources of the Font are created on first use by HandleForDPI.
 func NewFont(family string, pointSize float, style FontStyle) (*Font, os.Error) {
 	if style > FontBold|FontItalic|FontUnderline|FontStrikeOut {
 		return nil, newError("invalid style")
@@ -61,14 +63,6 @@ func NewFont(family string, pointSize float, style FontStyle) (*Font, os.Error)
 		dpi2hFont: make(map[int]HFONT),
 	}
 
-	hFont := font.createForDPI(screenDPIY)
-	if hFont == 0 {
-		return nil, newError("CreateFontIndirect failed")
-	}
-
-	font.dpi2hFont[screenDPIY] = hFont
-	font.dpi2hFont[0] = hFont // Make HFONT for screen easier accessible.
-
 	return font, nil
 }
 
@@ -124,7 +118,7 @@ func (f *Font) createForDPI(dpi int) HFONT {
 	lf.LfQuality = CLEARTYPE_QUALITY
 	lf.LfPitchAndFamily = VARIABLE_PITCH | FF_SWISS
 
-	src := syscall.StringToUTF16(f.family)
+	src := StringToUTF16(f.family)
 	dest := lf.LfFaceName[:]
 	copy(dest, src)
 
@@ -143,9 +137,7 @@ func (f *Font) Bold() bool {
 // after calling this method. It is safe to call Dispose multiple times.
 func (f *Font) Dispose() {
 	for dpi, hFont := range f.dpi2hFont {
-		if dpi != 0 {
-			DeleteObject(HGDIOBJ(hFont))
-		}
+		DeleteObject(HGDIOBJ(hFont))
 
 		f.dpi2hFont[dpi] = 0, false
 	}
@@ -166,6 +158,10 @@ func (f *Font) Italic() bool {
 //
 // A value of 0 returns a HFONT suitable for the screen.
 func (f *Font) HandleForDPI(dpi int) HFONT {
+	if dpi == 0 {
+		dpi = screenDPI()
+	}
+
 	hFont := f.dpi2hFont[dpi]
 	if hFont == 0 {
 		hFont = f.createForDPI(dpi)
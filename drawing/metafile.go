@@ -6,11 +6,11 @@ package drawing
 
 import (
 	"os"
-	"syscall"
 	"unsafe"
 )
 
 import (
+	. "walk/winapi"
 	. "walk/winapi/gdi32"
 )
 
@@ -20,7 +20,7 @@ type Metafile struct {
 	size Size
 }
 
-func NewMetafile(referenceSurface *Surface) (*Metafile, os.Error) {
+func NewMetafile(referenceSurface *GDISurface) (*Metafile, os.Error) {
 	var rc RECT
 
 	hdc := CreateEnhMetaFile(referenceSurface.hdc, nil, &rc, nil)
@@ -32,7 +32,7 @@ func NewMetafile(referenceSurface *Surface) (*Metafile, os.Error) {
 }
 
 func NewMetafileFromFile(filePath string) (*Metafile, os.Error) {
-	hemf := GetEnhMetaFile(syscall.StringToUTF16Ptr(filePath))
+	hemf := GetEnhMetaFile(StringToUTF16Ptr(filePath))
 	if hemf == 0 {
 		return nil, newError("GetEnhMetaFile failed")
 	}
@@ -58,7 +58,7 @@ func (mf *Metafile) Dispose() {
 }
 
 func (mf *Metafile) Save(filePath string) os.Error {
-	hemf := CopyEnhMetaFile(mf.hemf, syscall.StringToUTF16Ptr(filePath))
+	hemf := CopyEnhMetaFile(mf.hemf, StringToUTF16Ptr(filePath))
 	if hemf == 0 {
 		return newError("CopyEnhMetaFile failed")
 	}
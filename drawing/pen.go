@@ -41,6 +41,11 @@ const (
 	PenJoinRound PenStyle = PS_JOIN_ROUND
 )
 
+// Pen is implemented by the pens of this package.
+//
+// Pens create their GDI pen on first use by a GDISurface, so they can be used
+// with a RasterSurface where GDI is not available. If the GDI pen cannot be
+// created, drawing with the pen fails.
 type Pen interface {
 	handle() HPEN
 	Dispose()
@@ -52,17 +57,6 @@ type nullPen struct {
 	hPen HPEN
 }
 
-func newNullPen() *nullPen {
-	lb := &LOGBRUSH{LbStyle: BS_NULL}
-
-	hPen := ExtCreatePen(PS_COSMETIC|PS_NULL, 1, lb, 0, nil)
-	if hPen == 0 {
-		panic("failed to create null brush")
-	}
-
-	return &nullPen{hPen: hPen}
-}
-
 func (p *nullPen) Dispose() {
 	if p.hPen != 0 {
 		DeleteObject(HGDIOBJ(p.hPen))
@@ -72,6 +66,12 @@ func (p *nullPen) Dispose() {
 }
 
 func (p *nullPen) handle() HPEN {
+	if p.hPen == 0 {
+		lb := &LOGBRUSH{LbStyle: BS_NULL}
+
+		p.hPen = ExtCreatePen(PS_COSMETIC|PS_NULL, 1, lb, 0, nil)
+	}
+
 	return p.hPen
 }
 
@@ -83,7 +83,7 @@ func (p *nullPen) Width() int {
 	return 0
 }
 
-var nullPenSingleton Pen = newNullPen()
+var nullPenSingleton Pen = new(nullPen)
 
 func NullPen() Pen {
 	return nullPenSingleton
@@ -96,16 +96,7 @@ type CosmeticPen struct {
 }
 
 func NewCosmeticPen(style PenStyle, color Color) (*CosmeticPen, os.Error) {
-	lb := &LOGBRUSH{LbStyle: BS_SOLID, LbColor: COLORREF(color)}
-
-	style |= PS_COSMETIC
-
-	hPen := ExtCreatePen(uint(style), 1, lb, 0, nil)
-	if hPen == 0 {
-		return nil, newError("ExtCreatePen failed")
-	}
-
-	return &CosmeticPen{hPen: hPen, style: style, color: color}, nil
+	return &CosmeticPen{style: style | PS_COSMETIC, color: color}, nil
 }
 
 func (p *CosmeticPen) Dispose() {
@@ -117,6 +108,12 @@ func (p *CosmeticPen) Dispose() {
 }
 
 func (p *CosmeticPen) handle() HPEN {
+	if p.hPen == 0 {
+		lb := &LOGBRUSH{LbStyle: BS_SOLID, LbColor: COLORREF(p.color)}
+
+		p.hPen = ExtCreatePen(uint(p.style), 1, lb, 0, nil)
+	}
+
 	return p.hPen
 }
 
@@ -144,14 +141,7 @@ func NewGeometricPen(style PenStyle, width int, brush Brush) (*GeometricPen, os.
 		return nil, newError("brush cannot be nil")
 	}
 
-	style |= PS_GEOMETRIC
-
-	hPen := ExtCreatePen(uint(style), uint(width), brush.logbrush(), 0, nil)
-	if hPen == 0 {
-		return nil, newError("ExtCreatePen failed")
-	}
-
-	return &GeometricPen{hPen: hPen, style: style, width: width, brush: brush}, nil
+	return &GeometricPen{style: style | PS_GEOMETRIC, width: width, brush: brush}, nil
 }
 
 func (p *GeometricPen) Dispose() {
@@ -163,6 +153,10 @@ func (p *GeometricPen) Dispose() {
 }
 
 func (p *GeometricPen) handle() HPEN {
+	if p.hPen == 0 {
+		p.hPen = ExtCreatePen(uint(p.style), uint(p.width), p.brush.logbrush(), 0, nil)
+	}
+
 	return p.hPen
 }
 
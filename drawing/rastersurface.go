@@ -0,0 +1,821 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package drawing
+
+import (
+	"image"
+	"math"
+	"os"
+)
+
+import (
+	. "walk/winapi/gdi32"
+)
+
+// paint returns the color of the pixel at x, y or false, if the pixel should
+// be left untouched.
+type paint func(x, y int) (Color, bool)
+
+// RasterSurface is a Surface that rasterizes all drawing operations into an
+// in-memory RGBA image, without using GDI.
+//
+// The results follow the GDI conventions of GDISurface closely, but are not
+// pixel identical. Text is rendered using a built-in bitmap font that is
+// scaled to the size of the Font, the family of the Font is ignored.
+type RasterSurface struct {
+	img    *image.RGBA
+	bounds Rectangle
+	dpi    int
+}
+
+// NewRasterSurface returns a new RasterSurface of the specified size, that is
+// initially filled with white.
+func NewRasterSurface(size Size) (*RasterSurface, os.Error) {
+	if size.Width <= 0 || size.Height <= 0 {
+		return nil, newError("invalid size")
+	}
+
+	s := &RasterSurface{
+		img:    image.NewRGBA(size.Width, size.Height),
+		bounds: Rectangle{Width: size.Width, Height: size.Height},
+		dpi:    96,
+	}
+
+	white := RGB(255, 255, 255)
+	s.fill(func(x, y int) (Color, bool) { return white, true }, s.bounds)
+
+	return s, nil
+}
+
+// NewRasterSurfaceFromRGBA returns a new RasterSurface that draws into img.
+func NewRasterSurfaceFromRGBA(img *image.RGBA) (*RasterSurface, os.Error) {
+	if img == nil {
+		return nil, newError("img cannot be nil")
+	}
+
+	r := img.Bounds()
+
+	return &RasterSurface{
+		img:    img,
+		bounds: Rectangle{r.Min.X, r.Min.Y, r.Dx(), r.Dy()},
+		dpi:    96,
+	}, nil
+}
+
+// Image returns the image the RasterSurface draws into.
+func (s *RasterSurface) Image() *image.RGBA {
+	return s.img
+}
+
+// DPI returns the resolution that is used to convert Font point sizes into
+// pixels.
+func (s *RasterSurface) DPI() int {
+	return s.dpi
+}
+
+// SetDPI sets the resolution that is used to convert Font point sizes into
+// pixels.
+//
+// The default is 96, the usual screen resolution. Print previews will want to
+// use the resolution of the printer instead.
+func (s *RasterSurface) SetDPI(value int) os.Error {
+	if value <= 0 {
+		return newError("invalid dpi")
+	}
+
+	s.dpi = value
+
+	return nil
+}
+
+// Dispose does nothing. The image remains valid.
+func (s *RasterSurface) Dispose() {
+}
+
+func (s *RasterSurface) Bounds() Rectangle {
+	return s.bounds
+}
+
+func (s *RasterSurface) setPixel(x, y int, color Color) {
+	if x < s.bounds.X || y < s.bounds.Y || x >= s.bounds.X+s.bounds.Width || y >= s.bounds.Y+s.bounds.Height {
+		return
+	}
+
+	s.img.Set(x, y, image.RGBAColor{color.R(), color.G(), color.B(), 0xff})
+}
+
+func (s *RasterSurface) fill(p paint, bounds Rectangle) {
+	for y := bounds.Y; y < bounds.Y+bounds.Height; y++ {
+		for x := bounds.X; x < bounds.X+bounds.Width; x++ {
+			if color, ok := p(x, y); ok {
+				s.setPixel(x, y, color)
+			}
+		}
+	}
+}
+
+func colorFromImageColor(c image.Color) Color {
+	r, g, b, _ := c.RGBA()
+
+	return RGB(byte(r>>8), byte(g>>8), byte(b>>8))
+}
+
+func hatchContains(style HatchStyle, x, y int) bool {
+	switch style {
+	case HatchHorizontal:
+		return y&7 == 0
+
+	case HatchVertical:
+		return x&7 == 0
+
+	case HatchForwardDiagonal:
+		return (x-y)&7 == 0
+
+	case HatchBackwardDiagonal:
+		return (x+y)&7 == 0
+
+	case HatchCross:
+		return x&7 == 0 || y&7 == 0
+
+	case HatchDiagonalCross:
+		return (x-y)&7 == 0 || (x+y)&7 == 0
+	}
+
+	return false
+}
+
+func brushPaint(brush Brush) (paint, os.Error) {
+	switch b := brush.(type) {
+	case *nullBrush:
+		return nil, nil
+
+	case *SolidColorBrush:
+		color := b.color
+
+		return func(x, y int) (Color, bool) {
+			return color, true
+		}, nil
+
+	case *HatchBrush:
+		color, style := b.color, b.style
+
+		// The background is transparent, like on a GDISurface.
+		return func(x, y int) (Color, bool) {
+			return color, hatchContains(style, x, y)
+		}, nil
+
+	case *BitmapBrush:
+		img, err := b.bitmap.toRGBA()
+		if err != nil {
+			return nil, err
+		}
+
+		size := b.bitmap.Size()
+		if size.Width <= 0 || size.Height <= 0 {
+			return nil, nil
+		}
+
+		// The pattern is aligned to the surface origin, like the GDI brush
+		// origin.
+		return func(x, y int) (Color, bool) {
+			x %= size.Width
+			if x < 0 {
+				x += size.Width
+			}
+			y %= size.Height
+			if y < 0 {
+				y += size.Height
+			}
+
+			return colorFromImageColor(img.At(x, y)), true
+		}, nil
+
+	case nil:
+		return nil, newError("brush cannot be nil")
+	}
+
+	return nil, newError("unsupported brush type")
+}
+
+// penPainter draws the pixels of lines using a Pen, keeping track of the
+// position within the dash pattern across line segments.
+type penPainter struct {
+	paint   paint
+	width   int
+	pattern []int
+	pos     int
+}
+
+func newPenPainter(pen Pen) (*penPainter, os.Error) {
+	var p paint
+	var err os.Error
+
+	switch pen := pen.(type) {
+	case *nullPen:
+		return nil, nil
+
+	case *CosmeticPen:
+		color := pen.color
+
+		p = func(x, y int) (Color, bool) {
+			return color, true
+		}
+
+	case *GeometricPen:
+		if p, err = brushPaint(pen.brush); err != nil {
+			return nil, err
+		}
+
+	case nil:
+		return nil, newError("pen cannot be nil")
+
+	default:
+		return nil, newError("unsupported pen type")
+	}
+
+	if p == nil {
+		return nil, nil
+	}
+
+	width := pen.Width()
+	if width < 1 {
+		width = 1
+	}
+
+	var pattern []int
+	switch pen.Style() & PS_STYLE_MASK {
+	case PenNull:
+		return nil, nil
+
+	case PenDash:
+		pattern = []int{18, 6}
+
+	case PenDot:
+		pattern = []int{3, 3}
+
+	case PenDashDot:
+		pattern = []int{9, 6, 3, 6}
+
+	case PenDashDotDot:
+		pattern = []int{9, 3, 3, 3, 3, 3}
+
+	case PenAlternate:
+		pattern = []int{1, 1}
+	}
+
+	// Geometric pens scale their dashes with the width.
+	for i := range pattern {
+		pattern[i] *= width
+	}
+
+	return &penPainter{paint: p, width: width, pattern: pattern}, nil
+}
+
+func (pp *penPainter) on() bool {
+	if pp.pattern == nil {
+		return true
+	}
+
+	var period int
+	for _, n := range pp.pattern {
+		period += n
+	}
+
+	pos := pp.pos % period
+	for i, n := range pp.pattern {
+		if pos < n {
+			return i%2 == 0
+		}
+		pos -= n
+	}
+
+	return false
+}
+
+func (pp *penPainter) plot(s *RasterSurface, x, y int) {
+	if pp.on() {
+		x0 := x - pp.width/2
+		y0 := y - pp.width/2
+
+		for py := y0; py < y0+pp.width; py++ {
+			for px := x0; px < x0+pp.width; px++ {
+				if color, ok := pp.paint(px, py); ok {
+					s.setPixel(px, py, color)
+				}
+			}
+		}
+	}
+
+	pp.pos++
+}
+
+// line draws a line from from to to, excluding the end point like GDI does.
+func (pp *penPainter) line(s *RasterSurface, from, to Point) {
+	dx := to.X - from.X
+	if dx < 0 {
+		dx = -dx
+	}
+	dy := to.Y - from.Y
+	if dy > 0 {
+		dy = -dy
+	}
+
+	sx, sy := 1, 1
+	if from.X > to.X {
+		sx = -1
+	}
+	if from.Y > to.Y {
+		sy = -1
+	}
+
+	x, y := from.X, from.Y
+	e := dx + dy
+
+	for x != to.X || y != to.Y {
+		pp.plot(s, x, y)
+
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y += sy
+		}
+	}
+}
+
+func (s *RasterSurface) DrawLine(pen Pen, from, to Point) os.Error {
+	pp, err := newPenPainter(pen)
+	if err != nil || pp == nil {
+		return err
+	}
+
+	pp.line(s, from, to)
+
+	return nil
+}
+
+func (s *RasterSurface) DrawRectangle(pen Pen, bounds Rectangle) os.Error {
+	pp, err := newPenPainter(pen)
+	if err != nil || pp == nil {
+		return err
+	}
+
+	if bounds.Width <= 0 || bounds.Height <= 0 {
+		return nil
+	}
+
+	left, top, right, bottom := bounds.Left(), bounds.Top(), bounds.Right(), bounds.Bottom()
+
+	pp.line(s, Point{left, top}, Point{right, top})
+	pp.line(s, Point{right, top}, Point{right, bottom})
+	pp.line(s, Point{right, bottom}, Point{left, bottom})
+	pp.line(s, Point{left, bottom}, Point{left, top})
+
+	if left == right || top == bottom {
+		pp.plot(s, right, bottom)
+	}
+
+	return nil
+}
+
+func (s *RasterSurface) FillRectangle(brush Brush, bounds Rectangle) os.Error {
+	p, err := brushPaint(brush)
+	if err != nil || p == nil {
+		return err
+	}
+
+	s.fill(p, bounds)
+
+	return nil
+}
+
+func ellipseContains(bounds Rectangle, x, y int) bool {
+	if bounds.Width <= 0 || bounds.Height <= 0 {
+		return false
+	}
+
+	rx := float64(bounds.Width) / 2
+	ry := float64(bounds.Height) / 2
+	dx := (float64(x) + 0.5 - float64(bounds.X) - rx) / rx
+	dy := (float64(y) + 0.5 - float64(bounds.Y) - ry) / ry
+
+	return dx*dx+dy*dy <= 1
+}
+
+// ellipseOutlinePosition returns a function that maps pixels to their
+// position along the outline of the ellipse that fits into bounds, measured in
+// pixels counterclockwise from its rightmost point. Pen patterns are laid out
+// along the outline by these positions.
+func ellipseOutlinePosition(bounds Rectangle) func(x, y int) int {
+	const steps = 360
+
+	rx := float64(bounds.Width) / 2
+	ry := float64(bounds.Height) / 2
+	cx := float64(bounds.X) + rx
+	cy := float64(bounds.Y) + ry
+
+	// lengths[i] is the length of the outline up to the angle 2π*i/steps.
+	var lengths [steps + 1]float64
+	px, py := rx, 0.0
+	for i := 1; i <= steps; i++ {
+		t := 2 * math.Pi * float64(i) / steps
+		x, y := rx*math.Cos(t), -ry*math.Sin(t)
+		lengths[i] = lengths[i-1] + math.Hypot(x-px, y-py)
+		px, py = x, y
+	}
+
+	return func(x, y int) int {
+		t := math.Atan2(-(float64(y)+0.5-cy)/ry, (float64(x)+0.5-cx)/rx)
+		if t < 0 {
+			t += 2 * math.Pi
+		}
+
+		f := t / (2 * math.Pi) * steps
+		i := int(f)
+		if i >= steps {
+			i = steps - 1
+		}
+
+		return int(lengths[i] + (f-float64(i))*(lengths[i+1]-lengths[i]))
+	}
+}
+
+// DrawEllipse draws the outline of the ellipse that fits into bounds. The
+// outline is as wide as the pen, on the inside of bounds.
+func (s *RasterSurface) DrawEllipse(pen Pen, bounds Rectangle) os.Error {
+	pp, err := newPenPainter(pen)
+	if err != nil || pp == nil {
+		return err
+	}
+
+	if bounds.Width <= 0 || bounds.Height <= 0 {
+		return nil
+	}
+
+	w := pp.width
+	inner := Rectangle{bounds.X + w, bounds.Y + w, bounds.Width - 2*w, bounds.Height - 2*w}
+
+	position := ellipseOutlinePosition(bounds)
+
+	s.fill(func(x, y int) (Color, bool) {
+		if !ellipseContains(bounds, x, y) || ellipseContains(inner, x, y) {
+			return 0, false
+		}
+
+		if pp.pattern != nil {
+			pp.pos = position(x, y)
+			if !pp.on() {
+				return 0, false
+			}
+		}
+
+		return pp.paint(x, y)
+	}, bounds)
+
+	return nil
+}
+
+func (s *RasterSurface) FillEllipse(brush Brush, bounds Rectangle) os.Error {
+	p, err := brushPaint(brush)
+	if err != nil || p == nil {
+		return err
+	}
+
+	s.fill(func(x, y int) (Color, bool) {
+		if !ellipseContains(bounds, x, y) {
+			return 0, false
+		}
+
+		return p(x, y)
+	}, bounds)
+
+	return nil
+}
+
+func imageToRGBA(img Image) (*image.RGBA, os.Error) {
+	switch img := img.(type) {
+	case *Bitmap:
+		return img.toRGBA()
+
+	case nil:
+		return nil, newError("image cannot be nil")
+	}
+
+	return nil, newError("unsupported image type")
+}
+
+func (s *RasterSurface) DrawImage(image Image, location Point) os.Error {
+	src, err := imageToRGBA(image)
+	if err != nil {
+		return err
+	}
+
+	r := src.Bounds()
+
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			s.setPixel(location.X+x-r.Min.X, location.Y+y-r.Min.Y, colorFromImageColor(src.At(x, y)))
+		}
+	}
+
+	return nil
+}
+
+func (s *RasterSurface) DrawImageStretched(image Image, bounds Rectangle) os.Error {
+	src, err := imageToRGBA(image)
+	if err != nil {
+		return err
+	}
+
+	r := src.Bounds()
+	if r.Dx() == 0 || r.Dy() == 0 {
+		return nil
+	}
+
+	s.fill(func(x, y int) (Color, bool) {
+		sx := r.Min.X + (x-bounds.X)*r.Dx()/bounds.Width
+		sy := r.Min.Y + (y-bounds.Y)*r.Dy()/bounds.Height
+
+		return colorFromImageColor(src.At(sx, sy)), true
+	}, bounds)
+
+	return nil
+}
+
+// rasterFontScale returns by how much the glyphs of the built-in font are
+// scaled to approximate the pixel size of font.
+func (s *RasterSurface) rasterFontScale(font *Font) (int, os.Error) {
+	if font == nil {
+		return 0, newError("font cannot be nil")
+	}
+
+	pixelHeight := int(math.Floor(float64(font.PointSize())*float64(s.dpi)/72 + 0.5))
+
+	scale := (pixelHeight + rasterCellHeight/2) / rasterCellHeight
+	if scale < 1 {
+		scale = 1
+	}
+
+	return scale, nil
+}
+
+func (s *RasterSurface) FontHeight(font *Font) (height int, err os.Error) {
+	scale, err := s.rasterFontScale(font)
+	if err != nil {
+		return 0, err
+	}
+
+	return rasterCellHeight * scale, nil
+}
+
+type rasterTextLine struct {
+	runes []int
+	width int
+	// consumed is the number of runes of the text that are used up by the
+	// line, including line breaks and the space a line was wrapped at.
+	consumed int
+}
+
+// advance returns the horizontal position after the rune r, when drawn at x.
+func rasterAdvance(r int, x, cellWidth int, format DrawTextFormat) int {
+	switch r {
+	case '\r', '\n':
+		return x
+
+	case '\t':
+		if format&TextExpandTabs != 0 {
+			tabWidth := 8 * cellWidth
+			return (x/tabWidth + 1) * tabWidth
+		}
+	}
+
+	return x + cellWidth
+}
+
+func appendRasterTextLine(lines []rasterTextLine, line rasterTextLine) []rasterTextLine {
+	count := len(lines)
+	if count == cap(lines) {
+		grown := make([]rasterTextLine, count, count*2+1)
+		copy(grown, lines)
+		lines = grown
+	}
+
+	lines = lines[0 : count+1]
+	lines[count] = line
+
+	return lines
+}
+
+func layoutRasterText(runes []int, cellWidth, maxWidth int, format DrawTextFormat) []rasterTextLine {
+	var lines []rasterTextLine
+
+	singleLine := format&TextSingleLine != 0
+	wordbreak := format&TextWordbreak != 0 && !singleLine && maxWidth > 0
+
+	start := 0
+	for start < len(runes) || len(lines) == 0 {
+		end := start
+		x := 0
+		consumed := 0
+		lastSpace := -1
+
+		for end < len(runes) {
+			r := runes[end]
+
+			if r == '\n' && !singleLine {
+				consumed = end - start + 1
+				break
+			}
+
+			next := rasterAdvance(r, x, cellWidth, format)
+
+			if wordbreak && next > maxWidth && end > start {
+				if r == ' ' {
+					consumed = end - start + 1
+				} else if lastSpace > start {
+					end = lastSpace
+					consumed = end - start + 1
+				} else {
+					consumed = end - start
+				}
+				break
+			}
+
+			if r == ' ' {
+				lastSpace = end
+			}
+
+			x = next
+			end++
+		}
+
+		if consumed == 0 {
+			consumed = end - start
+		}
+
+		line := rasterTextLine{runes: runes[start:end], consumed: consumed}
+		for _, r := range line.runes {
+			line.width = rasterAdvance(r, line.width, cellWidth, format)
+		}
+
+		lines = appendRasterTextLine(lines, line)
+
+		if consumed == 0 {
+			break
+		}
+
+		start += consumed
+
+		if start == len(runes) && runes[start-1] == '\n' && !singleLine {
+			lines = appendRasterTextLine(lines, rasterTextLine{})
+		}
+	}
+
+	return lines
+}
+
+func (s *RasterSurface) drawGlyph(r int, x, y, scale int, font *Font, color Color, clip *Rectangle) {
+	glyph := rasterGlyph(r)
+
+	bold := 0
+	if font.Bold() {
+		bold = scale
+	}
+
+	for col := 0; col < rasterGlyphColumns; col++ {
+		bits := glyph[col]
+
+		for row := 0; row < rasterGlyphRows; row++ {
+			if bits&(1<<uint(row)) == 0 {
+				continue
+			}
+
+			px := x + col*scale
+			py := y + row*scale
+
+			if font.Italic() {
+				px += (rasterGlyphRows - 1 - row) * scale / 3
+			}
+
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale+bold; dx++ {
+					s.setClippedPixel(px+dx, py+dy, color, clip)
+				}
+			}
+		}
+	}
+}
+
+func (s *RasterSurface) setClippedPixel(x, y int, color Color, clip *Rectangle) {
+	if clip != nil && (x < clip.X || y < clip.Y || x >= clip.X+clip.Width || y >= clip.Y+clip.Height) {
+		return
+	}
+
+	s.setPixel(x, y, color)
+}
+
+func (s *RasterSurface) drawTextRule(x, y, width, thickness int, color Color, clip *Rectangle) {
+	for py := y; py < y+thickness; py++ {
+		for px := x; px < x+width; px++ {
+			s.setClippedPixel(px, py, color, clip)
+		}
+	}
+}
+
+func (s *RasterSurface) DrawText(text string, font *Font, color Color, bounds Rectangle, format DrawTextFormat) os.Error {
+	scale, err := s.rasterFontScale(font)
+	if err != nil {
+		return err
+	}
+
+	if format&TextCalcRect != 0 {
+		return nil
+	}
+
+	cellWidth := rasterCellWidth * scale
+	lineHeight := rasterCellHeight * scale
+
+	lines := layoutRasterText([]int(text), cellWidth, bounds.Width, format)
+
+	var clip *Rectangle
+	if format&TextNoClip == 0 {
+		clip = &bounds
+	}
+
+	y := bounds.Y
+	switch {
+	case format&TextVCenter != 0:
+		y += (bounds.Height - len(lines)*lineHeight) / 2
+
+	case format&TextBottom != 0:
+		y += bounds.Height - len(lines)*lineHeight
+	}
+
+	for _, line := range lines {
+		x := bounds.X
+		switch {
+		case format&TextCenter != 0:
+			x += (bounds.Width - line.width) / 2
+
+		case format&TextRight != 0:
+			x += bounds.Width - line.width
+		}
+
+		startX := x
+		for _, r := range line.runes {
+			if r > ' ' {
+				s.drawGlyph(r, x, y, scale, font, color, clip)
+			}
+
+			x = rasterAdvance(r, x-startX, cellWidth, format) + startX
+		}
+
+		if font.Underline() {
+			s.drawTextRule(startX, y+rasterGlyphRows*scale, line.width, scale, color, clip)
+		}
+		if font.StrikeOut() {
+			s.drawTextRule(startX, y+rasterGlyphRows/2*scale, line.width, scale, color, clip)
+		}
+
+		y += lineHeight
+	}
+
+	return nil
+}
+
+// MeasureText always wraps lines at word boundaries, whether format includes
+// TextWordbreak or not. GDISurface.MeasureText adds DT_WORDBREAK as well and
+// both have to measure the same way, for layouts to work on either surface.
+func (s *RasterSurface) MeasureText(text string, font *Font, bounds Rectangle, format DrawTextFormat) (boundsMeasured Rectangle, runesFitted int, err os.Error) {
+	scale, err := s.rasterFontScale(font)
+	if err != nil {
+		return
+	}
+
+	cellWidth := rasterCellWidth * scale
+	lineHeight := rasterCellHeight * scale
+
+	lines := layoutRasterText([]int(text), cellWidth, bounds.Width, format|TextWordbreak)
+
+	var width, height int
+	for i, line := range lines {
+		if i > 0 && height+lineHeight > bounds.Height {
+			break
+		}
+
+		if line.width > width {
+			width = line.width
+		}
+		height += lineHeight
+		runesFitted += line.consumed
+	}
+
+	boundsMeasured = Rectangle{bounds.X, bounds.Y, width, height}
+
+	return
+}
@@ -0,0 +1,528 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package drawing
+
+import (
+	"image"
+	"strings"
+	"testing"
+)
+
+var (
+	black = RGB(0, 0, 0)
+	white = RGB(255, 255, 255)
+)
+
+// checkGolden compares the pixels of s with golden, which has a string per
+// row with a '#' for each black and a '.' for each white pixel.
+func checkGolden(t *testing.T, name string, s *RasterSurface, golden []string) {
+	bounds := s.Bounds()
+
+	actual := make([]string, bounds.Height)
+	for y := 0; y < bounds.Height; y++ {
+		row := make([]byte, bounds.Width)
+		for x := 0; x < bounds.Width; x++ {
+			switch colorFromImageColor(s.Image().At(x, y)) {
+			case black:
+				row[x] = '#'
+
+			case white:
+				row[x] = '.'
+
+			default:
+				row[x] = '?'
+			}
+		}
+		actual[y] = string(row)
+	}
+
+	if strings.Join(actual, "\n") != strings.Join(golden, "\n") {
+		t.Errorf("%s: expected\n%s\ngot\n%s", name, strings.Join(golden, "\n"), strings.Join(actual, "\n"))
+	}
+}
+
+func newTestRasterSurface(t *testing.T, size Size) *RasterSurface {
+	s, err := NewRasterSurface(size)
+	if err != nil {
+		t.Fatalf("NewRasterSurface failed: %s", err)
+	}
+
+	return s
+}
+
+// newTestBitmap returns a bitmap created from an image with the pixels of
+// pattern, in the format of the goldens of checkGolden.
+func newTestBitmap(t *testing.T, pattern []string) *Bitmap {
+	img := image.NewRGBA(len(pattern[0]), len(pattern))
+
+	for y, row := range pattern {
+		for x := 0; x < len(row); x++ {
+			color := white
+			if row[x] == '#' {
+				color = black
+			}
+
+			img.Set(x, y, image.RGBAColor{color.R(), color.G(), color.B(), 0xff})
+		}
+	}
+
+	bmp, err := NewBitmapFromImage(img)
+	if err != nil {
+		t.Fatalf("NewBitmapFromImage failed: %s", err)
+	}
+
+	return bmp
+}
+
+// newTestFont returns a font that is drawn with the built-in font unscaled.
+func newTestFont(t *testing.T) *Font {
+	font, err := NewFont("Arial", 6, 0)
+	if err != nil {
+		t.Fatalf("NewFont failed: %s", err)
+	}
+
+	return font
+}
+
+func newTestCosmeticPen(t *testing.T, style PenStyle) *CosmeticPen {
+	pen, err := NewCosmeticPen(style, black)
+	if err != nil {
+		t.Fatalf("NewCosmeticPen failed: %s", err)
+	}
+
+	return pen
+}
+
+func TestRasterSurfaceDrawLine(t *testing.T) {
+	s := newTestRasterSurface(t, Size{8, 6})
+
+	if err := s.DrawLine(newTestCosmeticPen(t, PenSolid), Point{1, 1}, Point{6, 4}); err != nil {
+		t.Fatalf("DrawLine failed: %s", err)
+	}
+
+	checkGolden(t, "line", s, []string{
+		"........",
+		".#......",
+		"..##....",
+		"....##..",
+		"........",
+		"........",
+	})
+}
+
+func TestRasterSurfaceDrawLineGeometricPen(t *testing.T) {
+	s := newTestRasterSurface(t, Size{10, 7})
+
+	brush, _ := NewSolidColorBrush(black)
+	pen, err := NewGeometricPen(PenSolid|PenCapFlat, 3, brush)
+	if err != nil {
+		t.Fatalf("NewGeometricPen failed: %s", err)
+	}
+
+	s.DrawLine(pen, Point{1, 3}, Point{8, 3})
+
+	checkGolden(t, "geometric line", s, []string{
+		"..........",
+		"..........",
+		"#########.",
+		"#########.",
+		"#########.",
+		"..........",
+		"..........",
+	})
+}
+
+func TestRasterSurfaceDrawRectangleDotted(t *testing.T) {
+	s := newTestRasterSurface(t, Size{12, 8})
+
+	s.DrawRectangle(newTestCosmeticPen(t, PenDot), Rectangle{1, 1, 10, 6})
+
+	checkGolden(t, "dotted rectangle", s, []string{
+		"............",
+		".###...###..",
+		"............",
+		".#..........",
+		".#........#.",
+		".#........#.",
+		"....###...#.",
+		"............",
+	})
+}
+
+func TestRasterSurfaceDrawEllipse(t *testing.T) {
+	s := newTestRasterSurface(t, Size{11, 9})
+
+	s.DrawEllipse(newTestCosmeticPen(t, PenSolid), Rectangle{1, 1, 9, 7})
+
+	checkGolden(t, "ellipse", s, []string{
+		"...........",
+		"...#####...",
+		"..#.....#..",
+		".#.......#.",
+		".#.......#.",
+		".#.......#.",
+		"..#.....#..",
+		"...#####...",
+		"...........",
+	})
+}
+
+func TestRasterSurfaceDrawEllipseDotted(t *testing.T) {
+	s := newTestRasterSurface(t, Size{24, 16})
+
+	s.DrawEllipse(newTestCosmeticPen(t, PenDot), Rectangle{1, 1, 22, 14})
+
+	checkGolden(t, "dotted ellipse", s, []string{
+		"........................",
+		"...........###..........",
+		"......##.........##.....",
+		"........................",
+		"..##....................",
+		"..#..................#..",
+		"......................#.",
+		"......................#.",
+		"......................#.",
+		".#....................#.",
+		"..#..................#..",
+		"..##....................",
+		"........................",
+		".....###........###.....",
+		"...........###..........",
+		"........................",
+	})
+}
+
+func TestRasterSurfaceFillEllipse(t *testing.T) {
+	s := newTestRasterSurface(t, Size{9, 7})
+
+	brush, _ := NewSolidColorBrush(black)
+	s.FillEllipse(brush, Rectangle{1, 1, 7, 5})
+
+	checkGolden(t, "filled ellipse", s, []string{
+		".........",
+		"..#####..",
+		".#######.",
+		".#######.",
+		".#######.",
+		"..#####..",
+		".........",
+	})
+}
+
+func TestRasterSurfaceFillRectangleHatched(t *testing.T) {
+	s := newTestRasterSurface(t, Size{12, 12})
+
+	brush, _ := NewHatchBrush(black, HatchCross)
+	s.FillRectangle(brush, Rectangle{1, 1, 10, 10})
+
+	// The pattern is aligned to the origin of the surface and the background
+	// stays transparent.
+	checkGolden(t, "hatched rectangle", s, []string{
+		"............",
+		"........#...",
+		"........#...",
+		"........#...",
+		"........#...",
+		"........#...",
+		"........#...",
+		"........#...",
+		".##########.",
+		"........#...",
+		"........#...",
+		"............",
+	})
+}
+
+func TestRasterSurfaceNullPenAndBrush(t *testing.T) {
+	s := newTestRasterSurface(t, Size{4, 4})
+
+	s.DrawRectangle(NullPen(), Rectangle{0, 0, 4, 4})
+	s.FillRectangle(NullBrush(), Rectangle{0, 0, 4, 4})
+
+	checkGolden(t, "null pen and brush", s, []string{
+		"....",
+		"....",
+		"....",
+		"....",
+	})
+}
+
+func TestRasterSurfaceDrawImage(t *testing.T) {
+	s := newTestRasterSurface(t, Size{6, 4})
+
+	bmp := newTestBitmap(t, []string{
+		"#.#",
+		".#.",
+	})
+
+	if err := s.DrawImage(bmp, Point{1, 1}); err != nil {
+		t.Fatalf("DrawImage failed: %s", err)
+	}
+	// Partially outside of the surface.
+	if err := s.DrawImage(bmp, Point{4, 2}); err != nil {
+		t.Fatalf("DrawImage failed: %s", err)
+	}
+
+	checkGolden(t, "image", s, []string{
+		"......",
+		".#.#..",
+		"..#.#.",
+		".....#",
+	})
+}
+
+func TestRasterSurfaceDrawImageStretched(t *testing.T) {
+	s := newTestRasterSurface(t, Size{6, 6})
+
+	bmp := newTestBitmap(t, []string{
+		"#.",
+		".#",
+	})
+
+	if err := s.DrawImageStretched(bmp, Rectangle{1, 1, 4, 4}); err != nil {
+		t.Fatalf("DrawImageStretched failed: %s", err)
+	}
+
+	checkGolden(t, "stretched image", s, []string{
+		"......",
+		".##...",
+		".##...",
+		"...##.",
+		"...##.",
+		"......",
+	})
+}
+
+func TestRasterSurfaceFillRectangleBitmapBrush(t *testing.T) {
+	s := newTestRasterSurface(t, Size{6, 5})
+
+	brush, err := NewBitmapBrush(newTestBitmap(t, []string{
+		"#.",
+		".#",
+	}))
+	if err != nil {
+		t.Fatalf("NewBitmapBrush failed: %s", err)
+	}
+
+	if err := s.FillRectangle(brush, Rectangle{1, 1, 4, 3}); err != nil {
+		t.Fatalf("FillRectangle failed: %s", err)
+	}
+
+	// Like the hatch, the pattern is aligned to the origin of the surface.
+	checkGolden(t, "bitmap brush", s, []string{
+		"......",
+		".#.#..",
+		"..#.#.",
+		".#.#..",
+		"......",
+	})
+}
+
+func TestRasterSurfaceDrawTextWordbreak(t *testing.T) {
+	s := newTestRasterSurface(t, Size{12, 16})
+
+	if err := s.DrawText("-- --", newTestFont(t), black, Rectangle{0, 0, 12, 16}, TextWordbreak); err != nil {
+		t.Fatalf("DrawText failed: %s", err)
+	}
+
+	checkGolden(t, "wrapped text", s, []string{
+		"............",
+		"............",
+		"............",
+		"#####.#####.",
+		"............",
+		"............",
+		"............",
+		"............",
+		"............",
+		"............",
+		"............",
+		"#####.#####.",
+		"............",
+		"............",
+		"............",
+		"............",
+	})
+}
+
+func TestRasterSurfaceDrawTextClipping(t *testing.T) {
+	s := newTestRasterSurface(t, Size{12, 8})
+
+	// Without TextWordbreak, the text is cut off at the bounds.
+	if err := s.DrawText("-- --", newTestFont(t), black, Rectangle{0, 0, 12, 4}, 0); err != nil {
+		t.Fatalf("DrawText failed: %s", err)
+	}
+	if err := s.DrawText("--", newTestFont(t), black, Rectangle{0, 4, 8, 8}, 0); err != nil {
+		t.Fatalf("DrawText failed: %s", err)
+	}
+
+	checkGolden(t, "clipped text", s, []string{
+		"............",
+		"............",
+		"............",
+		"#####.#####.",
+		"............",
+		"............",
+		"............",
+		"#####.##....",
+	})
+
+	s = newTestRasterSurface(t, Size{12, 8})
+
+	if err := s.DrawText("--", newTestFont(t), black, Rectangle{0, 0, 8, 1}, TextNoClip); err != nil {
+		t.Fatalf("DrawText failed: %s", err)
+	}
+
+	checkGolden(t, "unclipped text", s, []string{
+		"............",
+		"............",
+		"............",
+		"#####.#####.",
+		"............",
+		"............",
+		"............",
+		"............",
+	})
+}
+
+func TestRasterSurfaceDrawTextAlignment(t *testing.T) {
+	s := newTestRasterSurface(t, Size{12, 16})
+	bounds := Rectangle{0, 0, 12, 16}
+
+	if err := s.DrawText("-", newTestFont(t), black, bounds, TextCenter|TextVCenter|TextSingleLine); err != nil {
+		t.Fatalf("DrawText failed: %s", err)
+	}
+	if err := s.DrawText("-", newTestFont(t), black, bounds, TextRight|TextBottom|TextSingleLine); err != nil {
+		t.Fatalf("DrawText failed: %s", err)
+	}
+
+	checkGolden(t, "aligned text", s, []string{
+		"............",
+		"............",
+		"............",
+		"............",
+		"............",
+		"............",
+		"............",
+		"...#####....",
+		"............",
+		"............",
+		"............",
+		"......#####.",
+		"............",
+		"............",
+		"............",
+		"............",
+	})
+}
+
+func TestRasterSurfaceMeasureText(t *testing.T) {
+	s := newTestRasterSurface(t, Size{1, 1})
+	font := newTestFont(t)
+
+	tests := []struct {
+		text     string
+		bounds   Rectangle
+		format   DrawTextFormat
+		measured Rectangle
+		fitted   int
+	}{
+		{"-- --", Rectangle{0, 0, 100, 100}, 0, Rectangle{0, 0, 30, 8}, 5},
+		{"-- --", Rectangle{2, 3, 12, 100}, TextWordbreak, Rectangle{2, 3, 12, 16}, 5},
+		// Lines are wrapped without TextWordbreak as well.
+		{"-- --", Rectangle{0, 0, 12, 100}, 0, Rectangle{0, 0, 12, 16}, 5},
+		// Only lines that fit into the height are measured, but always one.
+		{"-- --", Rectangle{0, 0, 12, 8}, 0, Rectangle{0, 0, 12, 8}, 3},
+		{"-- --", Rectangle{0, 0, 12, 0}, 0, Rectangle{0, 0, 12, 8}, 3},
+		{"-\n--", Rectangle{0, 0, 100, 100}, 0, Rectangle{0, 0, 12, 16}, 4},
+		{"", Rectangle{0, 0, 100, 100}, 0, Rectangle{0, 0, 0, 8}, 0},
+	}
+
+	for _, test := range tests {
+		measured, fitted, err := s.MeasureText(test.text, font, test.bounds, test.format)
+		if err != nil {
+			t.Fatalf("MeasureText failed: %s", err)
+		}
+
+		if measured != test.measured || fitted != test.fitted {
+			t.Errorf("%q in %v: expected %v and %d runes, got %v and %d",
+				test.text, test.bounds, test.measured, test.fitted, measured, fitted)
+		}
+	}
+}
+
+func TestNewBitmapFromImage(t *testing.T) {
+	img := image.NewRGBA(3, 2)
+	img.Set(2, 0, image.RGBAColor{0xff, 0, 0, 0xff})
+
+	bmp, err := NewBitmapFromImage(img)
+	if err != nil {
+		t.Fatalf("NewBitmapFromImage failed: %s", err)
+	}
+
+	// The bitmap keeps a copy.
+	img.Set(0, 0, image.RGBAColor{0xff, 0xff, 0xff, 0xff})
+
+	if size := bmp.Size(); size != (Size{3, 2}) {
+		t.Errorf("expected size 3x2, got %v", size)
+	}
+
+	rgba, err := bmp.toRGBA()
+	if err != nil {
+		t.Fatalf("toRGBA failed: %s", err)
+	}
+	if c := colorFromImageColor(rgba.At(0, 0)); c != black {
+		t.Errorf("expected the copy to stay black, got %06x", uint32(c))
+	}
+
+	data, err := bmp.PackedDIB()
+	if err != nil {
+		t.Fatalf("PackedDIB failed: %s", err)
+	}
+
+	// The 24 bit rows are padded from 9 to 12 bytes.
+	hdrSize := len(data) - 2*12
+	img = rgbaFromDIBPixels(data[hdrSize:], 3, 2, 12, 3, true)
+
+	if c := colorFromImageColor(img.At(2, 0)); c != RGB(0xff, 0, 0) {
+		t.Errorf("expected red in the packed DIB, got %06x", uint32(c))
+	}
+
+	if _, err := NewBitmapFromImage(nil); err == nil {
+		t.Error("expected NewBitmapFromImage(nil) to fail")
+	}
+}
+
+func TestRGBAFromDIBPixels(t *testing.T) {
+	// A 3x2 24 bit DIB, stored bottom-up. Rows are padded from 9 to 12 bytes.
+	pixels := []byte{
+		// Bottom row: blue, green, red
+		0xff, 0, 0, 0, 0xff, 0, 0, 0, 0xff, 0, 0, 0,
+		// Top row: white, black, gray
+		0xff, 0xff, 0xff, 0, 0, 0, 0x80, 0x80, 0x80, 0, 0, 0,
+	}
+
+	img := rgbaFromDIBPixels(pixels, 3, 2, 12, 3, true)
+
+	expected := [][]Color{
+		{white, black, RGB(0x80, 0x80, 0x80)},
+		{RGB(0, 0, 0xff), RGB(0, 0xff, 0), RGB(0xff, 0, 0)},
+	}
+
+	for y, row := range expected {
+		for x, color := range row {
+			if c := colorFromImageColor(img.At(x, y)); c != color {
+				t.Errorf("pixel %d, %d: expected %06x, got %06x", x, y, uint32(color), uint32(c))
+			}
+		}
+	}
+
+	// Top-down DIBs have negative heights, the rows come in display order.
+	img = rgbaFromDIBPixels(pixels, 3, 2, 12, 3, false)
+
+	if c := colorFromImageColor(img.At(2, 0)); c != RGB(0xff, 0, 0) {
+		t.Errorf("top-down: expected red, got %06x", uint32(c))
+	}
+}
@@ -6,11 +6,11 @@ package drawing
 
 import (
 	"os"
-	"syscall"
 	"unsafe"
 )
 
 import (
+	. "walk/winapi"
 	. "walk/winapi/gdi32"
 	. "walk/winapi/kernel32"
 	. "walk/winapi/user32"
@@ -46,9 +46,29 @@ const (
 	TextPrefixOnly           DrawTextFormat = DT_PREFIXONLY
 )
 
-var gM = syscall.StringToUTF16Ptr("gM")
+var gM = StringToUTF16Ptr("gM")
+
+// Surface is the common interface of everything that can be drawn on.
+//
+// GDISurface draws into a Windows device context, RasterSurface rasterizes
+// into an in-memory RGBA image.
+type Surface interface {
+	Bounds() Rectangle
+	Dispose()
+	DrawEllipse(pen Pen, bounds Rectangle) os.Error
+	DrawImage(image Image, location Point) os.Error
+	DrawImageStretched(image Image, bounds Rectangle) os.Error
+	DrawLine(pen Pen, from, to Point) os.Error
+	DrawRectangle(pen Pen, bounds Rectangle) os.Error
+	DrawText(text string, font *Font, color Color, bounds Rectangle, format DrawTextFormat) os.Error
+	FillEllipse(brush Brush, bounds Rectangle) os.Error
+	FillRectangle(brush Brush, bounds Rectangle) os.Error
+	FontHeight(font *Font) (height int, err os.Error)
+	MeasureText(text string, font *Font, bounds Rectangle, format DrawTextFormat) (boundsMeasured Rectangle, runesFitted int, err os.Error)
+}
 
-type Surface struct {
+// GDISurface is a Surface that draws into a Windows device context.
+type GDISurface struct {
 	hdc                 HDC
 	hwnd                HWND
 	dpix                int
@@ -58,9 +78,13 @@ type Surface struct {
 	measureTextMetafile *Metafile
 }
 
-func NewSurfaceFromImage(image Image) (*Surface, os.Error) {
+func NewSurfaceFromImage(image Image) (*GDISurface, os.Error) {
 	switch img := image.(type) {
 	case *Bitmap:
+		if err := img.realize(); err != nil {
+			return nil, err
+		}
+
 		hdc := CreateCompatibleDC(0)
 		if hdc == 0 {
 			return nil, newError("CreateCompatibleDC failed")
@@ -79,7 +103,7 @@ func NewSurfaceFromImage(image Image) (*Surface, os.Error) {
 
 		succeeded = true
 
-		return (&Surface{hdc: hdc}).init()
+		return (&GDISurface{hdc: hdc}).init()
 
 	case *Metafile:
 		surface, err := NewSurfaceFromHDC(img.hdc)
@@ -95,24 +119,24 @@ func NewSurfaceFromImage(image Image) (*Surface, os.Error) {
 	return nil, newError("unsupported image type")
 }
 
-func NewSurfaceFromHWND(hwnd HWND) (*Surface, os.Error) {
+func NewSurfaceFromHWND(hwnd HWND) (*GDISurface, os.Error) {
 	hdc := GetDC(hwnd)
 	if hdc == 0 {
 		return nil, newError("GetDC failed")
 	}
 
-	return (&Surface{hdc: hdc, hwnd: hwnd}).init()
+	return (&GDISurface{hdc: hdc, hwnd: hwnd}).init()
 }
 
-func NewSurfaceFromHDC(hdc HDC) (*Surface, os.Error) {
+func NewSurfaceFromHDC(hdc HDC) (*GDISurface, os.Error) {
 	if hdc == 0 {
 		return nil, newError("invalid hdc")
 	}
 
-	return (&Surface{hdc: hdc, doNotDispose: true}).init()
+	return (&GDISurface{hdc: hdc, doNotDispose: true}).init()
 }
 
-func (s *Surface) init() (*Surface, os.Error) {
+func (s *GDISurface) init() (*GDISurface, os.Error) {
 	s.dpix = GetDeviceCaps(s.hdc, LOGPIXELSX)
 	s.dpiy = GetDeviceCaps(s.hdc, LOGPIXELSY)
 
@@ -132,7 +156,7 @@ func (s *Surface) init() (*Surface, os.Error) {
 	return s, nil
 }
 
-func (s *Surface) Dispose() {
+func (s *GDISurface) Dispose() {
 	if !s.doNotDispose && s.hdc != 0 {
 		if s.hwnd == 0 {
 			DeleteDC(s.hdc)
@@ -154,7 +178,7 @@ func (s *Surface) Dispose() {
 	}
 }
 
-func (s *Surface) withGdiObj(handle HGDIOBJ, f func() os.Error) os.Error {
+func (s *GDISurface) withGdiObj(handle HGDIOBJ, f func() os.Error) os.Error {
 	oldHandle := SelectObject(s.hdc, handle)
 	if oldHandle == 0 {
 		return newError("SelectObject failed")
@@ -164,11 +188,11 @@ func (s *Surface) withGdiObj(handle HGDIOBJ, f func() os.Error) os.Error {
 	return f()
 }
 
-func (s *Surface) withBrush(brush Brush, f func() os.Error) os.Error {
+func (s *GDISurface) withBrush(brush Brush, f func() os.Error) os.Error {
 	return s.withGdiObj(HGDIOBJ(brush.handle()), f)
 }
 
-func (s *Surface) withFontAndTextColor(font *Font, color Color, f func() os.Error) os.Error {
+func (s *GDISurface) withFontAndTextColor(font *Font, color Color, f func() os.Error) os.Error {
 	return s.withGdiObj(HGDIOBJ(font.HandleForDPI(s.dpiy)), func() os.Error {
 		oldColor := SetTextColor(s.hdc, COLORREF(color))
 		if oldColor == CLR_INVALID {
@@ -182,24 +206,24 @@ func (s *Surface) withFontAndTextColor(font *Font, color Color, f func() os.Erro
 	})
 }
 
-func (s *Surface) Bounds() Rectangle {
+func (s *GDISurface) Bounds() Rectangle {
 	return Rectangle{
 		Width:  GetDeviceCaps(s.hdc, HORZRES),
 		Height: GetDeviceCaps(s.hdc, VERTRES),
 	}
 }
 
-func (s *Surface) withPen(pen Pen, f func() os.Error) os.Error {
+func (s *GDISurface) withPen(pen Pen, f func() os.Error) os.Error {
 	return s.withGdiObj(HGDIOBJ(pen.handle()), f)
 }
 
-func (s *Surface) withBrushAndPen(brush Brush, pen Pen, f func() os.Error) os.Error {
+func (s *GDISurface) withBrushAndPen(brush Brush, pen Pen, f func() os.Error) os.Error {
 	return s.withBrush(brush, func() os.Error {
 		return s.withPen(pen, f)
 	})
 }
 
-func (s *Surface) ellipse(brush Brush, pen Pen, bounds Rectangle, sizeCorrection int) os.Error {
+func (s *GDISurface) ellipse(brush Brush, pen Pen, bounds Rectangle, sizeCorrection int) os.Error {
 	return s.withBrushAndPen(brush, pen, func() os.Error {
 		if !Ellipse(s.hdc, bounds.X, bounds.Y, bounds.X+bounds.Width+sizeCorrection, bounds.Y+bounds.Height+sizeCorrection) {
 			return newError("Ellipse failed")
@@ -209,15 +233,15 @@ func (s *Surface) ellipse(brush Brush, pen Pen, bounds Rectangle, sizeCorrection
 	})
 }
 
-func (s *Surface) DrawEllipse(pen Pen, bounds Rectangle) os.Error {
+func (s *GDISurface) DrawEllipse(pen Pen, bounds Rectangle) os.Error {
 	return s.ellipse(nullBrushSingleton, pen, bounds, 0)
 }
 
-func (s *Surface) FillEllipse(brush Brush, bounds Rectangle) os.Error {
+func (s *GDISurface) FillEllipse(brush Brush, bounds Rectangle) os.Error {
 	return s.ellipse(brush, nullPenSingleton, bounds, 1)
 }
 
-func (s *Surface) DrawImage(image Image, location Point) os.Error {
+func (s *GDISurface) DrawImage(image Image, location Point) os.Error {
 	if image == nil {
 		return newError("image cannot be nil")
 	}
@@ -225,7 +249,7 @@ func (s *Surface) DrawImage(image Image, location Point) os.Error {
 	return image.draw(s.hdc, location)
 }
 
-func (s *Surface) DrawImageStretched(image Image, bounds Rectangle) os.Error {
+func (s *GDISurface) DrawImageStretched(image Image, bounds Rectangle) os.Error {
 	if image == nil {
 		return newError("image cannot be nil")
 	}
@@ -233,7 +257,7 @@ func (s *Surface) DrawImageStretched(image Image, bounds Rectangle) os.Error {
 	return image.drawStretched(s.hdc, bounds)
 }
 
-func (s *Surface) DrawLine(pen Pen, from, to Point) os.Error {
+func (s *GDISurface) DrawLine(pen Pen, from, to Point) os.Error {
 	if !MoveToEx(s.hdc, from.X, from.Y, nil) {
 		return newError("MoveToEx failed")
 	}
@@ -247,7 +271,7 @@ func (s *Surface) DrawLine(pen Pen, from, to Point) os.Error {
 	})
 }
 
-func (s *Surface) rectangle(brush Brush, pen Pen, bounds Rectangle, sizeCorrection int) os.Error {
+func (s *GDISurface) rectangle(brush Brush, pen Pen, bounds Rectangle, sizeCorrection int) os.Error {
 	return s.withBrushAndPen(brush, pen, func() os.Error {
 		if !Rectangle_(s.hdc, bounds.X, bounds.Y, bounds.X+bounds.Width+sizeCorrection, bounds.Y+bounds.Height+sizeCorrection) {
 			return newError("Rectangle_ failed")
@@ -257,18 +281,18 @@ func (s *Surface) rectangle(brush Brush, pen Pen, bounds Rectangle, sizeCorrecti
 	})
 }
 
-func (s *Surface) DrawRectangle(pen Pen, bounds Rectangle) os.Error {
+func (s *GDISurface) DrawRectangle(pen Pen, bounds Rectangle) os.Error {
 	return s.rectangle(nullBrushSingleton, pen, bounds, 0)
 }
 
-func (s *Surface) FillRectangle(brush Brush, bounds Rectangle) os.Error {
+func (s *GDISurface) FillRectangle(brush Brush, bounds Rectangle) os.Error {
 	return s.rectangle(brush, nullPenSingleton, bounds, 1)
 }
 
-func (s *Surface) DrawText(text string, font *Font, color Color, bounds Rectangle, format DrawTextFormat) os.Error {
+func (s *GDISurface) DrawText(text string, font *Font, color Color, bounds Rectangle, format DrawTextFormat) os.Error {
 	return s.withFontAndTextColor(font, color, func() os.Error {
 		rect := bounds.toRECT()
-		ret := DrawTextEx(s.hdc, syscall.StringToUTF16Ptr(text), -1, &rect, uint(format)|DT_EDITCONTROL, nil)
+		ret := DrawTextEx(s.hdc, StringToUTF16Ptr(text), -1, &rect, uint(format)|DT_EDITCONTROL, nil)
 		if ret == 0 {
 			return newError("DrawTextEx failed")
 		}
@@ -277,7 +301,7 @@ func (s *Surface) DrawText(text string, font *Font, color Color, bounds Rectangl
 	})
 }
 
-func (s *Surface) FontHeight(font *Font) (height int, err os.Error) {
+func (s *GDISurface) FontHeight(font *Font) (height int, err os.Error) {
 	err = s.withFontAndTextColor(font, 0, func() os.Error {
 		var size SIZE
 		if !GetTextExtentPoint32(s.hdc, gM, 2, &size) {
@@ -295,7 +319,7 @@ func (s *Surface) FontHeight(font *Font) (height int, err os.Error) {
 	return
 }
 
-func (s *Surface) MeasureText(text string, font *Font, bounds Rectangle, format DrawTextFormat) (boundsMeasured Rectangle, runesFitted int, err os.Error) {
+func (s *GDISurface) MeasureText(text string, font *Font, bounds Rectangle, format DrawTextFormat) (boundsMeasured Rectangle, runesFitted int, err os.Error) {
 	// HACK: We don't want to actually draw on the surface here, but if we use
 	// the DT_CALCRECT flag to avoid drawing, DRAWTEXTPARAMS.UiLengthDrawn will
 	// not contain a useful value. To work around this, we create an in-memory
@@ -319,7 +343,7 @@ func (s *Surface) MeasureText(text string, font *Font, bounds Rectangle, format
 	var params DRAWTEXTPARAMS
 	params.CbSize = uint(unsafe.Sizeof(params))
 
-	strPtr := syscall.StringToUTF16Ptr(text)
+	strPtr := StringToUTF16Ptr(text)
 	dtfmt := uint(format) | DT_EDITCONTROL | DT_WORDBREAK
 
 	height := DrawTextEx(s.measureTextMetafile.hdc, strPtr, -1, rect, dtfmt, &params)
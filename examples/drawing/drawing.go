@@ -55,7 +55,7 @@ func createBitmap() *drawing.Bitmap {
 	return bmp
 }
 
-func (mw *MainWindow) drawStuff(surface *drawing.Surface, updateBounds drawing.Rectangle) os.Error {
+func (mw *MainWindow) drawStuff(surface drawing.Surface, updateBounds drawing.Rectangle) os.Error {
 	bmp := createBitmap()
 	defer bmp.Dispose()
 
@@ -101,7 +101,7 @@ func runMainWindow() {
 
 	mw.ClientArea().SetLayout(gui.NewVBoxLayout())
 
-	mw.paintWidget, err = gui.NewCustomWidget(mw.ClientArea(), 0, func(surface *drawing.Surface, updateBounds drawing.Rectangle) os.Error {
+	mw.paintWidget, err = gui.NewCustomWidget(mw.ClientArea(), 0, func(surface drawing.Surface, updateBounds drawing.Rectangle) os.Error {
 		return mw.drawStuff(surface, updateBounds)
 	})
 	panicIfErr(err)
@@ -93,13 +93,13 @@ func runMainWindow() {
 	openAction := gui.NewAction()
 	openAction.SetImage(openBmp)
 	openAction.SetText("Open")
-	openAction.AddTriggeredHandler(func(args gui.EventArgs) { mw.openImage() })
+	openAction.Triggered().Attach(func(args gui.EventArgs) { mw.openImage() })
 	fileMenu.Actions().Add(openAction)
 	mw.ToolBar().Actions().Add(openAction)
 
 	exitAction := gui.NewAction()
 	exitAction.SetText("Exit")
-	exitAction.AddTriggeredHandler(func(args gui.EventArgs) { gui.Exit(0) })
+	exitAction.Triggered().Attach(func(args gui.EventArgs) { gui.Exit(0) })
 	fileMenu.Actions().Add(exitAction)
 
 	helpMenu, err := gui.NewMenu()
@@ -110,7 +110,7 @@ func runMainWindow() {
 
 	aboutAction := gui.NewAction()
 	aboutAction.SetText("About")
-	aboutAction.AddTriggeredHandler(func(args gui.EventArgs) {
+	aboutAction.Triggered().Attach(func(args gui.EventArgs) {
 		gui.MsgBox(mw, "About", "Walk Image Viewer Example", gui.MsgBoxOK|gui.MsgBoxIconInformation)
 	})
 	helpMenu.Actions().Add(aboutAction)
@@ -0,0 +1,136 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"container/vector"
+	"os"
+)
+
+// AcceleratorTable maps the shortcuts of actions to the actions.
+//
+// It follows changes of the shortcuts of its actions. Changing the shortcut of
+// an action to one that is already used by another action of the table fails.
+type AcceleratorTable struct {
+	actions         vector.Vector
+	shortcut2Action map[Shortcut]*Action
+}
+
+func NewAcceleratorTable() *AcceleratorTable {
+	return &AcceleratorTable{shortcut2Action: make(map[Shortcut]*Action)}
+}
+
+func (t *AcceleratorTable) Len() int {
+	return t.actions.Len()
+}
+
+func (t *AcceleratorTable) Contains(action *Action) bool {
+	for _, a := range t.actions {
+		if a.(*Action) == action {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Add adds action to the table. Actions without a shortcut can be added, they
+// are found as soon as they get one.
+func (t *AcceleratorTable) Add(action *Action) os.Error {
+	if action == nil {
+		return newError("action cannot be nil")
+	}
+
+	if t.Contains(action) {
+		return nil
+	}
+
+	if err := t.checkShortcut(action); err != nil {
+		return err
+	}
+
+	t.actions.Push(action)
+	t.update()
+
+	action.addChangedHandler(t)
+
+	return nil
+}
+
+func (t *AcceleratorTable) Remove(action *Action) {
+	for i, a := range t.actions {
+		if a.(*Action) == action {
+			action.removeChangedHandler(t)
+
+			t.actions.Delete(i)
+			t.update()
+			break
+		}
+	}
+}
+
+func (t *AcceleratorTable) Clear() {
+	for _, a := range t.actions {
+		a.(*Action).removeChangedHandler(t)
+	}
+
+	t.actions.Resize(0, 8)
+	t.update()
+}
+
+// Action returns the action that has shortcut or nil if there is none.
+func (t *AcceleratorTable) Action(shortcut Shortcut) *Action {
+	if shortcut.Key == 0 {
+		return nil
+	}
+
+	return t.shortcut2Action[shortcut]
+}
+
+// Trigger triggers the action that has shortcut, if it is enabled. It
+// returns whether there was such an action.
+func (t *AcceleratorTable) Trigger(shortcut Shortcut) bool {
+	action := t.Action(shortcut)
+	if action == nil || !action.enabled {
+		return false
+	}
+
+	action.Trigger()
+
+	return true
+}
+
+func (t *AcceleratorTable) checkShortcut(action *Action) os.Error {
+	if action.shortcut.Key == 0 {
+		return nil
+	}
+
+	if other := t.shortcut2Action[action.shortcut]; other != nil && other != action {
+		return newError("shortcut " + action.shortcut.String() + " is already in use")
+	}
+
+	return nil
+}
+
+func (t *AcceleratorTable) update() {
+	t.shortcut2Action = make(map[Shortcut]*Action)
+
+	for _, a := range t.actions {
+		action := a.(*Action)
+		if action.shortcut.Key != 0 {
+			t.shortcut2Action[action.shortcut] = action
+		}
+	}
+}
+
+func (t *AcceleratorTable) onActionChanged(action *Action) (err os.Error) {
+	if err = t.checkShortcut(action); err != nil {
+		return
+	}
+
+	t.update()
+
+	return
+}
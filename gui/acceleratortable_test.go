@@ -0,0 +1,142 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"testing"
+)
+
+import (
+	. "walk/winapi/user32"
+)
+
+func newTestShortcutAction(t *testing.T, s string) *Action {
+	shortcut, err := ParseShortcut(s)
+	if err != nil {
+		t.Fatalf("ParseShortcut failed: %s", err)
+	}
+
+	a := NewAction()
+	if err := a.SetShortcut(shortcut); err != nil {
+		t.Fatalf("SetShortcut failed: %s", err)
+	}
+
+	return a
+}
+
+func TestAcceleratorTableAdd(t *testing.T) {
+	table := NewAcceleratorTable()
+
+	save, open := newTestShortcutAction(t, "Ctrl+S"), newTestShortcutAction(t, "Ctrl+O")
+	table.Add(save)
+	table.Add(open)
+	table.Add(save)
+
+	if n := table.Len(); n != 2 {
+		t.Errorf("expected 2 actions, got %d", n)
+	}
+	if a := table.Action(Shortcut{ModControl, 'S'}); a != save {
+		t.Errorf("expected Ctrl+S to map to the save action, got %p", a)
+	}
+
+	if err := table.Add(newTestShortcutAction(t, "Ctrl+S")); err == nil {
+		t.Error("expected an error for a shortcut already in use")
+	}
+	if n := table.Len(); n != 2 {
+		t.Errorf("expected the conflicting action not to be added, got %d actions", n)
+	}
+
+	table.Remove(save)
+	if a := table.Action(Shortcut{ModControl, 'S'}); a != nil {
+		t.Errorf("expected Ctrl+S to be free after removing, got %p", a)
+	}
+
+	// The table stops following the removed action.
+	save.SetShortcut(Shortcut{ModControl, 'O'})
+	if a := table.Action(Shortcut{ModControl, 'O'}); a != open {
+		t.Errorf("expected Ctrl+O to still map to the open action, got %p", a)
+	}
+}
+
+func TestAcceleratorTableSetShortcut(t *testing.T) {
+	table := NewAcceleratorTable()
+
+	save, open := newTestShortcutAction(t, "Ctrl+S"), NewAction()
+	table.Add(save)
+	table.Add(open)
+
+	if err := open.SetShortcut(Shortcut{ModControl, 'O'}); err != nil {
+		t.Fatalf("SetShortcut failed: %s", err)
+	}
+	if a := table.Action(Shortcut{ModControl, 'O'}); a != open {
+		t.Errorf("expected a new shortcut to be found, got %p", a)
+	}
+
+	if err := open.SetShortcut(Shortcut{ModControl, 'S'}); err == nil {
+		t.Error("expected an error for a shortcut already in use")
+	}
+	if s := open.Shortcut(); s != (Shortcut{ModControl, 'O'}) {
+		t.Errorf("expected the shortcut to be rolled back to Ctrl+O, got %s", s)
+	}
+	if a := table.Action(Shortcut{ModControl, 'S'}); a != save {
+		t.Errorf("expected Ctrl+S to still map to the save action, got %p", a)
+	}
+	if a := table.Action(Shortcut{ModControl, 'O'}); a != open {
+		t.Errorf("expected Ctrl+O to still map to the open action, got %p", a)
+	}
+}
+
+func TestAcceleratorTableRollbackInOtherTables(t *testing.T) {
+	first, second := NewAcceleratorTable(), NewAcceleratorTable()
+
+	a := newTestShortcutAction(t, "F1")
+	first.Add(a)
+	second.Add(a)
+	second.Add(newTestShortcutAction(t, "F2"))
+
+	// The first table accepts the change, the second one rejects it, so the
+	// first one must follow the rollback.
+	if err := a.SetShortcut(Shortcut{0, VK_F2}); err == nil {
+		t.Fatal("expected an error for a shortcut already in use")
+	}
+
+	if other := first.Action(Shortcut{0, VK_F1}); other != a {
+		t.Errorf("expected F1 to map to the action again, got %p", other)
+	}
+	if other := first.Action(Shortcut{0, VK_F2}); other != nil {
+		t.Errorf("expected F2 to be free in the first table, got %p", other)
+	}
+}
+
+func TestAcceleratorTableTrigger(t *testing.T) {
+	b, mw := newTestMainWindow(t)
+
+	a := newTestShortcutAction(t, "Ctrl+K")
+	mw.AcceleratorTable().Add(a)
+
+	var triggered int
+	a.Triggered().Attach(func(args EventArgs) {
+		triggered++
+	})
+
+	label := newTestLabel(t, mw.ClientArea())
+
+	b.SetModifiersDown(ModControl)
+	b.KeyPress(label, 'K')
+	if triggered != 1 {
+		t.Errorf("expected Ctrl+K to trigger the action once, got %d", triggered)
+	}
+
+	b.SetModifiersDown(0)
+	b.KeyPress(label, 'K')
+	if triggered != 1 {
+		t.Errorf("expected K not to trigger the action, got %d", triggered)
+	}
+
+	a.SetEnabled(false)
+	if mw.AcceleratorTable().Trigger(Shortcut{ModControl, 'K'}) {
+		t.Error("expected a disabled action not to be triggered")
+	}
+}
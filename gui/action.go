@@ -18,26 +18,34 @@ type actionChangedHandler interface {
 }
 
 var (
-	// ISSUE: When pressing enter resp. escape,
-	// WM_COMMAND with wParam=1 resp. 2 is sent.
-	// Maybe there is more to consider.
+	// Ids 1 and 2 are IDOK and IDCANCEL, the commands a Dialog receives
+	// when Enter resp. Escape is pressed.
 	nextActionId uint16             = 3
 	actionsById  map[uint16]*Action = make(map[uint16]*Action)
 )
 
+// ActionPredicate decides about the state of an Action, based on the state
+// of the application.
+type ActionPredicate func() bool
+
 type Action struct {
-	menu              *Menu
-	triggeredHandlers vector.Vector
-	changedHandlers   vector.Vector
-	text              string
-	toolTip           string
-	image             *drawing.Bitmap
-	enabled           bool
-	visible           bool
-	checkable         bool
-	checked           bool
-	exclusive         bool
-	id                uint16
+	menu               *Menu
+	group              *ActionGroup
+	enabledCondition   ActionPredicate
+	checkedCondition   ActionPredicate
+	triggeredPublisher EventPublisher
+	changedHandlers    vector.Vector
+	text               string
+	toolTip            string
+	image              *drawing.Bitmap
+	shortcut           Shortcut
+	enabled            bool
+	visible            bool
+	checkable          bool
+	checked            bool
+	exclusive          bool
+	separator          bool
+	id                 uint16
 }
 
 func NewAction() *Action {
@@ -54,6 +62,19 @@ func NewAction() *Action {
 	return a
 }
 
+// NewSeparatorAction returns an Action that is displayed as a separator line
+// in menus and as a gap in tool bars.
+func NewSeparatorAction() *Action {
+	a := NewAction()
+	a.separator = true
+
+	return a
+}
+
+func (a *Action) IsSeparator() bool {
+	return a.separator
+}
+
 func (a *Action) Checkable() bool {
 	return a.checkable
 }
@@ -94,6 +115,19 @@ func (a *Action) SetChecked(value bool) (err os.Error) {
 	return
 }
 
+// CheckedCondition returns the predicate that decides whether the action is
+// checked.
+func (a *Action) CheckedCondition() ActionPredicate {
+	return a.checkedCondition
+}
+
+// SetCheckedCondition sets the predicate that decides whether the action is
+// checked whenever the action is updated. Pass nil to stop updating the
+// checked state.
+func (a *Action) SetCheckedCondition(value ActionPredicate) {
+	a.checkedCondition = value
+}
+
 func (a *Action) Enabled() bool {
 	return a.enabled
 }
@@ -114,6 +148,19 @@ func (a *Action) SetEnabled(value bool) (err os.Error) {
 	return
 }
 
+// EnabledCondition returns the predicate that decides whether the action is
+// enabled.
+func (a *Action) EnabledCondition() ActionPredicate {
+	return a.enabledCondition
+}
+
+// SetEnabledCondition sets the predicate that decides whether the action is
+// enabled whenever the action is updated. Pass nil to stop updating the
+// enabled state.
+func (a *Action) SetEnabledCondition(value ActionPredicate) {
+	a.enabledCondition = value
+}
+
 func (a *Action) Exclusive() bool {
 	return a.exclusive
 }
@@ -134,6 +181,11 @@ func (a *Action) SetExclusive(value bool) (err os.Error) {
 	return
 }
 
+// Group returns the ActionGroup the action belongs to, if any.
+func (a *Action) Group() *ActionGroup {
+	return a.group
+}
+
 func (a *Action) Image() *drawing.Bitmap {
 	return a.image
 }
@@ -154,6 +206,26 @@ func (a *Action) SetImage(value *drawing.Bitmap) (err os.Error) {
 	return
 }
 
+func (a *Action) Shortcut() Shortcut {
+	return a.shortcut
+}
+
+func (a *Action) SetShortcut(value Shortcut) (err os.Error) {
+	if value != a.shortcut {
+		old := a.shortcut
+
+		a.shortcut = value
+
+		err = a.raiseChanged()
+		if err != nil {
+			a.shortcut = old
+			a.raiseChanged()
+		}
+	}
+
+	return
+}
+
 func (a *Action) Text() string {
 	return a.text
 }
@@ -214,24 +286,60 @@ func (a *Action) SetVisible(value bool) (err os.Error) {
 	return
 }
 
-func (a *Action) AddTriggeredHandler(handler EventHandler) {
-	a.triggeredHandlers.Push(handler)
+// Update sets the enabled and checked state of the action from its
+// conditions.
+//
+// Actions are updated whenever the message loop becomes idle and before a
+// menu that contains them opens.
+func (a *Action) Update() (err os.Error) {
+	if a.enabledCondition != nil {
+		if err = a.SetEnabled(a.enabledCondition()); err != nil {
+			return
+		}
+	}
+
+	if a.checkedCondition != nil {
+		err = a.SetChecked(a.checkedCondition())
+	}
+
+	return
+}
+
+// UpdateActions updates all actions that have conditions.
+func UpdateActions() {
+	for _, a := range actionsById {
+		if a.enabledCondition != nil || a.checkedCondition != nil {
+			a.Update()
+		}
+	}
 }
 
-func (a *Action) RemoveTriggeredHandler(handler EventHandler) {
-	for i, h := range a.triggeredHandlers {
-		if h.(EventHandler) == handler {
-			a.triggeredHandlers.Delete(i)
-			break
+// Trigger does what happens when the user clicks the action in a menu or tool
+// bar. A checkable action is checked, or unchecked if it is checked and not
+// exclusive, then the triggered handlers are called. Disabled actions ignore
+// Trigger.
+func (a *Action) Trigger() {
+	if !a.enabled {
+		return
+	}
+
+	if a.checkable {
+		if a.exclusive {
+			a.SetChecked(true)
+		} else {
+			a.SetChecked(!a.checked)
 		}
 	}
+
+	a.raiseTriggered()
+}
+
+func (a *Action) Triggered() *Event {
+	return a.triggeredPublisher.Event()
 }
 
 func (a *Action) raiseTriggered() {
-	for _, handlerIface := range a.triggeredHandlers {
-		handler := handlerIface.(EventHandler)
-		handler(&eventArgs{a})
-	}
+	a.triggeredPublisher.Publish(&eventArgs{a})
 }
 
 func (a *Action) addChangedHandler(handler actionChangedHandler) {
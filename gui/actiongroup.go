@@ -0,0 +1,172 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"container/vector"
+	"os"
+)
+
+// ActionGroup groups actions of which at most one is checked at a time.
+//
+// The group does not depend on where its actions are displayed, so the same
+// actions can be shown in a Menu and a ToolBar and checking one in either
+// unchecks the others everywhere. With exclusivity turned off, the group just
+// collects related actions.
+type ActionGroup struct {
+	actions   vector.Vector
+	exclusive bool
+	updating  bool
+}
+
+func NewActionGroup() *ActionGroup {
+	return &ActionGroup{exclusive: true}
+}
+
+func (g *ActionGroup) Exclusive() bool {
+	return g.exclusive
+}
+
+// SetExclusive sets whether checking an action of the group unchecks the
+// others. Turning it on keeps only the first checked action checked.
+func (g *ActionGroup) SetExclusive(value bool) (err os.Error) {
+	if value == g.exclusive {
+		return
+	}
+
+	g.exclusive = value
+
+	for _, a := range g.actions {
+		if err = a.(*Action).SetExclusive(value); err != nil {
+			return
+		}
+	}
+
+	if value {
+		if checked := g.CheckedAction(); checked != nil {
+			err = g.uncheckOthers(checked)
+		}
+	}
+
+	return
+}
+
+func (g *ActionGroup) Len() int {
+	return g.actions.Len()
+}
+
+func (g *ActionGroup) At(index int) *Action {
+	return g.actions[index].(*Action)
+}
+
+func (g *ActionGroup) IndexOf(action *Action) int {
+	for i, a := range g.actions {
+		if a.(*Action) == action {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Add adds action to the group and makes it checkable. In an exclusive group,
+// a checked action unchecks the action that was checked before.
+func (g *ActionGroup) Add(action *Action) (err os.Error) {
+	if action == nil {
+		return newError("action cannot be nil")
+	}
+	if action.group == g {
+		return
+	}
+	if action.group != nil {
+		return newError("action already belongs to another group")
+	}
+
+	if err = action.SetCheckable(true); err != nil {
+		return
+	}
+	if err = action.SetExclusive(g.exclusive); err != nil {
+		return
+	}
+
+	g.actions.Push(action)
+	action.group = g
+
+	action.addChangedHandler(g)
+
+	if g.exclusive && action.checked {
+		err = g.uncheckOthers(action)
+	}
+
+	return
+}
+
+func (g *ActionGroup) Remove(action *Action) {
+	index := g.IndexOf(action)
+	if index == -1 {
+		return
+	}
+
+	action.removeChangedHandler(g)
+	action.group = nil
+
+	g.actions.Delete(index)
+}
+
+// CheckedAction returns the first checked action of the group or nil if none
+// is checked.
+func (g *ActionGroup) CheckedAction() *Action {
+	for _, a := range g.actions {
+		if action := a.(*Action); action.checked {
+			return action
+		}
+	}
+
+	return nil
+}
+
+// SetCheckedAction checks action, which must belong to the group, and
+// unchecks the others. Pass nil to uncheck all actions.
+func (g *ActionGroup) SetCheckedAction(action *Action) (err os.Error) {
+	if action != nil && action.group != g {
+		return newError("action does not belong to the group")
+	}
+
+	if action == nil {
+		return g.uncheckOthers(nil)
+	}
+
+	if err = action.SetChecked(true); err != nil {
+		return
+	}
+
+	// Without exclusivity, checking does not affect the others by itself.
+	return g.uncheckOthers(action)
+}
+
+func (g *ActionGroup) uncheckOthers(checked *Action) (err os.Error) {
+	g.updating = true
+	defer func() {
+		g.updating = false
+	}()
+
+	for _, a := range g.actions {
+		if action := a.(*Action); action != checked {
+			if err = action.SetChecked(false); err != nil {
+				return
+			}
+		}
+	}
+
+	return
+}
+
+func (g *ActionGroup) onActionChanged(action *Action) (err os.Error) {
+	if g.updating || !g.exclusive || !action.checked {
+		return
+	}
+
+	return g.uncheckOthers(action)
+}
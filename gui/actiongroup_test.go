@@ -0,0 +1,167 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"testing"
+)
+
+import (
+	. "walk/winapi/user32"
+)
+
+func newTestActionGroup(t *testing.T, count int) (*ActionGroup, []*Action) {
+	g := NewActionGroup()
+
+	actions := make([]*Action, count)
+	for i := range actions {
+		actions[i] = NewAction()
+		if err := g.Add(actions[i]); err != nil {
+			t.Fatalf("Add failed: %s", err)
+		}
+	}
+
+	return g, actions
+}
+
+func checkCheckedActions(t *testing.T, name string, actions []*Action, expected ...bool) {
+	for i, a := range actions {
+		if a.Checked() != expected[i] {
+			t.Errorf("%s: action %d: expected checked %t, got %t", name, i, expected[i], a.Checked())
+		}
+	}
+}
+
+func TestActionGroupExclusive(t *testing.T) {
+	g, actions := newTestActionGroup(t, 3)
+
+	for i, a := range actions {
+		if !a.Checkable() || !a.Exclusive() || a.Group() != g {
+			t.Errorf("action %d: expected a checkable, exclusive action of the group", i)
+		}
+	}
+
+	actions[0].SetChecked(true)
+	checkCheckedActions(t, "SetChecked", actions, true, false, false)
+
+	actions[1].SetChecked(true)
+	checkCheckedActions(t, "SetChecked other", actions, false, true, false)
+	if a := g.CheckedAction(); a != actions[1] {
+		t.Errorf("expected action 1 to be the checked action, got %d", g.IndexOf(a))
+	}
+
+	actions[2].Trigger()
+	checkCheckedActions(t, "Trigger", actions, false, false, true)
+
+	actions[2].Trigger()
+	checkCheckedActions(t, "Trigger checked", actions, false, false, true)
+
+	g.SetCheckedAction(actions[0])
+	checkCheckedActions(t, "SetCheckedAction", actions, true, false, false)
+
+	g.SetCheckedAction(nil)
+	checkCheckedActions(t, "SetCheckedAction nil", actions, false, false, false)
+}
+
+func TestActionGroupSetExclusive(t *testing.T) {
+	g, actions := newTestActionGroup(t, 3)
+
+	g.SetExclusive(false)
+	actions[1].SetChecked(true)
+	actions[2].Trigger()
+	checkCheckedActions(t, "not exclusive", actions, false, true, true)
+
+	actions[2].Trigger()
+	checkCheckedActions(t, "Trigger checked", actions, false, true, false)
+
+	actions[2].SetChecked(true)
+	g.SetExclusive(true)
+	checkCheckedActions(t, "exclusive again", actions, false, true, false)
+	for i, a := range actions {
+		if !a.Exclusive() {
+			t.Errorf("action %d: expected the action to follow the group", i)
+		}
+	}
+}
+
+func TestActionGroupAddRemove(t *testing.T) {
+	g, actions := newTestActionGroup(t, 2)
+	actions[0].SetChecked(true)
+
+	checked := NewAction()
+	checked.SetCheckable(true)
+	checked.SetChecked(true)
+
+	g.Add(checked)
+	actions = append(actions, checked)
+	checkCheckedActions(t, "Add checked", actions, false, false, true)
+
+	if err := NewActionGroup().Add(checked); err == nil {
+		t.Error("expected an error for an action of another group")
+	}
+
+	g.Remove(checked)
+	if g.Len() != 2 || checked.Group() != nil {
+		t.Fatalf("expected the action to be removed from the group")
+	}
+
+	actions[0].SetChecked(true)
+	checkCheckedActions(t, "removed", actions, true, false, true)
+
+	if err := g.SetCheckedAction(checked); err == nil {
+		t.Error("expected an error for an action outside the group")
+	}
+}
+
+func TestUpdateActions(t *testing.T) {
+	b, mw := newTestMainWindow(t)
+
+	var canSave, modified bool
+
+	save := NewAction()
+	save.SetEnabledCondition(func() bool {
+		return canSave
+	})
+
+	autoSave := NewAction()
+	autoSave.SetCheckable(true)
+	autoSave.SetCheckedCondition(func() bool {
+		return modified
+	})
+
+	plain := NewAction()
+	plain.SetEnabled(false)
+
+	UpdateActions()
+	if save.Enabled() || autoSave.Checked() {
+		t.Error("expected the actions to follow their conditions")
+	}
+	if plain.Enabled() {
+		t.Error("expected an action without conditions to keep its state")
+	}
+
+	// The actions are updated when the message loop becomes idle.
+	canSave = true
+	b.PostMessage(mw.hWnd, WM_NULL, 0, 0)
+	b.RunMessageLoop(func() bool { return true })
+	if !save.Enabled() {
+		t.Error("expected the action to be updated when the message loop becomes idle")
+	}
+
+	// The actions of a menu are updated before it opens.
+	mw.Menu().Actions().Add(autoSave)
+	modified = true
+	b.SendMessage(mw.hWnd, WM_INITMENUPOPUP, uintptr(mw.Menu().hMenu), 0)
+	if !autoSave.Checked() {
+		t.Error("expected the action to be updated before the menu opens")
+	}
+
+	save.SetEnabledCondition(nil)
+	canSave = false
+	UpdateActions()
+	if !save.Enabled() {
+		t.Error("expected the action to keep its state without a condition")
+	}
+}
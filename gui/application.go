@@ -5,9 +5,149 @@
 package gui
 
 import (
+	"os"
+	"sync"
+)
+
+import (
+	"walk/drawing"
 	. "walk/winapi/user32"
 )
 
+const applicationWindowClass = `\o/ Walk_Application_Class \o/`
+
+// synchronizeMsg is posted to the application window when calls are queued
+// by Synchronize.
+const synchronizeMsg = WM_APP + 1
+
+func applicationWindowWndProc(msg *MSG) uintptr {
+	if appWindow == nil || msg.HWnd != appWindow.hWnd {
+		// Messages sent before CreateWindowEx returns.
+		return backend.DefWindowProc(msg, 0)
+	}
+
+	return appWindow.wndProc(msg, 0)
+}
+
+// applicationWindow is a hidden message-only window, which receives the
+// messages that make the message loop run synchronized calls and timers.
+type applicationWindow struct {
+	Widget
+}
+
+var appWindow *applicationWindow
+
+// ensureApplicationWindow creates the application window, if that has not
+// been done yet. It must be called by the goroutine running the message loop,
+// which the window then belongs to.
+func ensureApplicationWindow() os.Error {
+	if appWindow != nil {
+		return nil
+	}
+
+	ensureRegisteredWindowClass(applicationWindowClass, applicationWindowWndProc)
+
+	hWnd, err := backend.CreateWindow(0, applicationWindowClass, 0, HWND_MESSAGE, drawing.Rectangle{})
+	if err != nil {
+		return err
+	}
+
+	appWindow = &applicationWindow{Widget: Widget{hWnd: hWnd}}
+
+	widgetsByHWnd[hWnd] = appWindow
+
+	synchronized.mutex.Lock()
+	synchronized.hWnd = hWnd
+	postSynchronized()
+	synchronized.mutex.Unlock()
+
+	scheduleTimers()
+
+	return nil
+}
+
+func (aw *applicationWindow) wndProc(msg *MSG, origWndProcPtr uintptr) uintptr {
+	switch msg.Message {
+	case synchronizeMsg:
+		runSynchronized()
+		return 0
+
+	case WM_TIMER:
+		triggerDueTimers()
+		return 0
+	}
+
+	return backend.DefWindowProc(msg, origWndProcPtr)
+}
+
+var synchronized struct {
+	mutex  sync.Mutex
+	funcs  []func()
+	hWnd   HWND
+	posted bool
+}
+
+// Synchronize queues f to be called by the goroutine running the message
+// loop, as soon as that has processed the pending messages.
+//
+// Widgets may only be used by that goroutine. Synchronize is the only function
+// of this package that may be called from any goroutine, so background work
+// uses it to update widgets with its results. Calls queued before the message
+// loop starts are made when it does.
+func Synchronize(f func()) {
+	synchronized.mutex.Lock()
+	defer synchronized.mutex.Unlock()
+
+	synchronized.funcs = append(synchronized.funcs, f)
+
+	postSynchronized()
+}
+
+// postSynchronized posts synchronizeMsg to the application window, unless that
+// does not exist yet or the message is still pending. The caller must hold
+// the mutex.
+func postSynchronized() {
+	if synchronized.posted || synchronized.hWnd == 0 || len(synchronized.funcs) == 0 {
+		return
+	}
+
+	if backend.PostMessage(synchronized.hWnd, synchronizeMsg, 0, 0) == nil {
+		synchronized.posted = true
+	}
+}
+
+// runSynchronized makes the calls queued by Synchronize. Calls queued in the
+// meantime are made by the next run.
+func runSynchronized() {
+	synchronized.mutex.Lock()
+	funcs := synchronized.funcs
+	synchronized.funcs = nil
+	synchronized.posted = false
+	synchronized.mutex.Unlock()
+
+	for _, f := range funcs {
+		f()
+	}
+}
+
+var idlePublisher EventPublisher
+
+// Idle is raised whenever the message loop has processed all pending
+// messages. Handlers should be quick, they delay the processing of new
+// messages.
+func Idle() *Event {
+	return idlePublisher.Event()
+}
+
 func Exit(exitCode int) {
-	PostQuitMessage(exitCode)
+	backend.PostQuitMessage(exitCode)
+}
+
+// onIdle is called by the backends whenever the message loop has processed
+// all pending messages.
+func onIdle() {
+	UpdateActions()
+	clipboard.checkContentsChanged()
+
+	idlePublisher.Publish(&eventArgs{nil})
 }
@@ -0,0 +1,115 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"os"
+)
+
+import (
+	"walk/drawing"
+	. "walk/winapi/comctl32"
+	. "walk/winapi/user32"
+)
+
+// Backend is the window system the widgets of this package are created in.
+//
+// All window creation, geometry, text, style, focus and message dispatch
+// operations of Widget and Container go through the current Backend. The
+// default is the Win32 backend on Windows and a MemoryBackend elsewhere.
+// NewMemoryBackend returns a headless implementation that keeps all window
+// state in memory, so widget trees can be built and exercised from tests on
+// any platform.
+//
+// Window procedures are plain Go functions, turning them into callbacks the
+// window system can call is up to the backend.
+//
+// PostMessage may be called from any goroutine, all other methods only from
+// the one running RunMessageLoop.
+//
+// The clipboard is part of the backend as well. Its data is a string in
+// TextFormat, a *drawing.Bitmap in ImageFormat and a []byte in any other
+// format. So are image lists and message boxes.
+type Backend interface {
+	RegisterWindowClass(className string, windowProc func(msg *MSG) uintptr)
+	CreateWindow(exStyle uint, className string, style uint, parent HWND, bounds drawing.Rectangle) (HWND, os.Error)
+	DestroyWindow(hWnd HWND) os.Error
+	SubclassWindow(hWnd HWND, windowProc func(msg *MSG, origWndProcPtr uintptr) uintptr) os.Error
+	SetParent(hWnd, parent HWND) os.Error
+	SetOwner(hWnd, owner HWND) os.Error
+	Style(hWnd HWND) (uint, os.Error)
+	SetStyle(hWnd HWND, style uint) os.Error
+	Bounds(hWnd HWND) (drawing.Rectangle, os.Error)
+	SetBounds(hWnd HWND, bounds drawing.Rectangle) os.Error
+	ClientBounds(hWnd HWND) (drawing.Rectangle, os.Error)
+	ShowWindow(hWnd HWND, cmdShow int) os.Error
+	WindowPlacement(hWnd HWND) (*WINDOWPLACEMENT, os.Error)
+	SetWindowPlacement(hWnd HWND, wp *WINDOWPLACEMENT) os.Error
+	Text(hWnd HWND) string
+	SetText(hWnd HWND, value string) os.Error
+	DefaultFont() *drawing.Font
+	SetFont(hWnd HWND, font *drawing.Font)
+	Focus() HWND
+	SetFocus(hWnd HWND) os.Error
+	ModifiersDown() Modifiers
+	Capture() HWND
+	SetCapture(hWnd HWND) os.Error
+	ReleaseCapture() os.Error
+	SetCursor(id uint16) os.Error
+	TrackMouseLeave(hWnd HWND) os.Error
+	ScreenToClient(hWnd HWND, point drawing.Point) (drawing.Point, os.Error)
+	ClientToScreen(hWnd HWND, point drawing.Point) (drawing.Point, os.Error)
+	WindowFromPoint(point drawing.Point) HWND
+	AcceptFiles(hWnd HWND, accept bool) os.Error
+	DroppedFiles(hDrop uintptr) (paths []string, point drawing.Point, err os.Error)
+	ClipboardSequenceNumber() uint
+	ClipboardContains(format string) bool
+	ClipboardData(format string) (interface{}, os.Error)
+	SetClipboardData(values map[string]interface{}) os.Error
+	CreateMenu(popup bool) (HMENU, os.Error)
+	DestroyMenu(hMenu HMENU) os.Error
+	SetMenu(hWnd HWND, hMenu HMENU) os.Error
+	InsertMenuItem(hMenu HMENU, position int, mii *MENUITEMINFO) os.Error
+	SetMenuItemInfo(hMenu HMENU, position int, mii *MENUITEMINFO) os.Error
+	RemoveMenuItem(hMenu HMENU, position int) os.Error
+	DrawMenuBar(hWnd HWND) os.Error
+	TrackPopupMenu(hMenu HMENU, owner HWND, point drawing.Point) os.Error
+	CreateImageList(imageSize drawing.Size) (HIMAGELIST, os.Error)
+	DestroyImageList(hIml HIMAGELIST) os.Error
+	AddImage(hIml HIMAGELIST, bitmap, maskBitmap *drawing.Bitmap) (int, os.Error)
+	AddMaskedImage(hIml HIMAGELIST, bitmap *drawing.Bitmap, maskColor drawing.Color) (int, os.Error)
+	MessageBox(owner HWND, title, message string, style uint) int
+	SetTheme(hWnd HWND, appName string) os.Error
+	DialogBaseUnits(hWnd HWND) drawing.Size
+	Invalidate(hWnd HWND, bounds drawing.Rectangle) os.Error
+	SendMessage(hWnd HWND, msg uint, wParam, lParam uintptr) uintptr
+	PostMessage(hWnd HWND, msg uint, wParam, lParam uintptr) os.Error
+	SetTimer(hWnd HWND, id uintptr, elapse uint) os.Error
+	KillTimer(hWnd HWND, id uintptr) os.Error
+	DefWindowProc(msg *MSG, origWndProcPtr uintptr) uintptr
+	RunMessageLoop(running func() bool) os.Error
+	PostQuitMessage(exitCode int)
+}
+
+var backend Backend = newDefaultBackend()
+
+// CurrentBackend returns the Backend new widgets are created in.
+func CurrentBackend() Backend {
+	return backend
+}
+
+// SetBackend replaces the Backend new widgets are created in.
+//
+// Widgets always use the current Backend, so this should be called before any
+// widget is created.
+func SetBackend(value Backend) os.Error {
+	if value == nil {
+		return newError("value cannot be nil")
+	}
+
+	backend = value
+
+	return nil
+}
@@ -0,0 +1,11 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+// There is no window system to create widgets in outside of Windows, so they
+// are kept in memory.
+func newDefaultBackend() Backend {
+	return NewMemoryBackend()
+}
@@ -5,27 +5,58 @@
 package gui
 
 import (
-	//    "log"
 	"os"
 )
 
 import (
 	"walk/drawing"
+	. "walk/winapi/user32"
 )
 
+// LayoutAlignment specifies where a widget is placed along the cross axis of
+// a BoxLayout, i.e. vertically in a horizontal box and horizontally in a
+// vertical box.
+type LayoutAlignment byte
+
+const (
+	AlignFill LayoutAlignment = iota
+	AlignStart
+	AlignCenter
+	AlignEnd
+)
+
+// BoxLayoutItem describes a widget to be laid out by BoxLayout.Compute.
+type BoxLayoutItem struct {
+	Flags                           LayoutFlags
+	MinSize, MaxSize, PreferredSize drawing.Size
+	StretchFactor                   int
+	Alignment                       LayoutAlignment
+}
+
 type BoxLayout struct {
-	container IContainer
-	margins   *Margins
-	spacing   int
-	vertical  bool
+	container          IContainer
+	margins            *Margins
+	spacing            int
+	vertical           bool
+	hWnd2StretchFactor map[HWND]int
+	hWnd2Alignment     map[HWND]LayoutAlignment
 }
 
 func NewHBoxLayout() *BoxLayout {
-	return &BoxLayout{margins: &Margins{}}
+	return newBoxLayout(false)
 }
 
 func NewVBoxLayout() *BoxLayout {
-	return &BoxLayout{margins: &Margins{}, vertical: true}
+	return newBoxLayout(true)
+}
+
+func newBoxLayout(vertical bool) *BoxLayout {
+	return &BoxLayout{
+		margins:            &Margins{},
+		vertical:           vertical,
+		hWnd2StretchFactor: make(map[HWND]int),
+		hWnd2Alignment:     make(map[HWND]LayoutAlignment),
+	}
 }
 
 func (l *BoxLayout) Container() IContainer {
@@ -59,7 +90,7 @@ func (l *BoxLayout) SetMargins(value *Margins) os.Error {
 
 	l.margins = value
 
-	return nil
+	return l.Update(false)
 }
 
 func (l *BoxLayout) Spacing() int {
@@ -80,195 +111,317 @@ func (l *BoxLayout) SetSpacing(value int) os.Error {
 	return nil
 }
 
-func (l *BoxLayout) Update(reset bool) (err os.Error) {
-	if l.container == nil {
-		return
+func (l *BoxLayout) StretchFactor(widget IWidget) int {
+	if widget == nil {
+		return 0
 	}
 
-	//    log.Stdout("*BoxLayout.Update")
+	return l.hWnd2StretchFactor[widget.Handle()]
+}
 
-	widgets := make([]IWidget, 0, l.container.Children().Len())
+// SetStretchFactor sets how much extra space widget receives relative to its
+// siblings, when the container is larger than preferred.
+//
+// A widget with a stretch factor may grow even if its LayoutFlags do not
+// allow it. If any widget has a stretch factor, only widgets with a stretch
+// factor grow.
+func (l *BoxLayout) SetStretchFactor(widget IWidget, factor int) os.Error {
+	if widget == nil {
+		return newError("widget cannot be nil")
+	}
+	if factor < 0 {
+		return newError("factor cannot be negative")
+	}
 
-	children := l.container.Children()
-	j := 0
-	for i := 0; i < cap(widgets); i++ {
-		widget := children.At(i)
+	if factor == 0 {
+		l.hWnd2StretchFactor[widget.Handle()] = 0, false
+	} else {
+		l.hWnd2StretchFactor[widget.Handle()] = factor
+	}
 
-		ps := widget.PreferredSize()
-		if ps.Width == 0 && ps.Height == 0 && widget.LayoutFlags() == 0 {
-			continue
-		}
+	return l.Update(false)
+}
 
-		widgets = widgets[0 : j+1]
-		widgets[j] = widget
-		j++
+func (l *BoxLayout) Alignment(widget IWidget) LayoutAlignment {
+	if widget == nil {
+		return AlignFill
+	}
+
+	return l.hWnd2Alignment[widget.Handle()]
+}
+
+// SetAlignment sets where widget is placed along the cross axis. The default
+// is AlignFill.
+func (l *BoxLayout) SetAlignment(widget IWidget, alignment LayoutAlignment) os.Error {
+	if widget == nil {
+		return newError("widget cannot be nil")
+	}
+	if alignment > AlignEnd {
+		return newError("invalid alignment")
+	}
+
+	if alignment == AlignFill {
+		l.hWnd2Alignment[widget.Handle()] = 0, false
+	} else {
+		l.hWnd2Alignment[widget.Handle()] = alignment
+	}
+
+	return l.Update(false)
+}
+
+func (l *BoxLayout) MinSize() drawing.Size {
+	_, items, err := l.items()
+	if err != nil {
+		return drawing.Size{}
+	}
+
+	minSize, _ := l.ComputeSizeHints(items)
+
+	return minSize
+}
+
+func (l *BoxLayout) PreferredSize() drawing.Size {
+	_, items, err := l.items()
+	if err != nil {
+		return drawing.Size{}
 	}
 
-	widgetCount := len(widgets)
+	_, prefSize := l.ComputeSizeHints(items)
 
-	if widgetCount == 0 {
+	return prefSize
+}
+
+func (l *BoxLayout) items() (widgets []IWidget, items []BoxLayoutItem, err os.Error) {
+	if l.container == nil {
 		return
 	}
 
-	// We will start by collecting some valuable information.
-	flags := make([]LayoutFlags, widgetCount)
-	prefSizes := make([]drawing.Size, widgetCount)
-	var prefSizeSum drawing.Size
-	var shrinkHorzCount, growHorzCount, shrinkVertCount, growVertCount int
+	children := l.container.Children()
 
-	for i := 0; i < widgetCount; i++ {
-		widget := widgets[i]
+	widgets = make([]IWidget, 0, children.Len())
+	items = make([]BoxLayoutItem, 0, children.Len())
 
-		ps := widget.PreferredSize()
+	for i := 0; i < children.Len(); i++ {
+		widget := children.At(i)
 
-		maxSize, err := widget.MaxSize()
-		if err != nil {
-			return err
+		ps := widget.PreferredSize()
+		if ps.Width == 0 && ps.Height == 0 && widget.LayoutFlags() == 0 {
+			continue
 		}
 
-		lf := widget.LayoutFlags()
-		if maxSize.Width > 0 {
-			lf &^= GrowHorz
-			ps.Width = maxSize.Width
-		}
-		if maxSize.Height > 0 {
-			lf &^= GrowVert
-			ps.Height = maxSize.Height
+		var minSize, maxSize drawing.Size
+
+		if minSize, err = widget.MinSize(); err != nil {
+			return
 		}
 
-		if lf&ShrinkHorz > 0 {
-			shrinkHorzCount++
+		if maxSize, err = widget.MaxSize(); err != nil {
+			return
 		}
-		if lf&GrowHorz > 0 {
-			growHorzCount++
+
+		j := len(widgets)
+		widgets = widgets[0 : j+1]
+		widgets[j] = widget
+
+		items = items[0 : j+1]
+		items[j] = BoxLayoutItem{
+			Flags:         widget.LayoutFlags(),
+			MinSize:       minSize,
+			MaxSize:       maxSize,
+			PreferredSize: ps,
+			StretchFactor: l.hWnd2StretchFactor[widget.Handle()],
+			Alignment:     l.hWnd2Alignment[widget.Handle()],
 		}
-		if lf&ShrinkVert > 0 {
-			shrinkVertCount++
+	}
+
+	return
+}
+
+func (l *BoxLayout) Update(reset bool) os.Error {
+	if reset && l.container != nil {
+		// Children may have been removed.
+		isChild := childHandles(l.container)
+		for hWnd := range l.hWnd2StretchFactor {
+			if !isChild[hWnd] {
+				l.hWnd2StretchFactor[hWnd] = 0, false
+			}
 		}
-		if lf&GrowVert > 0 {
-			growVertCount++
+		for hWnd := range l.hWnd2Alignment {
+			if !isChild[hWnd] {
+				l.hWnd2Alignment[hWnd] = 0, false
+			}
 		}
-		flags[i] = lf
+	}
 
-		prefSizeSum.Width += ps.Width
-		prefSizeSum.Height += ps.Height
-		prefSizes[i] = ps
+	widgets, items, err := l.items()
+	if err != nil {
+		return err
+	}
+
+	if len(widgets) == 0 {
+		return nil
 	}
 
 	cb, err := l.container.ClientBounds()
 	if err != nil {
-		return
+		return err
 	}
 
-	spacingSum := (widgetCount - 1) * l.spacing
+	for i, bounds := range l.Compute(items, cb) {
+		if err := widgets[i].SetBounds(bounds); err != nil {
+			return err
+		}
+	}
 
-	// Now do the actual layout thing.
-	if l.vertical {
-		diff := cb.Height - l.margins.Top - prefSizeSum.Height - spacingSum - l.margins.Bottom
+	return nil
+}
 
-		reqW := 0
+// ComputeSizeHints returns the minimum and preferred size of the area needed
+// to arrange items, including the margins and spacing of the BoxLayout.
+func (l *BoxLayout) ComputeSizeHints(items []BoxLayoutItem) (minSize, preferredSize drawing.Size) {
+	var mainMin, mainPref, crossMin, crossPref int
 
-		for i, s := range prefSizes {
-			if s.Width > reqW && (flags[i]&ShrinkHorz == 0) {
-				reqW = s.Width
-			}
+	for _, item := range items {
+		ai := newLayoutAxisItem(item.Flags, item.MinSize, item.MaxSize, item.PreferredSize, !l.vertical)
+		mainMin += ai.min
+		mainPref += ai.pref
+
+		ai = newLayoutAxisItem(item.Flags, item.MinSize, item.MaxSize, item.PreferredSize, l.vertical)
+		if ai.min > crossMin {
+			crossMin = ai.min
 		}
-		//        if reqW == 0 {
-		reqW = cb.Width - l.margins.Left - l.margins.Right
-		//        }
-
-		var change int
-		if diff < 0 {
-			if shrinkVertCount > 0 {
-				change = diff / shrinkVertCount
-			}
-		} else {
-			if growVertCount > 0 {
-				change = diff / growVertCount
-			}
+		if ai.pref > crossPref {
+			crossPref = ai.pref
 		}
+	}
 
-		//        log.Stdoutf("*BoxLayout.Update: widgetCount: %d, cb: %+v, prefSizeSum: %+v, diff: %d, change: %d, reqW: %d", widgetCount, cb, prefSizeSum, diff, change, reqW)
-
-		y := cb.Y + l.margins.Top
-		for i := 0; i < widgetCount; i++ {
-			widget := widgets[i]
+	if len(items) > 1 {
+		mainMin += (len(items) - 1) * l.spacing
+		mainPref += (len(items) - 1) * l.spacing
+	}
 
-			h := prefSizes[i].Height
+	if l.vertical {
+		minSize = drawing.Size{crossMin, mainMin}
+		preferredSize = drawing.Size{crossPref, mainPref}
+	} else {
+		minSize = drawing.Size{mainMin, crossMin}
+		preferredSize = drawing.Size{mainPref, crossPref}
+	}
 
-			switch {
-			case change < 0:
-				if flags[i]&ShrinkVert > 0 {
-					h += change
-				}
+	horz := l.margins.Left + l.margins.Right
+	vert := l.margins.Top + l.margins.Bottom
 
-			case change > 0:
-				if flags[i]&GrowVert > 0 {
-					h += change
-				}
-			}
+	minSize.Width += horz
+	minSize.Height += vert
+	preferredSize.Width += horz
+	preferredSize.Height += vert
 
-			bounds := drawing.Rectangle{cb.X + l.margins.Left, y, reqW, h}
+	return
+}
 
-			//            log.Stdoutf("*BoxLayout.Update: bounds: %+v", bounds)
+// Compute returns the bounds of items, as they would be arranged one after
+// another inside clientBounds using the margins and spacing of the BoxLayout.
+//
+// Space beyond the preferred sizes goes to the items that can grow, weighted
+// by their stretch factors. Missing space is taken from the items that can
+// shrink, but never below their MinSize. Pixels that cannot be divided evenly
+// are handed out one by one, so the items always fill the available space
+// unless their constraints prevent it.
+//
+// Compute does not need a container, so it can be used to test layouts without
+// creating any windows.
+func (l *BoxLayout) Compute(items []BoxLayoutItem, clientBounds drawing.Rectangle) []drawing.Rectangle {
+	area := drawing.Rectangle{
+		clientBounds.X + l.margins.Left,
+		clientBounds.Y + l.margins.Top,
+		clientBounds.Width - l.margins.Left - l.margins.Right,
+		clientBounds.Height - l.margins.Top - l.margins.Bottom,
+	}
 
-			widget.SetBounds(bounds)
+	mainPos, mainLength, crossPos, crossLength := area.X, area.Width, area.Y, area.Height
+	if l.vertical {
+		mainPos, mainLength, crossPos, crossLength = area.Y, area.Height, area.X, area.Width
+	}
 
-			y += h + l.spacing
+	lines := make([]layoutLine, len(items))
+	for i, item := range items {
+		ai := newLayoutAxisItem(item.Flags, item.MinSize, item.MaxSize, item.PreferredSize, !l.vertical)
+
+		line := layoutLine{
+			used:    true,
+			min:     ai.min,
+			pref:    ai.pref,
+			max:     ai.max,
+			grow:    ai.grow,
+			stretch: item.StretchFactor,
 		}
-	} else {
-		diff := cb.Width - l.margins.Left - prefSizeSum.Width - spacingSum - l.margins.Right
-		reqH := 0
 
-		for i, s := range prefSizes {
-			if s.Height > reqH && (flags[i]&ShrinkVert == 0) {
-				reqH = s.Height
-			}
-		}
-		//        if reqH == 0 {
-		reqH = cb.Height - l.margins.Top - l.margins.Bottom
-		//        }
-
-		var change int
-		if diff < 0 {
-			if shrinkHorzCount > 0 {
-				change = diff / shrinkHorzCount
+		if item.StretchFactor > 0 && !ai.grow {
+			maxSize := item.MaxSize.Width
+			if l.vertical {
+				maxSize = item.MaxSize.Height
 			}
-		} else {
-			if growHorzCount > 0 {
-				change = diff / growHorzCount
+
+			if maxSize > 0 {
+				line.max = maxSize
+			} else {
+				line.grow = true
 			}
 		}
 
-		//        log.Stdoutf("*BoxLayout.Update: widgetCount: %d, cb: %+v, prefSizeSum: %+v, diff: %d, change: %d, reqH: %d", widgetCount, cb, prefSizeSum, diff, change, reqH)
+		lines[i] = line
+	}
+
+	available := mainLength
+	if len(items) > 1 {
+		available -= (len(items) - 1) * l.spacing
+	}
 
-		x := cb.X + l.margins.Left
-		for i := 0; i < widgetCount; i++ {
-			widget := widgets[i]
+	sizes := distributeLayoutSpace(lines, available)
 
-			w := prefSizes[i].Width
+	bounds := make([]drawing.Rectangle, len(items))
 
-			switch {
-			case change < 0:
-				if flags[i]&ShrinkHorz > 0 {
-					w += change
-				}
+	for i, item := range items {
+		ai := newLayoutAxisItem(item.Flags, item.MinSize, item.MaxSize, item.PreferredSize, l.vertical)
 
-			case change > 0:
-				if flags[i]&GrowHorz > 0 {
-					w += change
-				}
-			}
+		pos, size := alignLayoutItem(ai, item.Alignment, crossPos, crossLength)
 
-			bounds := drawing.Rectangle{x, cb.Y + l.margins.Top, w, reqH}
+		if l.vertical {
+			bounds[i] = drawing.Rectangle{pos, mainPos, size, sizes[i]}
+		} else {
+			bounds[i] = drawing.Rectangle{mainPos, pos, sizes[i], size}
+		}
+
+		mainPos += sizes[i] + l.spacing
+	}
 
-			//            log.Stdoutf("*BoxLayout.Update: bounds: %+v", bounds)
+	return bounds
+}
 
-			widget.SetBounds(bounds)
+// alignLayoutItem returns the position and size of an item with the
+// constraints ai inside the space starting at pos with the specified length.
+func alignLayoutItem(ai layoutAxisItem, alignment LayoutAlignment, pos, length int) (int, int) {
+	size := length
 
-			x += w + l.spacing
+	if alignment == AlignFill {
+		if !ai.grow && size > ai.max {
+			size = ai.max
 		}
+	} else if size > ai.pref {
+		size = ai.pref
 	}
 
-	return
+	if size < ai.min {
+		size = ai.min
+	}
+
+	switch alignment {
+	case AlignCenter:
+		pos += (length - size) / 2
+
+	case AlignEnd:
+		pos += length - size
+	}
+
+	return pos, size
 }
@@ -0,0 +1,635 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+)
+
+import (
+	"walk/drawing"
+)
+
+// WidgetDesc describes a widget and its descendants, so that Create can build
+// the whole tree at once.
+type WidgetDesc struct {
+	// Kind is the kind of widget to create, e.g. "MainWindow", "Dialog",
+	// "Composite" or "PushButton", see RegisterWidgetKind.
+	Kind string
+
+	// Name identifies the widget in error messages and in the map returned by
+	// Create. It is optional.
+	Name string
+
+	// Properties maps property names like "Text", "Enabled" or "MinSize" to
+	// their values, see RegisterWidgetProperty. They are set in the order in
+	// which the properties were registered.
+	Properties map[string]interface{}
+
+	// Layout is the layout of a container, or nil for the default.
+	Layout *LayoutDesc
+
+	// StretchFactor, Alignment, Row, Column, RowSpan and ColumnSpan place the
+	// widget in the layout of its parent. The ones that do not apply to that
+	// layout are ignored.
+	StretchFactor int
+	Alignment     LayoutAlignment
+	Row           int
+	Column        int
+	RowSpan       int
+	ColumnSpan    int
+
+	// Children describes the children of a container. For a MainWindow, they
+	// are added to its ClientArea.
+	Children []*WidgetDesc
+
+	// Events maps event names like "Clicked" or "TextChanged" to handlers,
+	// see RegisterWidgetEvent. They are attached in the order in which the
+	// events were registered.
+	Events map[string]EventHandler
+
+	// AssignTo is a pointer to a variable that receives the widget, e.g. a
+	// **PushButton or an *IWidget.
+	AssignTo interface{}
+}
+
+// LayoutDesc describes the layout of a container.
+type LayoutDesc struct {
+	// Kind is "HBox", "VBox" or "Grid".
+	Kind    string
+	Margins *Margins
+	Spacing int
+}
+
+// WidgetCreator creates a widget of a registered kind as a child of parent,
+// which is nil for top level windows.
+type WidgetCreator func(parent IContainer) (IWidget, os.Error)
+
+// WidgetPropertySetter sets a property of a widget, or returns an error if the
+// widget does not have the property or value is of the wrong type.
+type WidgetPropertySetter func(widget IWidget, value interface{}) os.Error
+
+// WidgetEventAdder adds handler to an event of a widget, or returns an error
+// if the widget does not have the event.
+type WidgetEventAdder func(widget IWidget, handler EventHandler) os.Error
+
+var (
+	widgetCreatorsByKind  = make(map[string]WidgetCreator)
+	propertySettersByName = make(map[string]WidgetPropertySetter)
+	eventAddersByName     = make(map[string]WidgetEventAdder)
+
+	// propertyNames and eventNames are in the order of registration, which
+	// is the order Create applies them in.
+	propertyNames []string
+	eventNames    []string
+)
+
+// RegisterWidgetKind makes widgets of kind available to Create.
+func RegisterWidgetKind(kind string, creator WidgetCreator) {
+	widgetCreatorsByKind[kind] = creator
+}
+
+// RegisterWidgetProperty makes the property name available to Create.
+// Registering a name again replaces the setter, but keeps its position in the
+// order properties are set in.
+func RegisterWidgetProperty(name string, setter WidgetPropertySetter) {
+	if _, ok := propertySettersByName[name]; !ok {
+		propertyNames = append(propertyNames, name)
+	}
+
+	propertySettersByName[name] = setter
+}
+
+// RegisterWidgetEvent makes the event name available to Create. Registering a
+// name again replaces the adder, but keeps its position in the order events
+// are attached in.
+func RegisterWidgetEvent(name string, adder WidgetEventAdder) {
+	if _, ok := eventAddersByName[name]; !ok {
+		eventNames = append(eventNames, name)
+	}
+
+	eventAddersByName[name] = adder
+}
+
+// Create builds the widget tree described by desc as a child of parent, which
+// is nil if desc describes a top level window. It returns the root widget and
+// the widgets that have a Name.
+//
+// If a widget cannot be created, the widgets created so far are disposed of
+// and the error contains the path of the failing widget, e.g.
+// "MainWindow/Composite[0]/okButton: no such property: Txet".
+func Create(desc *WidgetDesc, parent IContainer) (widget IWidget, widgetsByName map[string]IWidget, err os.Error) {
+	if desc == nil {
+		return nil, nil, newError("desc cannot be nil")
+	}
+
+	b := &builder{widgetsByName: make(map[string]IWidget)}
+
+	path := desc.Name
+	if path == "" {
+		path = desc.Kind
+	}
+
+	if widget, err = b.build(desc, parent, path); err != nil {
+		return nil, nil, err
+	}
+
+	return widget, b.widgetsByName, nil
+}
+
+type builder struct {
+	widgetsByName map[string]IWidget
+}
+
+type builderError struct {
+	path string
+	err  os.Error
+}
+
+func (e *builderError) String() string {
+	return fmt.Sprintf("%s: %s", e.path, e.err.String())
+}
+
+func (b *builder) build(desc *WidgetDesc, parent IContainer, path string) (widget IWidget, err os.Error) {
+	defer func() {
+		if err != nil {
+			if widget != nil {
+				widget.Dispose()
+				widget = nil
+			}
+
+			if _, ok := err.(*builderError); !ok {
+				err = &builderError{path, err}
+			}
+		}
+	}()
+
+	creator, ok := widgetCreatorsByKind[desc.Kind]
+	if !ok {
+		return nil, newError(fmt.Sprintf("unknown widget kind: %s", desc.Kind))
+	}
+
+	if _, ok := parent.(*TabWidget); ok && desc.Kind != "TabPage" {
+		return nil, newError("only TabPages can be added to a TabWidget")
+	}
+
+	if widget, err = creator(parent); err != nil {
+		return
+	}
+
+	if desc.Name != "" {
+		if _, ok := b.widgetsByName[desc.Name]; ok {
+			return widget, newError(fmt.Sprintf("duplicate name: %s", desc.Name))
+		}
+
+		b.widgetsByName[desc.Name] = widget
+	}
+
+	if parent != nil {
+		if err = placeWidget(widget, parent, desc); err != nil {
+			return
+		}
+	}
+
+	var unknown []string
+	for name := range desc.Properties {
+		if _, ok := propertySettersByName[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.SortStrings(unknown)
+		return widget, newError(fmt.Sprintf("no such property: %s", unknown[0]))
+	}
+
+	for name := range desc.Events {
+		if _, ok := eventAddersByName[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.SortStrings(unknown)
+		return widget, newError(fmt.Sprintf("no such event: %s", unknown[0]))
+	}
+
+	for _, name := range propertyNames {
+		value, ok := desc.Properties[name]
+		if !ok {
+			continue
+		}
+
+		if err = propertySettersByName[name](widget, value); err != nil {
+			return widget, newError(fmt.Sprintf("%s: %s", name, err.String()))
+		}
+	}
+
+	for _, name := range eventNames {
+		handler, ok := desc.Events[name]
+		if !ok {
+			continue
+		}
+
+		if err = eventAddersByName[name](widget, handler); err != nil {
+			return widget, newError(fmt.Sprintf("%s: %s", name, err.String()))
+		}
+	}
+
+	container := builderContainer(widget)
+
+	if desc.Layout != nil {
+		if container == nil {
+			return widget, newError("only containers can have a layout")
+		}
+		if _, ok := container.(*TabWidget); ok {
+			return widget, newError("a TabWidget cannot have a layout")
+		}
+
+		var layout Layout
+		if layout, err = createLayout(desc.Layout); err != nil {
+			return
+		}
+
+		container.SetLayout(layout)
+	}
+
+	if len(desc.Children) > 0 && container == nil {
+		return widget, newError("only containers can have children")
+	}
+
+	for i, childDesc := range desc.Children {
+		childPath := childDesc.Name
+		if childPath == "" {
+			childPath = fmt.Sprintf("%s[%d]", childDesc.Kind, i)
+		}
+
+		if _, err = b.build(childDesc, container, path+"/"+childPath); err != nil {
+			return
+		}
+	}
+
+	if desc.AssignTo != nil {
+		err = assignWidget(desc.AssignTo, widget)
+	}
+
+	return
+}
+
+// builderContainer returns the container that receives the children and
+// layout described for widget, or nil if widget is not a container.
+func builderContainer(widget IWidget) IContainer {
+	if mw, ok := widget.(*MainWindow); ok {
+		return mw.ClientArea()
+	}
+
+	container, _ := widget.(IContainer)
+
+	return container
+}
+
+func createLayout(desc *LayoutDesc) (Layout, os.Error) {
+	var layout Layout
+
+	switch desc.Kind {
+	case "HBox":
+		layout = NewHBoxLayout()
+
+	case "VBox":
+		layout = NewVBoxLayout()
+
+	case "Grid":
+		layout = NewGridLayout()
+
+	default:
+		return nil, newError(fmt.Sprintf("unknown layout kind: %s", desc.Kind))
+	}
+
+	if desc.Margins != nil {
+		if err := layout.SetMargins(desc.Margins); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := layout.SetSpacing(desc.Spacing); err != nil {
+		return nil, err
+	}
+
+	return layout, nil
+}
+
+// placeWidget applies the layout parameters of desc to widget, using the
+// layout of parent.
+func placeWidget(widget IWidget, parent IContainer, desc *WidgetDesc) os.Error {
+	if splitter, ok := parent.(*Splitter); ok {
+		if desc.StretchFactor > 0 {
+			return splitter.SetStretchFactor(widget, desc.StretchFactor)
+		}
+
+		return nil
+	}
+
+	switch layout := parent.Layout().(type) {
+	case *BoxLayout:
+		if desc.StretchFactor > 0 {
+			if err := layout.SetStretchFactor(widget, desc.StretchFactor); err != nil {
+				return err
+			}
+		}
+
+		if desc.Alignment != AlignFill {
+			return layout.SetAlignment(widget, desc.Alignment)
+		}
+
+	case *GridLayout:
+		rowSpan, columnSpan := desc.RowSpan, desc.ColumnSpan
+		if rowSpan < 1 {
+			rowSpan = 1
+		}
+		if columnSpan < 1 {
+			columnSpan = 1
+		}
+
+		return layout.SetRange(widget, desc.Row, desc.Column, rowSpan, columnSpan)
+	}
+
+	return nil
+}
+
+// assignWidget stores widget in the variable target points to.
+func assignWidget(target interface{}, widget IWidget) os.Error {
+	if p, ok := target.(*IWidget); ok {
+		*p = widget
+		return nil
+	}
+
+	pv, ok := reflect.NewValue(target).(*reflect.PtrValue)
+	if !ok || pv.IsNil() {
+		return newError("AssignTo must be a non-nil pointer")
+	}
+
+	wv := reflect.NewValue(widget)
+	if pv.Elem().Type() != wv.Type() {
+		return newError(fmt.Sprintf("cannot assign %T to %s", widget, pv.Elem().Type()))
+	}
+
+	pv.Elem().SetValue(wv)
+
+	return nil
+}
+
+func init() {
+	RegisterWidgetKind("MainWindow", func(parent IContainer) (IWidget, os.Error) {
+		if parent != nil {
+			return nil, newError("a MainWindow cannot have a parent")
+		}
+		return NewMainWindow()
+	})
+	RegisterWidgetKind("Dialog", func(parent IContainer) (IWidget, os.Error) {
+		if parent != nil {
+			return nil, newError("a Dialog cannot have a parent")
+		}
+		return NewDialog()
+	})
+	RegisterWidgetKind("CheckBox", func(parent IContainer) (IWidget, os.Error) {
+		return NewCheckBox(parent)
+	})
+	RegisterWidgetKind("ComboBox", func(parent IContainer) (IWidget, os.Error) {
+		return NewComboBox(parent)
+	})
+	RegisterWidgetKind("Composite", func(parent IContainer) (IWidget, os.Error) {
+		return NewComposite(parent)
+	})
+	RegisterWidgetKind("GroupBox", func(parent IContainer) (IWidget, os.Error) {
+		return NewGroupBox(parent)
+	})
+	RegisterWidgetKind("ImageView", func(parent IContainer) (IWidget, os.Error) {
+		return NewImageView(parent)
+	})
+	RegisterWidgetKind("Label", func(parent IContainer) (IWidget, os.Error) {
+		return NewLabel(parent)
+	})
+	RegisterWidgetKind("LineEdit", func(parent IContainer) (IWidget, os.Error) {
+		return NewLineEdit(parent)
+	})
+	RegisterWidgetKind("ListView", func(parent IContainer) (IWidget, os.Error) {
+		return NewListView(parent)
+	})
+	RegisterWidgetKind("ProgressBar", func(parent IContainer) (IWidget, os.Error) {
+		return NewProgressBar(parent)
+	})
+	RegisterWidgetKind("PushButton", func(parent IContainer) (IWidget, os.Error) {
+		return NewPushButton(parent)
+	})
+	RegisterWidgetKind("RadioButton", func(parent IContainer) (IWidget, os.Error) {
+		return NewRadioButton(parent)
+	})
+	RegisterWidgetKind("Splitter", func(parent IContainer) (IWidget, os.Error) {
+		return NewSplitter(parent)
+	})
+	RegisterWidgetKind("TabPage", func(parent IContainer) (IWidget, os.Error) {
+		tw, ok := parent.(*TabWidget)
+		if !ok {
+			return nil, newError("a TabPage must be a child of a TabWidget")
+		}
+		page, err := NewTabPage()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tw.Pages().Add(page); err != nil {
+			page.Dispose()
+			return nil, err
+		}
+		return page, nil
+	})
+	RegisterWidgetKind("TabWidget", func(parent IContainer) (IWidget, os.Error) {
+		return NewTabWidget(parent)
+	})
+	RegisterWidgetKind("TextEdit", func(parent IContainer) (IWidget, os.Error) {
+		return NewTextEdit(parent)
+	})
+	RegisterWidgetKind("TreeView", func(parent IContainer) (IWidget, os.Error) {
+		return NewTreeView(parent)
+	})
+
+	RegisterWidgetProperty("Text", func(widget IWidget, value interface{}) os.Error {
+		s, ok := value.(string)
+		if !ok {
+			return wrongPropertyType(value, "string")
+		}
+		return widget.SetText(s)
+	})
+	RegisterWidgetProperty("Enabled", func(widget IWidget, value interface{}) os.Error {
+		b, ok := value.(bool)
+		if !ok {
+			return wrongPropertyType(value, "bool")
+		}
+		return widget.SetEnabled(b)
+	})
+	RegisterWidgetProperty("Visible", func(widget IWidget, value interface{}) os.Error {
+		b, ok := value.(bool)
+		if !ok {
+			return wrongPropertyType(value, "bool")
+		}
+		return widget.SetVisible(b)
+	})
+	RegisterWidgetProperty("Font", func(widget IWidget, value interface{}) os.Error {
+		switch v := value.(type) {
+		case *drawing.Font:
+			widget.SetFont(v)
+
+		case *FontDesc:
+			font, err := drawing.NewFont(v.Family, v.PointSize, v.Style)
+			if err != nil {
+				return err
+			}
+			widget.SetFont(font)
+
+		default:
+			return wrongPropertyType(value, "*drawing.Font")
+		}
+		return nil
+	})
+	RegisterWidgetProperty("MinSize", func(widget IWidget, value interface{}) os.Error {
+		size, ok := value.(drawing.Size)
+		if !ok {
+			return wrongPropertyType(value, "drawing.Size")
+		}
+		return widget.SetMinSize(size)
+	})
+	RegisterWidgetProperty("MaxSize", func(widget IWidget, value interface{}) os.Error {
+		size, ok := value.(drawing.Size)
+		if !ok {
+			return wrongPropertyType(value, "drawing.Size")
+		}
+		return widget.SetMaxSize(size)
+	})
+	RegisterWidgetProperty("Size", func(widget IWidget, value interface{}) os.Error {
+		size, ok := value.(drawing.Size)
+		if !ok {
+			return wrongPropertyType(value, "drawing.Size")
+		}
+		return widget.SetSize(size)
+	})
+	RegisterWidgetProperty("Checked", func(widget IWidget, value interface{}) os.Error {
+		w, ok := widget.(interface {
+			SetChecked(value bool)
+		})
+		if !ok {
+			return newError("not supported by this widget")
+		}
+		b, ok := value.(bool)
+		if !ok {
+			return wrongPropertyType(value, "bool")
+		}
+		w.SetChecked(b)
+		return nil
+	})
+	RegisterWidgetProperty("CueBanner", func(widget IWidget, value interface{}) os.Error {
+		le, ok := widget.(*LineEdit)
+		if !ok {
+			return newError("not supported by this widget")
+		}
+		s, ok := value.(string)
+		if !ok {
+			return wrongPropertyType(value, "string")
+		}
+		return le.SetCueBanner(s)
+	})
+	RegisterWidgetProperty("Validator", func(widget IWidget, value interface{}) os.Error {
+		w, ok := widget.(Validatable)
+		if !ok {
+			return newError("not supported by this widget")
+		}
+		v, ok := value.(Validator)
+		if !ok {
+			return wrongPropertyType(value, "Validator")
+		}
+		w.SetValidator(v)
+		return nil
+	})
+	RegisterWidgetProperty("Title", func(widget IWidget, value interface{}) os.Error {
+		tp, ok := widget.(*TabPage)
+		if !ok {
+			return newError("not supported by this widget")
+		}
+		s, ok := value.(string)
+		if !ok {
+			return wrongPropertyType(value, "string")
+		}
+		return tp.SetTitle(s)
+	})
+	RegisterWidgetProperty("Orientation", func(widget IWidget, value interface{}) os.Error {
+		s, ok := widget.(*Splitter)
+		if !ok {
+			return newError("not supported by this widget")
+		}
+		o, ok := value.(Orientation)
+		if !ok {
+			return wrongPropertyType(value, "Orientation")
+		}
+		return s.SetOrientation(o)
+	})
+
+	RegisterWidgetEvent("SizeChanged", func(widget IWidget, handler EventHandler) os.Error {
+		widget.SizeChanged().Attach(handler)
+		return nil
+	})
+	RegisterWidgetEvent("Clicked", func(widget IWidget, handler EventHandler) os.Error {
+		w, ok := widget.(interface {
+			Clicked() *Event
+		})
+		if !ok {
+			return newError("not supported by this widget")
+		}
+		w.Clicked().Attach(handler)
+		return nil
+	})
+	RegisterWidgetEvent("TextChanged", func(widget IWidget, handler EventHandler) os.Error {
+		w, ok := widget.(interface {
+			TextChanged() *Event
+		})
+		if !ok {
+			return newError("not supported by this widget")
+		}
+		w.TextChanged().Attach(handler)
+		return nil
+	})
+	RegisterWidgetEvent("CurrentIndexChanged", func(widget IWidget, handler EventHandler) os.Error {
+		w, ok := widget.(interface {
+			CurrentIndexChanged() *Event
+		})
+		if !ok {
+			return newError("not supported by this widget")
+		}
+		w.CurrentIndexChanged().Attach(handler)
+		return nil
+	})
+	RegisterWidgetEvent("SelectedIndexChanged", func(widget IWidget, handler EventHandler) os.Error {
+		w, ok := widget.(interface {
+			SelectedIndexChanged() *Event
+		})
+		if !ok {
+			return newError("not supported by this widget")
+		}
+		w.SelectedIndexChanged().Attach(handler)
+		return nil
+	})
+	RegisterWidgetEvent("ItemActivated", func(widget IWidget, handler EventHandler) os.Error {
+		w, ok := widget.(interface {
+			ItemActivated() *Event
+		})
+		if !ok {
+			return newError("not supported by this widget")
+		}
+		w.ItemActivated().Attach(handler)
+		return nil
+	})
+}
+
+func wrongPropertyType(value interface{}, typeName string) os.Error {
+	return newError(fmt.Sprintf("expected a value of type %s, got %T", typeName, value))
+}
@@ -0,0 +1,213 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+import (
+	"walk/drawing"
+)
+
+// builderOrder records the order the builder sets the test properties in.
+var builderOrder []string
+
+func init() {
+	for _, name := range []string{"TestFirst", "TestSecond", "TestThird"} {
+		name := name
+		RegisterWidgetProperty(name, func(widget IWidget, value interface{}) os.Error {
+			builderOrder = append(builderOrder, name)
+			return nil
+		})
+	}
+}
+
+func TestCreate(t *testing.T) {
+	b, _ := newTestMainWindow(t)
+
+	var clicked int
+	var okButton *PushButton
+
+	desc := &WidgetDesc{
+		Kind:   "MainWindow",
+		Layout: &LayoutDesc{Kind: "VBox"},
+		Properties: map[string]interface{}{
+			"Text": "Settings",
+			"Size": drawing.Size{400, 300},
+		},
+		Children: []*WidgetDesc{
+			{
+				Kind: "TabWidget",
+				Name: "tabs",
+				Children: []*WidgetDesc{
+					{
+						Kind:       "TabPage",
+						Properties: map[string]interface{}{"Title": "General"},
+						Layout:     &LayoutDesc{Kind: "Grid"},
+						Children: []*WidgetDesc{
+							{Kind: "LineEdit", Name: "nameEdit", Column: 1, Properties: map[string]interface{}{"Text": "Jane"}},
+						},
+					},
+					{
+						Kind:       "TabPage",
+						Properties: map[string]interface{}{"Title": "Advanced"},
+					},
+				},
+			},
+			{
+				Kind:       "PushButton",
+				Name:       "okButton",
+				Properties: map[string]interface{}{"Text": "OK", "Enabled": false},
+				Events: map[string]EventHandler{"Clicked": func(args EventArgs) {
+					clicked++
+				}},
+				AssignTo: &okButton,
+			},
+		},
+	}
+
+	widget, widgetsByName, err := Create(desc, nil)
+	if err != nil {
+		t.Fatalf("Create failed: %s", err)
+	}
+
+	mw, ok := widget.(*MainWindow)
+	if !ok {
+		t.Fatalf("expected a *MainWindow, got %T", widget)
+	}
+	if text := mw.Text(); text != "Settings" {
+		t.Errorf("expected the title Settings, got %q", text)
+	}
+	if _, ok := mw.ClientArea().Layout().(*BoxLayout); !ok {
+		t.Errorf("expected a BoxLayout, got %T", mw.ClientArea().Layout())
+	}
+
+	if len(widgetsByName) != 3 {
+		t.Errorf("expected 3 named widgets, got %v", widgetsByName)
+	}
+
+	tabs := widgetsByName["tabs"].(*TabWidget)
+	if n := tabs.Pages().Len(); n != 2 {
+		t.Fatalf("expected 2 pages, got %d", n)
+	}
+	if title := tabs.Pages().At(1).Title(); title != "Advanced" {
+		t.Errorf("expected the title Advanced, got %q", title)
+	}
+
+	nameEdit := widgetsByName["nameEdit"]
+	if nameEdit.Parent() != IContainer(tabs.Pages().At(0)) || nameEdit.Text() != "Jane" {
+		t.Errorf("expected the LineEdit on the first page with the text Jane")
+	}
+
+	if okButton == nil || IWidget(okButton) != widgetsByName["okButton"] {
+		t.Fatal("expected the PushButton to be assigned")
+	}
+	if enabled, _ := okButton.Enabled(); enabled {
+		t.Error("expected the PushButton to be disabled")
+	}
+
+	okButton.SetEnabled(true)
+	b.Click(okButton)
+	if clicked != 1 {
+		t.Errorf("expected the Clicked handler to be called once, got %d", clicked)
+	}
+}
+
+func TestCreatePropertyOrder(t *testing.T) {
+	newTestMainWindow(t)
+
+	for i := 0; i < 10; i++ {
+		builderOrder = nil
+
+		_, _, err := Create(&WidgetDesc{
+			Kind: "MainWindow",
+			Properties: map[string]interface{}{
+				"TestThird":  nil,
+				"TestFirst":  nil,
+				"TestSecond": nil,
+			},
+		}, nil)
+		if err != nil {
+			t.Fatalf("Create failed: %s", err)
+		}
+
+		if fmt.Sprint(builderOrder) != "[TestFirst TestSecond TestThird]" {
+			t.Fatalf("expected the properties in the order of registration, got %v", builderOrder)
+		}
+	}
+}
+
+func TestCreateErrors(t *testing.T) {
+	b, _ := newTestMainWindow(t)
+
+	tests := []struct {
+		desc     *WidgetDesc
+		expected string
+	}{
+		{
+			&WidgetDesc{Kind: "Frobnicator"},
+			"Frobnicator: unknown widget kind: Frobnicator",
+		},
+		{
+			&WidgetDesc{
+				Kind: "MainWindow",
+				Children: []*WidgetDesc{{Kind: "Composite", Children: []*WidgetDesc{{
+					Kind:       "PushButton",
+					Name:       "okButton",
+					Properties: map[string]interface{}{"Txet": "OK", "Enabeld": true, "Text": "OK"},
+				}}}},
+			},
+			"MainWindow/Composite[0]/okButton: no such property: Enabeld",
+		},
+		{
+			&WidgetDesc{Kind: "MainWindow", Events: map[string]EventHandler{"Clikced": nil}},
+			"MainWindow: no such event: Clikced",
+		},
+		{
+			&WidgetDesc{Kind: "MainWindow", Children: []*WidgetDesc{{Kind: "TabPage"}}},
+			"MainWindow/TabPage[0]: a TabPage must be a child of a TabWidget",
+		},
+		{
+			&WidgetDesc{Kind: "MainWindow", Children: []*WidgetDesc{{Kind: "TabWidget", Layout: &LayoutDesc{Kind: "VBox"}}}},
+			"MainWindow/TabWidget[0]: a TabWidget cannot have a layout",
+		},
+		{
+			&WidgetDesc{Kind: "MainWindow", Children: []*WidgetDesc{{Kind: "TabWidget", Children: []*WidgetDesc{{Kind: "Label"}}}}},
+			"MainWindow/TabWidget[0]/Label[0]: only TabPages can be added to a TabWidget",
+		},
+		{
+			&WidgetDesc{Kind: "Label", Properties: map[string]interface{}{"Title": "x"}},
+			"Label: Title: not supported by this widget",
+		},
+	}
+
+	windows := len(b.windows)
+
+	for _, test := range tests {
+		var parent IContainer
+		if test.desc.Kind == "Label" {
+			parent = newTestComposite(t, nil)
+			windows = len(b.windows)
+		}
+
+		_, _, err := Create(test.desc, parent)
+		if err == nil {
+			t.Errorf("expected the error %q", test.expected)
+			continue
+		}
+
+		if !strings.HasPrefix(err.String(), test.expected+"\n") {
+			t.Errorf("expected the error %q, got %q", test.expected, err.String())
+		}
+
+		if n := len(b.windows); n != windows {
+			t.Errorf("%s: expected the created windows to be destroyed, got %d windows instead of %d", test.expected, n, windows)
+		}
+	}
+}
@@ -5,7 +5,7 @@
 package gui
 
 import (
-	"container/vector"
+	"os"
 )
 
 import (
@@ -16,13 +16,19 @@ type clickable interface {
 	raiseClicked()
 }
 
+// commandNotified is implemented by widgets that handle the notifications
+// their parent receives from them with WM_COMMAND.
+type commandNotified interface {
+	onCommand(notificationCode uint16)
+}
+
 type Button struct {
 	Widget
-	clickedHandlers vector.Vector
+	clickedPublisher EventPublisher
 }
 
 func (b *Button) Checked() bool {
-	return SendMessage(b.hWnd, BM_GETCHECK, 0, 0) == BST_CHECKED
+	return backend.SendMessage(b.hWnd, BM_GETCHECK, 0, 0) == BST_CHECKED
 }
 
 func (b *Button) SetChecked(value bool) {
@@ -34,25 +40,34 @@ func (b *Button) SetChecked(value bool) {
 		chk = BST_UNCHECKED
 	}
 
-	SendMessage(b.hWnd, BM_SETCHECK, chk, 0)
+	backend.SendMessage(b.hWnd, BM_SETCHECK, chk, 0)
 }
 
-func (b *Button) AddClickedHandler(handler EventHandler) {
-	b.clickedHandlers.Push(handler)
-}
+// CheckedProperty returns a Property with a bool value that reflects the
+// check state of the button.
+func (b *Button) CheckedProperty() Property {
+	return &funcProperty{
+		get: func() interface{} {
+			return b.Checked()
+		},
+		set: func(value interface{}) os.Error {
+			checked, ok := value.(bool)
+			if !ok {
+				return newError("value must be a bool")
+			}
+
+			b.SetChecked(checked)
 
-func (b *Button) RemoveClickedHandler(handler EventHandler) {
-	for i, h := range b.clickedHandlers {
-		if h.(EventHandler) == handler {
-			b.clickedHandlers.Delete(i)
-			break
-		}
+			return nil
+		},
+		changed: b.Clicked(),
 	}
 }
 
+func (b *Button) Clicked() *Event {
+	return b.clickedPublisher.Event()
+}
+
 func (b *Button) raiseClicked() {
-	for _, handlerIface := range b.clickedHandlers {
-		handler := handlerIface.(EventHandler)
-		handler(&eventArgs{widgetsByHWnd[b.hWnd]})
-	}
+	b.clickedPublisher.Publish(&eventArgs{widgetsByHWnd[b.hWnd]})
 }
@@ -0,0 +1,204 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"bytes"
+	"fmt"
+	"gob"
+	"os"
+)
+
+import (
+	"walk/drawing"
+)
+
+// ClipboardService provides access to the clipboard of the current Backend.
+//
+// Text and images are stored in the standard formats, so other applications
+// can paste them. Any other format is a custom one, its data is serialized
+// with gob, so only Go applications that know its type can read it.
+type ClipboardService struct {
+	contentsChangedPublisher EventPublisher
+	sequenceNumber           uint
+	watching                 bool
+}
+
+var clipboard ClipboardService
+
+// Clipboard returns the ClipboardService.
+func Clipboard() *ClipboardService {
+	if !clipboard.watching {
+		clipboard.sequenceNumber = backend.ClipboardSequenceNumber()
+		clipboard.watching = true
+	}
+
+	return &clipboard
+}
+
+// ContentsChanged is raised when the contents of the clipboard have changed.
+// Changes by other applications are noticed when the message loop becomes
+// idle.
+func (c *ClipboardService) ContentsChanged() *Event {
+	return c.contentsChangedPublisher.Event()
+}
+
+// checkContentsChanged publishes ContentsChanged if the clipboard has changed
+// since the last check.
+func (c *ClipboardService) checkContentsChanged() {
+	if !c.watching {
+		return
+	}
+
+	seq := backend.ClipboardSequenceNumber()
+	if seq == c.sequenceNumber {
+		return
+	}
+
+	c.sequenceNumber = seq
+
+	c.contentsChangedPublisher.Publish(&eventArgs{c})
+}
+
+func (c *ClipboardService) set(values map[string]interface{}) os.Error {
+	if err := backend.SetClipboardData(values); err != nil {
+		return err
+	}
+
+	c.checkContentsChanged()
+
+	return nil
+}
+
+// Clear removes all data from the clipboard.
+func (c *ClipboardService) Clear() os.Error {
+	return c.set(nil)
+}
+
+func (c *ClipboardService) ContainsText() bool {
+	return backend.ClipboardContains(TextFormat)
+}
+
+// Text returns the text on the clipboard, or "" if there is none.
+func (c *ClipboardService) Text() (string, os.Error) {
+	if !c.ContainsText() {
+		return "", nil
+	}
+
+	value, err := backend.ClipboardData(TextFormat)
+	if err != nil {
+		return "", err
+	}
+
+	text, _ := value.(string)
+
+	return text, nil
+}
+
+// SetText replaces the contents of the clipboard with value.
+func (c *ClipboardService) SetText(value string) os.Error {
+	return c.set(map[string]interface{}{TextFormat: value})
+}
+
+func (c *ClipboardService) ContainsImage() bool {
+	return backend.ClipboardContains(ImageFormat)
+}
+
+// Image returns a copy of the image on the clipboard, or nil if there is
+// none.
+func (c *ClipboardService) Image() (*drawing.Bitmap, os.Error) {
+	if !c.ContainsImage() {
+		return nil, nil
+	}
+
+	value, err := backend.ClipboardData(ImageFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	bmp, _ := value.(*drawing.Bitmap)
+
+	return bmp, nil
+}
+
+// SetImage replaces the contents of the clipboard with value.
+func (c *ClipboardService) SetImage(value *drawing.Bitmap) os.Error {
+	if value == nil {
+		return newError("value cannot be nil")
+	}
+
+	return c.set(map[string]interface{}{ImageFormat: value})
+}
+
+// ContainsData returns whether the clipboard holds data in format.
+func (c *ClipboardService) ContainsData(format string) bool {
+	return backend.ClipboardContains(format)
+}
+
+// Data decodes the data in the custom format into value, which must be a
+// pointer to the type of the data passed to SetData.
+func (c *ClipboardService) Data(format string, value interface{}) os.Error {
+	raw, err := backend.ClipboardData(format)
+	if err != nil {
+		return err
+	}
+
+	data, ok := raw.([]byte)
+	if !ok {
+		return newError(fmt.Sprintf("%q is not a custom format", format))
+	}
+
+	return gob.NewDecoder(bytes.NewBuffer(data)).Decode(value)
+}
+
+// SetData replaces the contents of the clipboard with value in format.
+func (c *ClipboardService) SetData(format string, value interface{}) os.Error {
+	if value == nil {
+		return newError("value cannot be nil")
+	}
+
+	data := NewDataObject()
+	data.SetData(format, value)
+
+	return c.SetDataObject(data)
+}
+
+// SetDataObject replaces the contents of the clipboard with the data in all
+// formats of data, so applications can paste the format they understand best.
+func (c *ClipboardService) SetDataObject(data *DataObject) os.Error {
+	if data == nil {
+		return newError("data cannot be nil")
+	}
+
+	values := make(map[string]interface{})
+
+	for _, format := range data.Formats() {
+		value := data.Data(format)
+
+		switch format {
+		case TextFormat:
+			if _, ok := value.(string); !ok {
+				return newError(fmt.Sprintf("data in TextFormat must be a string, not %T", value))
+			}
+
+		case ImageFormat:
+			if _, ok := value.(*drawing.Bitmap); !ok {
+				return newError(fmt.Sprintf("data in ImageFormat must be a *drawing.Bitmap, not %T", value))
+			}
+
+		default:
+			buf := bytes.NewBuffer(nil)
+			if err := gob.NewEncoder(buf).Encode(value); err != nil {
+				return err
+			}
+
+			value = buf.Bytes()
+		}
+
+		values[format] = value
+	}
+
+	return c.set(values)
+}
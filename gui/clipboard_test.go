@@ -0,0 +1,222 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"bytes"
+	"image"
+	"os"
+	"testing"
+)
+
+import (
+	"walk/drawing"
+	. "walk/winapi/user32"
+)
+
+type testClipboardData struct {
+	Name  string
+	Count int
+}
+
+// newTestClipboard returns the clipboard of a new MemoryBackend.
+func newTestClipboard(t *testing.T) (*MemoryBackend, *ClipboardService) {
+	b, _ := newTestMainWindow(t)
+
+	// The sequence number of the last backend is meaningless.
+	clipboard = ClipboardService{}
+
+	return b, Clipboard()
+}
+
+// newTestBitmap returns a new bitmap of size with a red pixel in the top
+// right corner. The bitmap can be used without GDI.
+func newTestBitmap(t *testing.T, size drawing.Size) *drawing.Bitmap {
+	img := image.NewRGBA(size.Width, size.Height)
+	img.Set(size.Width-1, 0, image.RGBAColor{0xff, 0, 0, 0xff})
+
+	bmp, err := drawing.NewBitmapFromImage(img)
+	if err != nil {
+		t.Fatalf("NewBitmapFromImage failed: %s", err)
+	}
+
+	return bmp
+}
+
+func TestClipboardText(t *testing.T) {
+	_, c := newTestClipboard(t)
+
+	var changes int
+	c.ContentsChanged().Attach(func(args EventArgs) {
+		changes++
+	})
+
+	if text, err := c.Text(); c.ContainsText() || text != "" || err != nil {
+		t.Errorf("expected an empty clipboard, got %q", text)
+	}
+
+	if err := c.SetText("hello"); err != nil {
+		t.Fatalf("SetText failed: %s", err)
+	}
+	if text, _ := c.Text(); !c.ContainsText() || text != "hello" {
+		t.Errorf("expected the text hello, got %q", text)
+	}
+	if c.ContainsImage() || c.ContainsData("custom") {
+		t.Error("expected SetText to replace the contents")
+	}
+
+	if err := c.Clear(); err != nil {
+		t.Fatalf("Clear failed: %s", err)
+	}
+	if c.ContainsText() {
+		t.Error("expected Clear to remove the text")
+	}
+
+	if changes != 2 {
+		t.Errorf("expected 2 changes, got %d", changes)
+	}
+}
+
+func TestClipboardContentsChangedByOthers(t *testing.T) {
+	b, c := newTestClipboard(t)
+
+	var changes int
+	c.ContentsChanged().Attach(func(args EventArgs) {
+		changes++
+	})
+
+	b.SetClipboardData(map[string]interface{}{TextFormat: "from another application"})
+	if changes != 0 {
+		t.Error("expected the change to be noticed only when the message loop becomes idle")
+	}
+
+	b.PostMessage(0, WM_NULL, 0, 0)
+	b.RunMessageLoop(func() bool { return true })
+	if changes != 1 {
+		t.Errorf("expected 1 change, got %d", changes)
+	}
+	if text, _ := c.Text(); text != "from another application" {
+		t.Errorf("unexpected text %q", text)
+	}
+
+	b.PostMessage(0, WM_NULL, 0, 0)
+	b.RunMessageLoop(func() bool { return true })
+	if changes != 1 {
+		t.Errorf("expected no change without new contents, got %d", changes)
+	}
+}
+
+func TestClipboardData(t *testing.T) {
+	_, c := newTestClipboard(t)
+
+	data := NewDataObject()
+	data.SetText("two items")
+	data.SetData("walk/gui.test", &testClipboardData{"items", 2})
+
+	if err := c.SetDataObject(data); err != nil {
+		t.Fatalf("SetDataObject failed: %s", err)
+	}
+
+	if text, _ := c.Text(); text != "two items" {
+		t.Errorf("expected the text in all formats, got %q", text)
+	}
+
+	var value testClipboardData
+	if err := c.Data("walk/gui.test", &value); err != nil {
+		t.Fatalf("Data failed: %s", err)
+	}
+	if value != (testClipboardData{"items", 2}) {
+		t.Errorf("unexpected data %+v", value)
+	}
+
+	if err := c.Data(TextFormat, &value); err == nil {
+		t.Error("expected an error decoding a standard format")
+	}
+	if err := c.Data("missing", &value); err == nil {
+		t.Error("expected an error for a missing format")
+	}
+
+	if err := c.SetData("walk/gui.test", 42); err != nil {
+		t.Fatalf("SetData failed: %s", err)
+	}
+	if c.ContainsText() {
+		t.Error("expected SetData to replace the contents")
+	}
+
+	var n int
+	if err := c.Data("walk/gui.test", &n); err != nil || n != 42 {
+		t.Errorf("expected 42, got %d and %v", n, err)
+	}
+}
+
+func TestClipboardErrors(t *testing.T) {
+	_, c := newTestClipboard(t)
+	c.SetText("kept")
+
+	text := NewDataObject()
+	text.SetData(TextFormat, 42)
+
+	image := NewDataObject()
+	image.SetData(ImageFormat, "not an image")
+
+	for i, err := range []os.Error{
+		c.SetDataObject(nil),
+		c.SetDataObject(text),
+		c.SetDataObject(image),
+		c.SetData("walk/gui.test", nil),
+		c.SetImage(nil),
+	} {
+		if err == nil {
+			t.Errorf("%d: expected an error", i)
+		}
+	}
+
+	if s, _ := c.Text(); s != "kept" {
+		t.Errorf("expected failed calls to keep the contents, got %q", s)
+	}
+
+	if bmp, err := c.Image(); bmp != nil || err != nil {
+		t.Errorf("expected no image, got %v and %v", bmp, err)
+	}
+}
+
+func TestClipboardImage(t *testing.T) {
+	b, c := newTestClipboard(t)
+
+	bmp := newTestBitmap(t, drawing.Size{3, 2})
+	defer bmp.Dispose()
+
+	expected, err := bmp.PackedDIB()
+	if err != nil {
+		t.Fatalf("PackedDIB failed: %s", err)
+	}
+
+	if err := c.SetImage(bmp); err != nil {
+		t.Fatalf("SetImage failed: %s", err)
+	}
+	if _, ok := b.clipboard[ImageFormat].(*image.RGBA); !ok {
+		t.Errorf("expected the backend to keep the pixels, got %T", b.clipboard[ImageFormat])
+	}
+
+	first, err := c.Image()
+	if err != nil {
+		t.Fatalf("Image failed: %s", err)
+	}
+	defer first.Dispose()
+
+	second, _ := c.Image()
+	defer second.Dispose()
+
+	if first == bmp || first == second {
+		t.Error("expected Image to return a new copy each time")
+	}
+	if first.Size() != (drawing.Size{3, 2}) {
+		t.Errorf("expected the size 3x2, got %v", first.Size())
+	}
+
+	if data, _ := first.PackedDIB(); !bytes.Equal(data, expected) {
+		t.Error("expected the copy to have the pixels of the bitmap")
+	}
+}
@@ -6,18 +6,19 @@ package gui
 
 import (
 	"os"
-	"syscall"
 	"unsafe"
 )
 
 import (
 	"walk/drawing"
+	. "walk/winapi"
 	. "walk/winapi/user32"
 )
 
 type ComboBox struct {
 	Widget
-	items *ComboBoxItemList
+	items                        *ComboBoxItemList
+	currentIndexChangedPublisher EventPublisher
 }
 
 func NewComboBox(parent IContainer) (*ComboBox, os.Error) {
@@ -25,19 +26,19 @@ func NewComboBox(parent IContainer) (*ComboBox, os.Error) {
 		return nil, newError("parent cannot be nil")
 	}
 
-	hWnd := CreateWindowEx(
-		0, syscall.StringToUTF16Ptr("COMBOBOX"), nil,
+	hWnd, err := backend.CreateWindow(
+		0, "COMBOBOX",
 		CBS_DROPDOWN|WS_CHILD|WS_TABSTOP|WS_VISIBLE,
-		0, 0, 0, 0, parent.Handle(), 0, 0, nil)
-	if hWnd == 0 {
-		return nil, lastError("CreateWindowEx")
+		parent.Handle(), drawing.Rectangle{0, 0, 0, 0})
+	if err != nil {
+		return nil, err
 	}
 
 	cb := &ComboBox{Widget: Widget{hWnd: hWnd, parent: parent}}
 
 	cb.items = newComboBoxItemList(cb)
 
-	cb.SetFont(defaultFont)
+	cb.SetFont(backend.DefaultFont())
 
 	widgetsByHWnd[hWnd] = cb
 
@@ -54,8 +55,71 @@ func (cb *ComboBox) PreferredSize() drawing.Size {
 	return cb.dialogBaseUnitsToPixels(drawing.Size{50, 14})
 }
 
+func (cb *ComboBox) Items() *ComboBoxItemList {
+	return cb.items
+}
+
+// CurrentIndex returns the index of the selected item or -1 if none is
+// selected.
+func (cb *ComboBox) CurrentIndex() int {
+	return int(backend.SendMessage(cb.hWnd, CB_GETCURSEL, 0, 0))
+}
+
+// SetCurrentIndex selects the item at value. Pass -1 to clear the selection.
+func (cb *ComboBox) SetCurrentIndex(value int) os.Error {
+	if value < -1 || value >= cb.items.Len() {
+		return newError("value out of range")
+	}
+
+	if value == cb.CurrentIndex() {
+		return nil
+	}
+
+	if CB_ERR == backend.SendMessage(cb.hWnd, CB_SETCURSEL, uintptr(value), 0) && value != -1 {
+		return newError("CB_SETCURSEL failed")
+	}
+
+	cb.raiseCurrentIndexChanged()
+
+	return nil
+}
+
+func (cb *ComboBox) CurrentIndexChanged() *Event {
+	return cb.currentIndexChangedPublisher.Event()
+}
+
+func (cb *ComboBox) raiseCurrentIndexChanged() {
+	cb.currentIndexChangedPublisher.Publish(&eventArgs{widgetsByHWnd[cb.hWnd]})
+}
+
+// CurrentIndexProperty returns a Property with an int value that reflects
+// the index of the selected item.
+func (cb *ComboBox) CurrentIndexProperty() Property {
+	return &funcProperty{
+		get: func() interface{} {
+			return cb.CurrentIndex()
+		},
+		set: func(value interface{}) os.Error {
+			index, ok := value.(int)
+			if !ok {
+				return newError("value must be an int")
+			}
+
+			return cb.SetCurrentIndex(index)
+		},
+		changed: cb.CurrentIndexChanged(),
+	}
+}
+
+func (cb *ComboBox) onCommand(notificationCode uint16) {
+	switch notificationCode {
+	case CBN_SELCHANGE:
+		cb.raiseCurrentIndexChanged()
+	}
+}
+
 func (cb *ComboBox) onInsertingComboBoxItem(index int, item *ComboBoxItem) (err os.Error) {
-	if CB_ERR == SendMessage(cb.hWnd, CB_INSERTSTRING, uintptr(index), uintptr(unsafe.Pointer(syscall.StringToUTF16Ptr(item.text)))) {
+	if CB_ERR == backend.SendMessage(cb.hWnd, CB_INSERTSTRING, uintptr(index), uintptr(unsafe.Pointer(StringToUTF16Ptr(item.text)))) {
 		err = newError("CB_INSERTSTRING failed")
 	}
 
@@ -63,7 +127,7 @@ func (cb *ComboBox) onInsertingComboBoxItem(index int, item *ComboBoxItem) (err
 }
 
 func (cb *ComboBox) onRemovingComboBoxItem(index int, item *ComboBoxItem) (err os.Error) {
-	if CB_ERR == SendMessage(cb.hWnd, CB_DELETESTRING, uintptr(index), 0) {
+	if CB_ERR == backend.SendMessage(cb.hWnd, CB_DELETESTRING, uintptr(index), 0) {
 		err = newError("CB_DELETESTRING failed")
 	}
 
@@ -71,7 +135,7 @@ func (cb *ComboBox) onRemovingComboBoxItem(index int, item *ComboBoxItem) (err o
 }
 
 func (cb *ComboBox) onClearingComboBoxItems() (err os.Error) {
-	SendMessage(cb.hWnd, CB_RESETCONTENT, 0, 0)
+	backend.SendMessage(cb.hWnd, CB_RESETCONTENT, 0, 0)
 
 	return
 }
@@ -7,11 +7,11 @@ package gui
 import (
 	"fmt"
 	"os"
-	"syscall"
 	"unsafe"
 )
 
 import (
+	. "walk/winapi"
 	. "walk/winapi/comdlg32"
 )
 
@@ -30,7 +30,7 @@ func (dlg *FileDialog) show(owner RootWidget, fun func(ofn *OPENFILENAME) bool)
 	ofn.HwndOwner = owner.Handle()
 
 	filter := make([]uint16, len(dlg.Filter)+1)
-	copy(filter, syscall.StringToUTF16(dlg.Filter))
+	copy(filter, StringToUTF16(dlg.Filter))
 	// Replace '|' with the expected '\0'.
 	for i, c := range filter {
 		if byte(c) == '|' {
@@ -41,12 +41,12 @@ func (dlg *FileDialog) show(owner RootWidget, fun func(ofn *OPENFILENAME) bool)
 	ofn.NFilterIndex = uint(dlg.FilterIndex)
 
 	filePath := make([]uint16, 1024)
-	copy(filePath, syscall.StringToUTF16(dlg.FilePath))
+	copy(filePath, StringToUTF16(dlg.FilePath))
 	ofn.LpstrFile = &filePath[0]
 	ofn.NMaxFile = uint(len(filePath))
 
-	ofn.LpstrInitialDir = syscall.StringToUTF16Ptr(dlg.InitialDirPath)
-	ofn.LpstrTitle = syscall.StringToUTF16Ptr(dlg.Title)
+	ofn.LpstrInitialDir = StringToUTF16Ptr(dlg.InitialDirPath)
+	ofn.LpstrTitle = StringToUTF16Ptr(dlg.Title)
 	ofn.Flags = OFN_FILEMUSTEXIST
 
 	if !fun(ofn) {
@@ -57,7 +57,7 @@ func (dlg *FileDialog) show(owner RootWidget, fun func(ofn *OPENFILENAME) bool)
 		return
 	}
 
-	dlg.FilePath = syscall.UTF16ToString(filePath)
+	dlg.FilePath = UTF16ToString(filePath)
 
 	accepted = true
 
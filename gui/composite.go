@@ -6,7 +6,6 @@ package gui
 
 import (
 	"os"
-	"syscall"
 )
 
 import (
@@ -16,16 +15,12 @@ import (
 
 const compositeWindowClass = `\o/ Walk_Composite_Class \o/`
 
-var compositeWindowWndProcCallback *syscall.Callback
-
-func compositeWndProc(args *uintptr) uintptr {
-	msg := msgFromCallbackArgs(args)
-
+func compositeWndProc(msg *MSG) uintptr {
 	c, ok := widgetsByHWnd[msg.HWnd].(*Composite)
 	if !ok {
 		// Before CreateWindowEx returns, among others, WM_GETMINMAXINFO is sent.
 		// FIXME: Find a way to properly handle this.
-		return DefWindowProc(msg.HWnd, msg.Message, msg.WParam, msg.LParam)
+		return backend.DefWindowProc(msg, 0)
 	}
 
 	return c.wndProc(msg, 0)
@@ -40,21 +35,21 @@ func NewCompositeWithStyle(parent IContainer, style uint) (*Composite, os.Error)
 		return nil, newError("parent cannot be nil")
 	}
 
-	ensureRegisteredWindowClass(compositeWindowClass, compositeWndProc, &compositeWindowWndProcCallback)
+	ensureRegisteredWindowClass(compositeWindowClass, compositeWndProc)
 
-	hWnd := CreateWindowEx(
-		WS_EX_CONTROLPARENT, syscall.StringToUTF16Ptr(compositeWindowClass), nil,
+	hWnd, err := backend.CreateWindow(
+		WS_EX_CONTROLPARENT, compositeWindowClass,
 		WS_CHILD|WS_VISIBLE|style,
-		0, 0, 0, 0, parent.Handle(), 0, 0, nil)
-	if hWnd == 0 {
-		return nil, lastError("CreateWindowEx")
+		parent.Handle(), drawing.Rectangle{0, 0, 0, 0})
+	if err != nil {
+		return nil, err
 	}
 
 	c := &Composite{Container: Container{Widget: Widget{hWnd: hWnd, parent: parent}}}
 
 	c.children = newObservedWidgetList(c)
 
-	c.SetFont(defaultFont)
+	c.SetFont(backend.DefaultFont())
 
 	widgetsByHWnd[hWnd] = c
 
@@ -83,6 +78,10 @@ func (c *Composite) LayoutFlags() LayoutFlags {
 }
 
 func (c *Composite) PreferredSize() drawing.Size {
+	if c.layout != nil {
+		return c.layout.PreferredSize()
+	}
+
 	var maxW, maxH int
 
 	count := c.children.Len()
@@ -96,11 +95,5 @@ func (c *Composite) PreferredSize() drawing.Size {
 		}
 	}
 
-	if c.layout != nil {
-		marg := c.layout.Margins()
-		maxW += marg.Left + marg.Right
-		maxH += marg.Top + marg.Bottom
-	}
-
 	return drawing.Size{maxW, maxH}
 }
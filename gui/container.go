@@ -10,6 +10,7 @@ import (
 )
 
 import (
+	"walk/drawing"
 	. "walk/winapi"
 	. "walk/winapi/user32"
 )
@@ -26,6 +27,8 @@ type Layout interface {
 	Spacing() int
 	SetSpacing(value int) os.Error
 	Update(reset bool) os.Error
+	MinSize() drawing.Size
+	PreferredSize() drawing.Size
 }
 
 type IContainer interface {
@@ -68,9 +71,34 @@ func (c *Container) SetLayout(value Layout) {
 	}
 }
 
+// MinSize returns the larger of the minimum size set on the Container and the
+// minimum size its layout needs to arrange the children.
+func (c *Container) MinSize() (drawing.Size, os.Error) {
+	minSize, err := c.Widget.MinSize()
+	if err != nil || c.layout == nil {
+		return minSize, err
+	}
+
+	layoutMinSize := c.layout.MinSize()
+	if layoutMinSize.Width > minSize.Width {
+		minSize.Width = layoutMinSize.Width
+	}
+	if layoutMinSize.Height > minSize.Height {
+		minSize.Height = layoutMinSize.Height
+	}
+
+	return minSize, nil
+}
+
 func (c *Container) wndProc(msg *MSG, origWndProcPtr uintptr) uintptr {
 	switch msg.Message {
 	case WM_COMMAND:
+		if hWnd := HWND(msg.LParam); hWnd != 0 {
+			if widget, ok := widgetsByHWnd[hWnd].(commandNotified); ok {
+				widget.onCommand(HIWORD(uint(msg.WParam)))
+			}
+		}
+
 		switch HIWORD(uint(msg.WParam)) {
 		case 0:
 			hWnd := HWND(msg.LParam)
@@ -86,7 +114,7 @@ func (c *Container) wndProc(msg *MSG, origWndProcPtr uintptr) uintptr {
 			// Menu
 			actionId := uint16(LOWORD(uint(msg.WParam)))
 			if action, ok := actionsById[actionId]; ok {
-				action.raiseTriggered()
+				action.Trigger()
 				return 0
 			}
 
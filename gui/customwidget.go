@@ -6,7 +6,6 @@ package gui
 
 import (
 	"os"
-	"syscall"
 )
 
 import (
@@ -16,22 +15,18 @@ import (
 
 const customWidgetWindowClass = `\o/ Walk_CustomWidget_Class \o/`
 
-var customWidgetWndProcCallback *syscall.Callback
-
-func customWidgetWndProc(args *uintptr) uintptr {
-	msg := msgFromCallbackArgs(args)
-
+func customWidgetWndProc(msg *MSG) uintptr {
 	cw, ok := customWidgetsByHWND[msg.HWnd]
 	if !ok {
 		// Before CreateWindowEx returns, among others, WM_GETMINMAXINFO is sent.
 		// FIXME: Find a way to properly handle this.
-		return DefWindowProc(msg.HWnd, msg.Message, msg.WParam, msg.LParam)
+		return backend.DefWindowProc(msg, 0)
 	}
 
 	return cw.wndProc(msg, 0)
 }
 
-type PaintFunc func(surface *drawing.Surface, updateBounds drawing.Rectangle) os.Error
+type PaintFunc func(surface drawing.Surface, updateBounds drawing.Rectangle) os.Error
 
 type CustomWidget struct {
 	Widget
@@ -51,19 +46,19 @@ func NewCustomWidget(parent IContainer, style uint, paint PaintFunc) (*CustomWid
 		customWidgetsByHWND = make(map[HWND]*CustomWidget)
 	}
 
-	ensureRegisteredWindowClass(customWidgetWindowClass, customWidgetWndProc, &customWidgetWndProcCallback)
+	ensureRegisteredWindowClass(customWidgetWindowClass, customWidgetWndProc)
 
-	hWnd := CreateWindowEx(
-		0, syscall.StringToUTF16Ptr(customWidgetWindowClass), nil,
+	hWnd, err := backend.CreateWindow(
+		0, customWidgetWindowClass,
 		WS_CHILD|WS_VISIBLE|style,
-		0, 0, 0, 0, parent.Handle(), 0, 0, nil)
-	if hWnd == 0 {
-		return nil, lastError("CreateWindowEx")
+		parent.Handle(), drawing.Rectangle{0, 0, 0, 0})
+	if err != nil {
+		return nil, err
 	}
 
 	cw := &CustomWidget{Widget: Widget{hWnd: hWnd, parent: parent}, paint: paint}
 
-	cw.SetFont(defaultFont)
+	cw.SetFont(backend.DefaultFont())
 
 	widgetsByHWnd[hWnd] = cw
 	customWidgetsByHWND[hWnd] = cw
@@ -0,0 +1,464 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"container/vector"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTimeFormat is the layout bindings use to convert between time
+// values and text, unless they have their own.
+const DefaultTimeFormat = "2006-01-02"
+
+// Binding connects a field of the data source of a DataBinder to a Property.
+type Binding struct {
+	binder        *DataBinder
+	field         string
+	property      Property
+	format        string
+	fieldIndex    int
+	err           os.Error
+	changedHandle EventHandle
+}
+
+func (b *Binding) Field() string {
+	return b.field
+}
+
+func (b *Binding) Property() Property {
+	return b.property
+}
+
+// Format returns the layout used to convert between time values and text,
+// see time.Format.
+func (b *Binding) Format() string {
+	return b.format
+}
+
+func (b *Binding) SetFormat(value string) {
+	b.format = value
+}
+
+// Err returns the error of the last transfer of the value, e.g. because text
+// could not be converted to the type of the field, or nil.
+func (b *Binding) Err() os.Error {
+	return b.err
+}
+
+func (b *Binding) setErr(err os.Error) os.Error {
+	if err != nil {
+		err = newError(fmt.Sprintf("%s: %s", b.field, err.String()))
+	}
+
+	b.err = err
+
+	return err
+}
+
+// DataBinder transfers values between the fields of a struct, the data source,
+// and properties, typically of widgets.
+//
+// Values are converted as needed between strings, bools, numbers and times.
+// Reset copies the field values to the properties, Submit copies the property
+// values to the fields. With auto submit, the value of a property is submitted
+// whenever it changes.
+type DataBinder struct {
+	dataSource  interface{}
+	structValue *reflect.StructValue
+	bindings    vector.Vector
+	autoSubmit  bool
+	resetting   bool
+}
+
+func NewDataBinder() *DataBinder {
+	return &DataBinder{}
+}
+
+func (db *DataBinder) DataSource() interface{} {
+	return db.dataSource
+}
+
+// SetDataSource sets the data source, which must be a pointer to a struct,
+// and resets the properties to its field values.
+func (db *DataBinder) SetDataSource(value interface{}) os.Error {
+	var sv *reflect.StructValue
+	if value != nil {
+		var err os.Error
+		if sv, err = bindingStructValue(value); err != nil {
+			return err
+		}
+	}
+
+	db.dataSource = value
+	db.structValue = sv
+
+	if value == nil {
+		return nil
+	}
+
+	for _, b := range db.bindings {
+		db.resolve(b.(*Binding))
+	}
+
+	return db.Reset()
+}
+
+func (db *DataBinder) AutoSubmit() bool {
+	return db.autoSubmit
+}
+
+func (db *DataBinder) SetAutoSubmit(value bool) {
+	db.autoSubmit = value
+}
+
+// Bind binds property to the exported field of the data source that is named
+// field or has the tag field.
+func (db *DataBinder) Bind(field string, property Property) (*Binding, os.Error) {
+	if property == nil {
+		return nil, newError("property cannot be nil")
+	}
+
+	b := &Binding{
+		binder:   db,
+		field:    field,
+		property: property,
+		format:   DefaultTimeFormat,
+	}
+
+	b.changedHandle = property.Changed().Attach(func(args EventArgs) {
+		db.onPropertyChanged(b)
+	})
+
+	db.bindings.Push(b)
+
+	if db.dataSource != nil {
+		db.resolve(b)
+
+		return b, db.reset(b)
+	}
+
+	return b, nil
+}
+
+func (db *DataBinder) Unbind(binding *Binding) {
+	for i, b := range db.bindings {
+		if b.(*Binding) == binding {
+			binding.property.Changed().Detach(binding.changedHandle)
+
+			db.bindings.Delete(i)
+			break
+		}
+	}
+}
+
+// Reset copies the field values of the data source to the properties. It
+// returns the first error, the errors of all fields are available from
+// Errors.
+func (db *DataBinder) Reset() (err os.Error) {
+	for _, b := range db.bindings {
+		if e := db.reset(b.(*Binding)); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return
+}
+
+// Submit copies the property values to the fields of the data source. Fields
+// whose value cannot be converted keep their value. It returns the first
+// error, the errors of all fields are available from Errors.
+func (db *DataBinder) Submit() (err os.Error) {
+	for _, b := range db.bindings {
+		if e := db.submit(b.(*Binding)); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return
+}
+
+// Errors returns the errors of the last transfer by field. It is empty if all
+// values could be transferred.
+func (db *DataBinder) Errors() map[string]os.Error {
+	errors := make(map[string]os.Error)
+
+	for _, b := range db.bindings {
+		if binding := b.(*Binding); binding.err != nil {
+			errors[binding.field] = binding.err
+		}
+	}
+
+	return errors
+}
+
+// resolve looks up the field of b in the data source once, so transfers do
+// not have to search for it.
+func (db *DataBinder) resolve(b *Binding) {
+	b.fieldIndex = -1
+
+	st := db.structValue.Type().(*reflect.StructType)
+
+	for i := 0; i < st.NumField(); i++ {
+		if f := st.Field(i); f.PkgPath == "" && (f.Name == b.field || f.Tag == b.field) {
+			b.fieldIndex = i
+			return
+		}
+	}
+}
+
+func (db *DataBinder) fieldValue(b *Binding) (reflect.Value, os.Error) {
+	if db.dataSource == nil {
+		return nil, newError("no data source set")
+	}
+
+	if b.fieldIndex == -1 {
+		return nil, newError("no such field")
+	}
+
+	return db.structValue.Field(b.fieldIndex), nil
+}
+
+func (db *DataBinder) reset(b *Binding) os.Error {
+	db.resetting = true
+	defer func() {
+		db.resetting = false
+	}()
+
+	fv, err := db.fieldValue(b)
+	if err == nil {
+		var value interface{}
+		if value, err = convertBindingValue(fv.Interface(), b.property.Get(), b.format); err == nil {
+			err = b.property.Set(value)
+		}
+	}
+
+	return b.setErr(err)
+}
+
+func (db *DataBinder) submit(b *Binding) os.Error {
+	fv, err := db.fieldValue(b)
+	if err == nil {
+		var value interface{}
+		if value, err = convertBindingValue(b.property.Get(), fv.Interface(), b.format); err == nil {
+			fv.SetValue(reflect.NewValue(value))
+		}
+	}
+
+	return b.setErr(err)
+}
+
+func (db *DataBinder) onPropertyChanged(b *Binding) {
+	if db.resetting || !db.autoSubmit || db.dataSource == nil {
+		return
+	}
+
+	db.submit(b)
+}
+
+func bindingStructValue(dataSource interface{}) (*reflect.StructValue, os.Error) {
+	if pv, ok := reflect.NewValue(dataSource).(*reflect.PtrValue); ok && !pv.IsNil() {
+		if sv, ok := pv.Elem().(*reflect.StructValue); ok {
+			return sv, nil
+		}
+	}
+
+	return nil, newError("data source must be a pointer to a struct")
+}
+
+// convertBindingValue converts value to the type of like. Times are
+// converted to and from text using format.
+func convertBindingValue(value, like interface{}, format string) (interface{}, os.Error) {
+	switch like.(type) {
+	case nil:
+		return value, nil
+
+	case string:
+		return formatBindingValue(value, format), nil
+
+	case bool:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+
+		case string:
+			b, err := strconv.Atob(strings.TrimSpace(v))
+			if err != nil {
+				return nil, newError(fmt.Sprintf("%q is not a bool", v))
+			}
+			return b, nil
+		}
+
+	case *time.Time, time.Time:
+		var t *time.Time
+
+		switch v := value.(type) {
+		case *time.Time:
+			t = v
+
+		case time.Time:
+			t = &v
+
+		case string:
+			if v = strings.TrimSpace(v); v != "" {
+				var err os.Error
+				if t, err = time.Parse(format, v); err != nil {
+					return nil, newError(fmt.Sprintf("%q is not a time in the format %q", v, format))
+				}
+			}
+
+		default:
+			return nil, cannotConvertBindingValue(value, like)
+		}
+
+		if _, ok := like.(time.Time); ok {
+			if t == nil {
+				return time.Time{}, nil
+			}
+			return *t, nil
+		}
+
+		return t, nil
+
+	default:
+		return convertBindingNumber(value, like)
+	}
+
+	return nil, cannotConvertBindingValue(value, like)
+}
+
+func convertBindingNumber(value, like interface{}) (interface{}, os.Error) {
+	var i int64
+	var f float64
+	isInt := false
+
+	if n, ok := tableValueAsInt(value); ok {
+		i, f, isInt = n, float64(n), true
+	} else if x, ok := tableValueAsFloat(value); ok {
+		f = x
+	} else if s, ok := value.(string); ok {
+		s = strings.TrimSpace(s)
+
+		if n, err := strconv.Atoi64(s); err == nil {
+			i, f, isInt = n, float64(n), true
+		} else if x, err := strconv.Atof64(s); err == nil {
+			f = x
+		} else {
+			return nil, newError(fmt.Sprintf("%q is not a number", s))
+		}
+	} else {
+		return nil, cannotConvertBindingValue(value, like)
+	}
+
+	switch like.(type) {
+	case float:
+		return float(f), nil
+
+	case float32:
+		return float32(f), nil
+
+	case float64:
+		return f, nil
+	}
+
+	if !isInt {
+		if f != float64(int64(f)) {
+			return nil, newError(fmt.Sprintf("%v is not an integer", f))
+		}
+
+		i = int64(f)
+	}
+
+	var result interface{}
+
+	switch like.(type) {
+	case int:
+		result = int(i)
+
+	case int8:
+		result = int8(i)
+
+	case int16:
+		result = int16(i)
+
+	case int32:
+		result = int32(i)
+
+	case int64:
+		result = i
+
+	case uint:
+		result = uint(i)
+
+	case uint8:
+		result = uint8(i)
+
+	case uint16:
+		result = uint16(i)
+
+	case uint32:
+		result = uint32(i)
+
+	case uint64:
+		result = uint64(i)
+
+	default:
+		return nil, cannotConvertBindingValue(value, like)
+	}
+
+	if n, ok := tableValueAsInt(result); i < 0 && !isSignedBindingValue(result) || ok && n != i {
+		return nil, newError(fmt.Sprintf("%d is out of range", i))
+	}
+
+	return result, nil
+}
+
+func isSignedBindingValue(value interface{}) bool {
+	switch value.(type) {
+	case int, int8, int16, int32, int64:
+		return true
+	}
+
+	return false
+}
+
+func formatBindingValue(value interface{}, format string) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+
+	case string:
+		return v
+
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.Format(format)
+
+	case time.Time:
+		return v.Format(format)
+
+	case float:
+		return strconv.Ftoa(v, 'g', -1)
+
+	case float32:
+		return strconv.Ftoa32(v, 'g', -1)
+
+	case float64:
+		return strconv.Ftoa64(v, 'g', -1)
+	}
+
+	return fmt.Sprint(value)
+}
+
+func cannotConvertBindingValue(value, like interface{}) os.Error {
+	return newError(fmt.Sprintf("cannot convert %T to %T", value, like))
+}
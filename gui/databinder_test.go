@@ -0,0 +1,217 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+type testPerson struct {
+	Name    string
+	Age     int
+	Married bool
+	Born    *time.Time "birthday"
+	secret  string
+}
+
+type testPet struct {
+	Legs int
+	Name string
+}
+
+func newTestDataBinder(t *testing.T, dataSource interface{}, fields ...string) (*DataBinder, map[string]*MemoryProperty) {
+	db := NewDataBinder()
+
+	properties := make(map[string]*MemoryProperty)
+	for _, field := range fields {
+		properties[field] = NewMemoryProperty("")
+		if _, err := db.Bind(field, properties[field]); err != nil {
+			t.Fatalf("Bind failed: %s", err)
+		}
+	}
+
+	if err := db.SetDataSource(dataSource); err != nil {
+		t.Fatalf("SetDataSource failed: %s", err)
+	}
+
+	return db, properties
+}
+
+func TestDataBinderSetDataSource(t *testing.T) {
+	db := NewDataBinder()
+
+	for _, value := range []interface{}{testPerson{}, new(int), (*testPerson)(nil)} {
+		if err := db.SetDataSource(value); err == nil {
+			t.Errorf("%T: expected an error for a data source that is not a pointer to a struct", value)
+		}
+	}
+
+	if err := db.SetDataSource(nil); err != nil {
+		t.Errorf("expected no error for a nil data source, got %s", err)
+	}
+	if err := db.Submit(); err != nil {
+		t.Errorf("expected no error submitting without bindings, got %s", err)
+	}
+}
+
+func TestDataBinderReset(t *testing.T) {
+	born, err := time.Parse(DefaultTimeFormat, "1970-05-17")
+	if err != nil {
+		t.Fatalf("Parse failed: %s", err)
+	}
+
+	person := &testPerson{Name: "Jane", Age: 42, Married: true, Born: born}
+	_, properties := newTestDataBinder(t, person, "Name", "Age", "Married", "birthday")
+
+	for field, expected := range map[string]string{
+		"Name":     "Jane",
+		"Age":      "42",
+		"Married":  "true",
+		"birthday": "1970-05-17",
+	} {
+		if value := properties[field].Get(); value != expected {
+			t.Errorf("%s: expected %q, got %q", field, expected, value)
+		}
+	}
+}
+
+func TestDataBinderSubmit(t *testing.T) {
+	person := &testPerson{Age: 42}
+	db, properties := newTestDataBinder(t, person, "Name", "Age", "Married", "birthday")
+
+	properties["Name"].Set("John")
+	properties["Age"].Set(" 17 ")
+	properties["Married"].Set("false")
+	properties["birthday"].Set("2001-02-03")
+
+	if err := db.Submit(); err != nil {
+		t.Fatalf("Submit failed: %s", err)
+	}
+
+	if person.Name != "John" || person.Age != 17 || person.Married {
+		t.Errorf("unexpected field values %+v", person)
+	}
+	if person.Born == nil || person.Born.Format(DefaultTimeFormat) != "2001-02-03" {
+		t.Errorf("expected the birthday 2001-02-03, got %v", person.Born)
+	}
+
+	properties["Age"].Set("old")
+	properties["birthday"].Set("")
+
+	if err := db.Submit(); err == nil {
+		t.Error("expected an error for an invalid number")
+	}
+	if person.Age != 17 {
+		t.Errorf("expected the field to keep its value, got %d", person.Age)
+	}
+	if person.Born != nil {
+		t.Errorf("expected an empty text to clear the birthday, got %v", person.Born)
+	}
+
+	errors := db.Errors()
+	if len(errors) != 1 || errors["Age"] == nil {
+		t.Errorf("expected an error for Age only, got %v", errors)
+	}
+}
+
+func TestDataBinderAutoSubmit(t *testing.T) {
+	person := &testPerson{}
+	db, properties := newTestDataBinder(t, person, "Name")
+
+	properties["Name"].Change("Jane")
+	if person.Name != "" {
+		t.Errorf("expected no submit without auto submit, got %q", person.Name)
+	}
+
+	db.SetAutoSubmit(true)
+	properties["Name"].Change("John")
+	if person.Name != "John" {
+		t.Errorf("expected the change to be submitted, got %q", person.Name)
+	}
+
+	db.Unbind(db.bindings.At(0).(*Binding))
+	properties["Name"].Change("Jim")
+	if person.Name != "John" {
+		t.Errorf("expected no submit after Unbind, got %q", person.Name)
+	}
+}
+
+func TestDataBinderFields(t *testing.T) {
+	db, properties := newTestDataBinder(t, &testPerson{Name: "Jane"}, "Name")
+
+	if _, err := db.Bind("Missing", NewMemoryProperty("")); err == nil {
+		t.Error("expected an error for a missing field")
+	}
+	if _, err := db.Bind("secret", NewMemoryProperty("")); err == nil {
+		t.Error("expected an error for an unexported field")
+	}
+
+	// The fields are looked up again for a data source of another type.
+	pet := &testPet{Legs: 4, Name: "Rex"}
+	db.SetDataSource(pet)
+
+	if value := properties["Name"].Get(); value != "Rex" {
+		t.Errorf("expected the name of the pet, got %q", value)
+	}
+
+	properties["Name"].Set("Fido")
+	db.Submit()
+	if pet.Name != "Fido" || pet.Legs != 4 {
+		t.Errorf("unexpected field values %+v", pet)
+	}
+}
+
+func TestConvertBindingValue(t *testing.T) {
+	tests := []struct {
+		value, like interface{}
+		expected    interface{}
+	}{
+		{"12", int8(0), int8(12)},
+		{int64(7), uint16(0), uint16(7)},
+		{2.0, 0, 2},
+		{" 2.5 ", float64(0), float64(2.5)},
+		{3, float32(0), float32(3)},
+		{"true", false, true},
+		{3, "", "3"},
+		{2.5, "", "2.5"},
+		{nil, "", ""},
+		{"x", nil, "x"},
+	}
+
+	for _, test := range tests {
+		value, err := convertBindingValue(test.value, test.like, DefaultTimeFormat)
+		if err != nil {
+			t.Errorf("%#v to %T: unexpected error: %s", test.value, test.like, err)
+			continue
+		}
+
+		if fmt.Sprintf("%T %v", value, value) != fmt.Sprintf("%T %v", test.expected, test.expected) {
+			t.Errorf("%#v to %T: expected %#v, got %#v", test.value, test.like, test.expected, value)
+		}
+	}
+}
+
+func TestConvertBindingValueErrors(t *testing.T) {
+	tests := []struct {
+		value, like interface{}
+	}{
+		{"300", uint8(0)},
+		{"-1", uint(0)},
+		{1.5, 0},
+		{"ten", 0},
+		{"maybe", false},
+		{1, false},
+		{"17.5.2001", new(time.Time)},
+		{true, 0},
+	}
+
+	for _, test := range tests {
+		if value, err := convertBindingValue(test.value, test.like, DefaultTimeFormat); err == nil {
+			t.Errorf("%#v to %T: expected an error, got %#v", test.value, test.like, value)
+		}
+	}
+}
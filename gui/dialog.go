@@ -6,10 +6,11 @@ package gui
 
 import (
 	"os"
-	"syscall"
 )
 
 import (
+	"walk/drawing"
+	. "walk/winapi"
 	. "walk/winapi/user32"
 )
 
@@ -32,44 +33,277 @@ const (
 
 const dialogWindowClass = `\o/ Walk_Dialog_Class \o/`
 
-var dialogWndProcCallback *syscall.Callback
-
-func dialogWndProc(args *uintptr) uintptr {
-	msg := msgFromCallbackArgs(args)
-
+func dialogWndProc(msg *MSG) uintptr {
 	dlg, ok := widgetsByHWnd[msg.HWnd].(*Dialog)
 	if !ok {
 		// Before CreateWindowEx returns, among others, WM_GETMINMAXINFO is sent.
 		// FIXME: Find a way to properly handle this.
-		return DefWindowProc(msg.HWnd, msg.Message, msg.WParam, msg.LParam)
+		return backend.DefWindowProc(msg, 0)
 	}
 
 	return dlg.wndProc(msg, 0)
 }
 
+// Dialog is a top level window for a short interaction with the user, which
+// Run shows modally until it is accepted or cancelled.
 type Dialog struct {
 	TopLevelWindow
+	okButton      *PushButton
+	defaultButton *PushButton
+	cancelButton  *PushButton
+	result        DialogCommandId
+	modalOwner    RootWidget
+	running       bool
 }
 
 func NewDialog() (*Dialog, os.Error) {
-	ensureRegisteredWindowClass(dialogWindowClass, dialogWndProc, &dialogWndProcCallback)
+	ensureRegisteredWindowClass(dialogWindowClass, dialogWndProc)
 
-	hWnd := CreateWindowEx(
-		0, syscall.StringToUTF16Ptr(dialogWindowClass), nil,
+	hWnd, err := backend.CreateWindow(
+		0, dialogWindowClass,
 		WS_OVERLAPPEDWINDOW,
-		CW_USEDEFAULT, CW_USEDEFAULT, 400, 300, 0, 0, 0, nil)
-	if hWnd == 0 {
-		return nil, lastError("CreateWindowEx")
+		0, drawing.Rectangle{CW_USEDEFAULT, CW_USEDEFAULT, 400, 300})
+	if err != nil {
+		return nil, err
 	}
 
-	d := &Dialog{TopLevelWindow: TopLevelWindow{Container: Container{Widget: Widget{hWnd: hWnd}}}}
+	d := &Dialog{
+		TopLevelWindow: TopLevelWindow{Container: Container{Widget: Widget{hWnd: hWnd}}},
+		result:         DlgCmdCancel,
+	}
 
 	d.children = newObservedWidgetList(d)
 
 	widgetsByHWnd[hWnd] = d
 
+	d.ValidityChanged().Attach(func(args EventArgs) {
+		d.updateOKButton()
+	})
+
 	// This forces display of focus rectangles, as soon as the user starts to type.
-	SendMessage(hWnd, WM_CHANGEUISTATE, UIS_INITIALIZE, 0)
+	backend.SendMessage(hWnd, WM_CHANGEUISTATE, UIS_INITIALIZE, 0)
 
 	return d, nil
 }
+
+// SizeToContent resizes the Dialog to the size its layout prefers, so that all
+// children fit at their preferred sizes.
+func (d *Dialog) SizeToContent() os.Error {
+	if d.layout == nil {
+		return newError("dialog has no layout")
+	}
+
+	return d.SetSize(d.PreferredSize())
+}
+
+func (d *Dialog) OKButton() *PushButton {
+	return d.okButton
+}
+
+// SetOKButton sets the button that accepts the dialog. It is only enabled while
+// all Validatable descendants of the Dialog are valid.
+func (d *Dialog) SetOKButton(value *PushButton) os.Error {
+	d.okButton = value
+
+	return d.updateOKButton()
+}
+
+func (d *Dialog) updateOKButton() os.Error {
+	if d.okButton == nil {
+		return nil
+	}
+
+	return d.okButton.SetEnabled(d.IsValid())
+}
+
+func (d *Dialog) DefaultButton() *PushButton {
+	return d.defaultButton
+}
+
+// SetDefaultButton sets the button that is clicked when the user presses
+// Enter, unless the focused widget handles the key itself. The button must be
+// a descendant of the Dialog.
+func (d *Dialog) SetDefaultButton(value *PushButton) os.Error {
+	if err := d.checkButton(value); err != nil {
+		return err
+	}
+
+	if d.defaultButton != nil && d.defaultButton.hWnd != 0 {
+		if err := d.defaultButton.setDefault(false); err != nil {
+			return err
+		}
+	}
+
+	d.defaultButton = value
+
+	if value != nil {
+		return value.setDefault(true)
+	}
+
+	return nil
+}
+
+func (d *Dialog) CancelButton() *PushButton {
+	return d.cancelButton
+}
+
+// SetCancelButton sets the button that is clicked when the user presses
+// Escape. Without one, or while the button has no Clicked handlers, Escape
+// cancels the Dialog directly. The button must be a descendant of the Dialog.
+func (d *Dialog) SetCancelButton(value *PushButton) os.Error {
+	if err := d.checkButton(value); err != nil {
+		return err
+	}
+
+	d.cancelButton = value
+
+	return nil
+}
+
+func (d *Dialog) checkButton(button *PushButton) os.Error {
+	if button != nil && button.RootWidget().Handle() != d.hWnd {
+		return newError("button must be a descendant of the dialog")
+	}
+
+	return nil
+}
+
+// Result returns the DialogCommandId Run returns if the Dialog is closed now.
+// It is DlgCmdCancel, unless Closing is raised by Accept.
+func (d *Dialog) Result() DialogCommandId {
+	return d.result
+}
+
+// Accept closes the Dialog with the result DlgCmdOK, if all Validatable
+// descendants are valid. Otherwise the error of the first invalid one is
+// shown and returned, and the Dialog stays open.
+func (d *Dialog) Accept() os.Error {
+	if err := d.Validate(); err != nil {
+		return err
+	}
+
+	return d.closeWithResult(DlgCmdOK)
+}
+
+// Cancel closes the Dialog with the result DlgCmdCancel.
+func (d *Dialog) Cancel() os.Error {
+	return d.closeWithResult(DlgCmdCancel)
+}
+
+// closeWithResult closes the Dialog with result. If a Closing handler cancels
+// that, the result goes back to DlgCmdCancel, which closing the Dialog in any
+// other way results in.
+func (d *Dialog) closeWithResult(result DialogCommandId) os.Error {
+	d.result = result
+
+	err := d.Close()
+
+	if d.hWnd != 0 {
+		d.result = DlgCmdCancel
+	}
+
+	return err
+}
+
+// Run shows the Dialog modally and returns its result once it is closed,
+// DlgCmdOK if it was accepted and DlgCmdCancel otherwise.
+//
+// While the Dialog is open, owner, if not nil, is disabled, so the user can
+// only interact with the Dialog. If the message loop ends before the Dialog is
+// closed, e.g. because Exit was called, the Dialog is closed and cancelled.
+func (d *Dialog) Run(owner RootWidget) (DialogCommandId, os.Error) {
+	if d.running {
+		return 0, newError("dialog is already running")
+	}
+
+	if owner != nil {
+		if mw, ok := owner.(*MainWindow); ok {
+			if err := d.SetOwner(mw); err != nil {
+				return 0, err
+			}
+		} else if err := backend.SetOwner(d.hWnd, owner.Handle()); err != nil {
+			return 0, err
+		}
+
+		if err := owner.SetEnabled(false); err != nil {
+			return 0, err
+		}
+
+		d.modalOwner = owner
+	}
+
+	d.running = true
+	d.result = DlgCmdCancel
+
+	d.Show()
+
+	err := d.runMessageLoop()
+
+	if d.hWnd != 0 {
+		d.result = DlgCmdCancel
+		d.enableModalOwner()
+		d.close()
+	}
+
+	d.running = false
+
+	return d.result, err
+}
+
+// enableModalOwner enables the owner disabled by Run again. This must happen
+// before the Dialog is destroyed, so Windows activates the owner instead of
+// the window of some other application.
+func (d *Dialog) enableModalOwner() {
+	if d.modalOwner == nil {
+		return
+	}
+
+	d.modalOwner.SetEnabled(true)
+	d.modalOwner = nil
+}
+
+// clickIfEnabled raises the Clicked event of button, like a click of the user
+// would.
+func clickIfEnabled(button *PushButton) {
+	if enabled, _ := button.Enabled(); enabled {
+		button.raiseClicked()
+	}
+}
+
+func (d *Dialog) wndProc(msg *MSG, origWndProcPtr uintptr) uintptr {
+	switch msg.Message {
+	case WM_COMMAND:
+		// IsDialogMessage sends IDOK when the user presses Enter and IDCANCEL
+		// for Escape.
+		if msg.LParam != 0 || HIWORD(uint(msg.WParam)) != 0 {
+			break
+		}
+
+		switch LOWORD(uint(msg.WParam)) {
+		case IDOK:
+			if d.defaultButton != nil {
+				clickIfEnabled(d.defaultButton)
+			}
+			return 0
+
+		case IDCANCEL:
+			// Clicking a cancel button nobody handles would do nothing.
+			if d.cancelButton != nil && !d.cancelButton.Clicked().handlers.empty() {
+				clickIfEnabled(d.cancelButton)
+			} else {
+				d.closeReason = CloseReasonUser
+				d.Cancel()
+			}
+			return 0
+		}
+
+	case WM_CLOSE:
+		if d.confirmClose() {
+			d.enableModalOwner()
+			d.close()
+		}
+		return 0
+	}
+
+	return d.TopLevelWindow.wndProc(msg, origWndProcPtr)
+}
@@ -0,0 +1,232 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"os"
+	"testing"
+)
+
+import (
+	. "walk/winapi/user32"
+)
+
+type testDialog struct {
+	*Dialog
+	edit         *LineEdit
+	ok, cancel   *PushButton
+	ownerEnabled bool
+}
+
+func newTestDialog(t *testing.T) (*MemoryBackend, *MainWindow, *testDialog) {
+	b, mw := resetTestApplication(t)
+
+	d, err := NewDialog()
+	if err != nil {
+		t.Fatalf("NewDialog failed: %s", err)
+	}
+
+	td := &testDialog{Dialog: d, ownerEnabled: true}
+
+	if td.edit, err = NewLineEdit(d); err != nil {
+		t.Fatalf("NewLineEdit failed: %s", err)
+	}
+	if td.ok, err = NewPushButton(d); err != nil {
+		t.Fatalf("NewPushButton failed: %s", err)
+	}
+	if td.cancel, err = NewPushButton(d); err != nil {
+		t.Fatalf("NewPushButton failed: %s", err)
+	}
+
+	td.ok.Clicked().Attach(func(args EventArgs) {
+		d.Accept()
+	})
+	td.cancel.Clicked().Attach(func(args EventArgs) {
+		d.Cancel()
+	})
+
+	return b, mw, td
+}
+
+// run runs the dialog modally and makes f the first thing its message loop
+// does.
+func (td *testDialog) run(t *testing.T, owner *MainWindow, f func()) DialogCommandId {
+	Synchronize(func() {
+		td.ownerEnabled, _ = owner.Enabled()
+		f()
+	})
+
+	result, err := td.Run(owner)
+	if err != nil {
+		t.Fatalf("Run failed: %s", err)
+	}
+
+	if td.ownerEnabled {
+		t.Error("expected the owner to be disabled while the dialog runs")
+	}
+	if enabled, _ := owner.Enabled(); !enabled {
+		t.Error("expected the owner to be enabled again")
+	}
+	if !td.IsDisposed() {
+		t.Error("expected the dialog to be closed")
+	}
+
+	return result
+}
+
+func TestDialogRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		f        func(b *MemoryBackend, td *testDialog)
+		expected DialogCommandId
+	}{
+		{"Accept", func(b *MemoryBackend, td *testDialog) {
+			td.Accept()
+		}, DlgCmdOK},
+		{"Cancel", func(b *MemoryBackend, td *testDialog) {
+			td.Cancel()
+		}, DlgCmdCancel},
+		{"Close", func(b *MemoryBackend, td *testDialog) {
+			td.Close()
+		}, DlgCmdCancel},
+		{"Escape", func(b *MemoryBackend, td *testDialog) {
+			b.KeyPress(td.edit, KeyEscape)
+		}, DlgCmdCancel},
+		{"Escape with cancel button", func(b *MemoryBackend, td *testDialog) {
+			td.SetCancelButton(td.cancel)
+			b.KeyPress(td.edit, KeyEscape)
+		}, DlgCmdCancel},
+		{"Return with default button", func(b *MemoryBackend, td *testDialog) {
+			td.SetDefaultButton(td.ok)
+			b.KeyPress(td, KeyReturn)
+		}, DlgCmdOK},
+		{"Return in LineEdit", func(b *MemoryBackend, td *testDialog) {
+			// The LineEdit keeps Return to itself.
+			td.SetDefaultButton(td.ok)
+			b.KeyPress(td.edit, KeyReturn)
+		}, DlgCmdCancel},
+		{"Return on button", func(b *MemoryBackend, td *testDialog) {
+			b.KeyPress(td.ok, KeyReturn)
+		}, DlgCmdOK},
+		{"invalid Accept", func(b *MemoryBackend, td *testDialog) {
+			td.edit.SetValidator(NewRequiredValidator())
+			if td.Accept() == nil {
+				t.Error("invalid Accept: expected an error")
+			}
+		}, DlgCmdCancel},
+	}
+
+	for _, test := range tests {
+		b, mw, td := newTestDialog(t)
+
+		result := td.run(t, mw, func() {
+			test.f(b, td)
+		})
+
+		if result != test.expected {
+			t.Errorf("%s: expected the result %d, got %d", test.name, test.expected, result)
+		}
+	}
+}
+
+func TestDialogEscapeCancelButton(t *testing.T) {
+	tests := []struct {
+		name           string
+		attachHandler  bool
+		expectedClosed bool
+	}{
+		// Nobody would close the Dialog, so Escape does it.
+		{"without handler", false, true},
+		// The handler decides, here by doing nothing.
+		{"with handler", true, false},
+	}
+
+	for _, test := range tests {
+		b, mw, td := newTestDialog(t)
+
+		button, err := NewPushButton(td)
+		if err != nil {
+			t.Fatalf("NewPushButton failed: %s", err)
+		}
+
+		var clicked bool
+		if test.attachHandler {
+			button.Clicked().Attach(func(args EventArgs) {
+				clicked = true
+			})
+		}
+
+		if err := td.SetCancelButton(button); err != nil {
+			t.Fatalf("SetCancelButton failed: %s", err)
+		}
+
+		var closed bool
+		td.run(t, mw, func() {
+			b.KeyPress(td.edit, KeyEscape)
+			closed = td.IsDisposed()
+		})
+
+		if closed != test.expectedClosed {
+			t.Errorf("%s: expected closed %t, got %t", test.name, test.expectedClosed, closed)
+		}
+		if clicked != test.attachHandler {
+			t.Errorf("%s: expected clicked %t, got %t", test.name, test.attachHandler, clicked)
+		}
+	}
+}
+
+func TestDialogRunTwice(t *testing.T) {
+	_, mw, td := newTestDialog(t)
+
+	var err os.Error
+	td.run(t, mw, func() {
+		_, err = td.Run(mw)
+		td.Accept()
+	})
+
+	if err == nil {
+		t.Error("expected an error running a running dialog")
+	}
+}
+
+func TestDialogRunExit(t *testing.T) {
+	b, mw, td := newTestDialog(t)
+
+	var result DialogCommandId
+	var afterRun bool
+
+	Synchronize(func() {
+		Synchronize(func() {
+			Exit(3)
+		})
+
+		result, _ = td.Run(mw)
+
+		// The main loop ends before it gets to this.
+		Synchronize(func() {
+			afterRun = true
+		})
+	})
+
+	if err := mw.RunMessageLoop(); err != nil {
+		t.Fatalf("RunMessageLoop failed: %s", err)
+	}
+
+	if result != DlgCmdCancel || !td.IsDisposed() {
+		t.Errorf("expected Exit to close and cancel the dialog, got %d", result)
+	}
+	if afterRun {
+		t.Error("expected Exit to end the main loop as well")
+	}
+	if b.loopDepth != 0 {
+		t.Errorf("expected no running loop, got %d", b.loopDepth)
+	}
+
+	for i := 0; i < b.queue.Len(); i++ {
+		if msg := b.queue.At(i).(*MSG); msg.Message == WM_QUIT {
+			t.Error("expected the main loop to get WM_QUIT")
+		}
+	}
+}
@@ -0,0 +1,406 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+// Formats of the data a DataObject holds, besides custom ones.
+const (
+	// TextFormat holds a string.
+	TextFormat = "Text"
+
+	// FilesFormat holds a []string of file paths, e.g. of files dropped from
+	// the shell.
+	FilesFormat = "Files"
+
+	// ImageFormat holds a *drawing.Bitmap.
+	ImageFormat = "Image"
+
+	// ListViewRowsFormat holds the []int row indexes of the items dragged from
+	// a ListView.
+	ListViewRowsFormat = "walk/gui.ListViewRows"
+
+	// TreeViewItemFormat holds the item dragged from a TreeView, a
+	// *TreeViewItem or the TreeItem of its model.
+	TreeViewItemFormat = "walk/gui.TreeViewItem"
+)
+
+// DataObject holds the data of a drag and drop operation in one or more
+// formats.
+//
+// A format is just a name. Text and file paths have their own accessors, any
+// other Go value can be stored under a custom format, e.g. the items dragged
+// within an application.
+type DataObject struct {
+	formats []string
+	values  map[string]interface{}
+}
+
+func NewDataObject() *DataObject {
+	return &DataObject{values: make(map[string]interface{})}
+}
+
+// Formats returns the formats the data object holds data in, in the order
+// they were first set.
+func (d *DataObject) Formats() []string {
+	formats := make([]string, len(d.formats))
+	copy(formats, d.formats)
+
+	return formats
+}
+
+func (d *DataObject) HasFormat(format string) bool {
+	_, ok := d.values[format]
+
+	return ok
+}
+
+// Data returns the data in format, or nil if there is none.
+func (d *DataObject) Data(format string) interface{} {
+	return d.values[format]
+}
+
+// SetData stores value in format, replacing any previous data. A nil value
+// removes the format.
+func (d *DataObject) SetData(format string, value interface{}) {
+	_, ok := d.values[format]
+
+	if value == nil {
+		if !ok {
+			return
+		}
+
+		d.values[format] = nil, false
+
+		for i, f := range d.formats {
+			if f == format {
+				formats := make([]string, 0, len(d.formats)-1)
+				formats = append(formats, d.formats[:i]...)
+				d.formats = append(formats, d.formats[i+1:]...)
+				break
+			}
+		}
+
+		return
+	}
+
+	if !ok {
+		d.formats = append(d.formats, format)
+	}
+
+	d.values[format] = value
+}
+
+// Text returns the data in TextFormat, or "" if there is none.
+func (d *DataObject) Text() string {
+	text, _ := d.values[TextFormat].(string)
+
+	return text
+}
+
+func (d *DataObject) SetText(value string) {
+	d.SetData(TextFormat, value)
+}
+
+// FilePaths returns the data in FilesFormat, or nil if there is none.
+func (d *DataObject) FilePaths() []string {
+	paths, _ := d.values[FilesFormat].([]string)
+
+	return paths
+}
+
+// SetFilePaths stores a copy of value in FilesFormat. A nil value removes the
+// format.
+func (d *DataObject) SetFilePaths(value []string) {
+	if value == nil {
+		d.SetData(FilesFormat, nil)
+		return
+	}
+
+	paths := make([]string, len(value))
+	copy(paths, value)
+
+	d.SetData(FilesFormat, paths)
+}
+
+// DropEffect describes what a drop does with the dragged data. The values
+// match the DROPEFFECT constants of OLE.
+type DropEffect int
+
+const (
+	DropEffectNone DropEffect = 0
+	DropEffectCopy DropEffect = 1
+	DropEffectMove DropEffect = 2
+	DropEffectLink DropEffect = 4
+)
+
+// defaultDropEffect returns the effect a drop has unless the target chooses
+// otherwise. Like in Explorer, holding down Ctrl requests a copy, Shift a move
+// and both a link. Without modifiers, the first allowed one of move, copy and
+// link is used.
+func defaultDropEffect(allowed DropEffect, modifiers Modifiers) DropEffect {
+	switch modifiers & (ModControl | ModShift) {
+	case ModControl | ModShift:
+		return allowed & DropEffectLink
+
+	case ModControl:
+		return allowed & DropEffectCopy
+
+	case ModShift:
+		return allowed & DropEffectMove
+	}
+
+	for _, effect := range []DropEffect{DropEffectMove, DropEffectCopy, DropEffectLink} {
+		if allowed&effect != 0 {
+			return effect
+		}
+	}
+
+	return DropEffectNone
+}
+
+type DragEventArgs interface {
+	EventArgs
+
+	// Source returns the widget the data is dragged from, or nil if it comes
+	// from another application, e.g. the shell.
+	Source() IWidget
+
+	Data() *DataObject
+
+	// X and Y return the position of the mouse in client coordinates of the
+	// target. They are 0 for DragLeave events.
+	X() int
+	Y() int
+
+	// Modifiers returns the modifier keys that are held down.
+	Modifiers() Modifiers
+
+	// AllowedEffects returns the effects the source allows.
+	AllowedEffects() DropEffect
+
+	// Effect returns the effect the drop would have. It starts out as the
+	// effect requested by the modifier keys, as far as the source allows it.
+	Effect() DropEffect
+
+	// SetEffect sets the effect the drop would have. Targets that cannot
+	// accept the data set DropEffectNone. Effects the source does not allow
+	// are ignored.
+	SetEffect(value DropEffect)
+}
+
+type dragEventArgs struct {
+	eventArgs
+	source    IWidget
+	data      *DataObject
+	x, y      int
+	modifiers Modifiers
+	allowed   DropEffect
+	effect    DropEffect
+}
+
+func (a *dragEventArgs) Source() IWidget {
+	return a.source
+}
+
+func (a *dragEventArgs) Data() *DataObject {
+	return a.data
+}
+
+func (a *dragEventArgs) X() int {
+	return a.x
+}
+
+func (a *dragEventArgs) Y() int {
+	return a.y
+}
+
+func (a *dragEventArgs) Modifiers() Modifiers {
+	return a.modifiers
+}
+
+func (a *dragEventArgs) AllowedEffects() DropEffect {
+	return a.allowed
+}
+
+func (a *dragEventArgs) Effect() DropEffect {
+	return a.effect
+}
+
+func (a *dragEventArgs) SetEffect(value DropEffect) {
+	a.effect = value & a.allowed
+}
+
+type DragEventHandler func(args DragEventArgs)
+
+// DragEvent is an event that handlers of type DragEventHandler can be
+// attached to.
+type DragEvent struct {
+	handlers eventHandlerList
+}
+
+func (e *DragEvent) Attach(handler DragEventHandler) EventHandle {
+	return e.handlers.attach(handler, false)
+}
+
+func (e *DragEvent) Once(handler DragEventHandler) EventHandle {
+	return e.handlers.attach(handler, true)
+}
+
+func (e *DragEvent) Detach(handle EventHandle) {
+	e.handlers.detach(handle)
+}
+
+type DragEventPublisher struct {
+	event DragEvent
+}
+
+func (p *DragEventPublisher) Event() *DragEvent {
+	return &p.event
+}
+
+func (p *DragEventPublisher) Publish(args DragEventArgs) {
+	p.event.handlers.raise(func(handler interface{}) {
+		handler.(DragEventHandler)(args)
+	})
+}
+
+type dragEventKind int
+
+const (
+	dragEnterEvent dragEventKind = iota
+	dragOverEvent
+	dragLeaveEvent
+	dropEvent
+)
+
+// dropTarget is implemented by Widget, so a drag session can raise the drag
+// events of the widget the mouse is over.
+type dropTarget interface {
+	IWidget
+	publishDragEvent(kind dragEventKind, args *dragEventArgs)
+}
+
+// dragSession tracks a drag and drop operation within the application.
+//
+// It raises DragEnter, DragOver and DragLeave on the targets the mouse is
+// moved over, and Drop on the last one. The effect of the drop is negotiated
+// between the effects the source allows and the one the target chooses.
+type dragSession struct {
+	source    IWidget
+	data      *DataObject
+	allowed   DropEffect
+	completed func(effect DropEffect)
+	target    dropTarget
+	effect    DropEffect
+}
+
+func newDragSession(source IWidget, data *DataObject, allowed DropEffect, completed func(effect DropEffect)) *dragSession {
+	return &dragSession{
+		source:    source,
+		data:      data,
+		allowed:   allowed,
+		completed: completed,
+	}
+}
+
+func (s *dragSession) newEventArgs(x, y int, modifiers Modifiers) *dragEventArgs {
+	return &dragEventArgs{
+		eventArgs: eventArgs{s.target},
+		source:    s.source,
+		data:      s.data,
+		x:         x,
+		y:         y,
+		modifiers: modifiers,
+		allowed:   s.allowed,
+		effect:    defaultDropEffect(s.allowed, modifiers),
+	}
+}
+
+// moveTo moves the mouse to x and y in client coordinates of target, which is
+// nil if the mouse is not over a widget that allows drops. It returns the
+// effect a drop there would have.
+func (s *dragSession) moveTo(target dropTarget, x, y int, modifiers Modifiers) DropEffect {
+	kind := dragOverEvent
+
+	if target != s.target {
+		s.leave()
+
+		if target == nil {
+			return DropEffectNone
+		}
+
+		s.target = target
+		kind = dragEnterEvent
+	} else if target == nil {
+		return DropEffectNone
+	}
+
+	args := s.newEventArgs(x, y, modifiers)
+	target.publishDragEvent(kind, args)
+	s.effect = args.effect
+
+	return s.effect
+}
+
+func (s *dragSession) leave() {
+	if s.target == nil {
+		return
+	}
+
+	target := s.target
+	args := s.newEventArgs(0, 0, 0)
+
+	s.target = nil
+	s.effect = DropEffectNone
+
+	target.publishDragEvent(dragLeaveEvent, args)
+}
+
+// drop ends the session by dropping the data on the current target, if that
+// accepts it, and returns the effect of the drop.
+func (s *dragSession) drop(x, y int, modifiers Modifiers) DropEffect {
+	if s.target == nil || s.effect == DropEffectNone {
+		return s.cancel()
+	}
+
+	target := s.target
+	args := s.newEventArgs(x, y, modifiers)
+	args.effect = s.effect
+
+	s.target = nil
+
+	target.publishDragEvent(dropEvent, args)
+
+	return s.finish(args.effect)
+}
+
+// cancel ends the session without dropping.
+func (s *dragSession) cancel() DropEffect {
+	s.leave()
+
+	return s.finish(DropEffectNone)
+}
+
+func (s *dragSession) finish(effect DropEffect) DropEffect {
+	if s.completed != nil {
+		s.completed(effect)
+	}
+
+	return effect
+}
+
+// dragCompletedPublisher returns a function to pass to BeginDrag, that
+// publishes the effect of the drag to publisher. ListView and TreeView use it
+// for their DragCompleted events.
+func dragCompletedPublisher(publisher *DragEventPublisher, source IWidget, data *DataObject, allowed DropEffect) func(effect DropEffect) {
+	return func(effect DropEffect) {
+		publisher.Publish(&dragEventArgs{
+			eventArgs: eventArgs{source},
+			source:    source,
+			data:      data,
+			allowed:   allowed,
+			effect:    effect,
+		})
+	}
+}
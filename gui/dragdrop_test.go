@@ -0,0 +1,291 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"fmt"
+	"testing"
+)
+
+import (
+	"walk/drawing"
+	. "walk/winapi/user32"
+)
+
+func TestDataObject(t *testing.T) {
+	d := NewDataObject()
+
+	d.SetText("hello")
+	d.SetData("custom", 42)
+	d.SetFilePaths([]string{`C:\a.txt`})
+	d.SetText("hello again")
+
+	if formats := fmt.Sprint(d.Formats()); formats != "[Text custom Files]" {
+		t.Errorf("expected the formats in the order they were first set, got %s", formats)
+	}
+	if d.Text() != "hello again" || d.Data("custom") != 42 {
+		t.Errorf("unexpected data %q and %v", d.Text(), d.Data("custom"))
+	}
+
+	d.Formats()[0] = "changed"
+	if !d.HasFormat(TextFormat) || d.HasFormat("changed") {
+		t.Error("expected Formats to return a copy")
+	}
+
+	paths := []string{"a", "b"}
+	d.SetFilePaths(paths)
+	paths[0] = "changed"
+	if p := d.FilePaths(); len(p) != 2 || p[0] != "a" {
+		t.Errorf("expected SetFilePaths to store a copy, got %v", p)
+	}
+
+	d.SetData("custom", nil)
+	d.SetFilePaths(nil)
+	d.SetData("missing", nil)
+	if formats := fmt.Sprint(d.Formats()); formats != "[Text]" {
+		t.Errorf("expected nil values to remove the formats, got %s", formats)
+	}
+	if d.HasFormat("custom") || d.Data("custom") != nil || d.FilePaths() != nil {
+		t.Error("expected no data in removed formats")
+	}
+
+	d.SetData("custom", "back")
+	if formats := fmt.Sprint(d.Formats()); formats != "[Text custom]" {
+		t.Errorf("expected a format set again to be appended, got %s", formats)
+	}
+
+	if empty := NewDataObject(); empty.Text() != "" || empty.FilePaths() != nil || len(empty.Formats()) != 0 {
+		t.Error("expected a new data object to be empty")
+	}
+}
+
+func TestDefaultDropEffect(t *testing.T) {
+	const all = DropEffectCopy | DropEffectMove | DropEffectLink
+
+	tests := []struct {
+		allowed   DropEffect
+		modifiers Modifiers
+		expected  DropEffect
+	}{
+		{all, 0, DropEffectMove},
+		{DropEffectCopy | DropEffectLink, 0, DropEffectCopy},
+		{DropEffectLink, 0, DropEffectLink},
+		{DropEffectNone, 0, DropEffectNone},
+		{all, ModControl, DropEffectCopy},
+		{all, ModShift, DropEffectMove},
+		{all, ModControl | ModShift, DropEffectLink},
+		{all, ModAlt, DropEffectMove},
+		{all, ModAlt | ModControl, DropEffectCopy},
+		{DropEffectMove, ModControl, DropEffectNone},
+		{DropEffectCopy, ModShift, DropEffectNone},
+		{DropEffectCopy | DropEffectMove, ModControl | ModShift, DropEffectNone},
+	}
+
+	for _, test := range tests {
+		if effect := defaultDropEffect(test.allowed, test.modifiers); effect != test.expected {
+			t.Errorf("allowed %d, modifiers %d: expected %d, got %d", test.allowed, test.modifiers, test.expected, effect)
+		}
+	}
+}
+
+// testDropTarget records the drag events it receives and optionally chooses
+// the effect of the drop.
+type testDropTarget struct {
+	IWidget
+	name   string
+	events *[]string
+	effect DropEffect
+	choose bool
+}
+
+func (dt *testDropTarget) publishDragEvent(kind dragEventKind, args *dragEventArgs) {
+	names := []string{"enter", "over", "leave", "drop"}
+	*dt.events = append(*dt.events, fmt.Sprintf("%s %s %d", dt.name, names[kind], args.Effect()))
+
+	if dt.choose && kind != dragLeaveEvent {
+		args.SetEffect(dt.effect)
+	}
+}
+
+func TestDragSession(t *testing.T) {
+	var events []string
+	completed := DropEffect(-1)
+
+	a := &testDropTarget{name: "a", events: &events}
+	b := &testDropTarget{name: "b", events: &events, effect: DropEffectCopy, choose: true}
+
+	s := newDragSession(nil, NewDataObject(), DropEffectCopy|DropEffectMove, func(effect DropEffect) {
+		completed = effect
+	})
+
+	checkEffect := func(name string, effect, expected DropEffect) {
+		if effect != expected {
+			t.Errorf("%s: expected the effect %d, got %d", name, expected, effect)
+		}
+	}
+
+	checkEffect("outside", s.moveTo(nil, 0, 0, 0), DropEffectNone)
+	checkEffect("enter a", s.moveTo(a, 1, 1, 0), DropEffectMove)
+	checkEffect("over a", s.moveTo(a, 2, 2, ModControl), DropEffectCopy)
+
+	// b chooses a copy, whatever the modifiers request.
+	checkEffect("enter b", s.moveTo(b, 1, 1, 0), DropEffectCopy)
+
+	// Effects the source does not allow are ignored.
+	b.effect = DropEffectLink
+	checkEffect("link over b", s.moveTo(b, 2, 2, 0), DropEffectNone)
+	b.effect = DropEffectCopy | DropEffectLink
+	checkEffect("copy or link over b", s.moveTo(b, 3, 3, 0), DropEffectCopy)
+
+	checkEffect("drop", s.drop(3, 3, ModShift), DropEffectCopy)
+	checkEffect("completed", completed, DropEffectCopy)
+
+	expected := "[a enter 2 a over 1 a leave 2 b enter 2 b over 2 b over 2 b drop 1]"
+	if s := fmt.Sprint(events); s != expected {
+		t.Errorf("expected the events %s, got %s", expected, s)
+	}
+}
+
+func TestDragSessionRejected(t *testing.T) {
+	var events []string
+	completed := DropEffect(-1)
+
+	a := &testDropTarget{name: "a", events: &events, effect: DropEffectNone, choose: true}
+
+	s := newDragSession(nil, NewDataObject(), DropEffectMove, func(effect DropEffect) {
+		completed = effect
+	})
+
+	s.moveTo(a, 1, 1, 0)
+	if effect := s.drop(1, 1, 0); effect != DropEffectNone || completed != DropEffectNone {
+		t.Errorf("expected a target that rejects the data not to receive the drop, got %d", effect)
+	}
+
+	if s := fmt.Sprint(events); s != "[a enter 2 a leave 2]" {
+		t.Errorf("expected the drag to leave the target, got %s", s)
+	}
+}
+
+func TestDragSessionCancel(t *testing.T) {
+	var events []string
+	completed := DropEffect(-1)
+
+	a := &testDropTarget{name: "a", events: &events}
+
+	s := newDragSession(nil, NewDataObject(), DropEffectLink, func(effect DropEffect) {
+		completed = effect
+	})
+
+	s.moveTo(a, 1, 1, 0)
+	if effect := s.cancel(); effect != DropEffectNone || completed != DropEffectNone {
+		t.Errorf("expected a canceled drag to have no effect, got %d", effect)
+	}
+
+	if s := fmt.Sprint(events); s != "[a enter 4 a leave 4]" {
+		t.Errorf("expected the drag to leave the target, got %s", s)
+	}
+}
+
+func TestBeginDrag(t *testing.T) {
+	b, mw := newTestMainWindow(t)
+	mw.SetBounds(drawing.Rectangle{100, 50, 400, 300})
+	mw.ClientArea().SetBounds(drawing.Rectangle{0, 0, 400, 300})
+	mw.Show()
+
+	source := newTestLabel(t, mw.ClientArea())
+	source.SetBounds(drawing.Rectangle{0, 0, 100, 20})
+	target := newTestLabel(t, mw.ClientArea())
+	target.SetBounds(drawing.Rectangle{0, 50, 100, 20})
+	target.SetAllowDrop(true)
+
+	var events []string
+	record := func(name string) DragEventHandler {
+		return func(args DragEventArgs) {
+			events = append(events, fmt.Sprintf("%s %d,%d %d", name, args.X(), args.Y(), args.Effect()))
+			if args.Source() != IWidget(source) || args.Data().Text() != "dragged" {
+				t.Errorf("%s: unexpected source or data", name)
+			}
+		}
+	}
+	target.DragEnter().Attach(record("enter"))
+	target.DragOver().Attach(record("over"))
+	target.DragLeave().Attach(record("leave"))
+	target.Drop().Attach(record("drop"))
+
+	completed := DropEffect(-1)
+	complete := func(effect DropEffect) {
+		completed = effect
+	}
+
+	data := NewDataObject()
+	data.SetText("dragged")
+
+	if err := source.BeginDrag(nil, DropEffectCopy, nil); err == nil {
+		t.Error("expected an error for nil data")
+	}
+	if err := source.BeginDrag(data, DropEffectNone, nil); err == nil {
+		t.Error("expected an error without allowed effects")
+	}
+
+	if err := source.BeginDrag(data, DropEffectCopy|DropEffectMove, complete); err != nil {
+		t.Fatalf("BeginDrag failed: %s", err)
+	}
+	if err := source.BeginDrag(data, DropEffectCopy, nil); err == nil {
+		t.Error("expected an error while dragging")
+	}
+
+	b.MouseMove(target, drawing.Point{5, 6}, LeftButton)
+	b.SetModifiersDown(ModControl)
+	b.MouseMove(target, drawing.Point{7, 8}, LeftButton)
+	b.MouseUp(target, drawing.Point{9, 10}, LeftButton)
+	b.SetModifiersDown(0)
+
+	expected := "[enter 5,6 2 over 7,8 1 over 9,10 1 drop 9,10 1]"
+	if s := fmt.Sprint(events); s != expected {
+		t.Errorf("expected the events %s, got %s", expected, s)
+	}
+	if completed != DropEffectCopy {
+		t.Errorf("expected the drag to complete with a copy, got %d", completed)
+	}
+	if b.Capture() != 0 {
+		t.Error("expected the capture to be released")
+	}
+
+	// Escape cancels the drag.
+	events = nil
+	source.BeginDrag(data, DropEffectMove, complete)
+	b.MouseMove(target, drawing.Point{1, 2}, LeftButton)
+	b.SendMessage(source.hWnd, WM_KEYDOWN, uintptr(KeyEscape), 1)
+
+	if s := fmt.Sprint(events); s != "[enter 1,2 2 leave 0,0 2]" {
+		t.Errorf("expected Escape to leave the target, got %s", s)
+	}
+	if completed != DropEffectNone || b.Capture() != 0 {
+		t.Errorf("expected the drag to be canceled, got %d", completed)
+	}
+}
+
+func TestDropFiles(t *testing.T) {
+	b, mw := newTestMainWindow(t)
+
+	target := newTestLabel(t, mw.ClientArea())
+
+	var paths []string
+	var effect DropEffect
+	target.Drop().Attach(func(args DragEventArgs) {
+		paths, effect = args.Data().FilePaths(), args.Effect()
+	})
+
+	b.DropFiles(target, []string{"a.txt"}, drawing.Point{})
+	if paths != nil {
+		t.Errorf("expected no drop on a widget that does not allow drops, got %v", paths)
+	}
+
+	target.SetAllowDrop(true)
+	b.DropFiles(target, []string{"a.txt", "b.txt"}, drawing.Point{3, 4})
+	if len(paths) != 2 || paths[1] != "b.txt" || effect != DropEffectCopy {
+		t.Errorf("expected the files to be copied, got %v and %d", paths, effect)
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+// EventHandle identifies a handler attached to an event, so that it can be
+// detached again. The zero value identifies no handler.
+type EventHandle int
+
+type eventHandlerEntry struct {
+	handle   EventHandle
+	handler  interface{}
+	once     bool
+	detached bool
+}
+
+// eventHandlerList stores the handlers of an event, independent of their
+// type. The typed events like Event and MouseEvent wrap it.
+//
+// Handlers may be attached and detached while the event is raised. Handlers
+// attached during a raise are first called by the next one, handlers detached
+// during a raise are not called anymore, not even by the current one.
+type eventHandlerList struct {
+	entries    []*eventHandlerEntry
+	lastHandle EventHandle
+}
+
+func (l *eventHandlerList) attach(handler interface{}, once bool) EventHandle {
+	l.lastHandle++
+
+	l.entries = append(l.entries, &eventHandlerEntry{
+		handle:  l.lastHandle,
+		handler: handler,
+		once:    once,
+	})
+
+	return l.lastHandle
+}
+
+func (l *eventHandlerList) detach(handle EventHandle) {
+	for i, entry := range l.entries {
+		if entry.handle == handle {
+			entry.detached = true
+
+			// Build a new slice, so raises in progress are not affected.
+			entries := make([]*eventHandlerEntry, 0, len(l.entries)-1)
+			entries = append(entries, l.entries[:i]...)
+			l.entries = append(entries, l.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+func (l *eventHandlerList) empty() bool {
+	return len(l.entries) == 0
+}
+
+// raise calls call with each handler attached when raise is called, that has
+// not been detached in the meantime. One-shot handlers are detached before
+// they are called.
+func (l *eventHandlerList) raise(call func(handler interface{})) {
+	entries := l.entries
+
+	for _, entry := range entries {
+		if entry.detached {
+			continue
+		}
+
+		if entry.once {
+			l.detach(entry.handle)
+		}
+
+		call(entry.handler)
+	}
+}
+
+// Event is an event that handlers of type EventHandler can be attached to.
+type Event struct {
+	handlers eventHandlerList
+}
+
+// Attach attaches handler to the event and returns a handle to detach it.
+func (e *Event) Attach(handler EventHandler) EventHandle {
+	return e.handlers.attach(handler, false)
+}
+
+// Once attaches handler to the event, so that it is detached after it has
+// been called once.
+func (e *Event) Once(handler EventHandler) EventHandle {
+	return e.handlers.attach(handler, true)
+}
+
+// Detach detaches the handler identified by handle. Unknown handles are
+// ignored.
+func (e *Event) Detach(handle EventHandle) {
+	e.handlers.detach(handle)
+}
+
+// EventPublisher owns an Event and raises it. Types expose the Event to their
+// users and keep the EventPublisher to themselves.
+type EventPublisher struct {
+	event Event
+}
+
+func (p *EventPublisher) Event() *Event {
+	return &p.event
+}
+
+// Publish calls the handlers attached to the event with args.
+func (p *EventPublisher) Publish(args EventArgs) {
+	p.event.handlers.raise(func(handler interface{}) {
+		handler.(EventHandler)(args)
+	})
+}
+
+// KeyEvent is an event that handlers of type KeyEventHandler can be attached
+// to.
+type KeyEvent struct {
+	handlers eventHandlerList
+}
+
+func (e *KeyEvent) Attach(handler KeyEventHandler) EventHandle {
+	return e.handlers.attach(handler, false)
+}
+
+func (e *KeyEvent) Once(handler KeyEventHandler) EventHandle {
+	return e.handlers.attach(handler, true)
+}
+
+func (e *KeyEvent) Detach(handle EventHandle) {
+	e.handlers.detach(handle)
+}
+
+type KeyEventPublisher struct {
+	event KeyEvent
+}
+
+func (p *KeyEventPublisher) Event() *KeyEvent {
+	return &p.event
+}
+
+func (p *KeyEventPublisher) Publish(args KeyEventArgs) {
+	p.event.handlers.raise(func(handler interface{}) {
+		handler.(KeyEventHandler)(args)
+	})
+}
+
+// MouseEvent is an event that handlers of type MouseEventHandler can be
+// attached to.
+type MouseEvent struct {
+	handlers eventHandlerList
+}
+
+func (e *MouseEvent) Attach(handler MouseEventHandler) EventHandle {
+	return e.handlers.attach(handler, false)
+}
+
+func (e *MouseEvent) Once(handler MouseEventHandler) EventHandle {
+	return e.handlers.attach(handler, true)
+}
+
+func (e *MouseEvent) Detach(handle EventHandle) {
+	e.handlers.detach(handle)
+}
+
+type MouseEventPublisher struct {
+	event MouseEvent
+}
+
+func (p *MouseEventPublisher) Event() *MouseEvent {
+	return &p.event
+}
+
+func (p *MouseEventPublisher) Publish(args MouseEventArgs) {
+	p.event.handlers.raise(func(handler interface{}) {
+		handler.(MouseEventHandler)(args)
+	})
+}
+
+// CancelEvent is an event that handlers of type CancelEventHandler can be
+// attached to.
+type CancelEvent struct {
+	handlers eventHandlerList
+}
+
+func (e *CancelEvent) Attach(handler CancelEventHandler) EventHandle {
+	return e.handlers.attach(handler, false)
+}
+
+func (e *CancelEvent) Once(handler CancelEventHandler) EventHandle {
+	return e.handlers.attach(handler, true)
+}
+
+func (e *CancelEvent) Detach(handle EventHandle) {
+	e.handlers.detach(handle)
+}
+
+type CancelEventPublisher struct {
+	event CancelEvent
+}
+
+func (p *CancelEventPublisher) Event() *CancelEvent {
+	return &p.event
+}
+
+func (p *CancelEventPublisher) Publish(args CancelEventArgs) {
+	p.event.handlers.raise(func(handler interface{}) {
+		handler.(CancelEventHandler)(args)
+	})
+}
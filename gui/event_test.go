@@ -0,0 +1,127 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"fmt"
+	"testing"
+)
+
+// eventRecorder attaches handlers to an event that record their calls.
+type eventRecorder struct {
+	publisher EventPublisher
+	calls     []string
+}
+
+func (r *eventRecorder) attach(name string, f func()) EventHandle {
+	return r.publisher.Event().Attach(func(args EventArgs) {
+		r.calls = append(r.calls, name)
+		if f != nil {
+			f()
+		}
+	})
+}
+
+func (r *eventRecorder) publish() []string {
+	r.calls = nil
+	r.publisher.Publish(nil)
+	return r.calls
+}
+
+func checkEventCalls(t *testing.T, name string, calls []string, expected string) {
+	if s := fmt.Sprint(calls); s != expected {
+		t.Errorf("%s: expected the calls %s, got %s", name, expected, s)
+	}
+}
+
+func TestEventDetachSelf(t *testing.T) {
+	r := new(eventRecorder)
+
+	var b EventHandle
+	r.attach("a", nil)
+	b = r.attach("b", func() {
+		r.publisher.Event().Detach(b)
+	})
+	r.attach("c", nil)
+
+	checkEventCalls(t, "first", r.publish(), "[a b c]")
+	checkEventCalls(t, "second", r.publish(), "[a c]")
+}
+
+func TestEventDetachLater(t *testing.T) {
+	r := new(eventRecorder)
+
+	var c EventHandle
+	r.attach("a", func() {
+		r.publisher.Event().Detach(c)
+	})
+	r.attach("b", nil)
+	c = r.attach("c", nil)
+
+	checkEventCalls(t, "first", r.publish(), "[a b]")
+	checkEventCalls(t, "second", r.publish(), "[a b]")
+}
+
+func TestEventDetachEarlier(t *testing.T) {
+	r := new(eventRecorder)
+
+	var a EventHandle
+	a = r.attach("a", nil)
+	r.attach("b", func() {
+		r.publisher.Event().Detach(a)
+	})
+	r.attach("c", nil)
+
+	checkEventCalls(t, "first", r.publish(), "[a b c]")
+	checkEventCalls(t, "second", r.publish(), "[b c]")
+}
+
+func TestEventAttachDuringPublish(t *testing.T) {
+	r := new(eventRecorder)
+
+	attached := false
+	r.attach("a", func() {
+		if !attached {
+			attached = true
+			r.attach("d", nil)
+		}
+	})
+	r.attach("b", nil)
+
+	checkEventCalls(t, "first", r.publish(), "[a b]")
+	checkEventCalls(t, "second", r.publish(), "[a b d]")
+}
+
+func TestEventOnce(t *testing.T) {
+	r := new(eventRecorder)
+
+	r.attach("a", nil)
+	r.publisher.Event().Once(func(args EventArgs) {
+		r.calls = append(r.calls, "once")
+
+		// A nested raise does not call the handler again.
+		r.publisher.Publish(nil)
+	})
+
+	checkEventCalls(t, "first", r.publish(), "[a once a]")
+	checkEventCalls(t, "second", r.publish(), "[a]")
+}
+
+func TestEventDetachUnknown(t *testing.T) {
+	r := new(eventRecorder)
+
+	a := r.attach("a", nil)
+	r.publisher.Event().Detach(0)
+	r.publisher.Event().Detach(a + 1)
+	checkEventCalls(t, "unknown handles", r.publish(), "[a]")
+
+	r.publisher.Event().Detach(a)
+	r.publisher.Event().Detach(a)
+	checkEventCalls(t, "detached twice", r.publish(), "[]")
+
+	if b := r.attach("b", nil); b == a {
+		t.Error("expected a new handle for a new handler")
+	}
+}
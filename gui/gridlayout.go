@@ -0,0 +1,587 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"os"
+)
+
+import (
+	"walk/drawing"
+	. "walk/winapi/user32"
+)
+
+type gridLayoutCell struct {
+	row, column, rowSpan, columnSpan int
+}
+
+// GridLayoutItem describes a widget to be laid out by GridLayout.Compute.
+type GridLayoutItem struct {
+	Row, Column, RowSpan, ColumnSpan int
+	Flags                            LayoutFlags
+	MinSize, MaxSize, PreferredSize  drawing.Size
+}
+
+// GridLayout arranges the children of its container in rows and columns.
+//
+// Only children that were assigned a cell using SetCell or SetRange are laid
+// out.
+type GridLayout struct {
+	container            IContainer
+	margins              *Margins
+	spacing              int
+	hWnd2Cell            map[HWND]*gridLayoutCell
+	rowStretchFactors    map[int]int
+	columnStretchFactors map[int]int
+	rowMinSizes          map[int]int
+	columnMinSizes       map[int]int
+}
+
+func NewGridLayout() *GridLayout {
+	return &GridLayout{
+		margins:              &Margins{},
+		hWnd2Cell:            make(map[HWND]*gridLayoutCell),
+		rowStretchFactors:    make(map[int]int),
+		columnStretchFactors: make(map[int]int),
+		rowMinSizes:          make(map[int]int),
+		columnMinSizes:       make(map[int]int),
+	}
+}
+
+func (l *GridLayout) Container() IContainer {
+	return l.container
+}
+
+func (l *GridLayout) SetContainer(value IContainer) {
+	if value != l.container {
+		if l.container != nil {
+			l.container.SetLayout(nil)
+		}
+
+		l.container = value
+
+		if value != nil && value.Layout() != Layout(l) {
+			value.SetLayout(l)
+
+			l.Update(true)
+		}
+	}
+}
+
+func (l *GridLayout) Margins() *Margins {
+	return l.margins
+}
+
+func (l *GridLayout) SetMargins(value *Margins) os.Error {
+	if value == nil {
+		return newError("margins cannot be nil")
+	}
+
+	l.margins = value
+
+	return l.Update(false)
+}
+
+func (l *GridLayout) Spacing() int {
+	return l.spacing
+}
+
+func (l *GridLayout) SetSpacing(value int) os.Error {
+	if value != l.spacing {
+		if value < 0 {
+			return newError("spacing cannot be negative")
+		}
+
+		l.spacing = value
+
+		l.Update(false)
+	}
+
+	return nil
+}
+
+func (l *GridLayout) SetCell(widget IWidget, row, column int) os.Error {
+	return l.SetRange(widget, row, column, 1, 1)
+}
+
+// SetRange places widget in the cell at row and column, spanning rowSpan rows
+// and columnSpan columns.
+func (l *GridLayout) SetRange(widget IWidget, row, column, rowSpan, columnSpan int) os.Error {
+	if widget == nil {
+		return newError("widget cannot be nil")
+	}
+	if row < 0 || column < 0 {
+		return newError("row and column cannot be negative")
+	}
+	if rowSpan < 1 || columnSpan < 1 {
+		return newError("rowSpan and columnSpan must be positive")
+	}
+
+	l.hWnd2Cell[widget.Handle()] = &gridLayoutCell{row, column, rowSpan, columnSpan}
+
+	return l.Update(false)
+}
+
+// Range returns the cell range widget was placed in. If widget has not been
+// placed, ok is false.
+func (l *GridLayout) Range(widget IWidget) (row, column, rowSpan, columnSpan int, ok bool) {
+	if widget == nil {
+		return
+	}
+
+	cell, ok := l.hWnd2Cell[widget.Handle()]
+	if !ok {
+		return
+	}
+
+	return cell.row, cell.column, cell.rowSpan, cell.columnSpan, true
+}
+
+// RemoveWidget removes the cell assignment of widget, so it is no longer laid
+// out.
+func (l *GridLayout) RemoveWidget(widget IWidget) os.Error {
+	if widget == nil {
+		return newError("widget cannot be nil")
+	}
+
+	l.hWnd2Cell[widget.Handle()] = nil, false
+
+	return l.Update(false)
+}
+
+func setGridLayoutLineValue(m map[int]int, line, value int, what string) os.Error {
+	if line < 0 {
+		return newError("index cannot be negative")
+	}
+	if value < 0 {
+		return newError(what + " cannot be negative")
+	}
+
+	if value == 0 {
+		m[line] = 0, false
+	} else {
+		m[line] = value
+	}
+
+	return nil
+}
+
+func (l *GridLayout) RowStretchFactor(row int) int {
+	return l.rowStretchFactors[row]
+}
+
+// SetRowStretchFactor sets how much extra space the row receives relative to
+// the other rows, when the container is larger than preferred.
+//
+// If any row has a stretch factor, only rows with a stretch factor grow.
+func (l *GridLayout) SetRowStretchFactor(row, factor int) os.Error {
+	if err := setGridLayoutLineValue(l.rowStretchFactors, row, factor, "factor"); err != nil {
+		return err
+	}
+
+	return l.Update(false)
+}
+
+func (l *GridLayout) ColumnStretchFactor(column int) int {
+	return l.columnStretchFactors[column]
+}
+
+// SetColumnStretchFactor sets how much extra space the column receives
+// relative to the other columns, when the container is larger than preferred.
+//
+// If any column has a stretch factor, only columns with a stretch factor grow.
+func (l *GridLayout) SetColumnStretchFactor(column, factor int) os.Error {
+	if err := setGridLayoutLineValue(l.columnStretchFactors, column, factor, "factor"); err != nil {
+		return err
+	}
+
+	return l.Update(false)
+}
+
+func (l *GridLayout) RowMinSize(row int) int {
+	return l.rowMinSizes[row]
+}
+
+func (l *GridLayout) SetRowMinSize(row, size int) os.Error {
+	if err := setGridLayoutLineValue(l.rowMinSizes, row, size, "size"); err != nil {
+		return err
+	}
+
+	return l.Update(false)
+}
+
+func (l *GridLayout) ColumnMinSize(column int) int {
+	return l.columnMinSizes[column]
+}
+
+func (l *GridLayout) SetColumnMinSize(column, size int) os.Error {
+	if err := setGridLayoutLineValue(l.columnMinSizes, column, size, "size"); err != nil {
+		return err
+	}
+
+	return l.Update(false)
+}
+
+func (l *GridLayout) MinSize() drawing.Size {
+	_, items, err := l.items()
+	if err != nil {
+		return drawing.Size{}
+	}
+
+	minSize, _ := l.ComputeSizeHints(items)
+
+	return minSize
+}
+
+func (l *GridLayout) PreferredSize() drawing.Size {
+	_, items, err := l.items()
+	if err != nil {
+		return drawing.Size{}
+	}
+
+	_, prefSize := l.ComputeSizeHints(items)
+
+	return prefSize
+}
+
+func (l *GridLayout) items() (widgets []IWidget, items []GridLayoutItem, err os.Error) {
+	if l.container == nil {
+		return
+	}
+
+	children := l.container.Children()
+
+	widgets = make([]IWidget, 0, children.Len())
+	items = make([]GridLayoutItem, 0, children.Len())
+
+	for i := 0; i < children.Len(); i++ {
+		widget := children.At(i)
+
+		cell, ok := l.hWnd2Cell[widget.Handle()]
+		if !ok {
+			continue
+		}
+
+		var minSize, maxSize drawing.Size
+
+		if minSize, err = widget.MinSize(); err != nil {
+			return
+		}
+
+		if maxSize, err = widget.MaxSize(); err != nil {
+			return
+		}
+
+		j := len(widgets)
+		widgets = widgets[0 : j+1]
+		widgets[j] = widget
+
+		items = items[0 : j+1]
+		items[j] = GridLayoutItem{
+			Row:           cell.row,
+			Column:        cell.column,
+			RowSpan:       cell.rowSpan,
+			ColumnSpan:    cell.columnSpan,
+			Flags:         widget.LayoutFlags(),
+			MinSize:       minSize,
+			MaxSize:       maxSize,
+			PreferredSize: widget.PreferredSize(),
+		}
+	}
+
+	return
+}
+
+func (l *GridLayout) Update(reset bool) os.Error {
+	if reset && l.container != nil {
+		// Children may have been removed.
+		isChild := childHandles(l.container)
+		for hWnd := range l.hWnd2Cell {
+			if !isChild[hWnd] {
+				l.hWnd2Cell[hWnd] = nil, false
+			}
+		}
+	}
+
+	widgets, items, err := l.items()
+	if err != nil {
+		return err
+	}
+
+	if len(widgets) == 0 {
+		return nil
+	}
+
+	cb, err := l.container.ClientBounds()
+	if err != nil {
+		return err
+	}
+
+	for i, bounds := range l.Compute(items, cb) {
+		if err := widgets[i].SetBounds(bounds); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Compute returns the bounds of items, as they would be arranged inside
+// clientBounds using the margins, spacing, stretch factors and min sizes of the
+// GridLayout.
+//
+// Compute does not need a container, so it can be used to test layouts without
+// creating any windows.
+func (l *GridLayout) Compute(items []GridLayoutItem, clientBounds drawing.Rectangle) []drawing.Rectangle {
+	area := drawing.Rectangle{
+		clientBounds.X + l.margins.Left,
+		clientBounds.Y + l.margins.Top,
+		clientBounds.Width - l.margins.Left - l.margins.Right,
+		clientBounds.Height - l.margins.Top - l.margins.Bottom,
+	}
+
+	axisItems := make([]gridLayoutAxisItem, len(items))
+
+	for i, item := range items {
+		axisItems[i] = gridLayoutAxisItemFor(item, true)
+	}
+	columnStarts, columnSizes := l.computeAxis(axisItems, l.columnStretchFactors, l.columnMinSizes, area.X, area.Width)
+
+	for i, item := range items {
+		axisItems[i] = gridLayoutAxisItemFor(item, false)
+	}
+	rowStarts, rowSizes := l.computeAxis(axisItems, l.rowStretchFactors, l.rowMinSizes, area.Y, area.Height)
+
+	bounds := make([]drawing.Rectangle, len(items))
+
+	for i, item := range items {
+		x, width := gridLayoutItemPlacement(gridLayoutAxisItemFor(item, true), columnStarts, columnSizes)
+		y, height := gridLayoutItemPlacement(gridLayoutAxisItemFor(item, false), rowStarts, rowSizes)
+
+		bounds[i] = drawing.Rectangle{x, y, width, height}
+	}
+
+	return bounds
+}
+
+// ComputeSizeHints returns the minimum and preferred size of the area needed
+// to arrange items, including the margins and spacing of the GridLayout.
+func (l *GridLayout) ComputeSizeHints(items []GridLayoutItem) (minSize, preferredSize drawing.Size) {
+	axisItems := make([]gridLayoutAxisItem, len(items))
+
+	for i, item := range items {
+		axisItems[i] = gridLayoutAxisItemFor(item, true)
+	}
+	minSize.Width, preferredSize.Width = l.axisSizeHints(axisItems, l.columnStretchFactors, l.columnMinSizes)
+
+	for i, item := range items {
+		axisItems[i] = gridLayoutAxisItemFor(item, false)
+	}
+	minSize.Height, preferredSize.Height = l.axisSizeHints(axisItems, l.rowStretchFactors, l.rowMinSizes)
+
+	horz := l.margins.Left + l.margins.Right
+	vert := l.margins.Top + l.margins.Bottom
+
+	minSize.Width += horz
+	minSize.Height += vert
+	preferredSize.Width += horz
+	preferredSize.Height += vert
+
+	return
+}
+
+// gridLayoutAxisItem is the projection of a GridLayoutItem onto either the
+// horizontal or the vertical axis.
+type gridLayoutAxisItem struct {
+	layoutAxisItem
+	start, span int
+}
+
+func gridLayoutAxisItemFor(item GridLayoutItem, horizontal bool) gridLayoutAxisItem {
+	ai := gridLayoutAxisItem{
+		layoutAxisItem: newLayoutAxisItem(item.Flags, item.MinSize, item.MaxSize, item.PreferredSize, horizontal),
+	}
+
+	if horizontal {
+		ai.start, ai.span = item.Column, item.ColumnSpan
+	} else {
+		ai.start, ai.span = item.Row, item.RowSpan
+	}
+
+	if ai.span < 1 {
+		ai.span = 1
+	}
+
+	return ai
+}
+
+// axisLines returns the rows or columns of the grid, with their size
+// constraints derived from items.
+func (l *GridLayout) axisLines(items []gridLayoutAxisItem, stretchFactors, minSizes map[int]int) (lines []layoutLine, usedCount int) {
+	count := 0
+	for _, item := range items {
+		if item.start+item.span > count {
+			count = item.start + item.span
+		}
+	}
+	for line := range stretchFactors {
+		if line >= count {
+			count = line + 1
+		}
+	}
+	for line := range minSizes {
+		if line >= count {
+			count = line + 1
+		}
+	}
+
+	lines = make([]layoutLine, count)
+
+	for i := range lines {
+		line := &lines[i]
+
+		line.min = minSizes[i]
+		line.pref = line.min
+		line.max = line.min
+		line.stretch = stretchFactors[i]
+		line.used = line.min > 0 || line.stretch > 0
+		line.grow = line.stretch > 0
+	}
+
+	// Single cell items first, so spanning items only add what is missing.
+	for _, item := range items {
+		if item.span != 1 {
+			continue
+		}
+
+		line := &lines[item.start]
+
+		line.used = true
+		if item.min > line.min {
+			line.min = item.min
+		}
+		if item.pref > line.pref {
+			line.pref = item.pref
+		}
+		if item.max > line.max {
+			line.max = item.max
+		}
+		if item.grow {
+			line.grow = true
+		}
+	}
+
+	for _, item := range items {
+		if item.span == 1 {
+			continue
+		}
+
+		spanned := lines[item.start : item.start+item.span]
+
+		var minSum, prefSum int
+		grow := false
+		for i := range spanned {
+			spanned[i].used = true
+			minSum += spanned[i].min
+			prefSum += spanned[i].pref
+			grow = grow || spanned[i].grow
+		}
+
+		spacingSum := (item.span - 1) * l.spacing
+
+		if missing := item.min - minSum - spacingSum; missing > 0 {
+			for i := range spanned {
+				spanned[i].min += layoutShare(missing, len(spanned), i)
+			}
+		}
+		if missing := item.pref - prefSum - spacingSum; missing > 0 {
+			for i := range spanned {
+				spanned[i].pref += layoutShare(missing, len(spanned), i)
+			}
+		}
+
+		if item.grow && !grow {
+			for i := range spanned {
+				spanned[i].grow = true
+			}
+		}
+	}
+
+	for i := range lines {
+		line := &lines[i]
+
+		if line.pref < line.min {
+			line.pref = line.min
+		}
+		if line.max < line.pref {
+			line.max = line.pref
+		}
+
+		if line.used {
+			usedCount++
+		}
+	}
+
+	return
+}
+
+// axisSizeHints returns the minimum and preferred length of the rows or
+// columns of the grid, including spacing but excluding margins.
+func (l *GridLayout) axisSizeHints(items []gridLayoutAxisItem, stretchFactors, minSizes map[int]int) (min, pref int) {
+	lines, usedCount := l.axisLines(items, stretchFactors, minSizes)
+
+	for _, line := range lines {
+		if line.used {
+			min += line.min
+			pref += line.pref
+		}
+	}
+
+	if usedCount > 1 {
+		min += (usedCount - 1) * l.spacing
+		pref += (usedCount - 1) * l.spacing
+	}
+
+	return
+}
+
+func (l *GridLayout) computeAxis(items []gridLayoutAxisItem, stretchFactors, minSizes map[int]int, offset, length int) (starts, sizes []int) {
+	lines, usedCount := l.axisLines(items, stretchFactors, minSizes)
+
+	available := length
+	if usedCount > 1 {
+		available -= (usedCount - 1) * l.spacing
+	}
+
+	sizes = distributeLayoutSpace(lines, available)
+
+	starts = make([]int, len(lines))
+	pos := offset
+	for i, line := range lines {
+		starts[i] = pos
+
+		if line.used {
+			pos += sizes[i] + l.spacing
+		}
+	}
+
+	return
+}
+
+// gridLayoutItemPlacement returns the position and size of item within the
+// lines it spans. Items that cannot grow are aligned to the start of the cell.
+func gridLayoutItemPlacement(item gridLayoutAxisItem, starts, sizes []int) (pos, size int) {
+	last := item.start + item.span - 1
+
+	pos = starts[item.start]
+	cellSize := starts[last] + sizes[last] - pos
+
+	size = cellSize
+	if !item.grow && size > item.max {
+		size = item.max
+	}
+
+	return
+}
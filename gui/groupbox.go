@@ -6,7 +6,6 @@ package gui
 
 import (
 	"os"
-	"syscall"
 )
 
 import (
@@ -23,16 +22,16 @@ func NewGroupBox(parent IContainer) (*GroupBox, os.Error) {
 		return nil, newError("parent cannot be nil")
 	}
 
-	hWnd := CreateWindowEx(
-		0, syscall.StringToUTF16Ptr("BUTTON"), nil,
+	hWnd, err := backend.CreateWindow(
+		0, "BUTTON",
 		BS_GROUPBOX|WS_CHILD|WS_VISIBLE,
-		0, 0, 80, 24, parent.Handle(), 0, 0, nil)
-	if hWnd == 0 {
-		return nil, lastError("CreateWindowEx")
+		parent.Handle(), drawing.Rectangle{0, 0, 80, 24})
+	if err != nil {
+		return nil, err
 	}
 
 	gb := &GroupBox{Widget: Widget{hWnd: hWnd, parent: parent}}
-	gb.SetFont(defaultFont)
+	gb.SetFont(backend.DefaultFont())
 
 	widgetsByHWnd[hWnd] = gb
 
@@ -11,7 +11,6 @@ import (
 import (
 	"walk/drawing"
 	. "walk/winapi/comctl32"
-	. "walk/winapi/gdi32"
 )
 
 type ImageList struct {
@@ -20,9 +19,9 @@ type ImageList struct {
 }
 
 func NewImageList(imageSize drawing.Size, maskColor drawing.Color) (*ImageList, os.Error) {
-	hIml := ImageList_Create(imageSize.Width, imageSize.Height, ILC_MASK|ILC_COLOR24, 8, 8)
-	if hIml == 0 {
-		return nil, newError("ImageList_Create failed")
+	hIml, err := backend.CreateImageList(imageSize)
+	if err != nil {
+		return nil, err
 	}
 
 	return &ImageList{hIml: hIml, maskColor: maskColor}, nil
@@ -33,17 +32,7 @@ func (il *ImageList) Add(bitmap, maskBitmap *drawing.Bitmap) (int, os.Error) {
 		return 0, newError("bitmap cannot be nil")
 	}
 
-	var maskHandle HBITMAP
-	if maskBitmap != nil {
-		maskHandle = maskBitmap.Handle()
-	}
-
-	index := ImageList_Add(il.hIml, bitmap.Handle(), maskHandle)
-	if index == -1 {
-		return 0, newError("ImageList_Add failed")
-	}
-
-	return index, nil
+	return backend.AddImage(il.hIml, bitmap, maskBitmap)
 }
 
 func (il *ImageList) AddMasked(bitmap *drawing.Bitmap) (int, os.Error) {
@@ -51,17 +40,12 @@ func (il *ImageList) AddMasked(bitmap *drawing.Bitmap) (int, os.Error) {
 		return 0, newError("bitmap cannot be nil")
 	}
 
-	index := ImageList_AddMasked(il.hIml, bitmap.Handle(), COLORREF(il.maskColor))
-	if index == -1 {
-		return 0, newError("ImageList_AddMasked failed")
-	}
-
-	return index, nil
+	return backend.AddMaskedImage(il.hIml, bitmap, il.maskColor)
 }
 
 func (il *ImageList) Dispose() {
 	if il.hIml != 0 {
-		ImageList_Destroy(il.hIml)
+		backend.DestroyImageList(il.hIml)
 		il.hIml = 0
 	}
 }
@@ -20,7 +20,7 @@ type ImageView struct {
 func NewImageView(parent IContainer) (*ImageView, os.Error) {
 	iv := &ImageView{}
 
-	cw, err := NewCustomWidget(parent, 0, func(surface *drawing.Surface, updateBounds drawing.Rectangle) os.Error {
+	cw, err := NewCustomWidget(parent, 0, func(surface drawing.Surface, updateBounds drawing.Rectangle) os.Error {
 		return iv.drawImage(surface, updateBounds)
 	})
 	if err != nil {
@@ -50,7 +50,7 @@ func (iv *ImageView) SetImage(value drawing.Image) os.Error {
 	return iv.Invalidate()
 }
 
-func (iv *ImageView) drawImage(surface *drawing.Surface, updateBounds drawing.Rectangle) os.Error {
+func (iv *ImageView) drawImage(surface drawing.Surface, updateBounds drawing.Rectangle) os.Error {
 	if iv.image == nil {
 		return nil
 	}
@@ -0,0 +1,202 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	. "walk/winapi/user32"
+)
+
+// Key is a virtual key code. Letter and digit keys have the codes of their
+// upper case ASCII characters.
+type Key int
+
+const (
+	KeyA Key = 'A'
+	KeyB Key = 'B'
+	KeyC Key = 'C'
+	KeyD Key = 'D'
+	KeyE Key = 'E'
+	KeyF Key = 'F'
+	KeyG Key = 'G'
+	KeyH Key = 'H'
+	KeyI Key = 'I'
+	KeyJ Key = 'J'
+	KeyK Key = 'K'
+	KeyL Key = 'L'
+	KeyM Key = 'M'
+	KeyN Key = 'N'
+	KeyO Key = 'O'
+	KeyP Key = 'P'
+	KeyQ Key = 'Q'
+	KeyR Key = 'R'
+	KeyS Key = 'S'
+	KeyT Key = 'T'
+	KeyU Key = 'U'
+	KeyV Key = 'V'
+	KeyW Key = 'W'
+	KeyX Key = 'X'
+	KeyY Key = 'Y'
+	KeyZ Key = 'Z'
+)
+
+const (
+	Key0 Key = '0'
+	Key1 Key = '1'
+	Key2 Key = '2'
+	Key3 Key = '3'
+	Key4 Key = '4'
+	Key5 Key = '5'
+	Key6 Key = '6'
+	Key7 Key = '7'
+	Key8 Key = '8'
+	Key9 Key = '9'
+)
+
+const (
+	KeyF1  Key = VK_F1
+	KeyF2  Key = VK_F2
+	KeyF3  Key = VK_F3
+	KeyF4  Key = VK_F4
+	KeyF5  Key = VK_F5
+	KeyF6  Key = VK_F6
+	KeyF7  Key = VK_F7
+	KeyF8  Key = VK_F8
+	KeyF9  Key = VK_F9
+	KeyF10 Key = VK_F10
+	KeyF11 Key = VK_F11
+	KeyF12 Key = VK_F12
+	KeyF13 Key = VK_F13
+	KeyF14 Key = VK_F14
+	KeyF15 Key = VK_F15
+	KeyF16 Key = VK_F16
+	KeyF17 Key = VK_F17
+	KeyF18 Key = VK_F18
+	KeyF19 Key = VK_F19
+	KeyF20 Key = VK_F20
+	KeyF21 Key = VK_F21
+	KeyF22 Key = VK_F22
+	KeyF23 Key = VK_F23
+	KeyF24 Key = VK_F24
+)
+
+const (
+	KeyNumpad0 Key = VK_NUMPAD0
+	KeyNumpad1 Key = VK_NUMPAD1
+	KeyNumpad2 Key = VK_NUMPAD2
+	KeyNumpad3 Key = VK_NUMPAD3
+	KeyNumpad4 Key = VK_NUMPAD4
+	KeyNumpad5 Key = VK_NUMPAD5
+	KeyNumpad6 Key = VK_NUMPAD6
+	KeyNumpad7 Key = VK_NUMPAD7
+	KeyNumpad8 Key = VK_NUMPAD8
+	KeyNumpad9 Key = VK_NUMPAD9
+)
+
+const (
+	KeyBack        Key = VK_BACK
+	KeyTab         Key = VK_TAB
+	KeyClear       Key = VK_CLEAR
+	KeyReturn      Key = VK_RETURN
+	KeyShift       Key = VK_SHIFT
+	KeyControl     Key = VK_CONTROL
+	KeyAlt         Key = VK_MENU
+	KeyPause       Key = VK_PAUSE
+	KeyCapsLock    Key = VK_CAPITAL
+	KeyEscape      Key = VK_ESCAPE
+	KeySpace       Key = VK_SPACE
+	KeyPageUp      Key = VK_PRIOR
+	KeyPageDown    Key = VK_NEXT
+	KeyEnd         Key = VK_END
+	KeyHome        Key = VK_HOME
+	KeyLeft        Key = VK_LEFT
+	KeyUp          Key = VK_UP
+	KeyRight       Key = VK_RIGHT
+	KeyDown        Key = VK_DOWN
+	KeyPrintScreen Key = VK_SNAPSHOT
+	KeyInsert      Key = VK_INSERT
+	KeyDelete      Key = VK_DELETE
+	KeyHelp        Key = VK_HELP
+	KeyLWin        Key = VK_LWIN
+	KeyRWin        Key = VK_RWIN
+	KeyApps        Key = VK_APPS
+	KeyMultiply    Key = VK_MULTIPLY
+	KeyAdd         Key = VK_ADD
+	KeySeparator   Key = VK_SEPARATOR
+	KeySubtract    Key = VK_SUBTRACT
+	KeyDecimal     Key = VK_DECIMAL
+	KeyDivide      Key = VK_DIVIDE
+	KeyNumLock     Key = VK_NUMLOCK
+	KeyScrollLock  Key = VK_SCROLL
+	KeyLShift      Key = VK_LSHIFT
+	KeyRShift      Key = VK_RSHIFT
+	KeyLControl    Key = VK_LCONTROL
+	KeyRControl    Key = VK_RCONTROL
+	KeyLAlt        Key = VK_LMENU
+	KeyRAlt        Key = VK_RMENU
+	KeyOEMPlus     Key = VK_OEM_PLUS
+	KeyOEMComma    Key = VK_OEM_COMMA
+	KeyOEMMinus    Key = VK_OEM_MINUS
+	KeyOEMPeriod   Key = VK_OEM_PERIOD
+)
+
+// String returns the name of the key, as used in shortcuts, e.g. "Del" or
+// "F5".
+func (k Key) String() string {
+	return keyName(k)
+}
+
+type keyEventKind int
+
+const (
+	keyDownEvent keyEventKind = iota
+	keyUpEvent
+	keyPressEvent
+)
+
+// translateKeyMessage translates the keyboard message msg to the kind and
+// arguments of a key event of sender. It returns false for messages that are
+// not keyboard messages and for the first half of a surrogate pair, which is
+// kept in highSurrogate until the second half arrives.
+//
+// modifiers are the modifier keys held down while the message was generated.
+func translateKeyMessage(msg *MSG, sender interface{}, modifiers Modifiers, highSurrogate *uint16) (keyEventKind, *keyEventArgs, bool) {
+	args := &keyEventArgs{
+		eventArgs: eventArgs{sender},
+		modifiers: modifiers,
+	}
+
+	switch msg.Message {
+	case WM_KEYDOWN, WM_SYSKEYDOWN:
+		args.key = Key(msg.WParam)
+		args.repeat = msg.LParam&(1<<30) != 0
+		return keyDownEvent, args, true
+
+	case WM_KEYUP, WM_SYSKEYUP:
+		args.key = Key(msg.WParam)
+		return keyUpEvent, args, true
+
+	case WM_CHAR, WM_SYSCHAR:
+		unit := uint16(msg.WParam)
+
+		switch {
+		case unit >= 0xD800 && unit < 0xDC00:
+			*highSurrogate = unit
+			return 0, nil, false
+
+		case unit >= 0xDC00 && unit < 0xE000 && *highSurrogate != 0:
+			args.rune = 0x10000 + (int(*highSurrogate)-0xD800)<<10 + int(unit) - 0xDC00
+
+		default:
+			args.rune = int(unit)
+		}
+
+		*highSurrogate = 0
+		args.repeat = msg.LParam&(1<<30) != 0
+
+		return keyPressEvent, args, true
+	}
+
+	return 0, nil, false
+}
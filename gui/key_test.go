@@ -0,0 +1,148 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"testing"
+)
+
+import (
+	. "walk/winapi/user32"
+)
+
+func TestTranslateKeyMessage(t *testing.T) {
+	tests := []struct {
+		message   uint
+		wParam    uintptr
+		lParam    uintptr
+		modifiers Modifiers
+		kind      keyEventKind
+		key       Key
+		rune      int
+		repeat    bool
+	}{
+		{WM_KEYDOWN, uintptr(KeyA), 1, ModShift, keyDownEvent, KeyA, 0, false},
+		{WM_KEYDOWN, uintptr(KeyA), 1 | 1<<30, 0, keyDownEvent, KeyA, 0, true},
+		{WM_SYSKEYDOWN, uintptr(KeyF4), 1 | 1<<29, ModAlt, keyDownEvent, KeyF4, 0, false},
+		{WM_KEYUP, uintptr(KeyDelete), 1 | 3<<30, ModControl, keyUpEvent, KeyDelete, 0, false},
+		{WM_SYSKEYUP, uintptr(KeyAlt), 1 | 3<<30, 0, keyUpEvent, KeyAlt, 0, false},
+		{WM_CHAR, 'a', 1, 0, keyPressEvent, 0, 'a', false},
+		{WM_CHAR, 0xE4, 1 | 1<<30, ModShift, keyPressEvent, 0, 0xE4, true},
+		{WM_SYSCHAR, 'f', 1 | 1<<29, ModAlt, keyPressEvent, 0, 'f', false},
+	}
+
+	sender := new(int)
+
+	for i, test := range tests {
+		var highSurrogate uint16
+
+		msg := &MSG{Message: test.message, WParam: test.wParam, LParam: test.lParam}
+		kind, args, ok := translateKeyMessage(msg, sender, test.modifiers, &highSurrogate)
+		if !ok {
+			t.Errorf("%d: expected 0x%04X to be a key message", i, test.message)
+			continue
+		}
+
+		if kind != test.kind {
+			t.Errorf("%d: expected the kind %d, got %d", i, test.kind, kind)
+		}
+		if args.Sender() != sender {
+			t.Errorf("%d: expected the sender to be set", i)
+		}
+		if args.Key() != test.key || args.Rune() != test.rune {
+			t.Errorf("%d: expected the key %v and rune %q, got %v and %q", i, test.key, test.rune, args.Key(), args.Rune())
+		}
+		if args.Modifiers() != test.modifiers {
+			t.Errorf("%d: expected the modifiers %d, got %d", i, test.modifiers, args.Modifiers())
+		}
+		if args.IsRepeat() != test.repeat {
+			t.Errorf("%d: expected repeat %t, got %t", i, test.repeat, args.IsRepeat())
+		}
+		if args.Handled() {
+			t.Errorf("%d: expected the event not to be handled", i)
+		}
+	}
+
+	for _, message := range []uint{WM_MOUSEMOVE, WM_DEADCHAR, WM_SETFOCUS} {
+		var highSurrogate uint16
+		if _, _, ok := translateKeyMessage(&MSG{Message: message}, nil, 0, &highSurrogate); ok {
+			t.Errorf("0x%04X: expected no key message", message)
+		}
+	}
+}
+
+func TestTranslateKeyMessageSurrogates(t *testing.T) {
+	var highSurrogate uint16
+
+	translate := func(unit uint16) (int, bool) {
+		_, args, ok := translateKeyMessage(&MSG{Message: WM_CHAR, WParam: uintptr(unit), LParam: 1}, nil, 0, &highSurrogate)
+		if !ok {
+			return 0, false
+		}
+		return args.Rune(), true
+	}
+
+	// U+1F600 is encoded as D83D DE00.
+	if _, ok := translate(0xD83D); ok || highSurrogate != 0xD83D {
+		t.Fatalf("expected the high surrogate to be kept, got 0x%04X", highSurrogate)
+	}
+	if rune, ok := translate(0xDE00); !ok || rune != 0x1F600 {
+		t.Errorf("expected U+1F600, got %U", rune)
+	}
+	if highSurrogate != 0 {
+		t.Errorf("expected the high surrogate to be reset, got 0x%04X", highSurrogate)
+	}
+
+	// A low surrogate without a high one is passed on as it is.
+	if rune, ok := translate(0xDC01); !ok || rune != 0xDC01 {
+		t.Errorf("expected U+DC01, got %U", rune)
+	}
+
+	// A high surrogate followed by another character is dropped.
+	translate(0xD800)
+	if rune, ok := translate('x'); !ok || rune != 'x' || highSurrogate != 0 {
+		t.Errorf("expected x, got %q", rune)
+	}
+}
+
+func TestKeyEvents(t *testing.T) {
+	b, mw := newTestMainWindow(t)
+
+	le, err := NewLineEdit(mw.ClientArea())
+	if err != nil {
+		t.Fatalf("NewLineEdit failed: %s", err)
+	}
+
+	var downs, ups []Key
+	var typed string
+
+	le.KeyDown().Attach(func(args KeyEventArgs) {
+		downs = append(downs, args.Key())
+	})
+	le.KeyUp().Attach(func(args KeyEventArgs) {
+		ups = append(ups, args.Key())
+	})
+	le.KeyPress().Attach(func(args KeyEventArgs) {
+		typed += string(args.Rune())
+
+		// Digits are suppressed.
+		if args.Rune() >= '0' && args.Rune() <= '9' {
+			args.SetHandled(true)
+		}
+	})
+
+	b.KeyPress(le, KeyDelete)
+	if len(downs) != 1 || downs[0] != KeyDelete || len(ups) != 1 || ups[0] != KeyDelete {
+		t.Errorf("expected KeyDown and KeyUp for Del, got %v and %v", downs, ups)
+	}
+
+	b.TypeText(le, "a1b2\U0001F600")
+	if typed != "a1b2\U0001F600" {
+		t.Errorf("expected KeyPress for each character, got %q", typed)
+	}
+	if text := le.Text(); text != "ab\U0001F600" {
+		t.Errorf("expected the handled characters to be suppressed, got %q", text)
+	}
+}
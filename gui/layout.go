@@ -0,0 +1,211 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"walk/drawing"
+	. "walk/winapi/user32"
+)
+
+// childHandles returns the set of the window handles of the children of
+// container. Layouts use it to forget about removed children.
+func childHandles(container IContainer) map[HWND]bool {
+	children := container.Children()
+
+	handles := make(map[HWND]bool, children.Len())
+	for i := 0; i < children.Len(); i++ {
+		handles[children.At(i).Handle()] = true
+	}
+
+	return handles
+}
+
+// layoutAxisItem holds the size constraints of a widget along either the
+// horizontal or the vertical axis.
+type layoutAxisItem struct {
+	min, pref, max int
+	grow           bool
+}
+
+func newLayoutAxisItem(flags LayoutFlags, minSize, maxSize, prefSize drawing.Size, horizontal bool) layoutAxisItem {
+	var ai layoutAxisItem
+	var shrinkFlag, growFlag LayoutFlags
+	var min, max int
+
+	if horizontal {
+		ai.pref = prefSize.Width
+		min, max = minSize.Width, maxSize.Width
+		shrinkFlag, growFlag = ShrinkHorz, GrowHorz
+	} else {
+		ai.pref = prefSize.Height
+		min, max = minSize.Height, maxSize.Height
+		shrinkFlag, growFlag = ShrinkVert, GrowVert
+	}
+
+	if max > 0 && ai.pref > max {
+		ai.pref = max
+	}
+	if ai.pref < min {
+		ai.pref = min
+	}
+
+	if flags&shrinkFlag != 0 {
+		ai.min = min
+	} else {
+		ai.min = ai.pref
+	}
+
+	ai.grow = flags&growFlag != 0 && max == 0
+	switch {
+	case ai.grow:
+		ai.max = 0
+
+	case flags&growFlag != 0:
+		ai.max = max
+
+	default:
+		ai.max = ai.pref
+	}
+
+	return ai
+}
+
+// layoutLine is a row or column of a layout that space is distributed to.
+type layoutLine struct {
+	used           bool
+	min, pref, max int
+	grow           bool
+	stretch        int
+}
+
+// layoutShare returns the part of amount that the i-th of count receivers
+// gets, when amount is split as evenly as possible.
+func layoutShare(amount, count, i int) int {
+	share := amount / count
+	if i < amount%count {
+		share++
+	}
+
+	return share
+}
+
+func distributeLayoutSpace(lines []layoutLine, available int) []int {
+	sizes := make([]int, len(lines))
+
+	sum := 0
+	for i, line := range lines {
+		if line.used {
+			sizes[i] = line.pref
+			sum += line.pref
+		}
+	}
+
+	candidates := make([]int, 0, len(lines))
+
+	if sum > available {
+		// Shrink lines towards their min sizes.
+		deficit := sum - available
+
+		for deficit > 0 {
+			candidates = candidates[0:0]
+			for i, line := range lines {
+				if line.used && sizes[i] > line.min {
+					candidates = candidates[0 : len(candidates)+1]
+					candidates[len(candidates)-1] = i
+				}
+			}
+
+			if len(candidates) == 0 {
+				break
+			}
+
+			// The shares are computed from the deficit at the start of the
+			// round, so it is split evenly.
+			roundDeficit := deficit
+			for j, i := range candidates {
+				d := layoutShare(roundDeficit, len(candidates), j)
+				if room := sizes[i] - lines[i].min; d > room {
+					d = room
+				}
+
+				sizes[i] -= d
+				deficit -= d
+			}
+		}
+	} else if sum < available {
+		// Grow the lines that can grow, weighted by stretch factor.
+		extra := available - sum
+
+		haveStretch := false
+		for _, line := range lines {
+			if line.used && line.stretch > 0 {
+				haveStretch = true
+				break
+			}
+		}
+
+		for extra > 0 {
+			candidates = candidates[0:0]
+			weightSum := 0
+			for i, line := range lines {
+				if !line.used || (haveStretch && line.stretch == 0) {
+					continue
+				}
+				if !line.grow && sizes[i] >= line.max {
+					continue
+				}
+
+				candidates = candidates[0 : len(candidates)+1]
+				candidates[len(candidates)-1] = i
+
+				weightSum += layoutLineWeight(line, haveStretch)
+			}
+
+			if len(candidates) == 0 {
+				break
+			}
+
+			distributed := 0
+			remaining := extra
+			for _, i := range candidates {
+				d := remaining * layoutLineWeight(lines[i], haveStretch) / weightSum
+				if !lines[i].grow && sizes[i]+d > lines[i].max {
+					d = lines[i].max - sizes[i]
+				}
+
+				sizes[i] += d
+				extra -= d
+				distributed += d
+			}
+
+			// Hand out the pixels lost to integer division one by one.
+			for _, i := range candidates {
+				if extra == 0 {
+					break
+				}
+
+				if lines[i].grow || sizes[i] < lines[i].max {
+					sizes[i]++
+					extra--
+					distributed++
+				}
+			}
+
+			if distributed == 0 {
+				break
+			}
+		}
+	}
+
+	return sizes
+}
+
+func layoutLineWeight(line layoutLine, haveStretch bool) int {
+	if haveStretch {
+		return line.stretch
+	}
+
+	return 1
+}
@@ -0,0 +1,305 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"testing"
+)
+
+import (
+	"walk/drawing"
+)
+
+func fixedLayoutLine(min, pref int) layoutLine {
+	return layoutLine{used: true, min: min, pref: pref, max: pref}
+}
+
+func growingLayoutLine(pref, stretch int) layoutLine {
+	return layoutLine{used: true, pref: pref, max: pref, grow: true, stretch: stretch}
+}
+
+func TestDistributeLayoutSpace(t *testing.T) {
+	tests := []struct {
+		name      string
+		lines     []layoutLine
+		available int
+		expected  []int
+	}{
+		{
+			"preferred",
+			[]layoutLine{fixedLayoutLine(0, 10), fixedLayoutLine(0, 20)},
+			30,
+			[]int{10, 20},
+		},
+		{
+			"shrink evenly",
+			[]layoutLine{fixedLayoutLine(0, 10), fixedLayoutLine(0, 10), fixedLayoutLine(0, 10)},
+			21,
+			[]int{7, 7, 7},
+		},
+		{
+			"shrink to min",
+			[]layoutLine{fixedLayoutLine(8, 10), fixedLayoutLine(0, 10), fixedLayoutLine(0, 10)},
+			21,
+			[]int{8, 6, 7},
+		},
+		{
+			"shrink below all mins",
+			[]layoutLine{fixedLayoutLine(8, 10), fixedLayoutLine(5, 10)},
+			5,
+			[]int{8, 5},
+		},
+		{
+			"grow evenly",
+			[]layoutLine{growingLayoutLine(10, 0), growingLayoutLine(10, 0)},
+			25,
+			[]int{13, 12},
+		},
+		{
+			"grow by stretch factor",
+			[]layoutLine{growingLayoutLine(0, 1), growingLayoutLine(0, 2), growingLayoutLine(10, 0)},
+			40,
+			[]int{10, 20, 10},
+		},
+		{
+			"grow to max",
+			[]layoutLine{{used: true, pref: 10, max: 12}, growingLayoutLine(10, 0)},
+			30,
+			[]int{12, 18},
+		},
+		{
+			"fixed",
+			[]layoutLine{fixedLayoutLine(0, 10), fixedLayoutLine(0, 10)},
+			50,
+			[]int{10, 10},
+		},
+		{
+			"unused",
+			[]layoutLine{growingLayoutLine(10, 0), {}, growingLayoutLine(10, 0)},
+			30,
+			[]int{15, 0, 15},
+		},
+	}
+
+	for _, test := range tests {
+		sizes := distributeLayoutSpace(test.lines, test.available)
+
+		if len(sizes) != len(test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, sizes)
+			continue
+		}
+		for i := range sizes {
+			if sizes[i] != test.expected[i] {
+				t.Errorf("%s: expected %v, got %v", test.name, test.expected, sizes)
+				break
+			}
+		}
+	}
+}
+
+func checkLayoutBounds(t *testing.T, name string, expected, actual []drawing.Rectangle) {
+	if len(actual) != len(expected) {
+		t.Errorf("%s: expected %v, got %v", name, expected, actual)
+		return
+	}
+
+	for i := range actual {
+		if actual[i] != expected[i] {
+			t.Errorf("%s: item %d: expected %v, got %v", name, i, expected[i], actual[i])
+		}
+	}
+}
+
+func TestBoxLayoutCompute(t *testing.T) {
+	tests := []struct {
+		name     string
+		vertical bool
+		items    []BoxLayoutItem
+		expected []drawing.Rectangle
+	}{
+		{
+			"horizontal",
+			false,
+			[]BoxLayoutItem{
+				{PreferredSize: drawing.Size{20, 10}},
+				{Flags: GrowHorz | GrowVert, PreferredSize: drawing.Size{10, 10}},
+			},
+			[]drawing.Rectangle{{5, 5, 20, 10}, {27, 5, 68, 30}},
+		},
+		{
+			"stretch factors",
+			false,
+			[]BoxLayoutItem{
+				{Flags: GrowHorz | GrowVert, StretchFactor: 1},
+				{PreferredSize: drawing.Size{0, 10}, StretchFactor: 3},
+				{Flags: GrowHorz, PreferredSize: drawing.Size{10, 10}},
+			},
+			[]drawing.Rectangle{{5, 5, 19, 30}, {26, 5, 57, 10}, {85, 5, 10, 10}},
+		},
+		{
+			"vertical aligned",
+			true,
+			[]BoxLayoutItem{
+				{PreferredSize: drawing.Size{20, 10}, Alignment: AlignStart},
+				{PreferredSize: drawing.Size{20, 10}, Alignment: AlignCenter},
+				{PreferredSize: drawing.Size{20, 10}, Alignment: AlignEnd},
+				{Flags: GrowHorz, PreferredSize: drawing.Size{20, 10}},
+			},
+			[]drawing.Rectangle{{5, 5, 20, 10}, {40, 17, 20, 10}, {75, 29, 20, 10}, {5, 41, 90, 10}},
+		},
+		{
+			"shrink",
+			false,
+			[]BoxLayoutItem{
+				{Flags: ShrinkHorz, MinSize: drawing.Size{50, 0}, PreferredSize: drawing.Size{60, 10}},
+				{Flags: ShrinkHorz, PreferredSize: drawing.Size{60, 10}},
+			},
+			[]drawing.Rectangle{{5, 5, 50, 10}, {57, 5, 38, 10}},
+		},
+	}
+
+	for _, test := range tests {
+		l := newBoxLayout(test.vertical)
+		l.margins = &Margins{5, 5, 5, 5}
+		l.spacing = 2
+
+		checkLayoutBounds(t, test.name, test.expected, l.Compute(test.items, drawing.Rectangle{0, 0, 100, 40}))
+	}
+}
+
+func TestGridLayoutCompute(t *testing.T) {
+	l := NewGridLayout()
+	l.spacing = 4
+
+	items := []GridLayoutItem{
+		{Row: 0, Column: 0, Flags: GrowHorz | GrowVert, PreferredSize: drawing.Size{10, 10}},
+		{Row: 0, Column: 1, PreferredSize: drawing.Size{30, 20}},
+		{Row: 1, Column: 0, ColumnSpan: 2, Flags: GrowHorz, PreferredSize: drawing.Size{10, 10}},
+	}
+
+	checkLayoutBounds(t, "grid", []drawing.Rectangle{
+		{0, 0, 66, 36},
+		{70, 0, 30, 20},
+		{0, 40, 100, 10},
+	}, l.Compute(items, drawing.Rectangle{0, 0, 100, 50}))
+
+	l.SetRowStretchFactor(1, 1)
+
+	checkLayoutBounds(t, "row stretch factor", []drawing.Rectangle{
+		{0, 0, 66, 20},
+		{70, 0, 30, 20},
+		{0, 24, 100, 10},
+	}, l.Compute(items, drawing.Rectangle{0, 0, 100, 50}))
+}
+
+func newTestComposite(t *testing.T, layout Layout) *Composite {
+	_, mw := newTestMainWindow(t)
+
+	c, err := NewComposite(mw.ClientArea())
+	if err != nil {
+		t.Fatalf("NewComposite failed: %s", err)
+	}
+	c.SetLayout(layout)
+
+	if err := c.SetBounds(drawing.Rectangle{0, 0, 200, 100}); err != nil {
+		t.Fatalf("SetBounds failed: %s", err)
+	}
+
+	return c
+}
+
+func newTestLabel(t *testing.T, parent IContainer) *Label {
+	label, err := NewLabel(parent)
+	if err != nil {
+		t.Fatalf("NewLabel failed: %s", err)
+	}
+
+	return label
+}
+
+func TestGridLayoutForgetsRemovedWidgets(t *testing.T) {
+	l := NewGridLayout()
+	c := newTestComposite(t, l)
+
+	a, b := newTestLabel(t, c), newTestLabel(t, c)
+	l.SetCell(a, 0, 0)
+	l.SetCell(b, 0, 1)
+
+	hWnd := b.Handle()
+	if err := c.Children().Remove(b); err != nil {
+		t.Fatalf("Remove failed: %s", err)
+	}
+
+	if _, ok := l.hWnd2Cell[hWnd]; ok {
+		t.Error("expected the cell of the removed widget to be forgotten")
+	}
+	if _, _, _, _, ok := l.Range(a); !ok {
+		t.Error("expected the cell of the remaining widget to be kept")
+	}
+}
+
+func TestBoxLayoutForgetsRemovedWidgets(t *testing.T) {
+	l := NewHBoxLayout()
+	c := newTestComposite(t, l)
+
+	a, b := newTestLabel(t, c), newTestLabel(t, c)
+	l.SetStretchFactor(a, 1)
+	l.SetStretchFactor(b, 2)
+	l.SetAlignment(b, AlignCenter)
+
+	hWnd := b.Handle()
+	if err := c.Children().Remove(b); err != nil {
+		t.Fatalf("Remove failed: %s", err)
+	}
+
+	if _, ok := l.hWnd2StretchFactor[hWnd]; ok {
+		t.Error("expected the stretch factor of the removed widget to be forgotten")
+	}
+	if _, ok := l.hWnd2Alignment[hWnd]; ok {
+		t.Error("expected the alignment of the removed widget to be forgotten")
+	}
+	if f := l.StretchFactor(a); f != 1 {
+		t.Errorf("expected the stretch factor of the remaining widget to be kept, got %d", f)
+	}
+}
+
+func TestGridLayoutSetMargins(t *testing.T) {
+	l := NewGridLayout()
+	c := newTestComposite(t, l)
+
+	label := newTestLabel(t, c)
+	l.SetCell(label, 0, 0)
+
+	if err := l.SetMargins(&Margins{10, 20, 10, 20}); err != nil {
+		t.Fatalf("SetMargins failed: %s", err)
+	}
+
+	bounds, err := label.Bounds()
+	if err != nil {
+		t.Fatalf("Bounds failed: %s", err)
+	}
+	if bounds.X != 10 || bounds.Y != 20 {
+		t.Errorf("expected the widget to move to 10, 20, got %d, %d", bounds.X, bounds.Y)
+	}
+}
+
+func TestBoxLayoutSetMargins(t *testing.T) {
+	l := NewVBoxLayout()
+	c := newTestComposite(t, l)
+
+	label := newTestLabel(t, c)
+
+	if err := l.SetMargins(&Margins{10, 20, 10, 20}); err != nil {
+		t.Fatalf("SetMargins failed: %s", err)
+	}
+
+	bounds, err := label.Bounds()
+	if err != nil {
+		t.Fatalf("Bounds failed: %s", err)
+	}
+	if bounds.X != 10 || bounds.Y != 20 {
+		t.Errorf("expected the widget to move to 10, 20, got %d, %d", bounds.X, bounds.Y)
+	}
+}
@@ -6,7 +6,6 @@ package gui
 
 import (
 	"os"
-	"syscall"
 	"unsafe"
 )
 
@@ -16,25 +15,19 @@ import (
 	. "walk/winapi/user32"
 )
 
-var lineEditSubclassWndProcCallback *syscall.Callback
-var lineEditSubclassWndProcPtr uintptr
-var lineEditOrigWndProcPtr uintptr
-
-func lineEditSubclassWndProc(args *uintptr) uintptr {
-	msg := msgFromCallbackArgs(args)
-
+func lineEditSubclassWndProc(msg *MSG, origWndProcPtr uintptr) uintptr {
 	le, ok := widgetsByHWnd[msg.HWnd].(*LineEdit)
 	if !ok {
-		// Before CreateWindowEx returns, among others, WM_GETMINMAXINFO is sent.
-		// FIXME: Find a way to properly handle this.
-		return CallWindowProc(lineEditOrigWndProcPtr, msg.HWnd, msg.Message, msg.WParam, msg.LParam)
+		return backend.DefWindowProc(msg, origWndProcPtr)
 	}
 
-	return le.wndProc(msg, lineEditOrigWndProcPtr)
+	return le.wndProc(msg, origWndProcPtr)
 }
 
 type LineEdit struct {
 	Widget
+	validation
+	textChangedPublisher EventPublisher
 }
 
 func NewLineEdit(parent IContainer) (*LineEdit, os.Error) {
@@ -42,26 +35,21 @@ func NewLineEdit(parent IContainer) (*LineEdit, os.Error) {
 		return nil, newError("parent cannot be nil")
 	}
 
-	if lineEditSubclassWndProcCallback == nil {
-		lineEditSubclassWndProcCallback = syscall.NewCallback(lineEditSubclassWndProc, 4+4+4+4)
-		lineEditSubclassWndProcPtr = uintptr(lineEditSubclassWndProcCallback.ExtFnEntry())
-	}
-
-	hWnd := CreateWindowEx(
-		WS_EX_CLIENTEDGE, syscall.StringToUTF16Ptr("EDIT"), nil,
+	hWnd, err := backend.CreateWindow(
+		WS_EX_CLIENTEDGE, "EDIT",
 		ES_AUTOHSCROLL|WS_CHILD|WS_TABSTOP|WS_VISIBLE,
-		0, 0, 120, 24, parent.Handle(), 0, 0, nil)
-	if hWnd == 0 {
-		return nil, lastError("CreateWindowEx")
+		parent.Handle(), drawing.Rectangle{0, 0, 120, 24})
+	if err != nil {
+		return nil, err
 	}
 
-	lineEditOrigWndProcPtr = uintptr(SetWindowLong(hWnd, GWL_WNDPROC, int(lineEditSubclassWndProcPtr)))
-	if lineEditOrigWndProcPtr == 0 {
-		return nil, lastError("SetWindowLong")
+	if err := backend.SubclassWindow(hWnd, lineEditSubclassWndProc); err != nil {
+		return nil, err
 	}
 
 	le := &LineEdit{Widget: Widget{hWnd: hWnd, parent: parent}}
-	le.SetFont(defaultFont)
+	le.validation.widget = le
+	le.SetFont(backend.DefaultFont())
 
 	widgetsByHWnd[hWnd] = le
 
@@ -72,15 +60,15 @@ func NewLineEdit(parent IContainer) (*LineEdit, os.Error) {
 
 func (le *LineEdit) CueBanner() (string, os.Error) {
 	buf := make([]uint16, 128)
-	if FALSE == SendMessage(le.hWnd, EM_GETCUEBANNER, uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf))) {
+	if FALSE == backend.SendMessage(le.hWnd, EM_GETCUEBANNER, uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf))) {
 		return "", newError("EM_GETCUEBANNER failed")
 	}
 
-	return syscall.UTF16ToString(buf), nil
+	return UTF16ToString(buf), nil
 }
 
 func (le *LineEdit) SetCueBanner(value string) os.Error {
-	if FALSE == SendMessage(le.hWnd, EM_SETCUEBANNER, FALSE, uintptr(unsafe.Pointer(syscall.StringToUTF16Ptr(value)))) {
+	if FALSE == backend.SendMessage(le.hWnd, EM_SETCUEBANNER, FALSE, uintptr(unsafe.Pointer(StringToUTF16Ptr(value)))) {
 		return newError("EM_SETCUEBANNER failed")
 	}
 
@@ -95,6 +83,41 @@ func (le *LineEdit) PreferredSize() drawing.Size {
 	return le.dialogBaseUnitsToPixels(drawing.Size{50, 14})
 }
 
+func (le *LineEdit) TextChanged() *Event {
+	return le.textChangedPublisher.Event()
+}
+
+func (le *LineEdit) raiseTextChanged() {
+	le.textChangedPublisher.Publish(&eventArgs{widgetsByHWnd[le.hWnd]})
+}
+
+// TextProperty returns a Property with a string value that reflects the text
+// of the LineEdit.
+func (le *LineEdit) TextProperty() Property {
+	return &funcProperty{
+		get: func() interface{} {
+			return le.Text()
+		},
+		set: func(value interface{}) os.Error {
+			text, ok := value.(string)
+			if !ok {
+				return newError("value must be a string")
+			}
+
+			return le.SetText(text)
+		},
+		changed: le.TextChanged(),
+	}
+}
+
+func (le *LineEdit) onCommand(notificationCode uint16) {
+	switch notificationCode {
+	case EN_CHANGE:
+		le.updateValidity(backend.Focus() == le.hWnd)
+		le.raiseTextChanged()
+	}
+}
+
 func (le *LineEdit) wndProc(msg *MSG, origWndProcPtr uintptr) uintptr {
 	switch msg.Message {
 	case WM_GETDLGCODE:
@@ -103,5 +126,5 @@ func (le *LineEdit) wndProc(msg *MSG, origWndProcPtr uintptr) uintptr {
 		}
 	}
 
-	return le.Widget.wndProc(msg, lineEditOrigWndProcPtr)
+	return le.Widget.wndProc(msg, origWndProcPtr)
 }
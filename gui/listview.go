@@ -6,11 +6,10 @@ package gui
 
 import (
 	"bytes"
-	"container/vector"
+	"fmt"
 	"os"
 	"strconv"
 	"strings"
-	"syscall"
 	"unsafe"
 )
 
@@ -21,13 +20,71 @@ import (
 	. "walk/winapi/user32"
 )
 
+type IndexEventArgs interface {
+	EventArgs
+	Index() int
+}
+
+type indexEventArgs struct {
+	eventArgs
+	index int
+}
+
+func (a *indexEventArgs) Index() int {
+	return a.index
+}
+
+type IndexEventHandler func(args IndexEventArgs)
+
+// IndexEvent is an event that handlers of type IndexEventHandler can be
+// attached to.
+type IndexEvent struct {
+	handlers eventHandlerList
+}
+
+func (e *IndexEvent) Attach(handler IndexEventHandler) EventHandle {
+	return e.handlers.attach(handler, false)
+}
+
+func (e *IndexEvent) Once(handler IndexEventHandler) EventHandle {
+	return e.handlers.attach(handler, true)
+}
+
+func (e *IndexEvent) Detach(handle EventHandle) {
+	e.handlers.detach(handle)
+}
+
+type IndexEventPublisher struct {
+	event IndexEvent
+}
+
+func (p *IndexEventPublisher) Event() *IndexEvent {
+	return &p.event
+}
+
+func (p *IndexEventPublisher) Publish(args IndexEventArgs) {
+	p.event.handlers.raise(func(handler interface{}) {
+		handler.(IndexEventHandler)(args)
+	})
+}
+
 type ListView struct {
 	Widget
-	columns                      *ListViewColumnList
-	items                        *ListViewItemList
-	prevSelIndex                 int
-	selectedIndexChangedHandlers vector.Vector
-	itemActivatedHandlers        vector.Vector
+	columns                         *ListViewColumnList
+	items                           *ListViewItemList
+	model                           TableModel
+	selectionModel                  *SelectionModel
+	selectionChangedHandle          EventHandle
+	syncingSelection                bool
+	checkBoxes                      bool
+	checked                         indexSet
+	prevSelIndex                    int
+	selectedIndexChangedPublisher   EventPublisher
+	selectedIndexesChangedPublisher EventPublisher
+	itemActivatedPublisher          EventPublisher
+	itemCheckedPublisher            IndexEventPublisher
+	dragEnabled                     bool
+	dragCompletedPublisher          DragEventPublisher
 }
 
 func NewListView(parent IContainer) (*ListView, os.Error) {
@@ -35,17 +92,17 @@ func NewListView(parent IContainer) (*ListView, os.Error) {
 		return nil, newError("parent cannot be nil")
 	}
 
-	hWnd := CreateWindowEx(
-		WS_EX_CLIENTEDGE, syscall.StringToUTF16Ptr("SysListView32"), nil,
-		LVS_SINGLESEL|LVS_SHOWSELALWAYS|LVS_REPORT|WS_CHILD|WS_TABSTOP|WS_VISIBLE,
-		0, 0, 0, 0, parent.Handle(), 0, 0, nil)
-	if hWnd == 0 {
-		return nil, lastError("CreateWindowEx")
+	hWnd, err := backend.CreateWindow(
+		WS_EX_CLIENTEDGE, "SysListView32",
+		LVS_OWNERDATA|LVS_SINGLESEL|LVS_SHOWSELALWAYS|LVS_REPORT|WS_CHILD|WS_TABSTOP|WS_VISIBLE,
+		parent.Handle(), drawing.Rectangle{0, 0, 0, 0})
+	if err != nil {
+		return nil, err
 	}
 
-	exStyle := SendMessage(hWnd, LVM_GETEXTENDEDLISTVIEWSTYLE, 0, 0)
+	exStyle := backend.SendMessage(hWnd, LVM_GETEXTENDEDLISTVIEWSTYLE, 0, 0)
 	exStyle |= LVS_EX_DOUBLEBUFFER | LVS_EX_FULLROWSELECT //| LVS_EX_GRIDLINES
-	SendMessage(hWnd, LVM_SETEXTENDEDLISTVIEWSTYLE, 0, exStyle)
+	backend.SendMessage(hWnd, LVM_SETEXTENDEDLISTVIEWSTYLE, 0, exStyle)
 
 	lv := &ListView{Widget: Widget{hWnd: hWnd, parent: parent}}
 
@@ -55,9 +112,12 @@ func NewListView(parent IContainer) (*ListView, os.Error) {
 
 	lv.columns = newListViewColumnList(lv)
 	lv.items = newListViewItemList(lv)
+	lv.checked = make(indexSet)
 	lv.prevSelIndex = -1
 
-	lv.SetFont(defaultFont)
+	lv.SetSelectionModel(NewSelectionModel())
+
+	lv.SetFont(backend.DefaultFont())
 
 	widgetsByHWnd[hWnd] = lv
 
@@ -78,42 +138,420 @@ func (lv *ListView) Columns() *ListViewColumnList {
 	return lv.columns
 }
 
+// Items returns the items displayed by the ListView, if it has no model.
 func (lv *ListView) Items() *ListViewItemList {
 	return lv.items
 }
 
+func (lv *ListView) Model() TableModel {
+	return lv.model
+}
+
+// SetModel makes the ListView display the rows of value instead of its Items.
+//
+// The ListView only asks the model for the cells it actually displays, so
+// models with a large number of rows can be used. Pass nil to display the
+// Items again.
+func (lv *ListView) SetModel(value TableModel) os.Error {
+	if value == lv.model {
+		return nil
+	}
+
+	if value != nil && lv.items.Len() > 0 {
+		return newError("cannot set a model while the list view has items")
+	}
+
+	if lv.model != nil {
+		lv.model.removeObserver(lv)
+	}
+
+	lv.model = value
+
+	if value != nil {
+		value.addObserver(lv)
+	}
+
+	if err := lv.onRowsReset(); err != nil {
+		return err
+	}
+
+	return lv.updateSortIndicators()
+}
+
+func (lv *ListView) rowCount() int {
+	if lv.model != nil {
+		return lv.model.RowCount()
+	}
+
+	return lv.items.Len()
+}
+
+func (lv *ListView) cellText(row, col int) string {
+	if row < 0 || row >= lv.rowCount() {
+		return ""
+	}
+
+	if lv.model == nil {
+		texts := lv.items.At(row).Texts()
+		if col < len(texts) {
+			return texts[col]
+		}
+
+		return ""
+	}
+
+	value := lv.model.Value(row, col)
+
+	switch v := value.(type) {
+	case nil:
+		return ""
+
+	case string:
+		return v
+	}
+
+	return fmt.Sprint(value)
+}
+
+func (lv *ListView) setItemCount(flags uintptr) os.Error {
+	if 0 == backend.SendMessage(lv.hWnd, LVM_SETITEMCOUNT, uintptr(lv.rowCount()), flags) {
+		return newError("LVM_SETITEMCOUNT failed")
+	}
+
+	return nil
+}
+
+func (lv *ListView) redrawItems(from, to int) os.Error {
+	if FALSE == backend.SendMessage(lv.hWnd, LVM_REDRAWITEMS, uintptr(from), uintptr(to)) {
+		return newError("LVM_REDRAWITEMS failed")
+	}
+
+	return nil
+}
+
 func (lv *ListView) BeginUpdate() {
-	SendMessage(lv.hWnd, WM_SETREDRAW, 0, 0)
+	backend.SendMessage(lv.hWnd, WM_SETREDRAW, 0, 0)
 }
 
 func (lv *ListView) EndUpdate() {
-	SendMessage(lv.hWnd, WM_SETREDRAW, 1, 0)
+	backend.SendMessage(lv.hWnd, WM_SETREDRAW, 1, 0)
 }
 
-func (lv *ListView) SelectedIndex() int {
-	selCount := int(SendMessage(lv.hWnd, LVM_GETSELECTEDCOUNT, 0, 0))
-	if selCount == 0 {
-		return -1
+func (lv *ListView) SelectionModel() *SelectionModel {
+	return lv.selectionModel
+}
+
+// SetSelectionModel makes the ListView keep its selection in value.
+//
+// The ListView adjusts the selection model to its rows, so value must not be
+// used by another widget that has not been disposed. Other parts of the
+// program may observe and change it though.
+func (lv *ListView) SetSelectionModel(value *SelectionModel) os.Error {
+	if value == nil {
+		return newError("value cannot be nil")
+	}
+
+	if value.owner != nil && value.owner != IWidget(lv) && !value.owner.IsDisposed() {
+		return newError("value is already used by another widget")
+	}
+
+	if lv.selectionModel != nil {
+		lv.selectionModel.Changed().Detach(lv.selectionChangedHandle)
+		lv.selectionModel.owner = nil
+	}
+
+	lv.selectionModel = value
+	value.owner = lv
+
+	value.SetMultiSelection(lv.MultiSelection())
+	value.SetRowCount(lv.rowCount())
+	lv.selectionChangedHandle = value.Changed().Attach(func(args EventArgs) {
+		lv.onSelectionModelChanged()
+	})
+
+	lv.onSelectionModelChanged()
+
+	return nil
+}
+
+func (lv *ListView) MultiSelection() bool {
+	style, err := backend.Style(lv.hWnd)
+	if err != nil {
+		return false
 	}
 
-	return int(SendMessage(lv.hWnd, LVM_GETNEXTITEM, ^uintptr(0), LVNI_SELECTED)) // ^uintptr(0) == -1
+	return style&LVS_SINGLESEL == 0
 }
 
+func (lv *ListView) SetMultiSelection(value bool) os.Error {
+	style, err := backend.Style(lv.hWnd)
+	if err != nil {
+		return err
+	}
+
+	if value {
+		style &^= LVS_SINGLESEL
+	} else {
+		style |= LVS_SINGLESEL
+	}
+
+	if err := backend.SetStyle(lv.hWnd, style); err != nil {
+		return err
+	}
+
+	lv.selectionModel.SetMultiSelection(value)
+
+	return nil
+}
+
+// SelectedIndex returns the lowest selected index or -1 if no row is
+// selected.
+func (lv *ListView) SelectedIndex() int {
+	return lv.selectionModel.SelectedIndex()
+}
+
+// SetSelectedIndex selects only the row at value. Pass -1 to clear the
+// selection.
 func (lv *ListView) SetSelectedIndex(value int) os.Error {
-	var lvi LVITEM
+	if value == -1 {
+		lv.selectionModel.Clear()
+		return nil
+	}
+
+	return lv.selectionModel.SetSelectedIndexes([]int{value})
+}
+
+// SelectedIndexes returns the selected indexes in ascending order.
+func (lv *ListView) SelectedIndexes() []int {
+	return lv.selectionModel.SelectedIndexes()
+}
+
+func (lv *ListView) SetSelectedIndexes(indexes []int) os.Error {
+	return lv.selectionModel.SetSelectedIndexes(indexes)
+}
+
+func (lv *ListView) SelectAll() os.Error {
+	return lv.selectionModel.SelectAll()
+}
+
+// SelectRange adds the rows from through to to the selection.
+func (lv *ListView) SelectRange(from, to int) os.Error {
+	return lv.selectionModel.SelectRange(from, to)
+}
+
+// onSelectionModelChanged updates the control from the selection model,
+// unless the change came from the control itself.
+func (lv *ListView) onSelectionModelChanged() {
+	if !lv.syncingSelection {
+		lv.syncingSelection = true
+
+		var lvi LVITEM
+		lvi.StateMask = LVIS_SELECTED
+
+		backend.SendMessage(lv.hWnd, LVM_SETITEMSTATE, ^uintptr(0), uintptr(unsafe.Pointer(&lvi))) // ^uintptr(0) == -1
 
-	lvi.StateMask = LVIS_SELECTED
-	if value > -1 {
 		lvi.State = LVIS_SELECTED
+		for _, index := range lv.selectionModel.SelectedIndexes() {
+			backend.SendMessage(lv.hWnd, LVM_SETITEMSTATE, uintptr(index), uintptr(unsafe.Pointer(&lvi)))
+		}
+
+		lv.syncingSelection = false
+	}
+
+	lv.raiseSelectedIndexesChanged()
+
+	if selIndex := lv.SelectedIndex(); selIndex != lv.prevSelIndex {
+		lv.prevSelIndex = selIndex
+		lv.raiseSelectedIndexChanged()
+	}
+}
+
+// onItemStateChanged updates the selection model from the control.
+func (lv *ListView) onItemStateChanged(from, to int, oldState, newState uint) {
+	if (oldState^newState)&LVIS_SELECTED == 0 || lv.syncingSelection {
+		return
+	}
+
+	lv.syncingSelection = true
+	defer func() {
+		lv.syncingSelection = false
+	}()
+
+	selected := newState&LVIS_SELECTED != 0
+
+	if from == -1 {
+		if selected {
+			lv.selectionModel.SelectAll()
+		} else {
+			lv.selectionModel.Clear()
+		}
+		return
 	}
 
-	if FALSE == SendMessage(lv.hWnd, LVM_SETITEMSTATE, uintptr(value), uintptr(unsafe.Pointer(&lvi))) {
-		return newError("failed to set selected item")
+	if selected {
+		lv.selectionModel.SelectRange(from, to)
+	} else {
+		lv.selectionModel.DeselectRange(from, to)
+	}
+}
+
+func (lv *ListView) CheckBoxes() bool {
+	return lv.checkBoxes
+}
+
+// SetCheckBoxes sets whether a check box is displayed in front of each row.
+func (lv *ListView) SetCheckBoxes(value bool) os.Error {
+	if value == lv.checkBoxes {
+		return nil
+	}
+
+	var exStyle uintptr
+	if value {
+		exStyle = LVS_EX_CHECKBOXES
+	}
+
+	backend.SendMessage(lv.hWnd, LVM_SETEXTENDEDLISTVIEWSTYLE, LVS_EX_CHECKBOXES, exStyle)
+
+	lv.checkBoxes = value
+
+	return lv.Invalidate()
+}
+
+func (lv *ListView) Checked(index int) bool {
+	return lv.checked[index]
+}
+
+func (lv *ListView) SetChecked(index int, checked bool) os.Error {
+	if index < 0 || index >= lv.rowCount() {
+		return newError("index out of range")
+	}
+
+	if checked == lv.checked[index] {
+		return nil
+	}
+
+	if checked {
+		lv.checked[index] = true
+	} else {
+		lv.checked[index] = false, false
+	}
+
+	lv.redrawItems(index, index)
+
+	lv.raiseItemChecked(index)
+
+	return nil
+}
+
+// CheckedIndexes returns the indexes of the checked rows in ascending order.
+func (lv *ListView) CheckedIndexes() []int {
+	return lv.checked.sorted()
+}
+
+// SetCheckedIndexes checks exactly the rows at indexes.
+func (lv *ListView) SetCheckedIndexes(indexes []int) os.Error {
+	count := lv.rowCount()
+
+	checked := make(indexSet, len(indexes))
+	for _, index := range indexes {
+		if index < 0 || index >= count {
+			return newError("index out of range")
+		}
+
+		checked[index] = true
+	}
+
+	for _, index := range lv.checked.sorted() {
+		if !checked[index] {
+			if err := lv.SetChecked(index, false); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, index := range checked.sorted() {
+		if err := lv.SetChecked(index, true); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// toggleSelectedChecked toggles the check boxes of the selected rows, when the
+// user presses space.
+func (lv *ListView) toggleSelectedChecked() {
+	indexes := lv.SelectedIndexes()
+	if len(indexes) == 0 {
+		return
+	}
+
+	checked := !lv.checked[indexes[0]]
+
+	for _, index := range indexes {
+		lv.SetChecked(index, checked)
+	}
+}
+
+func (lv *ListView) onClick(nmia *NMITEMACTIVATE) {
+	if !lv.checkBoxes || nmia.IItem < 0 {
+		return
+	}
+
+	var hti LVHITTESTINFO
+	hti.Pt = nmia.PtAction
+
+	backend.SendMessage(lv.hWnd, LVM_HITTEST, 0, uintptr(unsafe.Pointer(&hti)))
+
+	if hti.Flags&LVHT_ONITEMSTATEICON != 0 && hti.IItem == nmia.IItem {
+		lv.SetChecked(hti.IItem, !lv.checked[hti.IItem])
+	}
+}
+
+func (lv *ListView) DragEnabled() bool {
+	return lv.dragEnabled
+}
+
+// SetDragEnabled sets whether the selected items can be dragged.
+//
+// The DataObject of the drag holds the indexes of the dragged rows in
+// ListViewRowsFormat and the text of their first column, one row per line, in
+// TextFormat. Items can be copied or moved, moving them is up to the handlers
+// of DragCompleted.
+func (lv *ListView) SetDragEnabled(value bool) {
+	lv.dragEnabled = value
+}
+
+// DragCompleted is raised when a drag of items has ended. The effect of the
+// event args is DropEffectNone if the drag was canceled.
+func (lv *ListView) DragCompleted() *DragEvent {
+	return lv.dragCompletedPublisher.Event()
+}
+
+func (lv *ListView) onBeginDrag() {
+	if !lv.dragEnabled {
+		return
+	}
+
+	rows := lv.SelectedIndexes()
+	if len(rows) == 0 {
+		return
+	}
+
+	texts := make([]string, len(rows))
+	for i, row := range rows {
+		texts[i] = lv.cellText(row, 0)
+	}
+
+	data := NewDataObject()
+	data.SetData(ListViewRowsFormat, rows)
+	data.SetText(strings.Join(texts, "\n"))
+
+	allowed := DropEffectCopy | DropEffectMove
+
+	lv.BeginDrag(data, allowed, dragCompletedPublisher(&lv.dragCompletedPublisher, lv, data, allowed))
+}
+
 func (lv *ListView) SaveState() (string, os.Error) {
 	buf := bytes.NewBuffer(nil)
 
@@ -123,7 +561,7 @@ func (lv *ListView) SaveState() (string, os.Error) {
 			buf.WriteString(" ")
 		}
 
-		width := SendMessage(lv.hWnd, LVM_GETCOLUMNWIDTH, uintptr(i), 0)
+		width := backend.SendMessage(lv.hWnd, LVM_GETCOLUMNWIDTH, uintptr(i), 0)
 		if width == 0 {
 			return "", newError("LVM_GETCOLUMNWIDTH failed")
 		}
@@ -143,7 +581,7 @@ func (lv *ListView) RestoreState(s string) os.Error {
 			return err
 		}
 
-		if FALSE == SendMessage(lv.hWnd, LVM_SETCOLUMNWIDTH, uintptr(i), uintptr(width)) {
+		if FALSE == backend.SendMessage(lv.hWnd, LVM_SETCOLUMNWIDTH, uintptr(i), uintptr(width)) {
 			return newError("LVM_SETCOLUMNWIDTH failed")
 		}
 	}
@@ -162,17 +600,244 @@ func (lv *ListView) wndProc(msg *MSG, origWndProcPtr uintptr) uintptr {
 		case crutches.ItemActivateMsgId():
 			lv.raiseItemActivated()*/
 
+	case WM_GETDLGCODE:
+		if msg.WParam == VK_RETURN {
+			return DLGC_WANTALLKEYS
+		}
+
 	case WM_KEYDOWN:
 		if msg.WParam == VK_RETURN && lv.SelectedIndex() > -1 {
 			lv.raiseItemActivated()
 		}
+
+	case WM_NOTIFY:
+		nmh := (*NMHDR)(unsafe.Pointer(msg.LParam))
+
+		switch nmh.Code {
+		case LVN_GETDISPINFO:
+			lv.onGetDispInfo((*NMLVDISPINFO)(unsafe.Pointer(msg.LParam)))
+			return 0
+
+		case LVN_COLUMNCLICK:
+			nmlv := (*NMLISTVIEW)(unsafe.Pointer(msg.LParam))
+			lv.onColumnClicked(nmlv.ISubItem)
+
+		case LVN_ITEMCHANGED:
+			nmlv := (*NMLISTVIEW)(unsafe.Pointer(msg.LParam))
+			if nmlv.UChanged&LVIF_STATE != 0 {
+				lv.onItemStateChanged(nmlv.IItem, nmlv.IItem, nmlv.UOldState, nmlv.UNewState)
+			}
+
+		case LVN_ODSTATECHANGED:
+			nmlvsc := (*NMLVODSTATECHANGE)(unsafe.Pointer(msg.LParam))
+			lv.onItemStateChanged(nmlvsc.IFrom, nmlvsc.ITo, nmlvsc.UOldState, nmlvsc.UNewState)
+
+		case LVN_KEYDOWN:
+			nmlvkd := (*NMLVKEYDOWN)(unsafe.Pointer(msg.LParam))
+			if nmlvkd.WVKey == VK_SPACE && lv.checkBoxes {
+				lv.toggleSelectedChecked()
+			}
+
+		case LVN_BEGINDRAG:
+			lv.onBeginDrag()
+
+		case NM_CLICK:
+			lv.onClick((*NMITEMACTIVATE)(unsafe.Pointer(msg.LParam)))
+		}
 	}
 
 	return lv.Widget.wndProc(msg, origWndProcPtr)
 }
 
+func (lv *ListView) onGetDispInfo(di *NMLVDISPINFO) {
+	item := &di.Item
+
+	if lv.checkBoxes && item.ISubItem == 0 {
+		// The control does not store the state of virtual items.
+		state := 1
+		if lv.checked[item.IItem] {
+			state = 2
+		}
+
+		item.Mask |= LVIF_STATE
+		item.StateMask = LVIS_STATEIMAGEMASK
+		item.State = INDEXTOSTATEIMAGEMASK(state)
+	}
+
+	if item.Mask&LVIF_TEXT == 0 || item.PszText == nil || item.CchTextMax <= 0 {
+		return
+	}
+
+	text := StringToUTF16(lv.cellText(item.IItem, item.ISubItem))
+
+	n := len(text)
+	if n > item.CchTextMax {
+		n = item.CchTextMax
+	}
+
+	buf := (*[1 << 16]uint16)(unsafe.Pointer(item.PszText))
+	copy(buf[0:n], text[0:n])
+	buf[n-1] = 0
+}
+
+// onColumnClicked makes column the primary sort key of the model. Clicking
+// the primary sort column again reverses the order. The previous keys are
+// kept as secondary keys.
+func (lv *ListView) onColumnClicked(column int) {
+	model, ok := lv.model.(sortableTableModel)
+	if !ok || column < 0 || column >= lv.columns.Len() || !lv.columns.At(column).Sortable() {
+		return
+	}
+
+	oldKeys := model.SortKeys()
+
+	keys := make([]SortKey, 1, len(oldKeys)+1)
+	keys[0] = SortKey{column, SortAscending}
+
+	for i, key := range oldKeys {
+		if key.Column == column {
+			if i == 0 && key.Order == SortAscending {
+				keys[0].Order = SortDescending
+			}
+			continue
+		}
+
+		keys = keys[0 : len(keys)+1]
+		keys[len(keys)-1] = key
+	}
+
+	model.SetSortKeys(keys)
+}
+
+// updateSortIndicators shows an arrow in the header of the primary sort
+// column.
+func (lv *ListView) updateSortIndicators() os.Error {
+	sortColumn := -1
+	var order SortOrder
+
+	if model, ok := lv.model.(sortableTableModel); ok {
+		if keys := model.SortKeys(); len(keys) > 0 {
+			sortColumn, order = keys[0].Column, keys[0].Order
+		}
+	}
+
+	hHeader := HWND(backend.SendMessage(lv.hWnd, LVM_GETHEADER, 0, 0))
+	if hHeader == 0 {
+		return nil
+	}
+
+	count := lv.columns.Len()
+	for i := 0; i < count; i++ {
+		var hdi HDITEM
+		hdi.Mask = HDI_FORMAT
+
+		if FALSE == backend.SendMessage(hHeader, HDM_GETITEM, uintptr(i), uintptr(unsafe.Pointer(&hdi))) {
+			return newError("HDM_GETITEM failed")
+		}
+
+		hdi.Fmt &^= HDF_SORTUP | HDF_SORTDOWN
+		if i == sortColumn {
+			if order == SortAscending {
+				hdi.Fmt |= HDF_SORTUP
+			} else {
+				hdi.Fmt |= HDF_SORTDOWN
+			}
+		}
+
+		if FALSE == backend.SendMessage(hHeader, HDM_SETITEM, uintptr(i), uintptr(unsafe.Pointer(&hdi))) {
+			return newError("HDM_SETITEM failed")
+		}
+	}
+
+	return nil
+}
+
+func (lv *ListView) onRowsReset() os.Error {
+	lv.checked = make(indexSet)
+
+	lv.selectionModel.Clear()
+	lv.selectionModel.SetRowCount(lv.rowCount())
+
+	return lv.setItemCount(0)
+}
+
+func (lv *ListView) onRowsInserted(from, to int) os.Error {
+	lv.checked = lv.checked.rowsInserted(from, to)
+
+	if err := lv.setItemCount(LVSICF_NOSCROLL); err != nil {
+		return err
+	}
+
+	// The control does not move the selection of virtual items.
+	lv.selectionModel.RowsInserted(from, to)
+
+	return nil
+}
+
+func (lv *ListView) onRowsRemoved(from, to int) os.Error {
+	lv.checked = lv.checked.rowsRemoved(from, to)
+
+	if err := lv.setItemCount(LVSICF_NOSCROLL); err != nil {
+		return err
+	}
+
+	lv.selectionModel.RowsRemoved(from, to)
+
+	return nil
+}
+
+// onRowsReordered keeps the selected and checked rows selected and checked.
+func (lv *ListView) onRowsReordered(newRows []int) os.Error {
+	lv.checked = lv.checked.rowsReordered(newRows)
+
+	lv.selectionModel.RowsReordered(newRows)
+
+	if count := lv.rowCount(); count > 0 {
+		return lv.redrawItems(0, count-1)
+	}
+
+	return nil
+}
+
+func (lv *ListView) onTableModelRowsReset() {
+	lv.onRowsReset()
+	lv.updateSortIndicators()
+}
+
+func (lv *ListView) onTableModelRowsInserted(from, to int) {
+	lv.onRowsInserted(from, to)
+}
+
+func (lv *ListView) onTableModelRowsRemoved(from, to int) {
+	lv.onRowsRemoved(from, to)
+}
+
+func (lv *ListView) onTableModelRowsChanged(from, to int) {
+	lv.redrawItems(from, to)
+}
+
+func (lv *ListView) onTableModelRowsReordered(newRows []int) {
+	lv.onRowsReordered(newRows)
+	lv.updateSortIndicators()
+}
+
 func (lv *ListView) onListViewColumnChanged(column *ListViewColumn) {
-	panic("not implemented")
+	index := lv.columns.IndexOf(column)
+	if index == -1 {
+		return
+	}
+
+	var lvc LVCOLUMN
+
+	lvc.Mask = LVCF_FMT | LVCF_WIDTH | LVCF_TEXT
+	lvc.PszText = StringToUTF16Ptr(column.Title())
+	lvc.Cx = column.Width()
+	lvc.Fmt = int(column.alignment)
+
+	backend.SendMessage(lv.hWnd, LVM_SETCOLUMN, uintptr(index), uintptr(unsafe.Pointer(&lvc)))
+
+	// Setting the format removes the sort indicator.
+	lv.updateSortIndicators()
 }
 
 func (lv *ListView) onInsertingListViewColumn(index int, column *ListViewColumn) (err os.Error) {
@@ -180,11 +845,11 @@ func (lv *ListView) onInsertingListViewColumn(index int, column *ListViewColumn)
 
 	lvc.Mask = LVCF_FMT | LVCF_WIDTH | LVCF_TEXT | LVCF_SUBITEM
 	lvc.ISubItem = index
-	lvc.PszText = syscall.StringToUTF16Ptr(column.Title())
+	lvc.PszText = StringToUTF16Ptr(column.Title())
 	lvc.Cx = 100
 	lvc.Fmt = int(column.alignment)
 
-	i := SendMessage(lv.hWnd, LVM_INSERTCOLUMN, uintptr(index), uintptr(unsafe.Pointer(&lvc)))
+	i := backend.SendMessage(lv.hWnd, LVM_INSERTCOLUMN, uintptr(index), uintptr(unsafe.Pointer(&lvc)))
 	if int(i) == -1 {
 		return newError("ListView.onInsertingListViewColumn: Failed to insert column.")
 	}
@@ -203,93 +868,75 @@ func (lv *ListView) onClearingListViewColumns() (err os.Error) {
 }
 
 func (lv *ListView) onListViewItemChanged(item *ListViewItem) {
-	panic("not implemented")
+	if index := lv.items.IndexOf(item); index > -1 {
+		lv.redrawItems(index, index)
+	}
 }
 
 func (lv *ListView) onInsertingListViewItem(index int, item *ListViewItem) (err os.Error) {
-	var lvi LVITEM
+	if lv.model != nil {
+		return newError("cannot add items to a list view that has a model")
+	}
 
-	lvi.Mask = LVIF_TEXT
-	lvi.IItem = index
+	return
+}
 
-	texts := item.Texts()
-	if len(texts) > 0 {
-		lvi.PszText = syscall.StringToUTF16Ptr(texts[0])
-	}
+func (lv *ListView) onInsertedListViewItem(index int, item *ListViewItem) (err os.Error) {
+	item.addChangedHandler(lv)
 
-	i := SendMessage(lv.hWnd, LVM_INSERTITEM, 0, uintptr(unsafe.Pointer(&lvi)))
-	if int(i) == -1 {
-		return newError("ListView.onInsertingListViewItem: Failed to insert item.")
-	}
+	return lv.onRowsInserted(index, index)
+}
 
-	colCount := lv.columns.Len()
+func (lv *ListView) onRemovingListViewItem(index int, item *ListViewItem) (err os.Error) {
+	return
+}
 
-	for colIndex := 1; colIndex < colCount; colIndex++ {
-		lvi.ISubItem = colIndex
+func (lv *ListView) onRemovedListViewItem(index int, item *ListViewItem) (err os.Error) {
+	item.removeChangedHandler(lv)
 
-		if colIndex < len(texts) {
-			lvi.PszText = syscall.StringToUTF16Ptr(texts[colIndex])
-		} else {
-			lvi.PszText = nil
-		}
+	return lv.onRowsRemoved(index, index)
+}
 
-		ret := SendMessage(lv.hWnd, LVM_SETITEM, 0, uintptr(unsafe.Pointer(&lvi)))
-		if ret == 0 {
-			return newError("ListView.onInsertingListViewItem: Failed to set sub item.")
-		}
+func (lv *ListView) onClearingListViewItems() (err os.Error) {
+	for i := lv.items.Len() - 1; i >= 0; i-- {
+		lv.items.At(i).removeChangedHandler(lv)
 	}
 
 	return
 }
 
-func (lv *ListView) onRemovingListViewItem(index int, item *ListViewItem) (err os.Error) {
-	panic("not implemented")
+func (lv *ListView) onClearedListViewItems() (err os.Error) {
+	return lv.onRowsReset()
 }
 
-func (lv *ListView) onClearingListViewItems() os.Error {
-	if FALSE == SendMessage(lv.hWnd, LVM_DELETEALLITEMS, 0, 0) {
-		return newError("LVM_DELETEALLITEMS failed")
-	}
+func (lv *ListView) SelectedIndexChanged() *Event {
+	return lv.selectedIndexChangedPublisher.Event()
+}
 
-	return nil
+func (lv *ListView) raiseSelectedIndexChanged() {
+	lv.selectedIndexChangedPublisher.Publish(&eventArgs{widgetsByHWnd[lv.hWnd]})
 }
 
-func (lv *ListView) AddSelectedIndexChangedHandler(handler EventHandler) {
-	lv.selectedIndexChangedHandlers.Push(handler)
+func (lv *ListView) SelectedIndexesChanged() *Event {
+	return lv.selectedIndexesChangedPublisher.Event()
 }
 
-func (lv *ListView) RemoveSelectedIndexChangedHandler(handler EventHandler) {
-	for i, h := range lv.selectedIndexChangedHandlers {
-		if h.(EventHandler) == handler {
-			lv.selectedIndexChangedHandlers.Delete(i)
-			break
-		}
-	}
+func (lv *ListView) raiseSelectedIndexesChanged() {
+	lv.selectedIndexesChangedPublisher.Publish(&eventArgs{widgetsByHWnd[lv.hWnd]})
 }
 
-func (lv *ListView) raiseSelectedIndexChanged() {
-	for _, handlerIface := range lv.selectedIndexChangedHandlers {
-		handler := handlerIface.(EventHandler)
-		handler(&eventArgs{widgetsByHWnd[lv.hWnd]})
-	}
+func (lv *ListView) ItemActivated() *Event {
+	return lv.itemActivatedPublisher.Event()
 }
 
-func (lv *ListView) AddItemActivatedHandler(handler EventHandler) {
-	lv.itemActivatedHandlers.Push(handler)
+func (lv *ListView) raiseItemActivated() {
+	lv.itemActivatedPublisher.Publish(&eventArgs{widgetsByHWnd[lv.hWnd]})
 }
 
-func (lv *ListView) RemoveItemActivatedHandler(handler EventHandler) {
-	for i, h := range lv.itemActivatedHandlers {
-		if h.(EventHandler) == handler {
-			lv.itemActivatedHandlers.Delete(i)
-			break
-		}
-	}
+func (lv *ListView) ItemChecked() *IndexEvent {
+	return lv.itemCheckedPublisher.Event()
 }
 
-func (lv *ListView) raiseItemActivated() {
-	for _, handlerIface := range lv.itemActivatedHandlers {
-		handler := handlerIface.(EventHandler)
-		handler(&eventArgs{widgetsByHWnd[lv.hWnd]})
-	}
+func (lv *ListView) raiseItemChecked(index int) {
+	lv.itemCheckedPublisher.Publish(&indexEventArgs{eventArgs: eventArgs{widgetsByHWnd[lv.hWnd]}, index: index})
 }
@@ -16,11 +16,12 @@ type ListViewColumn struct {
 	alignment       HorizontalAlignment
 	width           int
 	title           string
+	sortable        bool
 	changedHandlers vector.Vector
 }
 
 func NewListViewColumn() *ListViewColumn {
-	return &ListViewColumn{width: 100}
+	return &ListViewColumn{width: 100, sortable: true}
 }
 
 func (c *ListViewColumn) Alignment() HorizontalAlignment {
@@ -35,6 +36,17 @@ func (c *ListViewColumn) SetAlignment(value HorizontalAlignment) {
 	}
 }
 
+// Sortable returns whether clicking the header of the column sorts the rows
+// of the ListView by the column. This requires a model that can sort, like
+// SortFilterTableModel.
+func (c *ListViewColumn) Sortable() bool {
+	return c.sortable
+}
+
+func (c *ListViewColumn) SetSortable(value bool) {
+	c.sortable = value
+}
+
 func (c *ListViewColumn) Title() string {
 	return c.title
 }
@@ -11,8 +11,11 @@ import (
 
 type listViewItemListObserver interface {
 	onInsertingListViewItem(index int, item *ListViewItem) (err os.Error)
+	onInsertedListViewItem(index int, item *ListViewItem) (err os.Error)
 	onRemovingListViewItem(index int, item *ListViewItem) (err os.Error)
+	onRemovedListViewItem(index int, item *ListViewItem) (err os.Error)
 	onClearingListViewItems() (err os.Error)
+	onClearedListViewItems() (err os.Error)
 }
 
 type ListViewItemList struct {
@@ -47,8 +50,18 @@ func (l *ListViewItemList) Clear() (err os.Error) {
 		}
 	}
 
+	oldItems := l.items
+	l.items = nil
 	l.items.Resize(0, 8)
 
+	if observer != nil {
+		err = observer.onClearedListViewItems()
+		if err != nil {
+			l.items = oldItems
+			return
+		}
+	}
+
 	return
 }
 
@@ -73,6 +86,14 @@ func (l *ListViewItemList) Insert(index int, item *ListViewItem) (err os.Error)
 
 	l.items.Insert(index, item)
 
+	if observer != nil {
+		err = observer.onInsertedListViewItem(index, item)
+		if err != nil {
+			l.items.Delete(index)
+			return
+		}
+	}
+
 	return
 }
 
@@ -91,8 +112,8 @@ func (l *ListViewItemList) Remove(item *ListViewItem) (err os.Error) {
 
 func (l *ListViewItemList) RemoveAt(index int) (err os.Error) {
 	observer := l.observer
+	item := l.items[index].(*ListViewItem)
 	if observer != nil {
-		item := l.items[index].(*ListViewItem)
 		err = observer.onRemovingListViewItem(index, item)
 		if err != nil {
 			return
@@ -101,5 +122,13 @@ func (l *ListViewItemList) RemoveAt(index int) (err os.Error) {
 
 	l.items.Delete(index)
 
+	if observer != nil {
+		err = observer.onRemovedListViewItem(index, item)
+		if err != nil {
+			l.items.Insert(index, item)
+			return
+		}
+	}
+
 	return
 }
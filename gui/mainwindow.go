@@ -6,7 +6,6 @@ package gui
 
 import (
 	"os"
-	"syscall"
 )
 
 import (
@@ -17,16 +16,12 @@ import (
 
 const mainWindowWindowClass = `\o/ Walk_MainWindow_Class \o/`
 
-var mainWindowWndProcCallback *syscall.Callback
-
-func mainWindowWndProc(args *uintptr) uintptr {
-	msg := msgFromCallbackArgs(args)
-
+func mainWindowWndProc(msg *MSG) uintptr {
 	mw, ok := widgetsByHWnd[msg.HWnd].(*MainWindow)
 	if !ok {
 		// Before CreateWindowEx returns, among others, WM_GETMINMAXINFO is sent.
 		// FIXME: Find a way to properly handle this.
-		return DefWindowProc(msg.HWnd, msg.Message, msg.WParam, msg.LParam)
+		return backend.DefWindowProc(msg, 0)
 	}
 
 	return mw.wndProc(msg, 0)
@@ -39,14 +34,14 @@ type MainWindow struct {
 }
 
 func NewMainWindow() (mw *MainWindow, err os.Error) {
-	ensureRegisteredWindowClass(mainWindowWindowClass, mainWindowWndProc, &mainWindowWndProcCallback)
+	ensureRegisteredWindowClass(mainWindowWindowClass, mainWindowWndProc)
 
-	hWnd := CreateWindowEx(
-		WS_EX_CONTROLPARENT, syscall.StringToUTF16Ptr(mainWindowWindowClass), nil,
+	hWnd, err := backend.CreateWindow(
+		WS_EX_CONTROLPARENT, mainWindowWindowClass,
 		WS_OVERLAPPEDWINDOW,
-		CW_USEDEFAULT, CW_USEDEFAULT, 400, 300, 0, 0, 0, nil)
-	if hWnd == 0 {
-		return nil, lastError("CreateWindowEx")
+		0, drawing.Rectangle{CW_USEDEFAULT, CW_USEDEFAULT, 400, 300})
+	if err != nil {
+		return nil, err
 	}
 
 	wnd := &MainWindow{TopLevelWindow: TopLevelWindow{Container: Container{Widget: Widget{hWnd: hWnd}}}}
@@ -69,7 +64,10 @@ func NewMainWindow() (mw *MainWindow, err os.Error) {
 	if err != nil {
 		panic(err)
 	}
-	SetMenu(wnd.hWnd, wnd.menu.hMenu)
+	wnd.menu.hWnd = hWnd
+	if err := backend.SetMenu(wnd.hWnd, wnd.menu.hMenu); err != nil {
+		panic(err)
+	}
 
 	wnd.toolBar, err = NewToolBar(wnd)
 	if err != nil {
@@ -82,7 +80,7 @@ func NewMainWindow() (mw *MainWindow, err os.Error) {
 	}
 
 	// This forces display of focus rectangles, as soon as the user starts to type.
-	SendMessage(hWnd, WM_CHANGEUISTATE, UIS_INITIALIZE, 0)
+	backend.SendMessage(hWnd, WM_CHANGEUISTATE, UIS_INITIALIZE, 0)
 
 	mw = wnd
 
@@ -97,6 +95,35 @@ func (mw *MainWindow) ToolBar() *ToolBar {
 	return mw.toolBar
 }
 
+// handleShortcut also triggers the actions of the menu and tool bar, so their
+// shortcuts work without adding them to the AcceleratorTable.
+func (mw *MainWindow) handleShortcut(shortcut Shortcut) bool {
+	if mw.TopLevelWindow.handleShortcut(shortcut) {
+		return true
+	}
+
+	action := mw.menu.actionForShortcut(shortcut)
+
+	if action == nil {
+		actions := mw.toolBar.Actions()
+		for i := 0; i < actions.Len(); i++ {
+			a := actions.At(i)
+			if a.enabled && a.visible && a.shortcut == shortcut {
+				action = a
+				break
+			}
+		}
+	}
+
+	if action == nil {
+		return false
+	}
+
+	action.Trigger()
+
+	return true
+}
+
 func (mw *MainWindow) ClientBounds() (bounds drawing.Rectangle, err os.Error) {
 	bounds, err = mw.Widget.ClientBounds()
 	if err != nil {
@@ -119,7 +146,7 @@ func (mw *MainWindow) ClientBounds() (bounds drawing.Rectangle, err os.Error) {
 func (mw *MainWindow) wndProc(msg *MSG, origWndProcPtr uintptr) uintptr {
 	switch msg.Message {
 	case WM_SIZE, WM_SIZING:
-		SendMessage(mw.toolBar.hWnd, TB_AUTOSIZE, 0, 0)
+		backend.SendMessage(mw.toolBar.hWnd, TB_AUTOSIZE, 0, 0)
 	}
 
 	return mw.TopLevelWindow.wndProc(msg, origWndProcPtr)
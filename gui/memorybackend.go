@@ -0,0 +1,1410 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"container/vector"
+	"fmt"
+	"image"
+	"os"
+	"sync"
+	"unsafe"
+	"utf16"
+)
+
+import (
+	"walk/drawing"
+	. "walk/winapi"
+	. "walk/winapi/comctl32"
+	. "walk/winapi/user32"
+)
+
+type memoryTimer struct {
+	hWnd HWND
+	id   uintptr
+}
+
+type memoryDrop struct {
+	paths []string
+	point drawing.Point
+}
+
+// memoryMenuItem holds what InsertMenuItem and SetMenuItemInfo set.
+type memoryMenuItem struct {
+	id      uint
+	text    string
+	fType   uint
+	state   uint
+	subMenu HMENU
+}
+
+type memoryMenu struct {
+	popup bool
+	items []*memoryMenuItem
+}
+
+type memoryImageList struct {
+	imageSize drawing.Size
+	images    []*drawing.Bitmap
+}
+
+type memoryWindow struct {
+	className string
+	exStyle   uint
+	style     uint
+	parent    HWND
+	owner     HWND
+	bounds    drawing.Rectangle
+	placement WINDOWPLACEMENT
+	text      string
+	checked   bool
+	invalid   bool
+	itemCount int
+	curSel    int
+	menu      HMENU
+	surrogate uint16
+	listView  *memoryListView
+	treeView  *memoryTreeView
+	toolTip   *memoryToolTip
+}
+
+// MemoryBackend is a Backend that keeps all window state in memory instead of
+// creating real windows.
+//
+// Messages sent to a window are dispatched synchronously to the wndProc of
+// the widget owning it, posted messages are queued until RunMessageLoop is
+// called. Click, KeyPress, TypeText, MouseMove, MouseUp, DropFiles, SelectTab,
+// ClickListViewItem, ClickListViewColumn, ToggleTreeItem and Resize simulate
+// user input.
+//
+// DefWindowProc emulates the messages of buttons, edits, tab controls, list
+// views, tree views and tool tips that the widgets rely on.
+//
+// The clipboard is private to the backend, it starts out empty. Timers never
+// elapse by themselves, use a FakeClock to trigger them. Message boxes are
+// answered by the function set with SetMessageBoxHandler.
+type MemoryBackend struct {
+	windows         map[HWND]*memoryWindow
+	nextHWnd        HWND
+	focus           HWND
+	capture         HWND
+	cursor          uint16
+	hover           HWND
+	tracking        map[HWND]bool
+	modifiers       Modifiers
+	drops           map[uintptr]*memoryDrop
+	nextHDrop       uintptr
+	clipboard       map[string]interface{}
+	clipboardSeq    uint
+	queue           vector.Vector
+	queueMutex      sync.Mutex
+	timers          map[memoryTimer]uint
+	menus           map[HMENU]*memoryMenu
+	nextHMenu       HMENU
+	popupMenu       HMENU
+	imageLists      map[HIMAGELIST]*memoryImageList
+	nextHIml        HIMAGELIST
+	messageBox      func(title, message string, style MsgBoxStyle) DialogCommandId
+	dialogBaseUnits drawing.Size
+	defaultFont     *drawing.Font
+	loopDepth       int
+}
+
+// NewMemoryBackend returns a new, empty MemoryBackend.
+func NewMemoryBackend() *MemoryBackend {
+	// Fonts create their os resources on first use, which never happens here.
+	font, _ := drawing.NewFont("MS Shell Dlg 2", 8, 0)
+
+	return &MemoryBackend{
+		windows:         make(map[HWND]*memoryWindow),
+		tracking:        make(map[HWND]bool),
+		timers:          make(map[memoryTimer]uint),
+		drops:           make(map[uintptr]*memoryDrop),
+		clipboard:       make(map[string]interface{}),
+		menus:           make(map[HMENU]*memoryMenu),
+		imageLists:      make(map[HIMAGELIST]*memoryImageList),
+		nextHWnd:        1,
+		nextHDrop:       1,
+		nextHMenu:       1,
+		nextHIml:        1,
+		dialogBaseUnits: drawing.Size{6, 13},
+		defaultFont:     font,
+	}
+}
+
+func (b *MemoryBackend) window(hWnd HWND) (*memoryWindow, os.Error) {
+	w, ok := b.windows[hWnd]
+	if !ok {
+		return nil, newError("invalid window handle")
+	}
+
+	return w, nil
+}
+
+// IsWindow returns if hWnd identifies a window that has not been destroyed.
+func (b *MemoryBackend) IsWindow(hWnd HWND) bool {
+	_, ok := b.windows[hWnd]
+
+	return ok
+}
+
+// IsInvalidated returns if the client area of the window has been
+// invalidated since the last call to Validate.
+func (b *MemoryBackend) IsInvalidated(hWnd HWND) bool {
+	if w, ok := b.windows[hWnd]; ok {
+		return w.invalid
+	}
+
+	return false
+}
+
+// Validate resets the invalidated state of the window.
+func (b *MemoryBackend) Validate(hWnd HWND) {
+	if w, ok := b.windows[hWnd]; ok {
+		w.invalid = false
+	}
+}
+
+func (*MemoryBackend) RegisterWindowClass(className string, windowProc func(msg *MSG) uintptr) {
+}
+
+func (b *MemoryBackend) CreateWindow(exStyle uint, className string, style uint, parent HWND, bounds drawing.Rectangle) (HWND, os.Error) {
+	if parent == HWND_MESSAGE {
+		// Message-only windows have neither parent nor owner.
+		parent = 0
+	}
+
+	if parent != 0 {
+		if _, err := b.window(parent); err != nil {
+			return 0, err
+		}
+	}
+
+	hWnd := b.nextHWnd
+	b.nextHWnd++
+
+	w := &memoryWindow{
+		className: className,
+		exStyle:   exStyle,
+		style:     style,
+		bounds:    bounds,
+		curSel:    -1,
+	}
+	w.placement.ShowCmd = SW_SHOWNORMAL
+	w.initControl()
+	if style&WS_CHILD != 0 {
+		w.parent = parent
+	} else {
+		w.owner = parent
+	}
+
+	b.windows[hWnd] = w
+
+	return hWnd, nil
+}
+
+func (b *MemoryBackend) DestroyWindow(hWnd HWND) os.Error {
+	if _, err := b.window(hWnd); err != nil {
+		return err
+	}
+
+	for child, w := range b.windows {
+		if w.parent == hWnd || w.owner == hWnd {
+			b.DestroyWindow(child)
+		}
+	}
+
+	b.SendMessage(hWnd, WM_DESTROY, 0, 0)
+
+	if b.focus == hWnd {
+		b.focus = 0
+	}
+	if b.capture == hWnd {
+		b.capture = 0
+	}
+	if b.hover == hWnd {
+		b.hover = 0
+	}
+	b.tracking[hWnd] = false, false
+
+	for timer := range b.timers {
+		if timer.hWnd == hWnd {
+			b.timers[timer] = 0, false
+		}
+	}
+
+	b.windows[hWnd] = nil, false
+
+	return nil
+}
+
+func (*MemoryBackend) SubclassWindow(hWnd HWND, windowProc func(msg *MSG, origWndProcPtr uintptr) uintptr) os.Error {
+	// Messages are dispatched to the widget directly, so there is nothing to
+	// subclass.
+	return nil
+}
+
+func (b *MemoryBackend) SetParent(hWnd, parent HWND) os.Error {
+	w, err := b.window(hWnd)
+	if err != nil {
+		return err
+	}
+
+	if parent == HWND_MESSAGE {
+		// Like in CreateWindow, a message-only window just has no parent.
+		w.parent = 0
+		return nil
+	}
+
+	if parent == 0 {
+		w.style &^= WS_CHILD
+		w.style |= WS_POPUP
+	} else {
+		if _, err := b.window(parent); err != nil {
+			return err
+		}
+
+		w.style |= WS_CHILD
+		w.style &^= WS_POPUP
+	}
+
+	w.parent = parent
+
+	return nil
+}
+
+func (b *MemoryBackend) SetOwner(hWnd, owner HWND) os.Error {
+	w, err := b.window(hWnd)
+	if err != nil {
+		return err
+	}
+
+	w.owner = owner
+
+	return nil
+}
+
+func (b *MemoryBackend) Style(hWnd HWND) (uint, os.Error) {
+	w, err := b.window(hWnd)
+	if err != nil {
+		return 0, err
+	}
+
+	return w.style, nil
+}
+
+func (b *MemoryBackend) SetStyle(hWnd HWND, style uint) os.Error {
+	w, err := b.window(hWnd)
+	if err != nil {
+		return err
+	}
+
+	w.style = style
+
+	return nil
+}
+
+func (b *MemoryBackend) Bounds(hWnd HWND) (drawing.Rectangle, os.Error) {
+	w, err := b.window(hWnd)
+	if err != nil {
+		return drawing.Rectangle{}, err
+	}
+
+	return w.bounds, nil
+}
+
+func (b *MemoryBackend) SetBounds(hWnd HWND, bounds drawing.Rectangle) os.Error {
+	w, err := b.window(hWnd)
+	if err != nil {
+		return err
+	}
+
+	old := w.bounds
+	w.bounds = bounds
+
+	if bounds.X != old.X || bounds.Y != old.Y {
+		b.SendMessage(hWnd, WM_MOVE, 0, uintptr(MAKELONG(uint16(bounds.X), uint16(bounds.Y))))
+	}
+
+	if bounds.Width != old.Width || bounds.Height != old.Height {
+		w.invalid = true
+
+		b.SendMessage(hWnd, WM_SIZE, 0, uintptr(MAKELONG(uint16(bounds.Width), uint16(bounds.Height))))
+	}
+
+	return nil
+}
+
+func (b *MemoryBackend) ClientBounds(hWnd HWND) (drawing.Rectangle, os.Error) {
+	w, err := b.window(hWnd)
+	if err != nil {
+		return drawing.Rectangle{}, err
+	}
+
+	return drawing.Rectangle{Width: w.bounds.Width, Height: w.bounds.Height}, nil
+}
+
+func (b *MemoryBackend) ShowWindow(hWnd HWND, cmdShow int) os.Error {
+	w, err := b.window(hWnd)
+	if err != nil {
+		return err
+	}
+
+	visible := cmdShow != SW_HIDE
+	if visible {
+		w.placement.ShowCmd = uint(cmdShow)
+	}
+
+	if visible == (w.style&WS_VISIBLE != 0) {
+		return nil
+	}
+
+	if visible {
+		w.style |= WS_VISIBLE
+	} else {
+		w.style &^= WS_VISIBLE
+	}
+
+	b.SendMessage(hWnd, WM_SHOWWINDOW, uintptr(BoolToBOOL(visible)), 0)
+
+	return nil
+}
+
+// WindowPlacement returns the bounds of the window as its normal position, the
+// window is never actually minimized or maximized.
+func (b *MemoryBackend) WindowPlacement(hWnd HWND) (*WINDOWPLACEMENT, os.Error) {
+	w, err := b.window(hWnd)
+	if err != nil {
+		return nil, err
+	}
+
+	wp := w.placement
+	wp.Length = uint(unsafe.Sizeof(wp))
+	wp.RcNormalPosition.Left = w.bounds.X
+	wp.RcNormalPosition.Top = w.bounds.Y
+	wp.RcNormalPosition.Right = w.bounds.X + w.bounds.Width
+	wp.RcNormalPosition.Bottom = w.bounds.Y + w.bounds.Height
+
+	return &wp, nil
+}
+
+func (b *MemoryBackend) SetWindowPlacement(hWnd HWND, wp *WINDOWPLACEMENT) os.Error {
+	w, err := b.window(hWnd)
+	if err != nil {
+		return err
+	}
+
+	r := wp.RcNormalPosition
+	if r.Right < r.Left || r.Bottom < r.Top {
+		return newError("invalid normal position")
+	}
+
+	w.placement.Flags = wp.Flags
+	w.placement.PtMinPosition = wp.PtMinPosition
+	w.placement.PtMaxPosition = wp.PtMaxPosition
+
+	if err := b.SetBounds(hWnd, drawing.Rectangle{r.Left, r.Top, r.Right - r.Left, r.Bottom - r.Top}); err != nil {
+		return err
+	}
+
+	return b.ShowWindow(hWnd, int(wp.ShowCmd))
+}
+
+func (b *MemoryBackend) Text(hWnd HWND) string {
+	if w, ok := b.windows[hWnd]; ok {
+		return w.text
+	}
+
+	return ""
+}
+
+func (b *MemoryBackend) SetText(hWnd HWND, value string) os.Error {
+	w, err := b.window(hWnd)
+	if err != nil {
+		return err
+	}
+
+	w.text = value
+
+	if w.className == "EDIT" {
+		b.SendMessage(w.parent, WM_COMMAND, uintptr(MAKELONG(0, EN_CHANGE)), uintptr(hWnd))
+	}
+
+	return nil
+}
+
+func (b *MemoryBackend) DefaultFont() *drawing.Font {
+	return b.defaultFont
+}
+
+// SetFont does nothing, text is not measured or rendered.
+func (*MemoryBackend) SetFont(hWnd HWND, font *drawing.Font) {
+}
+
+func (b *MemoryBackend) Focus() HWND {
+	return b.focus
+}
+
+func (b *MemoryBackend) SetFocus(hWnd HWND) os.Error {
+	w, err := b.window(hWnd)
+	if err != nil {
+		return err
+	}
+
+	if w.style&WS_DISABLED != 0 {
+		return newError("cannot focus a disabled window")
+	}
+
+	if hWnd == b.focus {
+		return nil
+	}
+
+	old := b.focus
+	b.focus = hWnd
+
+	if old != 0 {
+		b.SendMessage(old, WM_KILLFOCUS, uintptr(hWnd), 0)
+	}
+	b.SendMessage(hWnd, WM_SETFOCUS, uintptr(old), 0)
+
+	return nil
+}
+
+func (b *MemoryBackend) ModifiersDown() Modifiers {
+	return b.modifiers
+}
+
+// SetModifiersDown simulates the user holding down the modifier keys value
+// during subsequent calls of KeyPress.
+func (b *MemoryBackend) SetModifiersDown(value Modifiers) {
+	b.modifiers = value
+}
+
+func (b *MemoryBackend) Capture() HWND {
+	return b.capture
+}
+
+func (b *MemoryBackend) SetCapture(hWnd HWND) os.Error {
+	if _, err := b.window(hWnd); err != nil {
+		return err
+	}
+
+	old := b.capture
+	b.capture = hWnd
+
+	if old != 0 && old != hWnd {
+		b.SendMessage(old, WM_CAPTURECHANGED, 0, uintptr(hWnd))
+	}
+
+	return nil
+}
+
+func (b *MemoryBackend) ReleaseCapture() os.Error {
+	old := b.capture
+	b.capture = 0
+
+	if old != 0 {
+		b.SendMessage(old, WM_CAPTURECHANGED, 0, 0)
+	}
+
+	return nil
+}
+
+// SetCursor records id, the IDC_* identifier of a system cursor, for Cursor.
+func (b *MemoryBackend) SetCursor(id uint16) os.Error {
+	b.cursor = id
+
+	return nil
+}
+
+// Cursor returns the identifier of the cursor last set with SetCursor, or 0.
+func (b *MemoryBackend) Cursor() uint16 {
+	return b.cursor
+}
+
+func (b *MemoryBackend) TrackMouseLeave(hWnd HWND) os.Error {
+	if _, err := b.window(hWnd); err != nil {
+		return err
+	}
+
+	b.tracking[hWnd] = true
+
+	return nil
+}
+
+// ScreenToClient treats the bounds of top level windows as screen
+// coordinates and the client area of a window as its whole bounds.
+func (b *MemoryBackend) ScreenToClient(hWnd HWND, point drawing.Point) (drawing.Point, os.Error) {
+	for hWnd != 0 {
+		w, err := b.window(hWnd)
+		if err != nil {
+			return point, err
+		}
+
+		point.X -= w.bounds.X
+		point.Y -= w.bounds.Y
+
+		hWnd = w.parent
+	}
+
+	return point, nil
+}
+
+func (b *MemoryBackend) ClientToScreen(hWnd HWND, point drawing.Point) (drawing.Point, os.Error) {
+	origin, err := b.ScreenToClient(hWnd, drawing.Point{})
+	if err != nil {
+		return point, err
+	}
+
+	return drawing.Point{point.X - origin.X, point.Y - origin.Y}, nil
+}
+
+// WindowFromPoint returns the innermost visible window containing point. Of
+// overlapping siblings, the one created last is considered to be on top.
+func (b *MemoryBackend) WindowFromPoint(point drawing.Point) HWND {
+	var found HWND
+
+	for {
+		var next HWND
+		var nextBounds drawing.Rectangle
+
+		for hWnd, w := range b.windows {
+			if w.parent != found || w.style&WS_VISIBLE == 0 || hWnd < next {
+				continue
+			}
+
+			if point.X >= w.bounds.X && point.X < w.bounds.X+w.bounds.Width &&
+				point.Y >= w.bounds.Y && point.Y < w.bounds.Y+w.bounds.Height {
+				next = hWnd
+				nextBounds = w.bounds
+			}
+		}
+
+		if next == 0 {
+			break
+		}
+
+		found = next
+		point.X -= nextBounds.X
+		point.Y -= nextBounds.Y
+	}
+
+	return found
+}
+
+func (b *MemoryBackend) AcceptFiles(hWnd HWND, accept bool) os.Error {
+	w, err := b.window(hWnd)
+	if err != nil {
+		return err
+	}
+
+	if accept {
+		w.exStyle |= WS_EX_ACCEPTFILES
+	} else {
+		w.exStyle &^= WS_EX_ACCEPTFILES
+	}
+
+	return nil
+}
+
+func (b *MemoryBackend) DroppedFiles(hDrop uintptr) (paths []string, point drawing.Point, err os.Error) {
+	drop, ok := b.drops[hDrop]
+	if !ok {
+		return nil, point, newError("invalid drop handle")
+	}
+
+	b.drops[hDrop] = nil, false
+
+	return drop.paths, drop.point, nil
+}
+
+func (b *MemoryBackend) ClipboardSequenceNumber() uint {
+	return b.clipboardSeq
+}
+
+func (b *MemoryBackend) ClipboardContains(format string) bool {
+	_, ok := b.clipboard[format]
+
+	return ok
+}
+
+func (b *MemoryBackend) ClipboardData(format string) (interface{}, os.Error) {
+	value, ok := b.clipboard[format]
+	if !ok {
+		return nil, newError("no clipboard data in format " + format)
+	}
+
+	switch v := value.(type) {
+	case *image.RGBA:
+		return drawing.NewBitmapFromImage(v)
+
+	case []byte:
+		if format == ImageFormat {
+			return drawing.NewBitmapFromPackedDIB(v)
+		}
+
+		return copyBytes(v), nil
+	}
+
+	return value, nil
+}
+
+// SetClipboardData replaces the contents of the clipboard with values. Like
+// other applications, tests can call it to change the clipboard behind the
+// back of the widgets.
+//
+// Like on the real clipboard, bitmaps are stored as a copy of their pixels,
+// so later changes to them do not affect the clipboard and reading them
+// returns a new bitmap each time. Bitmaps created by drawing.NewBitmapFromImage
+// are copied without GDI.
+func (b *MemoryBackend) SetClipboardData(values map[string]interface{}) os.Error {
+	clipboard := make(map[string]interface{})
+
+	for format, value := range values {
+		switch v := value.(type) {
+		case string:
+			clipboard[format] = v
+
+		case *drawing.Bitmap:
+			img, err := v.ToImage()
+			if err != nil {
+				return err
+			}
+			clipboard[format] = img
+
+		case []byte:
+			clipboard[format] = copyBytes(v)
+
+		default:
+			return newError(fmt.Sprintf("cannot put %T on the clipboard", value))
+		}
+	}
+
+	b.clipboard = clipboard
+	b.clipboardSeq++
+
+	return nil
+}
+
+func copyBytes(data []byte) []byte {
+	c := make([]byte, len(data))
+	copy(c, data)
+
+	return c
+}
+
+func (b *MemoryBackend) menu(hMenu HMENU) (*memoryMenu, os.Error) {
+	m, ok := b.menus[hMenu]
+	if !ok {
+		return nil, newError("invalid menu handle")
+	}
+
+	return m, nil
+}
+
+func (b *MemoryBackend) CreateMenu(popup bool) (HMENU, os.Error) {
+	hMenu := b.nextHMenu
+	b.nextHMenu++
+
+	b.menus[hMenu] = &memoryMenu{popup: popup}
+
+	return hMenu, nil
+}
+
+// DestroyMenu destroys the submenus as well, like the Win32 function does.
+func (b *MemoryBackend) DestroyMenu(hMenu HMENU) os.Error {
+	m, err := b.menu(hMenu)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range m.items {
+		if item.subMenu != 0 {
+			b.DestroyMenu(item.subMenu)
+		}
+	}
+
+	b.menus[hMenu] = nil, false
+
+	if b.popupMenu == hMenu {
+		b.popupMenu = 0
+	}
+
+	return nil
+}
+
+func (b *MemoryBackend) SetMenu(hWnd HWND, hMenu HMENU) os.Error {
+	w, err := b.window(hWnd)
+	if err != nil {
+		return err
+	}
+
+	if _, err := b.menu(hMenu); err != nil {
+		return err
+	}
+
+	w.menu = hMenu
+
+	return nil
+}
+
+// setMenuItemInfo copies the members of mii that its FMask selects to item.
+func setMenuItemInfo(item *memoryMenuItem, mii *MENUITEMINFO) {
+	if mii.FMask&MIIM_ID != 0 {
+		item.id = mii.WID
+	}
+	if mii.FMask&MIIM_FTYPE != 0 {
+		item.fType = mii.FType
+	}
+	if mii.FMask&MIIM_STATE != 0 {
+		item.state = mii.FState
+	}
+	if mii.FMask&MIIM_SUBMENU != 0 {
+		item.subMenu = mii.HSubMenu
+	}
+	if mii.FMask&MIIM_STRING != 0 {
+		item.text = ""
+		if mii.DwTypeData != nil {
+			item.text = UTF16PtrToString(mii.DwTypeData)
+		}
+	}
+}
+
+func (b *MemoryBackend) InsertMenuItem(hMenu HMENU, position int, mii *MENUITEMINFO) os.Error {
+	m, err := b.menu(hMenu)
+	if err != nil {
+		return err
+	}
+
+	if position < 0 || position > len(m.items) {
+		position = len(m.items)
+	}
+
+	item := new(memoryMenuItem)
+	setMenuItemInfo(item, mii)
+
+	items := make([]*memoryMenuItem, len(m.items)+1)
+	copy(items, m.items[:position])
+	items[position] = item
+	copy(items[position+1:], m.items[position:])
+	m.items = items
+
+	return nil
+}
+
+func (b *MemoryBackend) SetMenuItemInfo(hMenu HMENU, position int, mii *MENUITEMINFO) os.Error {
+	m, err := b.menu(hMenu)
+	if err != nil {
+		return err
+	}
+
+	if position < 0 || position >= len(m.items) {
+		return newError("position out of range")
+	}
+
+	setMenuItemInfo(m.items[position], mii)
+
+	return nil
+}
+
+// RemoveMenuItem removes the item at position, but like RemoveMenu does not
+// destroy its submenu.
+func (b *MemoryBackend) RemoveMenuItem(hMenu HMENU, position int) os.Error {
+	m, err := b.menu(hMenu)
+	if err != nil {
+		return err
+	}
+
+	if position < 0 || position >= len(m.items) {
+		return newError("position out of range")
+	}
+
+	items := make([]*memoryMenuItem, len(m.items)-1)
+	copy(items, m.items[:position])
+	copy(items[position:], m.items[position+1:])
+	m.items = items
+
+	return nil
+}
+
+func (b *MemoryBackend) DrawMenuBar(hWnd HWND) os.Error {
+	_, err := b.window(hWnd)
+
+	return err
+}
+
+// TrackPopupMenu sends WM_INITMENUPOPUP to owner and then remembers the menu
+// as the open popup menu, until the next call. Choosing an item is up to the
+// test, by sending WM_COMMAND with its id to owner.
+func (b *MemoryBackend) TrackPopupMenu(hMenu HMENU, owner HWND, point drawing.Point) os.Error {
+	if _, err := b.menu(hMenu); err != nil {
+		return err
+	}
+	if _, err := b.window(owner); err != nil {
+		return err
+	}
+
+	b.SendMessage(owner, WM_INITMENUPOPUP, uintptr(hMenu), 0)
+
+	b.popupMenu = hMenu
+
+	return nil
+}
+
+func (b *MemoryBackend) imageList(hIml HIMAGELIST) (*memoryImageList, os.Error) {
+	il, ok := b.imageLists[hIml]
+	if !ok {
+		return nil, newError("invalid image list handle")
+	}
+
+	return il, nil
+}
+
+func (b *MemoryBackend) CreateImageList(imageSize drawing.Size) (HIMAGELIST, os.Error) {
+	hIml := b.nextHIml
+	b.nextHIml++
+
+	b.imageLists[hIml] = &memoryImageList{imageSize: imageSize}
+
+	return hIml, nil
+}
+
+func (b *MemoryBackend) DestroyImageList(hIml HIMAGELIST) os.Error {
+	if _, err := b.imageList(hIml); err != nil {
+		return err
+	}
+
+	b.imageLists[hIml] = nil, false
+
+	return nil
+}
+
+// AddImage keeps bitmap itself, masks are ignored.
+func (b *MemoryBackend) AddImage(hIml HIMAGELIST, bitmap, maskBitmap *drawing.Bitmap) (int, os.Error) {
+	il, err := b.imageList(hIml)
+	if err != nil {
+		return 0, err
+	}
+
+	il.images = append(il.images, bitmap)
+
+	return len(il.images) - 1, nil
+}
+
+func (b *MemoryBackend) AddMaskedImage(hIml HIMAGELIST, bitmap *drawing.Bitmap, maskColor drawing.Color) (int, os.Error) {
+	return b.AddImage(hIml, bitmap, nil)
+}
+
+// SetMessageBoxHandler makes f answer the message boxes shown from now on.
+// Without a handler, MessageBox fails and returns 0.
+func (b *MemoryBackend) SetMessageBoxHandler(f func(title, message string, style MsgBoxStyle) DialogCommandId) {
+	b.messageBox = f
+}
+
+func (b *MemoryBackend) MessageBox(owner HWND, title, message string, style uint) int {
+	if owner != 0 && !b.IsWindow(owner) {
+		return 0
+	}
+
+	if b.messageBox == nil {
+		return 0
+	}
+
+	return int(b.messageBox(title, message, MsgBoxStyle(style)))
+}
+
+func (*MemoryBackend) SetTheme(hWnd HWND, appName string) os.Error {
+	return nil
+}
+
+func (b *MemoryBackend) DialogBaseUnits(hWnd HWND) drawing.Size {
+	return b.dialogBaseUnits
+}
+
+func (b *MemoryBackend) Invalidate(hWnd HWND, bounds drawing.Rectangle) os.Error {
+	w, err := b.window(hWnd)
+	if err != nil {
+		return err
+	}
+
+	w.invalid = true
+
+	return nil
+}
+
+func (b *MemoryBackend) SendMessage(hWnd HWND, msg uint, wParam, lParam uintptr) uintptr {
+	m := &MSG{HWnd: hWnd, Message: msg, WParam: wParam, LParam: lParam}
+
+	if widget, ok := widgetsByHWnd[hWnd]; ok {
+		return widget.wndProc(m, 0)
+	}
+
+	return b.DefWindowProc(m, 0)
+}
+
+// PostMessage queues the message. It may be called from any goroutine, so it
+// does not look at the window, messages to invalid windows are discarded by
+// RunMessageLoop.
+func (b *MemoryBackend) PostMessage(hWnd HWND, msg uint, wParam, lParam uintptr) os.Error {
+	b.queueMutex.Lock()
+	defer b.queueMutex.Unlock()
+
+	b.queue.Push(&MSG{HWnd: hWnd, Message: msg, WParam: wParam, LParam: lParam})
+
+	return nil
+}
+
+// nextMessage removes the first posted message from the queue. It returns
+// false if the queue is empty.
+func (b *MemoryBackend) nextMessage() (*MSG, bool) {
+	b.queueMutex.Lock()
+	defer b.queueMutex.Unlock()
+
+	if b.queue.Len() == 0 {
+		return nil, false
+	}
+
+	msg := b.queue.At(0).(*MSG)
+	b.queue.Delete(0)
+
+	return msg, true
+}
+
+func (b *MemoryBackend) hasMessages() bool {
+	b.queueMutex.Lock()
+	defer b.queueMutex.Unlock()
+
+	return b.queue.Len() > 0
+}
+
+func (b *MemoryBackend) SetTimer(hWnd HWND, id uintptr, elapse uint) os.Error {
+	if _, err := b.window(hWnd); err != nil {
+		return err
+	}
+
+	b.timers[memoryTimer{hWnd, id}] = elapse
+
+	return nil
+}
+
+func (b *MemoryBackend) KillTimer(hWnd HWND, id uintptr) os.Error {
+	timer := memoryTimer{hWnd, id}
+
+	if _, ok := b.timers[timer]; !ok {
+		return newError("no such timer")
+	}
+
+	b.timers[timer] = 0, false
+
+	return nil
+}
+
+func (b *MemoryBackend) DefWindowProc(msg *MSG, origWndProcPtr uintptr) uintptr {
+	w, ok := b.windows[msg.HWnd]
+	if !ok {
+		return 0
+	}
+
+	if ret, ok := b.controlProc(w, msg); ok {
+		return ret
+	}
+
+	switch msg.Message {
+	case BM_GETCHECK:
+		if w.checked {
+			return BST_CHECKED
+		}
+		return BST_UNCHECKED
+
+	case BM_SETCHECK:
+		w.checked = msg.WParam == BST_CHECKED
+
+	case BM_SETSTYLE:
+		w.style = w.style&^0xFFFF | uint(msg.WParam)&0xFFFF
+
+	case TCM_INSERTITEM:
+		index := int(msg.WParam)
+		if index > w.itemCount {
+			index = w.itemCount
+		}
+		w.itemCount++
+		if index <= w.curSel {
+			w.curSel++
+		}
+		return uintptr(index)
+
+	case TCM_DELETEITEM:
+		index := int(msg.WParam)
+		if index >= w.itemCount {
+			return FALSE
+		}
+		w.itemCount--
+		switch {
+		case index == w.curSel:
+			w.curSel = -1
+
+		case index < w.curSel:
+			w.curSel--
+		}
+		return TRUE
+
+	case TCM_DELETEALLITEMS:
+		w.itemCount = 0
+		w.curSel = -1
+		return TRUE
+
+	case TCM_SETITEM:
+		if int(msg.WParam) >= w.itemCount {
+			return FALSE
+		}
+		return TRUE
+
+	case TCM_GETITEMCOUNT:
+		return uintptr(w.itemCount)
+
+	case TCM_GETCURSEL:
+		return uintptr(w.curSel)
+
+	case TCM_SETCURSEL:
+		prev := w.curSel
+		if index := int(msg.WParam); index < w.itemCount {
+			w.curSel = index
+		}
+		return uintptr(prev)
+
+	case WM_SYSCOMMAND:
+		if msg.WParam == SC_CLOSE {
+			b.SendMessage(msg.HWnd, WM_CLOSE, 0, 0)
+		}
+
+	case WM_CLOSE:
+		b.DestroyWindow(msg.HWnd)
+	}
+
+	return 0
+}
+
+// RunMessageLoop dispatches posted messages until the queue is empty, a
+// WM_QUIT message is found or running returns false. Emptying the queue
+// counts as the message loop becoming idle.
+//
+// Like the Win32 loop, a nested loop queues WM_QUIT again, so the loop it was
+// started from ends as well.
+func (b *MemoryBackend) RunMessageLoop(running func() bool) os.Error {
+	b.loopDepth++
+	defer func() {
+		b.loopDepth--
+	}()
+
+	for running() {
+		msg, ok := b.nextMessage()
+		if !ok {
+			break
+		}
+
+		if msg.Message == WM_QUIT {
+			if b.loopDepth > 1 {
+				b.PostQuitMessage(int(msg.WParam))
+			}
+			return nil
+		}
+
+		if msg.HWnd == 0 || b.IsWindow(msg.HWnd) {
+			b.SendMessage(msg.HWnd, msg.Message, msg.WParam, msg.LParam)
+		}
+
+		if !b.hasMessages() {
+			onIdle()
+		}
+	}
+
+	return nil
+}
+
+// PostQuitMessage queues WM_QUIT, which makes RunMessageLoop return once it
+// gets to it.
+func (b *MemoryBackend) PostQuitMessage(exitCode int) {
+	b.PostMessage(0, WM_QUIT, uintptr(exitCode), 0)
+}
+
+// Click simulates the user clicking widget with the left mouse button.
+//
+// Auto check boxes and radio buttons update their check state the way the
+// real controls do, then the parent receives the BN_CLICKED notification.
+func (b *MemoryBackend) Click(widget IWidget) os.Error {
+	hWnd := widget.Handle()
+
+	w, err := b.window(hWnd)
+	if err != nil {
+		return err
+	}
+
+	if w.style&WS_DISABLED != 0 {
+		return nil
+	}
+
+	center := MAKELONG(uint16(w.bounds.Width/2), uint16(w.bounds.Height/2))
+
+	b.SendMessage(hWnd, WM_LBUTTONDOWN, 0, uintptr(center))
+	b.SendMessage(hWnd, WM_LBUTTONUP, 0, uintptr(center))
+
+	if w.className != "BUTTON" {
+		return nil
+	}
+
+	switch w.style & 0xF {
+	case BS_AUTOCHECKBOX:
+		w.checked = !w.checked
+
+	case BS_AUTORADIOBUTTON:
+		for _, sibling := range b.windows {
+			if sibling.parent == w.parent && sibling.className == "BUTTON" && sibling.style&0xF == BS_AUTORADIOBUTTON {
+				sibling.checked = false
+			}
+		}
+		w.checked = true
+	}
+
+	if w.parent != 0 {
+		b.SendMessage(w.parent, WM_COMMAND, uintptr(MAKELONG(0, BN_CLICKED)), uintptr(hWnd))
+	}
+
+	return nil
+}
+
+// KeyPress simulates the user pressing and releasing key while widget has
+// the keyboard focus.
+//
+// Like the Win32 message loop, Enter and Escape are handled by dialogKey,
+// unless widget wants them.
+func (b *MemoryBackend) KeyPress(widget IWidget, key Key) os.Error {
+	hWnd := widget.Handle()
+
+	if err := b.SetFocus(hWnd); err != nil {
+		return err
+	}
+
+	if b.dialogKey(hWnd, key) {
+		return nil
+	}
+
+	b.SendMessage(hWnd, WM_KEYDOWN, uintptr(key), 1)
+	b.SendMessage(hWnd, WM_KEYUP, uintptr(key), 1|3<<30)
+
+	return nil
+}
+
+// dialogKey emulates IsDialogMessage, which the Win32 message loop calls for
+// every message: Enter on a push button clicks it, otherwise Enter and Escape
+// are turned into the IDOK and IDCANCEL commands of the root window. Windows
+// that want the key, as reported by WM_GETDLGCODE, receive it instead. It
+// returns whether the key was handled.
+func (b *MemoryBackend) dialogKey(hWnd HWND, key Key) bool {
+	if key != KeyReturn && key != KeyEscape {
+		return false
+	}
+
+	if b.SendMessage(hWnd, WM_GETDLGCODE, uintptr(key), 0)&DLGC_WANTALLKEYS != 0 {
+		return false
+	}
+
+	w := b.windows[hWnd]
+
+	if key == KeyReturn && w.className == "BUTTON" && w.parent != 0 {
+		switch w.style & 0xF {
+		case BS_PUSHBUTTON, BS_DEFPUSHBUTTON:
+			b.SendMessage(w.parent, WM_COMMAND, uintptr(MAKELONG(0, BN_CLICKED)), uintptr(hWnd))
+			return true
+		}
+	}
+
+	root := hWnd
+	for b.windows[root].parent != 0 {
+		root = b.windows[root].parent
+	}
+
+	id := IDOK
+	if key == KeyEscape {
+		id = IDCANCEL
+	}
+
+	b.SendMessage(root, WM_COMMAND, uintptr(id), 0)
+
+	return true
+}
+
+// TypeText simulates the user typing text while widget has the keyboard
+// focus. Each character is sent as WM_CHAR, characters outside the Basic
+// Multilingual Plane as surrogate pairs.
+func (b *MemoryBackend) TypeText(widget IWidget, text string) os.Error {
+	hWnd := widget.Handle()
+
+	if err := b.SetFocus(hWnd); err != nil {
+		return err
+	}
+
+	for _, unit := range utf16.Encode([]int(text)) {
+		b.SendMessage(hWnd, WM_CHAR, uintptr(unit), 1)
+	}
+
+	return nil
+}
+
+// MouseMove simulates the user moving the mouse to point in the client area of
+// widget, with the buttons buttons held down.
+//
+// The move is sent to the window that has captured the mouse instead, if any.
+// If the mouse leaves a window that tracks it, that window receives
+// WM_MOUSELEAVE first.
+func (b *MemoryBackend) MouseMove(widget IWidget, point drawing.Point, buttons MouseButton) os.Error {
+	hWnd := widget.Handle()
+
+	if _, err := b.window(hWnd); err != nil {
+		return err
+	}
+
+	if b.hover != 0 && b.hover != hWnd && b.tracking[b.hover] {
+		b.tracking[b.hover] = false, false
+		b.SendMessage(b.hover, WM_MOUSELEAVE, 0, 0)
+	}
+	b.hover = hWnd
+
+	if b.capture != 0 && b.capture != hWnd {
+		// Capture receives coordinates relative to its own client area.
+		screen, _ := b.ClientToScreen(hWnd, point)
+		point, _ = b.ScreenToClient(b.capture, screen)
+		hWnd = b.capture
+	}
+
+	b.SendMessage(hWnd, WM_MOUSEMOVE, b.keyState(buttons), uintptr(MAKELONG(uint16(point.X), uint16(point.Y))))
+
+	return nil
+}
+
+// MouseUp simulates the user releasing button with the mouse at point in the
+// client area of widget. Like MouseMove, it is sent to the window that has
+// captured the mouse instead, if any.
+func (b *MemoryBackend) MouseUp(widget IWidget, point drawing.Point, button MouseButton) os.Error {
+	hWnd := widget.Handle()
+
+	if _, err := b.window(hWnd); err != nil {
+		return err
+	}
+
+	if b.capture != 0 && b.capture != hWnd {
+		screen, _ := b.ClientToScreen(hWnd, point)
+		point, _ = b.ScreenToClient(b.capture, screen)
+		hWnd = b.capture
+	}
+
+	var msg uint
+	switch button {
+	case LeftButton:
+		msg = WM_LBUTTONUP
+
+	case RightButton:
+		msg = WM_RBUTTONUP
+
+	case MiddleButton:
+		msg = WM_MBUTTONUP
+
+	default:
+		return newError("invalid button")
+	}
+
+	b.SendMessage(hWnd, msg, b.keyState(0), uintptr(MAKELONG(uint16(point.X), uint16(point.Y))))
+
+	return nil
+}
+
+func (b *MemoryBackend) keyState(buttons MouseButton) uintptr {
+	var keyState uintptr
+
+	if buttons&LeftButton != 0 {
+		keyState |= MK_LBUTTON
+	}
+	if buttons&RightButton != 0 {
+		keyState |= MK_RBUTTON
+	}
+	if buttons&MiddleButton != 0 {
+		keyState |= MK_MBUTTON
+	}
+	if b.modifiers&ModShift != 0 {
+		keyState |= MK_SHIFT
+	}
+	if b.modifiers&ModControl != 0 {
+		keyState |= MK_CONTROL
+	}
+
+	return keyState
+}
+
+// DropFiles simulates the user dropping the files paths from the shell at
+// point in the client area of widget. Nothing happens unless the widget
+// accepts dropped files.
+func (b *MemoryBackend) DropFiles(widget IWidget, paths []string, point drawing.Point) os.Error {
+	hWnd := widget.Handle()
+
+	w, err := b.window(hWnd)
+	if err != nil {
+		return err
+	}
+
+	if w.exStyle&WS_EX_ACCEPTFILES == 0 {
+		return nil
+	}
+
+	hDrop := b.nextHDrop
+	b.nextHDrop++
+
+	b.drops[hDrop] = &memoryDrop{paths, point}
+
+	b.SendMessage(hWnd, WM_DROPFILES, hDrop, 0)
+
+	// Like DragFinish, the handle is released whether it was used or not.
+	b.drops[hDrop] = nil, false
+
+	return nil
+}
+
+// SelectTab simulates the user clicking the tab at index of tabWidget. Like
+// the real tab control, it notifies the TabWidget with TCN_SELCHANGING, which
+// can prevent the change, and TCN_SELCHANGE.
+func (b *MemoryBackend) SelectTab(tabWidget *TabWidget, index int) os.Error {
+	w, err := b.window(tabWidget.hWndTab)
+	if err != nil {
+		return err
+	}
+
+	if index < 0 || index >= w.itemCount {
+		return newError("index out of range")
+	}
+
+	if index == w.curSel {
+		return nil
+	}
+
+	nmh := &NMHDR{HwndFrom: tabWidget.hWndTab, Code: TCN_SELCHANGING}
+	if b.SendMessage(w.parent, WM_NOTIFY, 0, uintptr(unsafe.Pointer(nmh))) != 0 {
+		return nil
+	}
+
+	w.curSel = index
+
+	nmh = &NMHDR{HwndFrom: tabWidget.hWndTab, Code: TCN_SELCHANGE}
+	b.SendMessage(w.parent, WM_NOTIFY, 0, uintptr(unsafe.Pointer(nmh)))
+
+	return nil
+}
+
+// Resize simulates the user resizing widget to size.
+func (b *MemoryBackend) Resize(widget IWidget, size drawing.Size) os.Error {
+	bounds, err := b.Bounds(widget.Handle())
+	if err != nil {
+		return err
+	}
+
+	return b.SetBounds(widget.Handle(), bounds.SetSize(size))
+}
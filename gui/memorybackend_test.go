@@ -0,0 +1,412 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"testing"
+	"unsafe"
+)
+
+import (
+	"walk/drawing"
+	. "walk/winapi"
+	. "walk/winapi/comctl32"
+	. "walk/winapi/user32"
+)
+
+// newTestMainWindow makes a new MemoryBackend the current backend and creates
+// a MainWindow on it.
+func newTestMainWindow(t *testing.T) (*MemoryBackend, *MainWindow) {
+	b := NewMemoryBackend()
+	SetBackend(b)
+
+	// The handles of the new backend start over.
+	widgetsByHWnd = make(map[HWND]widgetInternal)
+
+	mw, err := NewMainWindow()
+	if err != nil {
+		t.Fatalf("NewMainWindow failed: %s", err)
+	}
+
+	return b, mw
+}
+
+func newTestListView(t *testing.T) (*MemoryBackend, *ListView) {
+	b, mw := newTestMainWindow(t)
+
+	lv, err := NewListView(mw.ClientArea())
+	if err != nil {
+		t.Fatalf("NewListView failed: %s", err)
+	}
+
+	return b, lv
+}
+
+func newTestTreeView(t *testing.T) (*MemoryBackend, *TreeView) {
+	b, mw := newTestMainWindow(t)
+
+	tv, err := NewTreeView(mw.ClientArea())
+	if err != nil {
+		t.Fatalf("NewTreeView failed: %s", err)
+	}
+
+	return b, tv
+}
+
+func listViewItemCount(b *MemoryBackend, lv *ListView) int {
+	return int(b.SendMessage(lv.hWnd, LVM_GETITEMCOUNT, 0, 0))
+}
+
+func TestMemoryBackendListViewModel(t *testing.T) {
+	b, lv := newTestListView(t)
+
+	model := NewMemoryTableModel()
+	model.AddRows([][]interface{}{{"a"}, {"b"}, {"c"}})
+
+	if err := lv.SetModel(model); err != nil {
+		t.Fatalf("SetModel failed: %s", err)
+	}
+	if n := listViewItemCount(b, lv); n != 3 {
+		t.Errorf("item count after SetModel: expected 3, got %d", n)
+	}
+
+	model.AddRow([]interface{}{"d"})
+	if n := listViewItemCount(b, lv); n != 4 {
+		t.Errorf("item count after AddRow: expected 4, got %d", n)
+	}
+
+	if err := model.RemoveRows(0, 1); err != nil {
+		t.Fatalf("RemoveRows failed: %s", err)
+	}
+	if n := listViewItemCount(b, lv); n != 2 {
+		t.Errorf("item count after RemoveRows: expected 2, got %d", n)
+	}
+}
+
+func TestMemoryBackendListViewItems(t *testing.T) {
+	b, lv := newTestListView(t)
+
+	for i := 0; i < 2; i++ {
+		if _, err := lv.Items().Add(NewListViewItem()); err != nil {
+			t.Fatalf("Add failed: %s", err)
+		}
+	}
+
+	if n := listViewItemCount(b, lv); n != 2 {
+		t.Errorf("expected 2 items, got %d", n)
+	}
+}
+
+func TestMemoryBackendClickListViewItem(t *testing.T) {
+	b, lv := newTestListView(t)
+
+	model := NewMemoryTableModel()
+	model.AddRows([][]interface{}{{"a"}, {"b"}, {"c"}})
+	lv.SetModel(model)
+
+	if err := lv.SetCheckBoxes(true); err != nil {
+		t.Fatalf("SetCheckBoxes failed: %s", err)
+	}
+
+	if err := b.ClickListViewItem(lv, 1, false); err != nil {
+		t.Fatalf("ClickListViewItem failed: %s", err)
+	}
+	if i := lv.SelectedIndex(); i != 1 {
+		t.Errorf("expected row 1 to be selected, got %d", i)
+	}
+	if lv.Checked(1) {
+		t.Error("clicking the label must not check the row")
+	}
+
+	b.ClickListViewItem(lv, 2, true)
+	if i := lv.SelectedIndex(); i != 2 {
+		t.Errorf("expected row 2 to be selected, got %d", i)
+	}
+	if !lv.Checked(2) {
+		t.Error("clicking the check box must check the row")
+	}
+
+	if err := b.ClickListViewItem(lv, 3, false); err == nil {
+		t.Error("expected an error for a row out of range")
+	}
+}
+
+func TestMemoryBackendListViewColumns(t *testing.T) {
+	b, lv := newTestListView(t)
+
+	for _, title := range []string{"Name", "Size"} {
+		col := NewListViewColumn()
+		if _, err := lv.Columns().Add(col); err != nil {
+			t.Fatalf("Add failed: %s", err)
+		}
+		col.SetTitle(title)
+		col.SetWidth(80)
+	}
+
+	w := b.windows[lv.hWnd].listView
+	if len(w.columns) != 2 || w.columns[0].text != "Name" || w.columns[1].text != "Size" {
+		t.Fatalf("unexpected columns %v", w.columns)
+	}
+	if width := b.SendMessage(lv.hWnd, LVM_GETCOLUMNWIDTH, 1, 0); width != 80 {
+		t.Errorf("expected a width of 80, got %d", width)
+	}
+
+	hHeader := HWND(b.SendMessage(lv.hWnd, LVM_GETHEADER, 0, 0))
+	hdi := HDITEM{Mask: HDI_FORMAT, Fmt: HDF_SORTUP}
+	if FALSE == b.SendMessage(hHeader, HDM_SETITEM, 0, uintptr(unsafe.Pointer(&hdi))) {
+		t.Fatal("HDM_SETITEM failed")
+	}
+	if w.columns[0].fmt != HDF_SORTUP {
+		t.Errorf("expected the header to change the format of the column")
+	}
+}
+
+func TestMemoryBackendTreeViewInsert(t *testing.T) {
+	b, tv := newTestTreeView(t)
+
+	a, bb, c := NewTreeViewItem(), NewTreeViewItem(), NewTreeViewItem()
+	a.SetText("a")
+	bb.SetText("b")
+	c.SetText("c")
+
+	tv.Items().Add(a)
+	tv.Items().Add(bb)
+	a.Children().Add(c)
+
+	next := func(flag uintptr, h HTREEITEM) HTREEITEM {
+		return HTREEITEM(b.SendMessage(tv.hWnd, TVM_GETNEXTITEM, flag, uintptr(h)))
+	}
+
+	if h := next(TVGN_ROOT, 0); h != a.handle {
+		t.Errorf("expected a to be the first item, got %d", h)
+	}
+	if h := next(TVGN_NEXT, a.handle); h != bb.handle {
+		t.Errorf("expected b to follow a, got %d", h)
+	}
+	if h := next(TVGN_CHILD, a.handle); h != c.handle {
+		t.Errorf("expected c to be the child of a, got %d", h)
+	}
+	if h := next(TVGN_PARENT, c.handle); h != a.handle {
+		t.Errorf("expected a to be the parent of c, got %d", h)
+	}
+	if text, _ := b.TreeItemText(tv, c.handle); text != "c" {
+		t.Errorf("expected text c, got %q", text)
+	}
+	if n := b.SendMessage(tv.hWnd, TVM_GETCOUNT, 0, 0); n != 3 {
+		t.Errorf("expected 3 items, got %d", n)
+	}
+
+	b.SendMessage(tv.hWnd, TVM_DELETEITEM, 0, uintptr(a.handle))
+	if n := b.SendMessage(tv.hWnd, TVM_GETCOUNT, 0, 0); n != 1 {
+		t.Errorf("expected 1 item after deleting a, got %d", n)
+	}
+}
+
+func TestMemoryBackendToggleTreeItem(t *testing.T) {
+	b, tv := newTestTreeView(t)
+
+	a, c := NewTreeViewItem(), NewTreeViewItem()
+	tv.Items().Add(a)
+	a.Children().Add(c)
+
+	var events []string
+	record := func(name string) TreeViewItemEventHandler {
+		return func(args TreeViewItemEventArgs) {
+			if args.Item() != a {
+				t.Errorf("%s: unexpected item %p", name, args.Item())
+			}
+			events = append(events, name)
+		}
+	}
+	tv.ItemExpanding().Attach(record("expanding"))
+	tv.ItemExpanded().Attach(record("expanded"))
+	tv.ItemCollapsing().Attach(record("collapsing"))
+	tv.ItemCollapsed().Attach(record("collapsed"))
+
+	b.ToggleTreeItem(tv, a.handle)
+	b.ToggleTreeItem(tv, a.handle)
+
+	expected := []string{"expanding", "expanded", "collapsing", "collapsed"}
+	if len(events) != len(expected) {
+		t.Fatalf("expected events %v, got %v", expected, events)
+	}
+	for i, e := range expected {
+		if events[i] != e {
+			t.Errorf("expected events %v, got %v", expected, events)
+			break
+		}
+	}
+
+	// c has no children, so it has no button to click.
+	events = nil
+	b.ToggleTreeItem(tv, c.handle)
+	if len(events) != 0 {
+		t.Errorf("expected no events for an item without children, got %v", events)
+	}
+}
+
+func TestMemoryBackendToolTip(t *testing.T) {
+	b, mw := newTestMainWindow(t)
+
+	hWnd, err := b.CreateWindow(WS_EX_TOPMOST, "tooltips_class32", TTS_BALLOON|WS_POPUP, mw.hWnd, drawing.Rectangle{})
+	if err != nil {
+		t.Fatalf("CreateWindow failed: %s", err)
+	}
+	tt := &ToolTip{Widget: Widget{hWnd: hWnd}}
+
+	label, err := NewLabel(mw.ClientArea())
+	if err != nil {
+		t.Fatalf("NewLabel failed: %s", err)
+	}
+
+	if err := tt.AddWidget(label, "tip"); err != nil {
+		t.Fatalf("AddWidget failed: %s", err)
+	}
+	if err := tt.AddWidget(label, "other tip"); err != nil {
+		t.Fatalf("AddWidget failed: %s", err)
+	}
+
+	tools := b.windows[hWnd].toolTip.tools
+	if len(tools) != 1 || tools[0].text != "other tip" {
+		t.Errorf("expected a single tool with the second text, got %v", tools)
+	}
+
+	if err := tt.SetTitle("Title"); err != nil {
+		t.Fatalf("SetTitle failed: %s", err)
+	}
+	if title := tt.Title(); title != "Title" {
+		t.Errorf("expected title Title, got %q", title)
+	}
+}
+
+func TestMemoryBackendMenu(t *testing.T) {
+	b, mw := newTestMainWindow(t)
+
+	open := NewAction()
+	open.SetText("&Open")
+	if _, err := mw.Menu().Actions().Add(open); err != nil {
+		t.Fatalf("Add failed: %s", err)
+	}
+
+	m := b.menus[mw.Menu().hMenu]
+	if len(m.items) != 1 || m.items[0].text != "&Open" {
+		t.Fatalf("unexpected menu items %v", m.items)
+	}
+
+	open.SetText("&Open...")
+	if m.items[0].text != "&Open..." {
+		t.Errorf("expected the item text to follow the action, got %q", m.items[0].text)
+	}
+
+	mw.Menu().Actions().Remove(open)
+	if len(m.items) != 0 {
+		t.Errorf("expected the item to be removed, got %v", m.items)
+	}
+}
+
+func TestMainWindowSaveRestoreState(t *testing.T) {
+	b, mw := newTestMainWindow(t)
+
+	bounds := drawing.Rectangle{10, 20, 300, 200}
+	if err := mw.SetBounds(bounds); err != nil {
+		t.Fatalf("SetBounds failed: %s", err)
+	}
+	if err := b.ShowWindow(mw.hWnd, SW_SHOWMAXIMIZED); err != nil {
+		t.Fatalf("ShowWindow failed: %s", err)
+	}
+
+	state, err := mw.SaveState()
+	if err != nil {
+		t.Fatalf("SaveState failed: %s", err)
+	}
+
+	_, other := newTestMainWindow(t)
+
+	if err := other.RestoreState(state); err != nil {
+		t.Fatalf("RestoreState failed: %s", err)
+	}
+	if restored, _ := other.Bounds(); restored != bounds {
+		t.Errorf("expected bounds %v, got %v", bounds, restored)
+	}
+	if visible, _ := other.Visible(); !visible {
+		t.Error("expected the window to be shown")
+	}
+	if restored, _ := other.SaveState(); restored != state {
+		t.Errorf("expected state %q, got %q", state, restored)
+	}
+
+	for _, s := range []string{"", "1 2 3", "a b c d e f g h i j", "0 1 0 0 0 0 10 10 0 0"} {
+		if err := other.RestoreState(s); err == nil {
+			t.Errorf("expected RestoreState(%q) to fail", s)
+		}
+	}
+}
+
+func TestMemoryBackendImageList(t *testing.T) {
+	b, _ := newTestMainWindow(t)
+
+	il, err := NewImageList(drawing.Size{16, 16}, 0)
+	if err != nil {
+		t.Fatalf("NewImageList failed: %s", err)
+	}
+
+	// The backend only keeps the images, so zero bitmaps will do.
+	first, second := new(drawing.Bitmap), new(drawing.Bitmap)
+
+	if index, err := il.Add(first, nil); err != nil || index != 0 {
+		t.Errorf("expected index 0, got %d, %v", index, err)
+	}
+	if index, err := il.AddMasked(second); err != nil || index != 1 {
+		t.Errorf("expected index 1, got %d, %v", index, err)
+	}
+	if _, err := il.Add(nil, nil); err == nil {
+		t.Error("expected Add(nil) to fail")
+	}
+
+	images := b.imageLists[il.hIml].images
+	if len(images) != 2 || images[0] != first || images[1] != second {
+		t.Errorf("unexpected images %v", images)
+	}
+
+	hIml := il.hIml
+	il.Dispose()
+	if _, ok := b.imageLists[hIml]; ok {
+		t.Error("expected Dispose to destroy the image list")
+	}
+}
+
+func TestMsgBox(t *testing.T) {
+	b, mw := newTestMainWindow(t)
+
+	if ret := MsgBox(mw, "Title", "Message", MsgBoxOK); ret != 0 {
+		t.Errorf("expected 0 without a handler, got %d", ret)
+	}
+
+	var title, message string
+	var style MsgBoxStyle
+	b.SetMessageBoxHandler(func(t, m string, s MsgBoxStyle) DialogCommandId {
+		title, message, style = t, m, s
+		return DlgCmdNo
+	})
+
+	if ret := MsgBox(mw, "Title", "Save?", MsgBoxYesNo|MsgBoxIconQuestion); ret != DlgCmdNo {
+		t.Errorf("expected DlgCmdNo, got %d", ret)
+	}
+	if title != "Title" || message != "Save?" || style != MsgBoxYesNo|MsgBoxIconQuestion {
+		t.Errorf("unexpected message box %q, %q, %x", title, message, style)
+	}
+}
+
+func TestExit(t *testing.T) {
+	b := NewMemoryBackend()
+	SetBackend(b)
+
+	Exit(3)
+
+	msg, ok := b.nextMessage()
+	if !ok || msg.Message != WM_QUIT || msg.WParam != 3 {
+		t.Errorf("expected WM_QUIT with exit code 3, got %v", msg)
+	}
+}
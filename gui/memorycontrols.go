@@ -0,0 +1,836 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"os"
+	"unsafe"
+)
+
+import (
+	"walk/drawing"
+	. "walk/winapi"
+	. "walk/winapi/comctl32"
+	. "walk/winapi/gdi32"
+	. "walk/winapi/user32"
+)
+
+// memoryListViewRowHeight is the height of the rows of list views, starting at
+// the top of the client area. LVM_HITTEST and ClickListViewItem rely on it.
+const memoryListViewRowHeight = 16
+
+type memoryColumn struct {
+	text  string
+	width int
+	fmt   int
+}
+
+// memoryListView holds the state of a SysListView32 window. Only virtual lists
+// are supported, so the items are nothing more than a count and their states.
+type memoryListView struct {
+	exStyle uint
+	states  map[int]uint
+	columns []*memoryColumn
+	header  HWND
+}
+
+type memoryTreeItem struct {
+	parent    HTREEITEM
+	children  []HTREEITEM
+	text      string
+	lParam    uintptr
+	cChildren int
+	state     uint
+}
+
+// memoryTreeView holds the items of a SysTreeView32 window. TVI_ROOT is stored
+// as an item as well, its children are the top level items.
+type memoryTreeView struct {
+	items     map[HTREEITEM]*memoryTreeItem
+	nextHItem HTREEITEM
+}
+
+type memoryTool struct {
+	hWnd  HWND
+	id    uintptr
+	flags uint
+	text  string
+}
+
+// memoryToolTip holds the tools of a tooltips_class32 window and the state of
+// tracking, which is how balloons are shown.
+type memoryToolTip struct {
+	tools    []*memoryTool
+	title    string
+	icon     uint
+	trackPos drawing.Point
+	tracked  *memoryTool
+}
+
+// initControl sets up the state of the common controls emulated by
+// DefWindowProc for the window w of class className.
+func (w *memoryWindow) initControl() {
+	switch w.className {
+	case "SysListView32":
+		w.listView = &memoryListView{states: make(map[int]uint)}
+
+	case "SysTreeView32":
+		w.treeView = &memoryTreeView{
+			items:     map[HTREEITEM]*memoryTreeItem{TVI_ROOT: new(memoryTreeItem)},
+			nextHItem: 1,
+		}
+
+	case "tooltips_class32":
+		w.toolTip = new(memoryToolTip)
+	}
+}
+
+// controlProc handles the messages of the emulated common controls. It
+// returns false if msg is not one of them.
+func (b *MemoryBackend) controlProc(w *memoryWindow, msg *MSG) (uintptr, bool) {
+	switch {
+	case w.listView != nil:
+		return b.listViewProc(w, msg)
+
+	case w.treeView != nil:
+		return b.treeViewProc(w, msg)
+
+	case w.toolTip != nil:
+		return w.toolTip.proc(msg)
+
+	case w.className == "SysHeader32":
+		return b.headerProc(w, msg)
+
+	case w.className == "EDIT":
+		return b.editProc(w, msg)
+	}
+
+	return 0, false
+}
+
+// editProc lets EDIT windows insert typed characters at the end of their text
+// and delete the last character on backspace. Like the real control, they
+// notify their parent with EN_CHANGE.
+func (b *MemoryBackend) editProc(w *memoryWindow, msg *MSG) (uintptr, bool) {
+	if msg.Message != WM_CHAR {
+		return 0, false
+	}
+
+	unit := uint16(msg.WParam)
+
+	switch {
+	case unit == VK_BACK:
+		runes := []int(w.text)
+		if len(runes) == 0 {
+			return 0, true
+		}
+		w.text = string(runes[:len(runes)-1])
+
+	case unit >= 0xD800 && unit < 0xDC00:
+		// The low surrogate follows in the next WM_CHAR.
+		w.surrogate = unit
+		return 0, true
+
+	case unit >= 0xDC00 && unit < 0xE000:
+		if w.surrogate == 0 {
+			return 0, true
+		}
+		w.text += string(0x10000 + (int(w.surrogate)-0xD800)<<10 + int(unit) - 0xDC00)
+		w.surrogate = 0
+
+	case unit < 0x20:
+		return 0, true
+
+	default:
+		w.text += string(int(unit))
+	}
+
+	b.SendMessage(w.parent, WM_COMMAND, uintptr(MAKELONG(0, EN_CHANGE)), uintptr(msg.HWnd))
+
+	return 0, true
+}
+
+func (b *MemoryBackend) listViewProc(w *memoryWindow, msg *MSG) (uintptr, bool) {
+	lv := w.listView
+
+	switch msg.Message {
+	case LVM_GETEXTENDEDLISTVIEWSTYLE:
+		return uintptr(lv.exStyle), true
+
+	case LVM_SETEXTENDEDLISTVIEWSTYLE:
+		mask := uint(msg.WParam)
+		if mask == 0 {
+			mask = ^uint(0)
+		}
+		prev := lv.exStyle
+		lv.exStyle = lv.exStyle&^mask | uint(msg.LParam)&mask
+		return uintptr(prev), true
+
+	case LVM_SETITEMCOUNT:
+		w.itemCount = int(msg.WParam)
+		for index := range lv.states {
+			if index >= w.itemCount {
+				lv.states[index] = 0, false
+			}
+		}
+		return TRUE, true
+
+	case LVM_GETITEMCOUNT:
+		return uintptr(w.itemCount), true
+
+	case LVM_SETITEMSTATE:
+		lvi := (*LVITEM)(unsafe.Pointer(msg.LParam))
+		index := int(msg.WParam)
+		if index == -1 {
+			b.setAllListViewItemStates(msg.HWnd, w, lvi.State, lvi.StateMask)
+			return TRUE, true
+		}
+		if index < 0 || index >= w.itemCount {
+			return FALSE, true
+		}
+		b.setListViewItemState(msg.HWnd, w, index, lvi.State, lvi.StateMask)
+		return TRUE, true
+
+	case LVM_GETITEMSTATE:
+		return uintptr(lv.states[int(msg.WParam)] & uint(msg.LParam)), true
+
+	case LVM_REDRAWITEMS:
+		w.invalid = true
+		return TRUE, true
+
+	case LVM_HITTEST:
+		hti := (*LVHITTESTINFO)(unsafe.Pointer(msg.LParam))
+		hti.IItem = -1
+		hti.Flags = LVHT_NOWHERE
+		if hti.Pt.X >= 0 && hti.Pt.Y >= 0 {
+			if row := hti.Pt.Y / memoryListViewRowHeight; row < w.itemCount {
+				hti.IItem = row
+				if lv.exStyle&LVS_EX_CHECKBOXES != 0 && hti.Pt.X < memoryListViewRowHeight {
+					hti.Flags = LVHT_ONITEMSTATEICON
+				} else {
+					hti.Flags = LVHT_ONITEMLABEL
+				}
+			}
+		}
+		return uintptr(hti.IItem), true
+
+	case LVM_INSERTCOLUMN:
+		index := int(msg.WParam)
+		if index < 0 {
+			return ^uintptr(0), true
+		}
+		if index > len(lv.columns) {
+			index = len(lv.columns)
+		}
+		col := new(memoryColumn)
+		setMemoryColumn(col, (*LVCOLUMN)(unsafe.Pointer(msg.LParam)))
+		lv.columns = append(lv.columns, nil)
+		copy(lv.columns[index+1:], lv.columns[index:])
+		lv.columns[index] = col
+		return uintptr(index), true
+
+	case LVM_SETCOLUMN:
+		col := lv.column(int(msg.WParam))
+		if col == nil {
+			return FALSE, true
+		}
+		setMemoryColumn(col, (*LVCOLUMN)(unsafe.Pointer(msg.LParam)))
+		return TRUE, true
+
+	case LVM_DELETECOLUMN:
+		index := int(msg.WParam)
+		if lv.column(index) == nil {
+			return FALSE, true
+		}
+		lv.columns = append(lv.columns[:index], lv.columns[index+1:]...)
+		return TRUE, true
+
+	case LVM_GETCOLUMNWIDTH:
+		if col := lv.column(int(msg.WParam)); col != nil {
+			return uintptr(col.width), true
+		}
+		return 0, true
+
+	case LVM_SETCOLUMNWIDTH:
+		col := lv.column(int(msg.WParam))
+		if col == nil {
+			return FALSE, true
+		}
+		col.width = int(msg.LParam)
+		return TRUE, true
+
+	case LVM_GETHEADER:
+		if lv.header == 0 {
+			lv.header, _ = b.CreateWindow(0, "SysHeader32", WS_CHILD|WS_VISIBLE, msg.HWnd, drawing.Rectangle{})
+		}
+		return uintptr(lv.header), true
+
+	case WM_SETREDRAW:
+		return 0, true
+	}
+
+	return 0, false
+}
+
+func (lv *memoryListView) column(index int) *memoryColumn {
+	if index < 0 || index >= len(lv.columns) {
+		return nil
+	}
+
+	return lv.columns[index]
+}
+
+func setMemoryColumn(col *memoryColumn, lvc *LVCOLUMN) {
+	if lvc.Mask&LVCF_FMT != 0 {
+		col.fmt = lvc.Fmt
+	}
+	if lvc.Mask&LVCF_WIDTH != 0 {
+		col.width = lvc.Cx
+	}
+	if lvc.Mask&LVCF_TEXT != 0 {
+		col.text = ""
+		if lvc.PszText != nil {
+			col.text = UTF16PtrToString(lvc.PszText)
+		}
+	}
+}
+
+// setListViewItemState changes the state bits selected by mask of the item at
+// index and notifies the parent with LVN_ITEMCHANGED if that changed anything.
+// Like the real control, selecting an item of a LVS_SINGLESEL list view
+// deselects the other items first.
+func (b *MemoryBackend) setListViewItemState(hWnd HWND, w *memoryWindow, index int, state, mask uint) {
+	lv := w.listView
+
+	if w.style&LVS_SINGLESEL != 0 && mask&state&LVIS_SELECTED != 0 {
+		for i, s := range lv.states {
+			if i != index && s&LVIS_SELECTED != 0 {
+				b.setListViewItemState(hWnd, w, i, 0, LVIS_SELECTED)
+			}
+		}
+	}
+
+	old := lv.states[index]
+	newState := old&^mask | state&mask
+	if newState == old {
+		return
+	}
+
+	if newState == 0 {
+		lv.states[index] = 0, false
+	} else {
+		lv.states[index] = newState
+	}
+
+	b.notifyListViewItemChanged(hWnd, w, index, old, newState)
+}
+
+// setAllListViewItemStates handles LVM_SETITEMSTATE for index -1. Like the
+// real control, it sends a single LVN_ITEMCHANGED for item -1.
+func (b *MemoryBackend) setAllListViewItemStates(hWnd HWND, w *memoryWindow, state, mask uint) {
+	lv := w.listView
+
+	changed := false
+	for i := 0; i < w.itemCount; i++ {
+		old := lv.states[i]
+		newState := old&^mask | state&mask
+		if newState == old {
+			continue
+		}
+
+		if newState == 0 {
+			lv.states[i] = 0, false
+		} else {
+			lv.states[i] = newState
+		}
+		changed = true
+	}
+
+	if changed {
+		b.notifyListViewItemChanged(hWnd, w, -1, ^state&mask, state&mask)
+	}
+}
+
+func (b *MemoryBackend) notifyListViewItemChanged(hWnd HWND, w *memoryWindow, index int, oldState, newState uint) {
+	nmlv := &NMLISTVIEW{
+		Hdr:       NMHDR{HwndFrom: hWnd, Code: LVN_ITEMCHANGED},
+		IItem:     index,
+		UOldState: oldState,
+		UNewState: newState,
+		UChanged:  LVIF_STATE,
+	}
+
+	b.SendMessage(w.parent, WM_NOTIFY, 0, uintptr(unsafe.Pointer(nmlv)))
+}
+
+// headerProc handles the messages of the header of a list view. The header
+// items are the columns of the list view.
+func (b *MemoryBackend) headerProc(w *memoryWindow, msg *MSG) (uintptr, bool) {
+	switch msg.Message {
+	case HDM_GETITEM, HDM_SETITEM:
+		lvw, ok := b.windows[w.parent]
+		if !ok || lvw.listView == nil {
+			return FALSE, true
+		}
+
+		col := lvw.listView.column(int(msg.WParam))
+		if col == nil {
+			return FALSE, true
+		}
+
+		hdi := (*HDITEM)(unsafe.Pointer(msg.LParam))
+		if msg.Message == HDM_GETITEM {
+			if hdi.Mask&HDI_FORMAT != 0 {
+				hdi.Fmt = col.fmt
+			}
+			if hdi.Mask&HDI_WIDTH != 0 {
+				hdi.Cxy = col.width
+			}
+		} else {
+			if hdi.Mask&HDI_FORMAT != 0 {
+				col.fmt = hdi.Fmt
+			}
+			if hdi.Mask&HDI_WIDTH != 0 {
+				col.width = hdi.Cxy
+			}
+		}
+		return TRUE, true
+	}
+
+	return 0, false
+}
+
+// ClickListViewItem simulates the user clicking the row at index of listView,
+// on its check box if onCheckBox is true.
+//
+// Like the real control, the row becomes the only selected one before
+// listView is notified with NM_CLICK.
+func (b *MemoryBackend) ClickListViewItem(listView *ListView, index int, onCheckBox bool) os.Error {
+	hWnd := listView.hWnd
+
+	w, err := b.window(hWnd)
+	if err != nil {
+		return err
+	}
+
+	if index < 0 || index >= w.itemCount {
+		return newError("index out of range")
+	}
+
+	if w.style&WS_DISABLED != 0 {
+		return nil
+	}
+
+	b.SetFocus(hWnd)
+
+	b.setAllListViewItemStates(hWnd, w, 0, LVIS_SELECTED|LVIS_FOCUSED)
+	b.setListViewItemState(hWnd, w, index, LVIS_SELECTED|LVIS_FOCUSED, LVIS_SELECTED|LVIS_FOCUSED)
+
+	x := 2 * memoryListViewRowHeight
+	if onCheckBox {
+		x = memoryListViewRowHeight / 2
+	}
+
+	nmia := &NMITEMACTIVATE{
+		Hdr:      NMHDR{HwndFrom: hWnd, Code: NM_CLICK},
+		IItem:    index,
+		PtAction: POINT{x, index*memoryListViewRowHeight + memoryListViewRowHeight/2},
+	}
+	b.SendMessage(w.parent, WM_NOTIFY, 0, uintptr(unsafe.Pointer(nmia)))
+
+	return nil
+}
+
+// ClickListViewColumn simulates the user clicking the header of the column at
+// index of listView.
+func (b *MemoryBackend) ClickListViewColumn(listView *ListView, index int) os.Error {
+	hWnd := listView.hWnd
+
+	w, err := b.window(hWnd)
+	if err != nil {
+		return err
+	}
+
+	if w.listView == nil {
+		return newError("not a list view")
+	}
+	if w.listView.column(index) == nil {
+		return newError("index out of range")
+	}
+
+	if w.style&WS_DISABLED != 0 {
+		return nil
+	}
+
+	nmlv := &NMLISTVIEW{
+		Hdr:      NMHDR{HwndFrom: hWnd, Code: LVN_COLUMNCLICK},
+		IItem:    -1,
+		ISubItem: index,
+	}
+	b.SendMessage(w.parent, WM_NOTIFY, 0, uintptr(unsafe.Pointer(nmlv)))
+
+	return nil
+}
+
+func (b *MemoryBackend) treeViewProc(w *memoryWindow, msg *MSG) (uintptr, bool) {
+	tv := w.treeView
+
+	switch msg.Message {
+	case TVM_INSERTITEM:
+		tvins := (*TVINSERTSTRUCT)(unsafe.Pointer(msg.LParam))
+
+		hParent := tvins.HParent
+		if hParent == 0 {
+			hParent = TVI_ROOT
+		}
+		parent, ok := tv.items[hParent]
+		if !ok {
+			return 0, true
+		}
+
+		var index int
+		switch tvins.HInsertAfter {
+		case TVI_FIRST:
+			index = 0
+
+		case TVI_LAST, TVI_SORT:
+			index = len(parent.children)
+
+		default:
+			index = indexOfHTREEITEM(parent.children, tvins.HInsertAfter) + 1
+			if index == 0 {
+				return 0, true
+			}
+		}
+
+		hItem := tv.nextHItem
+		tv.nextHItem++
+
+		item := &memoryTreeItem{parent: hParent}
+		setMemoryTreeItem(item, &tvins.Item)
+		tv.items[hItem] = item
+
+		parent.children = append(parent.children, 0)
+		copy(parent.children[index+1:], parent.children[index:])
+		parent.children[index] = hItem
+
+		return uintptr(hItem), true
+
+	case TVM_DELETEITEM:
+		hItem := HTREEITEM(msg.LParam)
+		if hItem == 0 || hItem == TVI_ROOT {
+			root := tv.items[TVI_ROOT]
+			for _, child := range root.children {
+				tv.deleteSubtree(child)
+			}
+			root.children = nil
+			return TRUE, true
+		}
+
+		item, ok := tv.items[hItem]
+		if !ok {
+			return FALSE, true
+		}
+
+		parent := tv.items[item.parent]
+		i := indexOfHTREEITEM(parent.children, hItem)
+		parent.children = append(parent.children[:i], parent.children[i+1:]...)
+		tv.deleteSubtree(hItem)
+
+		return TRUE, true
+
+	case TVM_GETNEXTITEM:
+		hItem := HTREEITEM(msg.LParam)
+
+		switch msg.WParam {
+		case TVGN_ROOT:
+			return uintptr(tv.child(TVI_ROOT)), true
+
+		case TVGN_CHILD:
+			if hItem == 0 {
+				hItem = TVI_ROOT
+			}
+			return uintptr(tv.child(hItem)), true
+
+		case TVGN_NEXT:
+			return uintptr(tv.sibling(hItem, 1)), true
+
+		case TVGN_PREVIOUS:
+			return uintptr(tv.sibling(hItem, -1)), true
+
+		case TVGN_PARENT:
+			if item, ok := tv.items[hItem]; ok && hItem != TVI_ROOT && item.parent != TVI_ROOT {
+				return uintptr(item.parent), true
+			}
+		}
+		return 0, true
+
+	case TVM_GETCOUNT:
+		return uintptr(len(tv.items) - 1), true
+
+	case TVM_SETITEM:
+		tvi := (*TVITEM)(unsafe.Pointer(msg.LParam))
+		item, ok := tv.items[tvi.HItem]
+		if !ok || tvi.HItem == TVI_ROOT {
+			return FALSE, true
+		}
+		setMemoryTreeItem(item, tvi)
+		return TRUE, true
+
+	case TVM_GETITEM:
+		tvi := (*TVITEM)(unsafe.Pointer(msg.LParam))
+		item, ok := tv.items[tvi.HItem]
+		if !ok || tvi.HItem == TVI_ROOT {
+			return FALSE, true
+		}
+		if tvi.Mask&TVIF_TEXT != 0 && tvi.PszText != nil && tvi.CchTextMax > 0 {
+			copyToUTF16Buffer(item.text, tvi.PszText, tvi.CchTextMax)
+		}
+		if tvi.Mask&TVIF_PARAM != 0 {
+			tvi.LParam = item.lParam
+		}
+		if tvi.Mask&TVIF_CHILDREN != 0 {
+			tvi.CChildren = item.cChildren
+		}
+		if tvi.Mask&TVIF_STATE != 0 {
+			tvi.State = item.state & tvi.StateMask
+		}
+		return TRUE, true
+
+	case TVM_EXPAND:
+		// Like the real control, TVM_EXPAND does not send notifications.
+		item, ok := tv.items[HTREEITEM(msg.LParam)]
+		if !ok {
+			return FALSE, true
+		}
+		switch msg.WParam & TVE_TOGGLE {
+		case TVE_EXPAND:
+			item.state |= TVIS_EXPANDED
+
+		case TVE_COLLAPSE:
+			item.state &^= TVIS_EXPANDED
+
+		case TVE_TOGGLE:
+			item.state ^= TVIS_EXPANDED
+		}
+		return TRUE, true
+	}
+
+	return 0, false
+}
+
+func setMemoryTreeItem(item *memoryTreeItem, tvi *TVITEM) {
+	if tvi.Mask&TVIF_TEXT != 0 {
+		item.text = ""
+		if tvi.PszText != nil {
+			item.text = UTF16PtrToString(tvi.PszText)
+		}
+	}
+	if tvi.Mask&TVIF_PARAM != 0 {
+		item.lParam = tvi.LParam
+	}
+	if tvi.Mask&TVIF_CHILDREN != 0 {
+		item.cChildren = tvi.CChildren
+	}
+	if tvi.Mask&TVIF_STATE != 0 {
+		item.state = item.state&^tvi.StateMask | tvi.State&tvi.StateMask
+	}
+}
+
+func (tv *memoryTreeView) deleteSubtree(hItem HTREEITEM) {
+	for _, child := range tv.items[hItem].children {
+		tv.deleteSubtree(child)
+	}
+
+	tv.items[hItem] = nil, false
+}
+
+func (tv *memoryTreeView) child(hItem HTREEITEM) HTREEITEM {
+	if item, ok := tv.items[hItem]; ok && len(item.children) > 0 {
+		return item.children[0]
+	}
+
+	return 0
+}
+
+func (tv *memoryTreeView) sibling(hItem HTREEITEM, offset int) HTREEITEM {
+	item, ok := tv.items[hItem]
+	if !ok || hItem == TVI_ROOT {
+		return 0
+	}
+
+	siblings := tv.items[item.parent].children
+	i := indexOfHTREEITEM(siblings, hItem) + offset
+	if i < 0 || i >= len(siblings) {
+		return 0
+	}
+
+	return siblings[i]
+}
+
+func indexOfHTREEITEM(handles []HTREEITEM, hItem HTREEITEM) int {
+	for i, h := range handles {
+		if h == hItem {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// TreeItemText returns the text of the item hItem of treeView.
+func (b *MemoryBackend) TreeItemText(treeView *TreeView, hItem HTREEITEM) (string, os.Error) {
+	w, err := b.window(treeView.hWnd)
+	if err != nil {
+		return "", err
+	}
+
+	item, ok := w.treeView.items[hItem]
+	if !ok || hItem == TVI_ROOT {
+		return "", newError("invalid item handle")
+	}
+
+	return item.text, nil
+}
+
+// ToggleTreeItem simulates the user clicking the button of the item hItem of
+// treeView, to expand or collapse it.
+//
+// Like the real control, it notifies treeView with TVN_ITEMEXPANDING, which
+// can prevent the change, and TVN_ITEMEXPANDED. Items without children and a
+// children count of 0 have no button.
+func (b *MemoryBackend) ToggleTreeItem(treeView *TreeView, hItem HTREEITEM) os.Error {
+	hWnd := treeView.hWnd
+
+	w, err := b.window(hWnd)
+	if err != nil {
+		return err
+	}
+
+	item, ok := w.treeView.items[hItem]
+	if !ok || hItem == TVI_ROOT {
+		return newError("invalid item handle")
+	}
+
+	if len(item.children) == 0 && item.cChildren == 0 {
+		return nil
+	}
+
+	var action uint = TVE_EXPAND
+	if item.state&TVIS_EXPANDED != 0 {
+		action = TVE_COLLAPSE
+	}
+
+	nmtv := &NMTREEVIEW{
+		Hdr:    NMHDR{HwndFrom: hWnd, Code: TVN_ITEMEXPANDING},
+		Action: action,
+		ItemNew: TVITEM{
+			Mask:   TVIF_HANDLE | TVIF_PARAM | TVIF_STATE,
+			HItem:  hItem,
+			State:  item.state,
+			LParam: item.lParam,
+		},
+	}
+	if b.SendMessage(w.parent, WM_NOTIFY, 0, uintptr(unsafe.Pointer(nmtv))) != 0 {
+		return nil
+	}
+
+	// The handlers may have removed the item.
+	if _, ok := w.treeView.items[hItem]; !ok {
+		return nil
+	}
+
+	item.state ^= TVIS_EXPANDED
+
+	nmtv.Hdr.Code = TVN_ITEMEXPANDED
+	nmtv.ItemNew.State = item.state
+	b.SendMessage(w.parent, WM_NOTIFY, 0, uintptr(unsafe.Pointer(nmtv)))
+
+	return nil
+}
+
+func (tt *memoryToolTip) indexOf(ti *TOOLINFO) int {
+	for i, tool := range tt.tools {
+		if tool.hWnd == ti.Hwnd && tool.id == ti.UId {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (tt *memoryToolTip) proc(msg *MSG) (uintptr, bool) {
+	switch msg.Message {
+	case TTM_ADDTOOL:
+		ti := (*TOOLINFO)(unsafe.Pointer(msg.LParam))
+		tool := &memoryTool{hWnd: ti.Hwnd, id: ti.UId, flags: ti.UFlags}
+		if ti.LpszText != nil {
+			tool.text = UTF16PtrToString(ti.LpszText)
+		}
+		if i := tt.indexOf(ti); i > -1 {
+			tt.tools[i] = tool
+		} else {
+			tt.tools = append(tt.tools, tool)
+		}
+		return TRUE, true
+
+	case TTM_DELTOOL:
+		if i := tt.indexOf((*TOOLINFO)(unsafe.Pointer(msg.LParam))); i > -1 {
+			if tt.tracked == tt.tools[i] {
+				tt.tracked = nil
+			}
+			tt.tools = append(tt.tools[:i], tt.tools[i+1:]...)
+		}
+		return 0, true
+
+	case TTM_GETTOOLCOUNT:
+		return uintptr(len(tt.tools)), true
+
+	case TTM_SETTITLE:
+		tt.title = ""
+		if msg.LParam != 0 {
+			tt.title = UTF16PtrToString((*uint16)(unsafe.Pointer(msg.LParam)))
+		}
+		tt.icon = uint(msg.WParam)
+		return TRUE, true
+
+	case TTM_GETTITLE:
+		gt := (*TTGETTITLE)(unsafe.Pointer(msg.LParam))
+		gt.UTitleBitmap = tt.icon
+		if gt.PszTitle != nil && gt.Cch > 0 {
+			copyToUTF16Buffer(tt.title, gt.PszTitle, int(gt.Cch))
+		}
+		return 0, true
+
+	case TTM_TRACKPOSITION:
+		tt.trackPos = drawing.Point{GET_X_LPARAM(msg.LParam), GET_Y_LPARAM(msg.LParam)}
+		return 0, true
+
+	case TTM_TRACKACTIVATE:
+		tt.tracked = nil
+		if msg.WParam != FALSE {
+			if i := tt.indexOf((*TOOLINFO)(unsafe.Pointer(msg.LParam))); i > -1 {
+				tt.tracked = tt.tools[i]
+			}
+		}
+		return 0, true
+	}
+
+	return 0, false
+}
+
+// copyToUTF16Buffer copies s into the buffer of size cch that buf points to,
+// truncating it if necessary. The copy is always NUL terminated.
+func copyToUTF16Buffer(s string, buf *uint16, cch int) {
+	dst := (*[1 << 20]uint16)(unsafe.Pointer(buf))[:cch]
+
+	src := StringToUTF16(s)
+	if len(src) > cch {
+		src = src[:cch]
+	}
+
+	copy(dst, src)
+	dst[len(src)-1] = 0
+}
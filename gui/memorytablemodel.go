@@ -0,0 +1,156 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"container/vector"
+	"os"
+)
+
+// MemoryTableModel is a TableModel that keeps its rows in memory.
+type MemoryTableModel struct {
+	TableModelBase
+	rows vector.Vector
+}
+
+func NewMemoryTableModel() *MemoryTableModel {
+	return &MemoryTableModel{}
+}
+
+func (m *MemoryTableModel) RowCount() int {
+	return m.rows.Len()
+}
+
+func (m *MemoryTableModel) Value(row, col int) interface{} {
+	values := m.rows[row].([]interface{})
+
+	if col >= len(values) {
+		return nil
+	}
+
+	return values[col]
+}
+
+func (m *MemoryTableModel) checkRow(row int) os.Error {
+	if row < 0 || row >= m.rows.Len() {
+		return newError("row out of range")
+	}
+
+	return nil
+}
+
+// Row returns the values of row. The returned slice must not be modified.
+func (m *MemoryTableModel) Row(row int) []interface{} {
+	return m.rows[row].([]interface{})
+}
+
+func (m *MemoryTableModel) SetRow(row int, values []interface{}) os.Error {
+	if err := m.checkRow(row); err != nil {
+		return err
+	}
+
+	m.rows[row] = values
+
+	m.PublishRowsChanged(row, row)
+
+	return nil
+}
+
+// SetValue sets the value of the cell at row and col. The row grows as needed.
+func (m *MemoryTableModel) SetValue(row, col int, value interface{}) os.Error {
+	if err := m.checkRow(row); err != nil {
+		return err
+	}
+	if col < 0 {
+		return newError("col cannot be negative")
+	}
+
+	values := m.rows[row].([]interface{})
+	if col >= len(values) {
+		temp := make([]interface{}, col+1)
+		copy(temp, values)
+		values = temp
+		m.rows[row] = values
+	}
+
+	values[col] = value
+
+	m.PublishRowsChanged(row, row)
+
+	return nil
+}
+
+func (m *MemoryTableModel) AddRow(values []interface{}) {
+	m.rows.Push(values)
+
+	row := m.rows.Len() - 1
+
+	m.PublishRowsInserted(row, row)
+}
+
+func (m *MemoryTableModel) InsertRow(row int, values []interface{}) os.Error {
+	if row < 0 || row > m.rows.Len() {
+		return newError("row out of range")
+	}
+
+	m.rows.Insert(row, values)
+
+	m.PublishRowsInserted(row, row)
+
+	return nil
+}
+
+// AddRows appends all rows and notifies observers once.
+func (m *MemoryTableModel) AddRows(rows [][]interface{}) {
+	if len(rows) == 0 {
+		return
+	}
+
+	from := m.rows.Len()
+
+	for _, values := range rows {
+		m.rows.Push(values)
+	}
+
+	m.PublishRowsInserted(from, m.rows.Len()-1)
+}
+
+func (m *MemoryTableModel) RemoveRow(row int) os.Error {
+	return m.RemoveRows(row, row)
+}
+
+// RemoveRows removes the rows from through to and notifies observers once.
+func (m *MemoryTableModel) RemoveRows(from, to int) os.Error {
+	if from > to {
+		return newError("from cannot be greater than to")
+	}
+	if err := m.checkRow(from); err != nil {
+		return err
+	}
+	if err := m.checkRow(to); err != nil {
+		return err
+	}
+
+	m.rows.Cut(from, to+1)
+
+	m.PublishRowsRemoved(from, to)
+
+	return nil
+}
+
+// SetRows replaces all rows.
+func (m *MemoryTableModel) SetRows(rows [][]interface{}) {
+	m.rows.Resize(0, len(rows))
+
+	for _, values := range rows {
+		m.rows.Push(values)
+	}
+
+	m.PublishRowsReset()
+}
+
+func (m *MemoryTableModel) Clear() {
+	m.SetRows(nil)
+}
@@ -0,0 +1,197 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"os"
+)
+
+// MemoryTreeItem is an item of a MemoryTreeModel.
+type MemoryTreeItem struct {
+	text     string
+	parent   *MemoryTreeItem
+	children []*MemoryTreeItem
+}
+
+func (item *MemoryTreeItem) Text() string {
+	return item.text
+}
+
+// Parent returns the parent of item, or nil for top level items.
+func (item *MemoryTreeItem) Parent() *MemoryTreeItem {
+	return item.parent
+}
+
+// MemoryTreeModel is a TreeModel that keeps all of its items in memory.
+//
+// It counts how often the children of each item were requested, so tests can
+// verify that an observer loads items lazily.
+type MemoryTreeModel struct {
+	TreeModelBase
+	root          MemoryTreeItem
+	childRequests map[*MemoryTreeItem]int
+}
+
+func NewMemoryTreeModel() *MemoryTreeModel {
+	return &MemoryTreeModel{childRequests: make(map[*MemoryTreeItem]int)}
+}
+
+func (m *MemoryTreeModel) item(item TreeItem) *MemoryTreeItem {
+	if item == nil {
+		return &m.root
+	}
+
+	return item.(*MemoryTreeItem)
+}
+
+// treeItem returns item as a TreeItem, using nil for the root.
+func (m *MemoryTreeModel) treeItem(item *MemoryTreeItem) TreeItem {
+	if item == nil || item == &m.root {
+		return nil
+	}
+
+	return item
+}
+
+func (m *MemoryTreeModel) ChildCount(parent TreeItem) int {
+	return len(m.item(parent).children)
+}
+
+func (m *MemoryTreeModel) Child(parent TreeItem, index int) TreeItem {
+	p := m.item(parent)
+
+	m.childRequests[p]++
+
+	return p.children[index]
+}
+
+func (m *MemoryTreeModel) HasChildren(item TreeItem) bool {
+	return len(m.item(item).children) > 0
+}
+
+func (m *MemoryTreeModel) Text(item TreeItem) string {
+	return m.item(item).text
+}
+
+// ChildRequests returns how often Child was called for the children of
+// parent. Pass nil for the top level items.
+func (m *MemoryTreeModel) ChildRequests(parent *MemoryTreeItem) int {
+	if parent == nil {
+		parent = &m.root
+	}
+
+	return m.childRequests[parent]
+}
+
+// Children returns the children of parent. Pass nil for the top level items.
+// The returned slice must not be modified.
+func (m *MemoryTreeModel) Children(parent *MemoryTreeItem) []*MemoryTreeItem {
+	if parent == nil {
+		parent = &m.root
+	}
+
+	return parent.children
+}
+
+// AddItem appends a new item with the specified text to the children of
+// parent. Pass nil to add a top level item.
+func (m *MemoryTreeModel) AddItem(parent *MemoryTreeItem, text string) *MemoryTreeItem {
+	if parent == nil {
+		parent = &m.root
+	}
+
+	item, _ := m.InsertItem(parent, len(parent.children), text)
+
+	return item
+}
+
+// InsertItem inserts a new item with the specified text at index into the
+// children of parent. Pass nil to insert a top level item.
+func (m *MemoryTreeModel) InsertItem(parent *MemoryTreeItem, index int, text string) (*MemoryTreeItem, os.Error) {
+	if parent == nil {
+		parent = &m.root
+	}
+
+	count := len(parent.children)
+	if index < 0 || index > count {
+		return nil, newError("index out of range")
+	}
+
+	item := &MemoryTreeItem{text: text}
+	if parent != &m.root {
+		item.parent = parent
+	}
+
+	children := make([]*MemoryTreeItem, count+1)
+	copy(children, parent.children[0:index])
+	children[index] = item
+	copy(children[index+1:], parent.children[index:])
+	parent.children = children
+
+	m.PublishItemsInserted(m.treeItem(parent), index, index)
+
+	if count == 0 && parent != &m.root {
+		m.PublishItemChanged(parent)
+	}
+
+	return item, nil
+}
+
+// RemoveItem removes item and all of its descendants.
+func (m *MemoryTreeModel) RemoveItem(item *MemoryTreeItem) os.Error {
+	if item == nil {
+		return newError("item cannot be nil")
+	}
+
+	parent := item.parent
+	if parent == nil {
+		parent = &m.root
+	}
+
+	index := -1
+	for i, child := range parent.children {
+		if child == item {
+			index = i
+			break
+		}
+	}
+	if index == -1 {
+		return newError("item is not part of the model")
+	}
+
+	children := make([]*MemoryTreeItem, len(parent.children)-1)
+	copy(children, parent.children[0:index])
+	copy(children[index:], parent.children[index+1:])
+	parent.children = children
+
+	m.PublishItemsRemoved(m.treeItem(parent), index, index)
+
+	if len(children) == 0 && parent != &m.root {
+		m.PublishItemChanged(parent)
+	}
+
+	return nil
+}
+
+func (m *MemoryTreeModel) SetText(item *MemoryTreeItem, text string) os.Error {
+	if item == nil {
+		return newError("item cannot be nil")
+	}
+
+	if text != item.text {
+		item.text = text
+
+		m.PublishItemChanged(item)
+	}
+
+	return nil
+}
+
+// Reset notifies observers that the subtree below parent has changed, e.g.
+// after modifying it without using the methods of the MemoryTreeModel. Pass
+// nil to reset the whole tree.
+func (m *MemoryTreeModel) Reset(parent *MemoryTreeItem) {
+	m.PublishItemsReset(m.treeItem(parent))
+}
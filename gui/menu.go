@@ -6,61 +6,56 @@ package gui
 
 import (
 	"os"
-	"syscall"
 	"unsafe"
 )
 
 import (
+	. "walk/winapi"
 	. "walk/winapi/user32"
 )
 
+var menusByHMenu = make(map[HMENU]*Menu)
+
 type Menu struct {
-	hMenu   HMENU
-	hWnd    HWND
-	actions *ActionList
+	hMenu            HMENU
+	hWnd             HWND
+	actions          *ActionList
+	inserted         map[*Action]bool
+	showingPublisher EventPublisher
 }
 
-func newMenuBar() (*Menu, os.Error) {
-	hMenu := CreateMenu()
-	if hMenu == 0 {
-		return nil, lastError("CreateMenu")
-	}
-
-	m := &Menu{hMenu: hMenu}
+func newMenu(hMenu HMENU) *Menu {
+	m := &Menu{hMenu: hMenu, inserted: make(map[*Action]bool)}
 	m.actions = newActionList(m)
 
-	return m, nil
-}
+	menusByHMenu[hMenu] = m
 
-func NewMenu() (*Menu, os.Error) {
-	hMenu := CreatePopupMenu()
-	if hMenu == 0 {
-		return nil, lastError("CreatePopupMenu")
-	}
-
-	var mi MENUINFO
-	mi.CbSize = uint(unsafe.Sizeof(mi))
+	return m
+}
 
-	if !GetMenuInfo(hMenu, &mi) {
-		return nil, lastError("GetMenuInfo")
+func newMenuBar() (*Menu, os.Error) {
+	hMenu, err := backend.CreateMenu(false)
+	if err != nil {
+		return nil, err
 	}
 
-	mi.FMask |= MIM_STYLE
-	mi.DwStyle = MNS_CHECKORBMP
+	return newMenu(hMenu), nil
+}
 
-	if !SetMenuInfo(hMenu, &mi) {
-		return nil, lastError("SetMenuInfo")
+func NewMenu() (*Menu, os.Error) {
+	hMenu, err := backend.CreateMenu(true)
+	if err != nil {
+		return nil, err
 	}
 
-	m := &Menu{hMenu: hMenu}
-	m.actions = newActionList(m)
-
-	return m, nil
+	return newMenu(hMenu), nil
 }
 
 func (m *Menu) Dispose() {
 	if m.hMenu != 0 {
-		DestroyMenu(m.hMenu)
+		menusByHMenu[m.hMenu] = nil, false
+
+		backend.DestroyMenu(m.hMenu)
 		m.hMenu = 0
 	}
 }
@@ -73,17 +68,55 @@ func (m *Menu) Actions() *ActionList {
 	return m.actions
 }
 
+// Showing is raised right before the menu opens. Handlers can use it to populate the menu dynamically.
+func (m *Menu) Showing() *Event {
+	return m.showingPublisher.Event()
+}
+
+func (m *Menu) raiseShowing() {
+	m.showingPublisher.Publish(&eventArgs{m})
+}
+
+func (m *Menu) updateActions() {
+	for i := 0; i < m.actions.Len(); i++ {
+		m.actions.At(i).Update()
+	}
+}
+
 func (m *Menu) initMenuItemInfoFromAction(mii *MENUITEMINFO, action *Action) {
 	mii.CbSize = uint(unsafe.Sizeof(*mii))
-	mii.FMask = MIIM_FTYPE | MIIM_ID | MIIM_STRING
+	mii.FMask = MIIM_BITMAP | MIIM_FTYPE | MIIM_ID | MIIM_STATE
 	if action.image != nil {
-		mii.FMask |= MIIM_BITMAP
 		mii.HbmpItem = action.image.Handle()
 	}
-	mii.FType = MFT_STRING
 	mii.WID = uint(action.id)
-	mii.DwTypeData = syscall.StringToUTF16Ptr(action.Text())
-	mii.Cch = uint(len([]int(action.Text())))
+
+	if action.separator {
+		mii.FType = MFT_SEPARATOR
+		return
+	}
+
+	mii.FMask |= MIIM_STRING
+	mii.FType = MFT_STRING
+	if action.exclusive {
+		mii.FType |= MFT_RADIOCHECK
+	}
+
+	text := action.Text()
+	if s := action.Shortcut(); s.Key != 0 {
+		text += "\t" + s.String()
+	}
+	buf := StringToUTF16(text)
+	mii.DwTypeData = &buf[0]
+	// Cch counts UTF-16 code units, without the terminating NUL.
+	mii.Cch = uint(len(buf) - 1)
+
+	if !action.enabled {
+		mii.FState |= MFS_DISABLED
+	}
+	if action.checked {
+		mii.FState |= MFS_CHECKED
+	}
 
 	menu := action.menu
 	if menu != nil {
@@ -92,25 +125,101 @@ func (m *Menu) initMenuItemInfoFromAction(mii *MENUITEMINFO, action *Action) {
 	}
 }
 
-func (m *Menu) onActionChanged(action *Action) (err os.Error) {
+// position returns the position of the menu item for the action at index.
+// Invisible actions have no menu item, so they are skipped.
+func (m *Menu) position(index int) int {
+	pos := 0
+
+	for i := 0; i < index; i++ {
+		if m.inserted[m.actions.At(i)] {
+			pos++
+		}
+	}
+
+	return pos
+}
+
+func (m *Menu) insertItem(index int, action *Action) os.Error {
 	var mii MENUITEMINFO
 
 	m.initMenuItemInfoFromAction(&mii, action)
 
-	if !SetMenuItemInfo(m.hMenu, uint(m.actions.IndexOf(action)), true, &mii) {
-		err = newError("SetMenuItemInfo failed")
+	if err := backend.InsertMenuItem(m.hMenu, m.position(index), &mii); err != nil {
+		return err
 	}
 
-	return
+	m.inserted[action] = true
+
+	return nil
 }
 
-func (m *Menu) onInsertingAction(index int, action *Action) (err os.Error) {
-	var mii MENUITEMINFO
+func (m *Menu) removeItem(index int, action *Action) os.Error {
+	if err := backend.RemoveMenuItem(m.hMenu, m.position(index)); err != nil {
+		return err
+	}
 
-	m.initMenuItemInfoFromAction(&mii, action)
+	m.inserted[action] = false, false
+
+	return nil
+}
 
-	if !InsertMenuItem(m.hMenu, uint(index), true, &mii) {
-		return newError("wingui.Menu.onInsertingAction: win32.InsertMenuItem failed")
+func (m *Menu) updateMenuBar() {
+	if m.hWnd != 0 {
+		backend.DrawMenuBar(m.hWnd)
+	}
+}
+
+// actionForShortcut returns the enabled and visible action of the menu or
+// one of its submenus that has shortcut.
+func (m *Menu) actionForShortcut(shortcut Shortcut) *Action {
+	for i := 0; i < m.actions.Len(); i++ {
+		action := m.actions.At(i)
+		if !action.enabled || !action.visible {
+			continue
+		}
+
+		if action.menu != nil {
+			if a := action.menu.actionForShortcut(shortcut); a != nil {
+				return a
+			}
+		} else if action.shortcut == shortcut {
+			return action
+		}
+	}
+
+	return nil
+}
+
+func (m *Menu) onActionChanged(action *Action) (err os.Error) {
+	index := m.actions.IndexOf(action)
+
+	switch {
+	case !action.visible:
+		if m.inserted[action] {
+			err = m.removeItem(index, action)
+		}
+
+	case !m.inserted[action]:
+		err = m.insertItem(index, action)
+
+	default:
+		var mii MENUITEMINFO
+
+		m.initMenuItemInfoFromAction(&mii, action)
+
+		err = backend.SetMenuItemInfo(m.hMenu, m.position(index), &mii)
+	}
+
+	m.updateMenuBar()
+
+	return
+}
+
+func (m *Menu) onInsertingAction(index int, action *Action) (err os.Error) {
+	if action.visible {
+		if err = m.insertItem(index, action); err != nil {
+			return
+		}
 	}
 
 	action.addChangedHandler(m)
@@ -120,17 +229,36 @@ func (m *Menu) onInsertingAction(index int, action *Action) (err os.Error) {
 		menu.hWnd = m.hWnd
 	}
 
-	if m.hWnd != 0 {
-		DrawMenuBar(m.hWnd)
-	}
+	m.updateMenuBar()
 
 	return
 }
 
 func (m *Menu) onRemovingAction(index int, action *Action) (err os.Error) {
-	panic("not implemented")
+	if m.inserted[action] {
+		if err = m.removeItem(index, action); err != nil {
+			return
+		}
+	}
+
+	action.removeChangedHandler(m)
+
+	menu := action.menu
+	if menu != nil {
+		menu.hWnd = 0
+	}
+
+	m.updateMenuBar()
+
+	return
 }
 
 func (m *Menu) onClearingActions() (err os.Error) {
-	panic("not implemented")
+	for i := m.actions.Len() - 1; i >= 0; i-- {
+		if err = m.onRemovingAction(i, m.actions.At(i)); err != nil {
+			return
+		}
+	}
+
+	return
 }
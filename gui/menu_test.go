@@ -0,0 +1,238 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"testing"
+)
+
+import (
+	. "walk/winapi"
+	. "walk/winapi/user32"
+)
+
+// menuTexts returns the texts of the items of hMenu, separators as "-".
+func menuTexts(b *MemoryBackend, hMenu HMENU) []string {
+	items := b.menus[hMenu].items
+
+	texts := make([]string, len(items))
+	for i, item := range items {
+		if item.fType&MFT_SEPARATOR != 0 {
+			texts[i] = "-"
+		} else {
+			texts[i] = item.text
+		}
+	}
+
+	return texts
+}
+
+func checkMenuTexts(t *testing.T, name string, b *MemoryBackend, menu *Menu, expected ...string) {
+	texts := menuTexts(b, menu.hMenu)
+
+	if len(texts) != len(expected) {
+		t.Errorf("%s: expected items %q, got %q", name, expected, texts)
+		return
+	}
+
+	for i := range texts {
+		if texts[i] != expected[i] {
+			t.Errorf("%s: expected items %q, got %q", name, expected, texts)
+			return
+		}
+	}
+}
+
+func newTestAction(t *testing.T, menu *Menu, text string) *Action {
+	action := NewAction()
+	action.SetText(text)
+
+	if _, err := menu.Actions().Add(action); err != nil {
+		t.Fatalf("Add failed: %s", err)
+	}
+
+	return action
+}
+
+func TestMenuItemTypesAndStates(t *testing.T) {
+	b, mw := newTestMainWindow(t)
+	menu := mw.Menu()
+
+	newTestAction(t, menu, "&Open")
+	if _, err := menu.Actions().Add(NewSeparatorAction()); err != nil {
+		t.Fatalf("Add failed: %s", err)
+	}
+	small := newTestAction(t, menu, "&Small")
+	save := newTestAction(t, menu, "&Save")
+	bold := newTestAction(t, menu, "&Bold")
+
+	small.SetExclusive(true)
+	save.SetEnabled(false)
+	bold.SetChecked(true)
+
+	checkMenuTexts(t, "items", b, menu, "&Open", "-", "&Small", "&Save", "&Bold")
+
+	items := b.menus[menu.hMenu].items
+
+	if items[1].fType&MFT_SEPARATOR == 0 {
+		t.Error("expected a separator")
+	}
+	if items[2].fType&MFT_RADIOCHECK == 0 {
+		t.Error("expected an exclusive action to have a radio check mark")
+	}
+	if items[0].fType&MFT_RADIOCHECK != 0 {
+		t.Error("expected a plain action to have no radio check mark")
+	}
+	if items[3].state&MFS_DISABLED == 0 {
+		t.Error("expected a disabled action to disable its item")
+	}
+	if items[4].state&MFS_CHECKED == 0 {
+		t.Error("expected a checked action to check its item")
+	}
+
+	save.SetEnabled(true)
+	bold.SetChecked(false)
+	if items[3].state&MFS_DISABLED != 0 || items[4].state&MFS_CHECKED != 0 {
+		t.Error("expected the states to follow the actions")
+	}
+}
+
+func TestMenuSkipsHiddenActions(t *testing.T) {
+	b, mw := newTestMainWindow(t)
+	menu := mw.Menu()
+
+	a := newTestAction(t, menu, "a")
+	bAction := newTestAction(t, menu, "b")
+	c := newTestAction(t, menu, "c")
+
+	bAction.SetVisible(false)
+	checkMenuTexts(t, "hidden", b, menu, "a", "c")
+
+	// Items after hidden actions are found by their position among the
+	// visible ones.
+	c.SetText("c2")
+	checkMenuTexts(t, "changed after hidden", b, menu, "a", "c2")
+
+	if err := menu.Actions().Insert(2, NewAction()); err != nil {
+		t.Fatalf("Insert failed: %s", err)
+	}
+	menu.Actions().At(2).SetText("new")
+	checkMenuTexts(t, "inserted after hidden", b, menu, "a", "new", "c2")
+
+	bAction.SetVisible(true)
+	checkMenuTexts(t, "shown again", b, menu, "a", "b", "new", "c2")
+
+	a.SetVisible(false)
+	bAction.SetVisible(false)
+	checkMenuTexts(t, "two hidden", b, menu, "new", "c2")
+
+	if err := menu.Actions().Remove(bAction); err != nil {
+		t.Fatalf("Remove failed: %s", err)
+	}
+	if err := menu.Actions().Remove(c); err != nil {
+		t.Fatalf("Remove failed: %s", err)
+	}
+	checkMenuTexts(t, "removed", b, menu, "new")
+
+	a.SetVisible(true)
+	checkMenuTexts(t, "first shown again", b, menu, "a", "new")
+}
+
+func TestMenuShowing(t *testing.T) {
+	b, mw := newTestMainWindow(t)
+
+	sub, err := NewMenu()
+	if err != nil {
+		t.Fatalf("NewMenu failed: %s", err)
+	}
+	if _, _, err := mw.Menu().Actions().AddMenu(sub); err != nil {
+		t.Fatalf("AddMenu failed: %s", err)
+	}
+
+	// Handlers can populate the menu right before it opens.
+	var showing int
+	sub.Showing().Attach(func(args EventArgs) {
+		showing++
+
+		sub.Actions().Clear()
+		newTestAction(t, sub, "recent")
+	})
+
+	b.SendMessage(mw.hWnd, WM_INITMENUPOPUP, uintptr(sub.hMenu), 0)
+	b.SendMessage(mw.hWnd, WM_INITMENUPOPUP, uintptr(sub.hMenu), 0)
+
+	if showing != 2 {
+		t.Errorf("expected 2 Showing events, got %d", showing)
+	}
+	checkMenuTexts(t, "populated", b, sub, "recent")
+
+	// Other menus do not raise the event.
+	b.SendMessage(mw.hWnd, WM_INITMENUPOPUP, uintptr(mw.Menu().hMenu), 0)
+	if showing != 2 {
+		t.Errorf("expected the event only for the menu that opens, got %d", showing)
+	}
+}
+
+func TestMenuClearActions(t *testing.T) {
+	b, mw := newTestMainWindow(t)
+	menu := mw.Menu()
+
+	open := newTestAction(t, menu, "&Open")
+	hidden := newTestAction(t, menu, "&Hidden")
+	hidden.SetVisible(false)
+
+	sub, _ := NewMenu()
+	_, subAction, err := menu.Actions().AddMenu(sub)
+	if err != nil {
+		t.Fatalf("AddMenu failed: %s", err)
+	}
+	subAction.SetText("&Recent")
+
+	if sub.hWnd != mw.hWnd {
+		t.Error("expected the submenu to know the window of the menu bar")
+	}
+
+	if err := menu.Actions().Clear(); err != nil {
+		t.Fatalf("Clear failed: %s", err)
+	}
+
+	checkMenuTexts(t, "cleared", b, menu)
+	if menu.Actions().Len() != 0 {
+		t.Errorf("expected no actions, got %d", menu.Actions().Len())
+	}
+	if sub.hWnd != 0 {
+		t.Error("expected the submenu to forget the window")
+	}
+
+	// The menu no longer follows the actions.
+	open.SetText("&Open...")
+	hidden.SetVisible(true)
+	checkMenuTexts(t, "changed after clear", b, menu)
+}
+
+func TestMenuItemTextLength(t *testing.T) {
+	_, mw := newTestMainWindow(t)
+	menu := mw.Menu()
+
+	// The G clef is a surrogate pair in UTF-16.
+	clef := newTestAction(t, menu, "\U0001D11E clef")
+
+	var mii MENUITEMINFO
+	menu.initMenuItemInfoFromAction(&mii, clef)
+	if mii.Cch != 7 {
+		t.Errorf("expected 7 UTF-16 code units, got %d", mii.Cch)
+	}
+
+	shortcut := Shortcut{ModControl, 'S'}
+	clef.SetShortcut(shortcut)
+
+	menu.initMenuItemInfoFromAction(&mii, clef)
+	if expected := uint(7 + 1 + len(shortcut.String())); mii.Cch != expected {
+		t.Errorf("expected %d UTF-16 code units with the shortcut, got %d", expected, mii.Cch)
+	}
+	if text := UTF16PtrToString(mii.DwTypeData); text != "\U0001D11E clef\t"+shortcut.String() {
+		t.Errorf("unexpected text %q", text)
+	}
+}
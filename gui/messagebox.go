@@ -4,10 +4,6 @@
 
 package gui
 
-import (
-	"syscall"
-)
-
 import (
 	. "walk/winapi/user32"
 )
@@ -44,5 +40,5 @@ func MsgBox(owner RootWidget, title, message string, style MsgBoxStyle) DialogCo
 		ownerHWnd = owner.Handle()
 	}
 
-	return DialogCommandId(MessageBox(ownerHWnd, syscall.StringToUTF16Ptr(message), syscall.StringToUTF16Ptr(title), uint(style)))
+	return DialogCommandId(backend.MessageBox(ownerHWnd, title, message, uint(style)))
 }
@@ -0,0 +1,105 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	. "walk/winapi"
+	. "walk/winapi/user32"
+)
+
+type mouseEventKind int
+
+const (
+	mouseDownEvent mouseEventKind = iota
+	mouseUpEvent
+	mouseDoubleClickEvent
+	mouseMoveEvent
+	mouseWheelEvent
+)
+
+// translateMouseMessage translates the mouse message msg to the kind and
+// arguments of a mouse event of sender. It returns false for messages that
+// are not mouse messages.
+//
+// modifiers are the modifier keys held down, in addition to the ones the
+// message reports itself. The position of wheel events is in screen
+// coordinates, as in the message.
+func translateMouseMessage(msg *MSG, sender interface{}, modifiers Modifiers) (mouseEventKind, *mouseEventArgs, bool) {
+	var kind mouseEventKind
+	var button MouseButton
+
+	switch msg.Message {
+	case WM_LBUTTONDOWN, WM_RBUTTONDOWN, WM_MBUTTONDOWN:
+		kind = mouseDownEvent
+
+	case WM_LBUTTONUP, WM_RBUTTONUP, WM_MBUTTONUP:
+		kind = mouseUpEvent
+
+	case WM_LBUTTONDBLCLK, WM_RBUTTONDBLCLK, WM_MBUTTONDBLCLK:
+		kind = mouseDoubleClickEvent
+
+	case WM_MOUSEMOVE:
+		kind = mouseMoveEvent
+
+	case WM_MOUSEWHEEL:
+		kind = mouseWheelEvent
+
+	default:
+		return 0, nil, false
+	}
+
+	switch msg.Message {
+	case WM_LBUTTONDOWN, WM_LBUTTONUP, WM_LBUTTONDBLCLK:
+		button = LeftButton
+
+	case WM_RBUTTONDOWN, WM_RBUTTONUP, WM_RBUTTONDBLCLK:
+		button = RightButton
+
+	case WM_MBUTTONDOWN, WM_MBUTTONUP, WM_MBUTTONDBLCLK:
+		button = MiddleButton
+	}
+
+	keyState := uint(LOWORD(uint(msg.WParam)))
+
+	// The coordinates are signed, they are negative for positions left of or
+	// above the widget while it has captured the mouse.
+	args := &mouseEventArgs{
+		eventArgs: eventArgs{sender},
+		x:         int(int16(LOWORD(uint(msg.LParam)))),
+		y:         int(int16(HIWORD(uint(msg.LParam)))),
+		button:    button,
+		buttons:   mouseButtonsFromKeyState(keyState),
+		modifiers: modifiers,
+	}
+
+	if keyState&MK_SHIFT != 0 {
+		args.modifiers |= ModShift
+	}
+	if keyState&MK_CONTROL != 0 {
+		args.modifiers |= ModControl
+	}
+
+	if kind == mouseWheelEvent {
+		args.wheelDelta = int(int16(HIWORD(uint(msg.WParam))))
+	}
+
+	return kind, args, true
+}
+
+func mouseButtonsFromKeyState(keyState uint) MouseButton {
+	var buttons MouseButton
+
+	if keyState&MK_LBUTTON != 0 {
+		buttons |= LeftButton
+	}
+	if keyState&MK_RBUTTON != 0 {
+		buttons |= RightButton
+	}
+	if keyState&MK_MBUTTON != 0 {
+		buttons |= MiddleButton
+	}
+
+	return buttons
+}
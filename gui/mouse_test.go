@@ -0,0 +1,103 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"testing"
+)
+
+import (
+	"walk/drawing"
+	. "walk/winapi"
+	. "walk/winapi/user32"
+)
+
+// mouseParam packs lo and hi into a message parameter, as signed words.
+func mouseParam(lo, hi int) uintptr {
+	return uintptr(MAKELONG(uint16(int16(lo)), uint16(int16(hi))))
+}
+
+func TestTranslateMouseMessage(t *testing.T) {
+	tests := []struct {
+		message    uint
+		wParam     uintptr
+		modifiers  Modifiers
+		kind       mouseEventKind
+		button     MouseButton
+		buttons    MouseButton
+		mods       Modifiers
+		wheelDelta int
+	}{
+		{WM_LBUTTONDOWN, MK_LBUTTON, 0, mouseDownEvent, LeftButton, LeftButton, 0, 0},
+		{WM_RBUTTONDOWN, MK_RBUTTON | MK_LBUTTON, 0, mouseDownEvent, RightButton, LeftButton | RightButton, 0, 0},
+		{WM_MBUTTONDOWN, MK_MBUTTON | MK_SHIFT, 0, mouseDownEvent, MiddleButton, MiddleButton, ModShift, 0},
+		{WM_LBUTTONUP, 0, ModAlt, mouseUpEvent, LeftButton, 0, ModAlt, 0},
+		{WM_RBUTTONUP, MK_CONTROL, 0, mouseUpEvent, RightButton, 0, ModControl, 0},
+		{WM_MBUTTONUP, MK_LBUTTON, 0, mouseUpEvent, MiddleButton, LeftButton, 0, 0},
+		{WM_LBUTTONDBLCLK, MK_LBUTTON, 0, mouseDoubleClickEvent, LeftButton, LeftButton, 0, 0},
+		{WM_RBUTTONDBLCLK, MK_RBUTTON, 0, mouseDoubleClickEvent, RightButton, RightButton, 0, 0},
+		{WM_MBUTTONDBLCLK, MK_MBUTTON, 0, mouseDoubleClickEvent, MiddleButton, MiddleButton, 0, 0},
+		{WM_MOUSEMOVE, MK_SHIFT | MK_CONTROL, ModAlt, mouseMoveEvent, 0, 0, ModShift | ModControl | ModAlt, 0},
+		{WM_MOUSEWHEEL, mouseParam(MK_CONTROL, 240), 0, mouseWheelEvent, 0, 0, ModControl, 240},
+		{WM_MOUSEWHEEL, mouseParam(MK_RBUTTON, -120), 0, mouseWheelEvent, 0, RightButton, 0, -120},
+	}
+
+	sender := new(int)
+
+	for i, test := range tests {
+		msg := &MSG{Message: test.message, WParam: test.wParam, LParam: mouseParam(-5, 300)}
+
+		kind, args, ok := translateMouseMessage(msg, sender, test.modifiers)
+		if !ok {
+			t.Errorf("%d: expected 0x%04X to be a mouse message", i, test.message)
+			continue
+		}
+
+		if kind != test.kind {
+			t.Errorf("%d: expected the kind %d, got %d", i, test.kind, kind)
+		}
+		if args.Sender() != sender {
+			t.Errorf("%d: expected the sender to be set", i)
+		}
+		if args.X() != -5 || args.Y() != 300 {
+			t.Errorf("%d: expected the position (-5, 300), got (%d, %d)", i, args.X(), args.Y())
+		}
+		if args.Button() != test.button || args.Buttons() != test.buttons {
+			t.Errorf("%d: expected the button %d and buttons %d, got %d and %d", i, test.button, test.buttons, args.Button(), args.Buttons())
+		}
+		if args.Modifiers() != test.mods {
+			t.Errorf("%d: expected the modifiers %d, got %d", i, test.mods, args.Modifiers())
+		}
+		if args.WheelDelta() != test.wheelDelta {
+			t.Errorf("%d: expected the wheel delta %d, got %d", i, test.wheelDelta, args.WheelDelta())
+		}
+	}
+}
+
+func TestTranslateMouseMessageOther(t *testing.T) {
+	for _, message := range []uint{WM_KEYDOWN, WM_MOUSELEAVE, WM_CHAR, WM_SETCURSOR} {
+		if _, _, ok := translateMouseMessage(&MSG{Message: message}, nil, 0); ok {
+			t.Errorf("0x%04X: expected no mouse message", message)
+		}
+	}
+}
+
+func TestMouseWheelPosition(t *testing.T) {
+	b, mw := newTestMainWindow(t)
+	mw.SetBounds(drawing.Rectangle{100, 50, 400, 300})
+
+	origin, _ := b.ClientToScreen(mw.ClientArea().hWnd, drawing.Point{})
+
+	var x, y, delta int
+	mw.ClientArea().MouseWheel().Attach(func(args MouseEventArgs) {
+		x, y, delta = args.X(), args.Y(), args.WheelDelta()
+	})
+
+	b.SendMessage(mw.ClientArea().hWnd, WM_MOUSEWHEEL, mouseParam(0, 120), mouseParam(origin.X+10, origin.Y+20))
+
+	if x != 10 || y != 20 || delta != 120 {
+		t.Errorf("expected the wheel delta 120 at (10, 20), got %d at (%d, %d)", delta, x, y)
+	}
+}
@@ -6,7 +6,6 @@ package gui
 
 import (
 	"os"
-	"syscall"
 )
 
 import (
@@ -24,16 +23,16 @@ func NewProgressBar(parent IContainer) (*ProgressBar, os.Error) {
 		return nil, newError("parent cannot be nil")
 	}
 
-	hWnd := CreateWindowEx(
-		0, syscall.StringToUTF16Ptr("msctls_progress32"), nil,
+	hWnd, err := backend.CreateWindow(
+		0, "msctls_progress32",
 		WS_CHILD|WS_VISIBLE,
-		0, 0, 80, 24, parent.Handle(), 0, 0, nil)
-	if hWnd == 0 {
-		return nil, lastError("CreateWindowEx")
+		parent.Handle(), drawing.Rectangle{0, 0, 80, 24})
+	if err != nil {
+		return nil, err
 	}
 
 	pb := &ProgressBar{Widget: Widget{hWnd: hWnd, parent: parent}}
-	pb.SetFont(defaultFont)
+	pb.SetFont(backend.DefaultFont())
 
 	widgetsByHWnd[hWnd] = pb
 
@@ -51,9 +50,9 @@ func (pb *ProgressBar) PreferredSize() drawing.Size {
 }
 
 func (pb *ProgressBar) ProgressPercent() int {
-	return int(SendMessage(pb.hWnd, PBM_GETPOS, 0, 0))
+	return int(backend.SendMessage(pb.hWnd, PBM_GETPOS, 0, 0))
 }
 
 func (pb *ProgressBar) SetProgressPercent(value int) {
-	SendMessage(pb.hWnd, PBM_SETPOS, uintptr(value), 0)
+	backend.SendMessage(pb.hWnd, PBM_SETPOS, uintptr(value), 0)
 }
@@ -0,0 +1,81 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"os"
+)
+
+// Property is a value, typically of a widget, that a DataBinder can bind to a
+// field of a struct.
+//
+// The type of the value returned by Get determines what the field value is
+// converted to, Set only needs to accept values of that type.
+type Property interface {
+	Get() interface{}
+	Set(value interface{}) os.Error
+	Changed() *Event
+}
+
+// funcProperty implements Property with functions, which makes it easy for
+// widgets to expose their properties.
+type funcProperty struct {
+	get     func() interface{}
+	set     func(value interface{}) os.Error
+	changed *Event
+}
+
+func (p *funcProperty) Get() interface{} {
+	return p.get()
+}
+
+func (p *funcProperty) Set(value interface{}) os.Error {
+	return p.set(value)
+}
+
+func (p *funcProperty) Changed() *Event {
+	return p.changed
+}
+
+// MemoryProperty is a Property that just stores its value.
+//
+// It can stand in for the property of a widget in tests.
+type MemoryProperty struct {
+	value            interface{}
+	changedPublisher EventPublisher
+}
+
+// NewMemoryProperty returns a MemoryProperty with the initial value value,
+// whose type is the type of values the property accepts.
+func NewMemoryProperty(value interface{}) *MemoryProperty {
+	return &MemoryProperty{value: value}
+}
+
+func (p *MemoryProperty) Get() interface{} {
+	return p.value
+}
+
+// Set sets the value without raising the changed event.
+func (p *MemoryProperty) Set(value interface{}) os.Error {
+	p.value = value
+
+	return nil
+}
+
+// Change sets the value and raises the changed event, which simulates the
+// user changing a widget.
+func (p *MemoryProperty) Change(value interface{}) {
+	p.value = value
+
+	p.raiseChanged()
+}
+
+func (p *MemoryProperty) Changed() *Event {
+	return p.changedPublisher.Event()
+}
+
+func (p *MemoryProperty) raiseChanged() {
+	p.changedPublisher.Publish(&eventArgs{p})
+}
@@ -6,7 +6,6 @@ package gui
 
 import (
 	"os"
-	"syscall"
 	"unsafe"
 )
 
@@ -25,16 +24,16 @@ func NewPushButton(parent IContainer) (*PushButton, os.Error) {
 		return nil, newError("parent cannot be nil")
 	}
 
-	hWnd := CreateWindowEx(
-		0, syscall.StringToUTF16Ptr("BUTTON"), nil,
+	hWnd, err := backend.CreateWindow(
+		0, "BUTTON",
 		/*BS_NOTIFY|*/ BS_PUSHBUTTON|WS_CHILD|WS_TABSTOP|WS_VISIBLE,
-		0, 0, 120, 24, parent.Handle(), 0, 0, nil)
-	if hWnd == 0 {
-		return nil, lastError("CreateWindowEx")
+		parent.Handle(), drawing.Rectangle{0, 0, 120, 24})
+	if err != nil {
+		return nil, err
 	}
 
 	pb := &PushButton{Button: Button{Widget: Widget{hWnd: hWnd, parent: parent}}}
-	pb.SetFont(defaultFont)
+	pb.SetFont(backend.DefaultFont())
 
 	widgetsByHWnd[hWnd] = pb
 
@@ -50,7 +49,24 @@ func (*PushButton) LayoutFlags() LayoutFlags {
 func (pb *PushButton) PreferredSize() drawing.Size {
 	var s drawing.Size
 
-	SendMessage(pb.hWnd, BCM_GETIDEALSIZE, 0, uintptr(unsafe.Pointer(&s)))
+	backend.SendMessage(pb.hWnd, BCM_GETIDEALSIZE, 0, uintptr(unsafe.Pointer(&s)))
 
 	return s
 }
+
+// setDefault makes the button look like the one Enter clicks, or not.
+func (pb *PushButton) setDefault(value bool) os.Error {
+	style, err := backend.Style(pb.hWnd)
+	if err != nil {
+		return err
+	}
+
+	style &^= BS_DEFPUSHBUTTON
+	if value {
+		style |= BS_DEFPUSHBUTTON
+	}
+
+	backend.SendMessage(pb.hWnd, BM_SETSTYLE, uintptr(style), 1)
+
+	return nil
+}
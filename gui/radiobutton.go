@@ -6,7 +6,6 @@ package gui
 
 import (
 	"os"
-	"syscall"
 )
 
 import (
@@ -23,16 +22,16 @@ func NewRadioButton(parent IContainer) (*RadioButton, os.Error) {
 		return nil, newError("parent cannot be nil")
 	}
 
-	hWnd := CreateWindowEx(
-		0, syscall.StringToUTF16Ptr("BUTTON"), nil,
+	hWnd, err := backend.CreateWindow(
+		0, "BUTTON",
 		BS_AUTORADIOBUTTON /*|BS_NOTIFY*/ |WS_CHILD|WS_TABSTOP|WS_VISIBLE,
-		0, 0, 120, 24, parent.Handle(), 0, 0, nil)
-	if hWnd == 0 {
-		return nil, lastError("CreateWindowEx")
+		parent.Handle(), drawing.Rectangle{0, 0, 120, 24})
+	if err != nil {
+		return nil, err
 	}
 
 	rb := &RadioButton{Button: Button{Widget: Widget{hWnd: hWnd, parent: parent}}}
-	rb.SetFont(defaultFont)
+	rb.SetFont(backend.DefaultFont())
 
 	widgetsByHWnd[hWnd] = rb
 
@@ -48,3 +47,43 @@ func (*RadioButton) LayoutFlags() LayoutFlags {
 func (rb *RadioButton) PreferredSize() drawing.Size {
 	return rb.dialogBaseUnitsToPixels(drawing.Size{50, 10})
 }
+
+// NewRadioButtonGroupProperty returns a Property with an int value that is
+// the index of the checked button of buttons, or -1 if none is checked.
+func NewRadioButtonGroupProperty(buttons []*RadioButton) Property {
+	changedPublisher := new(EventPublisher)
+
+	for _, rb := range buttons {
+		rb.Clicked().Attach(func(args EventArgs) {
+			changedPublisher.Publish(args)
+		})
+	}
+
+	return &funcProperty{
+		get: func() interface{} {
+			for i, rb := range buttons {
+				if rb.Checked() {
+					return i
+				}
+			}
+
+			return -1
+		},
+		set: func(value interface{}) os.Error {
+			index, ok := value.(int)
+			if !ok {
+				return newError("value must be an int")
+			}
+			if index < -1 || index >= len(buttons) {
+				return newError("value out of range")
+			}
+
+			for i, rb := range buttons {
+				rb.SetChecked(i == index)
+			}
+
+			return nil
+		},
+		changed: changedPublisher.Event(),
+	}
+}
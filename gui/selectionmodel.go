@@ -0,0 +1,372 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"os"
+	"sort"
+)
+
+// indexSet is a set of row indexes, that can follow rows being inserted and
+// removed.
+type indexSet map[int]bool
+
+func (s indexSet) sorted() []int {
+	indexes := make([]int, len(s))
+
+	i := 0
+	for index := range s {
+		indexes[i] = index
+		i++
+	}
+
+	sort.SortInts(indexes)
+
+	return indexes
+}
+
+// rowsInserted returns the set with the indexes adjusted for rows from
+// through to having been inserted.
+func (s indexSet) rowsInserted(from, to int) indexSet {
+	moved := make(indexSet, len(s))
+
+	for index := range s {
+		if index >= from {
+			index += to - from + 1
+		}
+
+		moved[index] = true
+	}
+
+	return moved
+}
+
+// rowsRemoved returns the set with the indexes adjusted for the rows that were
+// at from through to having been removed.
+func (s indexSet) rowsRemoved(from, to int) indexSet {
+	moved := make(indexSet, len(s))
+
+	for index := range s {
+		switch {
+		case index > to:
+			index -= to - from + 1
+
+		case index >= from:
+			continue
+		}
+
+		moved[index] = true
+	}
+
+	return moved
+}
+
+// rowsReordered returns the set with the indexes adjusted for the rows having
+// been rearranged, so that the row previously at i is now at newRows[i].
+func (s indexSet) rowsReordered(newRows []int) indexSet {
+	moved := make(indexSet, len(s))
+
+	for index := range s {
+		if index < len(newRows) {
+			moved[newRows[index]] = true
+		}
+	}
+
+	return moved
+}
+
+// SelectionModel keeps track of the selected rows of a widget like ListView.
+//
+// It does not depend on any window, so it can be used in tests. The widget
+// using it adjusts it to its rows, so it can only be used by one widget at a
+// time. Other parts of the program may observe and change it though. Indexes
+// outside 0 through RowCount()-1 cannot be selected.
+type SelectionModel struct {
+	owner            IWidget
+	selected         indexSet
+	rowCount         int
+	multiSelection   bool
+	changedPublisher EventPublisher
+}
+
+func NewSelectionModel() *SelectionModel {
+	return &SelectionModel{selected: make(indexSet)}
+}
+
+func (sm *SelectionModel) RowCount() int {
+	return sm.rowCount
+}
+
+// SetRowCount sets the number of rows that can be selected. Selected rows
+// beyond the new count are deselected.
+func (sm *SelectionModel) SetRowCount(value int) os.Error {
+	if value < 0 {
+		return newError("value cannot be negative")
+	}
+
+	sm.rowCount = value
+
+	changed := false
+	for index := range sm.selected {
+		if index >= value {
+			sm.selected[index] = false, false
+			changed = true
+		}
+	}
+
+	if changed {
+		sm.raiseChanged()
+	}
+
+	return nil
+}
+
+func (sm *SelectionModel) MultiSelection() bool {
+	return sm.multiSelection
+}
+
+// SetMultiSelection sets whether more than one row can be selected. Turning
+// it off keeps only the first selected row selected.
+func (sm *SelectionModel) SetMultiSelection(value bool) {
+	sm.multiSelection = value
+
+	if !value && len(sm.selected) > 1 {
+		sm.set([]int{sm.sortedFirst()})
+	}
+}
+
+func (sm *SelectionModel) checkIndex(index int) os.Error {
+	if index < 0 || index >= sm.rowCount {
+		return newError("index out of range")
+	}
+
+	return nil
+}
+
+func (sm *SelectionModel) sortedFirst() int {
+	first := -1
+
+	for index := range sm.selected {
+		if first == -1 || index < first {
+			first = index
+		}
+	}
+
+	return first
+}
+
+func (sm *SelectionModel) IsSelected(index int) bool {
+	return sm.selected[index]
+}
+
+// Count returns the number of selected rows.
+func (sm *SelectionModel) Count() int {
+	return len(sm.selected)
+}
+
+// SelectedIndex returns the lowest selected index or -1 if no row is
+// selected.
+func (sm *SelectionModel) SelectedIndex() int {
+	return sm.sortedFirst()
+}
+
+// SelectedIndexes returns the selected indexes in ascending order.
+func (sm *SelectionModel) SelectedIndexes() []int {
+	return sm.selected.sorted()
+}
+
+// SetSelectedIndexes selects exactly indexes.
+func (sm *SelectionModel) SetSelectedIndexes(indexes []int) os.Error {
+	for _, index := range indexes {
+		if err := sm.checkIndex(index); err != nil {
+			return err
+		}
+	}
+
+	if !sm.multiSelection && len(indexes) > 1 {
+		return newError("multi selection is disabled")
+	}
+
+	sm.set(indexes)
+
+	return nil
+}
+
+func (sm *SelectionModel) set(indexes []int) {
+	selected := make(indexSet, len(indexes))
+	for _, index := range indexes {
+		selected[index] = true
+	}
+
+	if len(selected) == len(sm.selected) {
+		equal := true
+		for index := range selected {
+			if !sm.selected[index] {
+				equal = false
+				break
+			}
+		}
+
+		if equal {
+			return
+		}
+	}
+
+	sm.selected = selected
+
+	sm.raiseChanged()
+}
+
+// Select adds index to the selection. Without multi selection, it replaces
+// the selection.
+func (sm *SelectionModel) Select(index int) os.Error {
+	if err := sm.checkIndex(index); err != nil {
+		return err
+	}
+
+	if !sm.multiSelection {
+		sm.set([]int{index})
+		return nil
+	}
+
+	if !sm.selected[index] {
+		sm.selected[index] = true
+
+		sm.raiseChanged()
+	}
+
+	return nil
+}
+
+func (sm *SelectionModel) Deselect(index int) {
+	if sm.selected[index] {
+		sm.selected[index] = false, false
+
+		sm.raiseChanged()
+	}
+}
+
+// SelectRange adds the rows from through to to the selection.
+func (sm *SelectionModel) SelectRange(from, to int) os.Error {
+	if from > to {
+		from, to = to, from
+	}
+
+	if err := sm.checkIndex(from); err != nil {
+		return err
+	}
+	if err := sm.checkIndex(to); err != nil {
+		return err
+	}
+
+	if !sm.multiSelection && from != to {
+		return newError("multi selection is disabled")
+	}
+
+	if !sm.multiSelection {
+		sm.set([]int{from})
+		return nil
+	}
+
+	changed := false
+	for index := from; index <= to; index++ {
+		if !sm.selected[index] {
+			sm.selected[index] = true
+			changed = true
+		}
+	}
+
+	if changed {
+		sm.raiseChanged()
+	}
+
+	return nil
+}
+
+// DeselectRange removes the rows from through to from the selection.
+func (sm *SelectionModel) DeselectRange(from, to int) {
+	if from > to {
+		from, to = to, from
+	}
+
+	changed := false
+	for index := range sm.selected {
+		if index >= from && index <= to {
+			sm.selected[index] = false, false
+			changed = true
+		}
+	}
+
+	if changed {
+		sm.raiseChanged()
+	}
+}
+
+func (sm *SelectionModel) SelectAll() os.Error {
+	if sm.rowCount == 0 {
+		return nil
+	}
+
+	return sm.SelectRange(0, sm.rowCount-1)
+}
+
+func (sm *SelectionModel) Clear() {
+	if len(sm.selected) > 0 {
+		sm.selected = make(indexSet)
+
+		sm.raiseChanged()
+	}
+}
+
+// RowsInserted moves the selection along with the rows that follow the rows
+// from through to, which have been inserted.
+func (sm *SelectionModel) RowsInserted(from, to int) {
+	sm.rowCount += to - from + 1
+
+	if len(sm.selected) == 0 {
+		return
+	}
+
+	sm.selected = sm.selected.rowsInserted(from, to)
+
+	sm.raiseChanged()
+}
+
+// RowsRemoved deselects the rows that were at from through to and moves the
+// selection along with the rows that followed them.
+func (sm *SelectionModel) RowsRemoved(from, to int) {
+	sm.rowCount -= to - from + 1
+	if sm.rowCount < 0 {
+		sm.rowCount = 0
+	}
+
+	if len(sm.selected) == 0 {
+		return
+	}
+
+	sm.selected = sm.selected.rowsRemoved(from, to)
+
+	sm.raiseChanged()
+}
+
+// RowsReordered moves the selection along with the rows, which have been
+// rearranged so that the row previously at i is now at newRows[i].
+func (sm *SelectionModel) RowsReordered(newRows []int) {
+	if len(sm.selected) == 0 {
+		return
+	}
+
+	sm.selected = sm.selected.rowsReordered(newRows)
+
+	sm.raiseChanged()
+}
+
+func (sm *SelectionModel) Changed() *Event {
+	return sm.changedPublisher.Event()
+}
+
+func (sm *SelectionModel) raiseChanged() {
+	sm.changedPublisher.Publish(&eventArgs{sm})
+}
@@ -0,0 +1,144 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"fmt"
+	"testing"
+)
+
+func newTestSelectionModel(t *testing.T, rowCount int, multiSelection bool) (*SelectionModel, *int) {
+	sm := NewSelectionModel()
+	sm.SetMultiSelection(multiSelection)
+	if err := sm.SetRowCount(rowCount); err != nil {
+		t.Fatalf("SetRowCount failed: %s", err)
+	}
+
+	changes := new(int)
+	sm.Changed().Attach(func(args EventArgs) {
+		*changes++
+	})
+
+	return sm, changes
+}
+
+func checkSelection(t *testing.T, name string, sm *SelectionModel, changes *int, expectedChanges int, expected ...int) {
+	if indexes := sm.SelectedIndexes(); fmt.Sprint(indexes) != fmt.Sprint(expected) {
+		t.Errorf("%s: expected selection %v, got %v", name, expected, indexes)
+	}
+	if *changes != expectedChanges {
+		t.Errorf("%s: expected %d Changed events, got %d", name, expectedChanges, *changes)
+	}
+
+	*changes = 0
+}
+
+func TestSelectionModelSingleSelection(t *testing.T) {
+	sm, changes := newTestSelectionModel(t, 5, false)
+
+	sm.Select(1)
+	checkSelection(t, "Select", sm, changes, 1, 1)
+
+	sm.Select(3)
+	checkSelection(t, "Select replaces", sm, changes, 1, 3)
+
+	if err := sm.SelectRange(1, 2); err == nil {
+		t.Error("expected an error for a range without multi selection")
+	}
+	checkSelection(t, "SelectRange", sm, changes, 0, 3)
+
+	if err := sm.Select(5); err == nil {
+		t.Error("expected an error for an index out of range")
+	}
+	checkSelection(t, "Select out of range", sm, changes, 0, 3)
+
+	sm.Deselect(3)
+	checkSelection(t, "Deselect", sm, changes, 1)
+}
+
+func TestSelectionModelMultiSelection(t *testing.T) {
+	sm, changes := newTestSelectionModel(t, 10, true)
+
+	sm.SelectRange(5, 2)
+	checkSelection(t, "SelectRange", sm, changes, 1, 2, 3, 4, 5)
+
+	sm.Select(8)
+	checkSelection(t, "Select", sm, changes, 1, 2, 3, 4, 5, 8)
+
+	sm.DeselectRange(3, 4)
+	checkSelection(t, "DeselectRange", sm, changes, 1, 2, 5, 8)
+
+	sm.SetRowCount(6)
+	checkSelection(t, "SetRowCount", sm, changes, 1, 2, 5)
+
+	sm.SetMultiSelection(false)
+	checkSelection(t, "SetMultiSelection", sm, changes, 1, 2)
+
+	sm.SetMultiSelection(true)
+	sm.SelectAll()
+	checkSelection(t, "SelectAll", sm, changes, 1, 0, 1, 2, 3, 4, 5)
+
+	sm.Clear()
+	checkSelection(t, "Clear", sm, changes, 1)
+}
+
+func TestSelectionModelRowChanges(t *testing.T) {
+	sm, changes := newTestSelectionModel(t, 5, true)
+	sm.SetSelectedIndexes([]int{1, 3})
+	*changes = 0
+
+	sm.RowsInserted(2, 3)
+	checkSelection(t, "RowsInserted", sm, changes, 1, 1, 5)
+	if n := sm.RowCount(); n != 7 {
+		t.Errorf("expected 7 rows after inserting, got %d", n)
+	}
+
+	sm.RowsRemoved(0, 1)
+	checkSelection(t, "RowsRemoved", sm, changes, 1, 3)
+	if n := sm.RowCount(); n != 5 {
+		t.Errorf("expected 5 rows after removing, got %d", n)
+	}
+
+	sm.RowsReordered([]int{4, 3, 2, 1, 0})
+	checkSelection(t, "RowsReordered", sm, changes, 1, 1)
+
+	sm.Clear()
+	*changes = 0
+
+	sm.RowsInserted(0, 0)
+	sm.RowsRemoved(0, 0)
+	sm.RowsReordered([]int{1, 0, 2, 3, 4})
+	checkSelection(t, "without selection", sm, changes, 0)
+}
+
+func TestSelectionModelSingleListView(t *testing.T) {
+	_, first := newTestListView(t)
+	_, second := newTestListView(t)
+
+	sm := first.SelectionModel()
+
+	if err := second.SetSelectionModel(sm); err == nil {
+		t.Error("expected an error for a selection model used by another ListView")
+	}
+	if second.SelectionModel() == sm {
+		t.Error("expected the rejected selection model not to be used")
+	}
+
+	if err := first.SetSelectionModel(sm); err != nil {
+		t.Errorf("expected a ListView to accept its own selection model again, got %s", err)
+	}
+
+	if err := first.SetSelectionModel(NewSelectionModel()); err != nil {
+		t.Fatalf("SetSelectionModel failed: %s", err)
+	}
+	if err := second.SetSelectionModel(sm); err != nil {
+		t.Errorf("expected a released selection model to be accepted, got %s", err)
+	}
+
+	second.Dispose()
+	if err := first.SetSelectionModel(sm); err != nil {
+		t.Errorf("expected the selection model of a disposed ListView to be accepted, got %s", err)
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+import (
+	. "walk/winapi/user32"
+)
+
+type Modifiers byte
+
+const (
+	ModShift Modifiers = 1 << iota
+	ModControl
+	ModAlt
+)
+
+// String returns the modifiers the way they are displayed in menus, e.g.
+// "Ctrl+Shift".
+func (m Modifiers) String() string {
+	buf := bytes.NewBuffer(nil)
+
+	for _, mod := range []Modifiers{ModControl, ModShift, ModAlt} {
+		if m&mod == 0 {
+			continue
+		}
+
+		if buf.Len() > 0 {
+			buf.WriteString("+")
+		}
+
+		switch mod {
+		case ModControl:
+			buf.WriteString("Ctrl")
+
+		case ModShift:
+			buf.WriteString("Shift")
+
+		case ModAlt:
+			buf.WriteString("Alt")
+		}
+	}
+
+	return buf.String()
+}
+
+// Shortcut is a key combination that triggers an Action.
+//
+// The zero value means no shortcut.
+type Shortcut struct {
+	Modifiers Modifiers
+	Key       Key
+}
+
+var keyNames = map[Key]string{
+	VK_BACK:       "Backspace",
+	VK_TAB:        "Tab",
+	VK_RETURN:     "Enter",
+	VK_PAUSE:      "Pause",
+	VK_ESCAPE:     "Esc",
+	VK_SPACE:      "Space",
+	VK_PRIOR:      "PgUp",
+	VK_NEXT:       "PgDown",
+	VK_END:        "End",
+	VK_HOME:       "Home",
+	VK_LEFT:       "Left",
+	VK_UP:         "Up",
+	VK_RIGHT:      "Right",
+	VK_DOWN:       "Down",
+	VK_INSERT:     "Ins",
+	VK_DELETE:     "Del",
+	VK_MULTIPLY:   "Num*",
+	VK_ADD:        "Num+",
+	VK_SUBTRACT:   "Num-",
+	VK_DECIMAL:    "Num.",
+	VK_DIVIDE:     "Num/",
+	VK_OEM_PLUS:   "+",
+	VK_OEM_COMMA:  ",",
+	VK_OEM_MINUS:  "-",
+	VK_OEM_PERIOD: ".",
+}
+
+// keyAliases are accepted by ParseShortcut in addition to the names of
+// keyNames.
+var keyAliases = map[string]Key{
+	"back":     VK_BACK,
+	"return":   VK_RETURN,
+	"escape":   VK_ESCAPE,
+	"pageup":   VK_PRIOR,
+	"pagedown": VK_NEXT,
+	"insert":   VK_INSERT,
+	"delete":   VK_DELETE,
+}
+
+var modifierNames = map[string]Modifiers{
+	"shift":   ModShift,
+	"ctrl":    ModControl,
+	"control": ModControl,
+	"alt":     ModAlt,
+}
+
+// keyName returns the name of key, or "" if it has none.
+func keyName(key Key) string {
+	switch {
+	case key >= 'A' && key <= 'Z', key >= '0' && key <= '9':
+		return string(key)
+
+	case key >= VK_F1 && key <= VK_F24:
+		return fmt.Sprintf("F%d", key-VK_F1+1)
+
+	case key >= VK_NUMPAD0 && key <= VK_NUMPAD0+9:
+		return fmt.Sprintf("Num%d", key-VK_NUMPAD0)
+	}
+
+	return keyNames[key]
+}
+
+// keyForName returns the key named name, ignoring case.
+func keyForName(name string) (Key, bool) {
+	lower := strings.ToLower(name)
+
+	if len(name) == 1 {
+		c := int(strings.ToUpper(name)[0])
+		if c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+			return Key(c), true
+		}
+	}
+
+	if key, ok := keyAliases[lower]; ok {
+		return key, true
+	}
+
+	for key, n := range keyNames {
+		if strings.ToLower(n) == lower {
+			return key, true
+		}
+	}
+
+	if strings.HasPrefix(lower, "f") {
+		if n, err := strconv.Atoi(lower[1:]); err == nil && n >= 1 && n <= 24 {
+			return Key(VK_F1 + n - 1), true
+		}
+	}
+	if strings.HasPrefix(lower, "num") {
+		if n, err := strconv.Atoi(lower[3:]); err == nil && n >= 0 && n <= 9 {
+			return Key(VK_NUMPAD0 + n), true
+		}
+	}
+
+	return 0, false
+}
+
+// ParseShortcut parses shortcuts like "Ctrl+Shift+S", "F5" or "Ctrl++".
+//
+// Modifier and key names are case-insensitive. An empty string results in the
+// zero Shortcut.
+func ParseShortcut(s string) (Shortcut, os.Error) {
+	var shortcut Shortcut
+
+	rest := strings.TrimSpace(s)
+	if rest == "" {
+		return shortcut, nil
+	}
+
+	for {
+		i := strings.Index(rest, "+")
+		if i < 1 {
+			break
+		}
+
+		modifier, ok := modifierNames[strings.ToLower(strings.TrimSpace(rest[:i]))]
+		if !ok {
+			break
+		}
+
+		shortcut.Modifiers |= modifier
+		rest = strings.TrimSpace(rest[i+1:])
+	}
+
+	key, ok := keyForName(rest)
+	if !ok {
+		return Shortcut{}, newError(fmt.Sprintf("invalid shortcut %q: unknown key %q", s, rest))
+	}
+
+	shortcut.Key = key
+
+	return shortcut, nil
+}
+
+// String returns the shortcut the way it is displayed in menus, e.g.
+// "Ctrl+Shift+S".
+func (s Shortcut) String() string {
+	if s.Key == 0 {
+		return ""
+	}
+
+	name := keyName(s.Key)
+	if name == "" {
+		name = fmt.Sprintf("0x%02X", int(s.Key))
+	}
+
+	if s.Modifiers == 0 {
+		return name
+	}
+
+	return s.Modifiers.String() + "+" + name
+}
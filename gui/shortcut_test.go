@@ -0,0 +1,111 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"testing"
+)
+
+import (
+	. "walk/winapi/user32"
+)
+
+func TestParseShortcut(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected Shortcut
+	}{
+		{"", Shortcut{}},
+		{"A", Shortcut{0, 'A'}},
+		{"ctrl+s", Shortcut{ModControl, 'S'}},
+		{"Control + Shift + S", Shortcut{ModControl | ModShift, 'S'}},
+		{"Alt+F4", Shortcut{ModAlt, VK_F4}},
+		{"f24", Shortcut{0, VK_F24}},
+		{"Ctrl++", Shortcut{ModControl, VK_OEM_PLUS}},
+		{"+", Shortcut{0, VK_OEM_PLUS}},
+		{"Shift+Num7", Shortcut{ModShift, VK_NUMPAD0 + 7}},
+		{"Ctrl+Return", Shortcut{ModControl, VK_RETURN}},
+		{"PageDown", Shortcut{0, VK_NEXT}},
+		{"del", Shortcut{0, VK_DELETE}},
+	}
+
+	for _, test := range tests {
+		shortcut, err := ParseShortcut(test.s)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", test.s, err)
+			continue
+		}
+
+		if shortcut != test.expected {
+			t.Errorf("%q: expected %v, got %v", test.s, test.expected, shortcut)
+		}
+	}
+}
+
+func TestParseShortcutErrors(t *testing.T) {
+	for _, s := range []string{"Ctrl+", "Hyper+A", "F25", "Num10", "AB"} {
+		if shortcut, err := ParseShortcut(s); err == nil {
+			t.Errorf("%q: expected an error, got %v", s, shortcut)
+		}
+	}
+}
+
+func TestShortcutString(t *testing.T) {
+	tests := []struct {
+		shortcut Shortcut
+		expected string
+	}{
+		{Shortcut{}, ""},
+		{Shortcut{ModControl, 0}, ""},
+		{Shortcut{0, 'A'}, "A"},
+		{Shortcut{ModAlt | ModShift | ModControl, 'S'}, "Ctrl+Shift+Alt+S"},
+		{Shortcut{ModControl, VK_OEM_PLUS}, "Ctrl++"},
+		{Shortcut{0, VK_F12}, "F12"},
+		{Shortcut{ModShift, VK_NUMPAD0 + 3}, "Shift+Num3"},
+		{Shortcut{0, VK_ESCAPE}, "Esc"},
+		{Shortcut{0, 0xFF}, "0xFF"},
+	}
+
+	for _, test := range tests {
+		if s := test.shortcut.String(); s != test.expected {
+			t.Errorf("%v: expected %q, got %q", test.shortcut, test.expected, s)
+		}
+	}
+}
+
+func TestShortcutRoundTrip(t *testing.T) {
+	var keys []Key
+	for key := Key('A'); key <= 'Z'; key++ {
+		keys = append(keys, key)
+	}
+	for key := Key('0'); key <= '9'; key++ {
+		keys = append(keys, key)
+	}
+	for key := Key(VK_F1); key <= VK_F24; key++ {
+		keys = append(keys, key)
+	}
+	for key := Key(VK_NUMPAD0); key <= VK_NUMPAD0+9; key++ {
+		keys = append(keys, key)
+	}
+	for key := range keyNames {
+		keys = append(keys, key)
+	}
+
+	for _, key := range keys {
+		for mods := Modifiers(0); mods <= ModShift|ModControl|ModAlt; mods++ {
+			shortcut := Shortcut{mods, key}
+
+			parsed, err := ParseShortcut(shortcut.String())
+			if err != nil {
+				t.Errorf("%q: unexpected error: %s", shortcut.String(), err)
+				continue
+			}
+
+			if parsed != shortcut {
+				t.Errorf("%q: expected %v, got %v", shortcut.String(), shortcut, parsed)
+			}
+		}
+	}
+}
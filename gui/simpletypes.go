@@ -30,40 +30,107 @@ type EventHandler func(args EventArgs)
 
 type KeyEventArgs interface {
 	EventArgs
-	Key() int
+
+	// Key returns the key that was pressed or released. It is 0 for KeyPress
+	// events.
+	Key() Key
+
+	// Rune returns the character that was typed. It is 0 for KeyDown and
+	// KeyUp events.
+	Rune() int
+
+	// Modifiers returns the modifier keys that were held down.
+	Modifiers() Modifiers
+
+	// IsRepeat returns whether the event was caused by the key being held
+	// down.
+	IsRepeat() bool
+
+	// Handled returns whether a handler has marked the event as handled.
+	Handled() bool
+
+	// SetHandled marks the event as handled, so that the widget does not
+	// process the key itself, e.g. a LineEdit does not insert a character.
+	SetHandled(value bool)
 }
 
 type keyEventArgs struct {
 	eventArgs
-	key int
+	key       Key
+	rune      int
+	modifiers Modifiers
+	repeat    bool
+	handled   bool
 }
 
-func (a *keyEventArgs) Key() int {
+func (a *keyEventArgs) Key() Key {
 	return a.key
 }
 
+func (a *keyEventArgs) Rune() int {
+	return a.rune
+}
+
+func (a *keyEventArgs) Modifiers() Modifiers {
+	return a.modifiers
+}
+
+func (a *keyEventArgs) IsRepeat() bool {
+	return a.repeat
+}
+
+func (a *keyEventArgs) Handled() bool {
+	return a.handled
+}
+
+func (a *keyEventArgs) SetHandled(value bool) {
+	a.handled = value
+}
+
 type KeyEventHandler func(args KeyEventArgs)
 
 
+// MouseButton identifies mouse buttons. The values can be combined to describe
+// the buttons that are held down.
 type MouseButton int
 
 const (
-	LeftButton MouseButton = iota
+	LeftButton MouseButton = 1 << iota
 	RightButton
 	MiddleButton
 )
 
 type MouseEventArgs interface {
 	EventArgs
+
+	// X and Y return the position of the mouse in client coordinates of the
+	// widget.
 	X() int
 	Y() int
+
+	// Button returns the button that was pressed or released, or 0 if the
+	// event is not about a button.
 	Button() MouseButton
+
+	// Buttons returns the buttons that are held down.
+	Buttons() MouseButton
+
+	// Modifiers returns the modifier keys that are held down.
+	Modifiers() Modifiers
+
+	// WheelDelta returns the distance the wheel was rotated by, in multiples
+	// of 120 for wheels without free rotation. It is positive if the wheel
+	// was rotated away from the user.
+	WheelDelta() int
 }
 
 type mouseEventArgs struct {
 	eventArgs
-	x, y   int
-	button MouseButton
+	x, y       int
+	button     MouseButton
+	buttons    MouseButton
+	modifiers  Modifiers
+	wheelDelta int
 }
 
 func (a *mouseEventArgs) X() int {
@@ -78,6 +145,18 @@ func (a *mouseEventArgs) Button() MouseButton {
 	return a.button
 }
 
+func (a *mouseEventArgs) Buttons() MouseButton {
+	return a.buttons
+}
+
+func (a *mouseEventArgs) Modifiers() Modifiers {
+	return a.modifiers
+}
+
+func (a *mouseEventArgs) WheelDelta() int {
+	return a.wheelDelta
+}
+
 type MouseEventHandler func(args MouseEventArgs)
 
 
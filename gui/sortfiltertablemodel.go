@@ -0,0 +1,572 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+type SortOrder byte
+
+const (
+	SortAscending SortOrder = iota
+	SortDescending
+)
+
+// SortKey specifies a column to sort by and the direction.
+type SortKey struct {
+	Column int
+	Order  SortOrder
+}
+
+// TableValueComparator returns a negative number if a sorts before b, a
+// positive number if b sorts before a and 0 if they are equal.
+type TableValueComparator func(a, b interface{}) int
+
+// TableRowFilter returns whether row of model should be displayed.
+type TableRowFilter func(model TableModel, row int) bool
+
+type sortableTableModel interface {
+	TableModel
+	SortKeys() []SortKey
+	SetSortKeys(keys []SortKey) os.Error
+}
+
+// SortFilterTableModel is a TableModel that presents the rows of another
+// TableModel, the source, sorted and filtered.
+//
+// Sorting is stable, so rows that are equal in all sort keys keep the order
+// they have in the source.
+//
+// Changes to the source are published as changes to the rows they affect, and
+// sorting publishes the rows as reordered, so widgets like ListView keep their
+// selection. Only a reset of the source resets the SortFilterTableModel.
+type SortFilterTableModel struct {
+	TableModelBase
+	source      TableModel
+	sortKeys    []SortKey
+	comparators map[int]TableValueComparator
+	filter      TableRowFilter
+	rows        []int
+}
+
+func NewSortFilterTableModel(source TableModel) (*SortFilterTableModel, os.Error) {
+	if source == nil {
+		return nil, newError("source cannot be nil")
+	}
+
+	m := &SortFilterTableModel{
+		source:      source,
+		comparators: make(map[int]TableValueComparator),
+	}
+
+	m.update()
+
+	source.addObserver(m)
+
+	return m, nil
+}
+
+// Dispose detaches the SortFilterTableModel from its source.
+func (m *SortFilterTableModel) Dispose() {
+	if m.source != nil {
+		m.source.removeObserver(m)
+		m.source = nil
+		m.rows = nil
+	}
+}
+
+func (m *SortFilterTableModel) Source() TableModel {
+	return m.source
+}
+
+func (m *SortFilterTableModel) RowCount() int {
+	return len(m.rows)
+}
+
+func (m *SortFilterTableModel) Value(row, col int) interface{} {
+	return m.source.Value(m.rows[row], col)
+}
+
+// SourceRow returns the row of the source that is displayed as row.
+func (m *SortFilterTableModel) SourceRow(row int) int {
+	return m.rows[row]
+}
+
+// RowForSourceRow returns the row that sourceRow is displayed as, or -1 if it
+// is filtered out.
+func (m *SortFilterTableModel) RowForSourceRow(sourceRow int) int {
+	for row, r := range m.rows {
+		if r == sourceRow {
+			return row
+		}
+	}
+
+	return -1
+}
+
+func (m *SortFilterTableModel) SortKeys() []SortKey {
+	return m.sortKeys
+}
+
+// SetSortKeys sorts the rows by keys. The first key has the highest priority.
+// An empty slice restores the order of the source.
+func (m *SortFilterTableModel) SetSortKeys(keys []SortKey) os.Error {
+	for _, key := range keys {
+		if key.Column < 0 {
+			return newError("column cannot be negative")
+		}
+		if key.Order != SortAscending && key.Order != SortDescending {
+			return newError("invalid sort order")
+		}
+	}
+
+	m.sortKeys = make([]SortKey, len(keys))
+	copy(m.sortKeys, keys)
+
+	m.resort()
+
+	return nil
+}
+
+// Sort sorts the rows by column only.
+func (m *SortFilterTableModel) Sort(column int, order SortOrder) os.Error {
+	return m.SetSortKeys([]SortKey{SortKey{column, order}})
+}
+
+// Comparator returns the function used to compare values of column.
+func (m *SortFilterTableModel) Comparator(column int) TableValueComparator {
+	if comparator, ok := m.comparators[column]; ok {
+		return comparator
+	}
+
+	return CompareTableValues
+}
+
+// SetComparator sets the function used to compare values of column. Pass nil
+// to use CompareTableValues.
+func (m *SortFilterTableModel) SetComparator(column int, comparator TableValueComparator) {
+	if comparator == nil {
+		m.comparators[column] = nil, false
+	} else {
+		m.comparators[column] = comparator
+	}
+
+	if m.sortsBy(column) {
+		m.resort()
+	}
+}
+
+func (m *SortFilterTableModel) Filter() TableRowFilter {
+	return m.filter
+}
+
+// SetFilter sets the function that decides which rows of the source are
+// displayed. Pass nil to display all rows.
+func (m *SortFilterTableModel) SetFilter(filter TableRowFilter) {
+	m.filter = filter
+
+	oldRows := m.copyRows()
+	m.update()
+	m.publishRowChanges(oldRows, 0, -1, false)
+}
+
+func (m *SortFilterTableModel) resort() {
+	oldRows := m.copyRows()
+	m.update()
+	m.publishRowChanges(oldRows, 0, -1, true)
+}
+
+func (m *SortFilterTableModel) copyRows() []int {
+	rows := make([]int, len(m.rows))
+	copy(rows, m.rows)
+
+	return rows
+}
+
+func (m *SortFilterTableModel) sortsBy(column int) bool {
+	for _, key := range m.sortKeys {
+		if key.Column == column {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *SortFilterTableModel) update() {
+	count := m.source.RowCount()
+
+	rows := make([]int, 0, count)
+	for row := 0; row < count; row++ {
+		if m.filter != nil && !m.filter(m.source, row) {
+			continue
+		}
+
+		rows = rows[0 : len(rows)+1]
+		rows[len(rows)-1] = row
+	}
+
+	if len(m.sortKeys) > 0 {
+		comparators := make([]TableValueComparator, len(m.sortKeys))
+		for i, key := range m.sortKeys {
+			comparators[i] = m.Comparator(key.Column)
+		}
+
+		stableSortRows(rows, func(a, b int) bool {
+			for i, key := range m.sortKeys {
+				c := comparators[i](m.source.Value(a, key.Column), m.source.Value(b, key.Column))
+				if key.Order == SortDescending {
+					c = -c
+				}
+
+				if c != 0 {
+					return c < 0
+				}
+			}
+
+			return false
+		})
+	}
+
+	m.rows = rows
+}
+
+// publishRowChanges notifies observers of how the rows changed from oldRows,
+// the source rows displayed before, to m.rows. oldRows must already be
+// adjusted to the current rows of the source, with -1 for removed ones.
+//
+// Rows that are no longer displayed are published as removed first, then the
+// remaining rows as reordered, if their order changed or resorted is true, and
+// finally the rows that are displayed now as inserted. Remaining rows of the
+// source rows changedFrom through changedTo are published as changed.
+func (m *SortFilterTableModel) publishRowChanges(oldRows []int, changedFrom, changedTo int, resorted bool) {
+	isDisplayed := make(map[int]bool, len(m.rows))
+	for _, sourceRow := range m.rows {
+		isDisplayed[sourceRow] = true
+	}
+
+	kept := make([]bool, len(oldRows))
+	wasDisplayed := make(map[int]bool, len(oldRows))
+	for row, sourceRow := range oldRows {
+		if isDisplayed[sourceRow] {
+			kept[row] = true
+			wasDisplayed[sourceRow] = true
+		}
+	}
+
+	// Going backwards, the removed rows keep their indexes until published.
+	for to := len(oldRows) - 1; to >= 0; to-- {
+		if kept[to] {
+			continue
+		}
+
+		from := to
+		for from > 0 && !kept[from-1] {
+			from--
+		}
+
+		m.PublishRowsRemoved(from, to)
+
+		to = from
+	}
+
+	// The remaining rows, in their old order, move to the order they have
+	// among each other in m.rows.
+	rankBySourceRow := make(map[int]int, len(wasDisplayed))
+	for _, sourceRow := range m.rows {
+		if wasDisplayed[sourceRow] {
+			rankBySourceRow[sourceRow] = len(rankBySourceRow)
+		}
+	}
+
+	newRows := make([]int, 0, len(rankBySourceRow))
+	reordered := false
+	for _, sourceRow := range oldRows {
+		if wasDisplayed[sourceRow] {
+			rank := rankBySourceRow[sourceRow]
+			if rank != len(newRows) {
+				reordered = true
+			}
+
+			newRows = append(newRows, rank)
+		}
+	}
+
+	if reordered || resorted {
+		m.PublishRowsReordered(newRows)
+	}
+
+	// Going forwards, the inserted rows get their final indexes.
+	for from := 0; from < len(m.rows); from++ {
+		if wasDisplayed[m.rows[from]] {
+			continue
+		}
+
+		to := from
+		for to+1 < len(m.rows) && !wasDisplayed[m.rows[to+1]] {
+			to++
+		}
+
+		m.PublishRowsInserted(from, to)
+
+		from = to
+	}
+
+	isChanged := func(row int) bool {
+		sourceRow := m.rows[row]
+		return wasDisplayed[sourceRow] && sourceRow >= changedFrom && sourceRow <= changedTo
+	}
+
+	for from := 0; from < len(m.rows); from++ {
+		if !isChanged(from) {
+			continue
+		}
+
+		to := from
+		for to+1 < len(m.rows) && isChanged(to+1) {
+			to++
+		}
+
+		m.PublishRowsChanged(from, to)
+
+		from = to
+	}
+}
+
+func (m *SortFilterTableModel) onTableModelRowsReset() {
+	m.update()
+	m.PublishRowsReset()
+}
+
+func (m *SortFilterTableModel) onTableModelRowsInserted(from, to int) {
+	oldRows := m.copyRows()
+	for i, sourceRow := range oldRows {
+		if sourceRow >= from {
+			oldRows[i] += to - from + 1
+		}
+	}
+
+	m.update()
+	m.publishRowChanges(oldRows, 0, -1, false)
+}
+
+func (m *SortFilterTableModel) onTableModelRowsRemoved(from, to int) {
+	oldRows := m.copyRows()
+	for i, sourceRow := range oldRows {
+		switch {
+		case sourceRow > to:
+			oldRows[i] -= to - from + 1
+
+		case sourceRow >= from:
+			oldRows[i] = -1
+		}
+	}
+
+	m.update()
+	m.publishRowChanges(oldRows, 0, -1, false)
+}
+
+// onTableModelRowsChanged publishes the changed rows as changed, but also as
+// removed or inserted if the filter now rejects or accepts them, and all rows
+// as reordered if they have to be sorted differently.
+func (m *SortFilterTableModel) onTableModelRowsChanged(from, to int) {
+	oldRows := m.copyRows()
+
+	m.update()
+	m.publishRowChanges(oldRows, from, to, false)
+}
+
+func (m *SortFilterTableModel) onTableModelRowsReordered(newRows []int) {
+	oldRows := m.copyRows()
+	for i, sourceRow := range oldRows {
+		oldRows[i] = newRows[sourceRow]
+	}
+
+	m.update()
+	m.publishRowChanges(oldRows, 0, -1, false)
+}
+
+// stableSortRows sorts rows using a merge sort, which keeps rows that are
+// neither less nor greater than each other in their original order.
+func stableSortRows(rows []int, less func(a, b int) bool) {
+	if len(rows) < 2 {
+		return
+	}
+
+	src, dst := rows, make([]int, len(rows))
+
+	for width := 1; width < len(rows); width *= 2 {
+		for lo := 0; lo < len(rows); lo += 2 * width {
+			mid := lo + width
+			if mid > len(rows) {
+				mid = len(rows)
+			}
+			hi := mid + width
+			if hi > len(rows) {
+				hi = len(rows)
+			}
+
+			i, j, k := lo, mid, lo
+			for i < mid && j < hi {
+				if less(src[j], src[i]) {
+					dst[k] = src[j]
+					j++
+				} else {
+					dst[k] = src[i]
+					i++
+				}
+				k++
+			}
+			k += copy(dst[k:hi], src[i:mid])
+			copy(dst[k:hi], src[j:hi])
+		}
+
+		src, dst = dst, src
+	}
+
+	// After an odd number of passes the sorted rows are in the buffer.
+	if &src[0] != &rows[0] {
+		copy(rows, src)
+	}
+}
+
+// CompareTableValues is the default TableValueComparator.
+//
+// nil sorts before everything else. Numbers are compared numerically, bools
+// false before true and strings case-insensitively. Values of other types
+// are compared by their text as formatted by fmt.Sprint.
+func CompareTableValues(a, b interface{}) int {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0
+
+		case a == nil:
+			return -1
+		}
+
+		return 1
+	}
+
+	if x, ok := tableValueAsInt(a); ok {
+		if y, ok := tableValueAsInt(b); ok {
+			switch {
+			case x < y:
+				return -1
+
+			case x > y:
+				return 1
+			}
+
+			return 0
+		}
+	}
+
+	if x, ok := tableValueAsFloat(a); ok {
+		if y, ok := tableValueAsFloat(b); ok {
+			switch {
+			case x < y:
+				return -1
+
+			case x > y:
+				return 1
+			}
+
+			return 0
+		}
+	}
+
+	if x, ok := a.(bool); ok {
+		if y, ok := b.(bool); ok {
+			switch {
+			case x == y:
+				return 0
+
+			case y:
+				return -1
+			}
+
+			return 1
+		}
+	}
+
+	x, y := fmt.Sprint(a), fmt.Sprint(b)
+
+	if c := compareStrings(strings.ToLower(x), strings.ToLower(y)); c != 0 {
+		return c
+	}
+
+	return compareStrings(x, y)
+}
+
+func compareStrings(a, b string) int {
+	switch {
+	case a < b:
+		return -1
+
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+func tableValueAsInt(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+
+	case int8:
+		return int64(v), true
+
+	case int16:
+		return int64(v), true
+
+	case int32:
+		return int64(v), true
+
+	case int64:
+		return v, true
+
+	case uint:
+		return int64(v), true
+
+	case uint8:
+		return int64(v), true
+
+	case uint16:
+		return int64(v), true
+
+	case uint32:
+		return int64(v), true
+	}
+
+	return 0, false
+}
+
+func tableValueAsFloat(value interface{}) (float64, bool) {
+	if i, ok := tableValueAsInt(value); ok {
+		return float64(i), true
+	}
+
+	switch v := value.(type) {
+	case float:
+		return float64(v), true
+
+	case float32:
+		return float64(v), true
+
+	case float64:
+		return v, true
+
+	case uint64:
+		return float64(v), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,317 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"fmt"
+	"testing"
+)
+
+import (
+	. "walk/winapi/comctl32"
+)
+
+func newTestSortFilterTableModel(t *testing.T, values ...interface{}) (*MemoryTableModel, *SortFilterTableModel) {
+	source := NewMemoryTableModel()
+	for _, value := range values {
+		source.AddRow([]interface{}{value})
+	}
+
+	m, err := NewSortFilterTableModel(source)
+	if err != nil {
+		t.Fatalf("NewSortFilterTableModel failed: %s", err)
+	}
+
+	return source, m
+}
+
+func checkTableValues(t *testing.T, name string, m TableModel, expected ...interface{}) {
+	values := make([]interface{}, m.RowCount())
+	for row := range values {
+		values[row] = m.Value(row, 0)
+	}
+
+	if fmt.Sprint(values) != fmt.Sprint(expected) {
+		t.Errorf("%s: expected rows %v, got %v", name, expected, values)
+	}
+}
+
+func TestSortFilterTableModelSort(t *testing.T) {
+	_, m := newTestSortFilterTableModel(t, "b", 3, nil, "A", 1, "a")
+
+	m.Sort(0, SortAscending)
+	checkTableValues(t, "ascending", m, nil, 1, 3, "A", "a", "b")
+
+	m.Sort(0, SortDescending)
+	checkTableValues(t, "descending", m, "b", "a", "A", 3, 1, nil)
+
+	m.SetSortKeys(nil)
+	checkTableValues(t, "unsorted", m, "b", 3, nil, "A", 1, "a")
+
+	m.SetComparator(0, func(a, b interface{}) int {
+		return CompareTableValues(fmt.Sprint(a), fmt.Sprint(b))
+	})
+	m.Sort(0, SortAscending)
+	checkTableValues(t, "comparator", m, 1, 3, nil, "A", "a", "b")
+}
+
+func TestSortFilterTableModelNotifications(t *testing.T) {
+	source, m := newTestSortFilterTableModel(t, 30, 10, 20)
+	m.Sort(0, SortAscending)
+
+	r := &tableModelRecorder{}
+	m.addObserver(r)
+
+	source.AddRow([]interface{}{0})
+	checkTableValues(t, "AddRow", m, 0, 10, 20, 30)
+	r.check(t, "AddRow", "inserted 0-0")
+
+	source.AddRows([][]interface{}{{50}, {40}})
+	checkTableValues(t, "AddRows", m, 0, 10, 20, 30, 40, 50)
+	r.check(t, "AddRows", "inserted 4-5")
+
+	source.RemoveRow(0)
+	checkTableValues(t, "RemoveRow", m, 0, 10, 20, 40, 50)
+	r.check(t, "RemoveRow", "removed 3-3")
+
+	source.SetValue(0, 0, 11)
+	checkTableValues(t, "SetValue in place", m, 0, 11, 20, 40, 50)
+	r.check(t, "SetValue in place", "changed 1-1")
+
+	source.SetValue(0, 0, 45)
+	checkTableValues(t, "SetValue moving", m, 0, 20, 40, 45, 50)
+	r.check(t, "SetValue moving", "reordered [0 3 1 2 4]", "changed 3-3")
+
+	m.SetFilter(func(model TableModel, row int) bool {
+		return model.Value(row, 0).(int)%20 == 0
+	})
+	checkTableValues(t, "SetFilter", m, 0, 20, 40)
+	r.check(t, "SetFilter", "removed 3-4")
+
+	source.SetValue(0, 0, 60)
+	checkTableValues(t, "SetValue accepted", m, 0, 20, 40, 60)
+	r.check(t, "SetValue accepted", "inserted 3-3")
+
+	m.SetFilter(nil)
+	checkTableValues(t, "no filter", m, 0, 20, 40, 50, 60)
+	r.check(t, "no filter", "inserted 3-3")
+
+	m.Sort(0, SortDescending)
+	checkTableValues(t, "descending", m, 60, 50, 40, 20, 0)
+	r.check(t, "descending", "reordered [4 3 2 1 0]")
+
+	source.SetRows([][]interface{}{{1}})
+	r.check(t, "SetRows", "reset")
+}
+
+func TestSortFilterTableModelDispose(t *testing.T) {
+	source, m := newTestSortFilterTableModel(t, 1, 2)
+
+	m.Dispose()
+
+	if source.observers.Len() != 0 {
+		t.Errorf("expected Dispose to stop observing the source, got %d observers", source.observers.Len())
+	}
+}
+
+func TestListViewKeepsSelectionWhenSorting(t *testing.T) {
+	_, lv := newTestListView(t)
+	lv.SetMultiSelection(true)
+	lv.SetCheckBoxes(true)
+
+	source, m := newTestSortFilterTableModel(t, "c", "a", "b")
+	lv.SetModel(m)
+
+	// Select "a" and check "b" and "c".
+	lv.SetSelectedIndex(1)
+	lv.SetCheckedIndexes([]int{0, 2})
+
+	m.Sort(0, SortAscending)
+
+	if i := lv.SelectedIndex(); i != 0 {
+		t.Errorf("expected the selection to move to row 0, got %d", i)
+	}
+	if indexes := lv.CheckedIndexes(); fmt.Sprint(indexes) != "[1 2]" {
+		t.Errorf("expected rows [1 2] to be checked, got %v", indexes)
+	}
+
+	source.AddRow([]interface{}{"0"})
+
+	if i := lv.SelectedIndex(); i != 1 {
+		t.Errorf("expected the selection to move to row 1 after inserting, got %d", i)
+	}
+	if indexes := lv.CheckedIndexes(); fmt.Sprint(indexes) != "[2 3]" {
+		t.Errorf("expected rows [2 3] to be checked after inserting, got %v", indexes)
+	}
+
+	m.SetFilter(func(model TableModel, row int) bool {
+		return model.Value(row, 0) != "b"
+	})
+
+	if i := lv.SelectedIndex(); i != 1 {
+		t.Errorf("expected the selection to stay at row 1 after filtering, got %d", i)
+	}
+	if indexes := lv.CheckedIndexes(); fmt.Sprint(indexes) != "[2]" {
+		t.Errorf("expected row [2] to be checked after filtering, got %v", indexes)
+	}
+}
+
+// columnValues returns the values of column of all rows of m as string.
+func columnValues(m TableModel, column int) string {
+	values := make([]interface{}, m.RowCount())
+	for row := range values {
+		values[row] = m.Value(row, column)
+	}
+
+	return fmt.Sprint(values)
+}
+
+func newTestMultiColumnModel(t *testing.T) (*MemoryTableModel, *SortFilterTableModel) {
+	source := NewMemoryTableModel()
+	source.AddRows([][]interface{}{
+		{"b", 2, "x"},
+		{"a", 2, "y"},
+		{"b", 1, "z"},
+		{"a", 1, "w"},
+		{"a", 2, "v"},
+	})
+
+	m, err := NewSortFilterTableModel(source)
+	if err != nil {
+		t.Fatalf("NewSortFilterTableModel failed: %s", err)
+	}
+
+	return source, m
+}
+
+func TestSortFilterTableModelSortKeys(t *testing.T) {
+	_, m := newTestMultiColumnModel(t)
+
+	keys := []SortKey{{1, SortAscending}, {0, SortDescending}}
+	if err := m.SetSortKeys(keys); err != nil {
+		t.Fatalf("SetSortKeys failed: %s", err)
+	}
+	if rows := columnValues(m, 2); rows != "[z w x y v]" {
+		t.Errorf("expected rows [z w x y v], got %s", rows)
+	}
+
+	// The model keeps its own copy of the keys.
+	keys[0].Order = SortDescending
+	if m.SortKeys()[0].Order != SortAscending {
+		t.Error("expected changes to the keys passed in to be ignored")
+	}
+
+	if err := m.SetSortKeys([]SortKey{{-1, SortAscending}}); err == nil {
+		t.Error("expected a negative column to fail")
+	}
+	if err := m.SetSortKeys([]SortKey{{0, SortOrder(2)}}); err == nil {
+		t.Error("expected an invalid order to fail")
+	}
+	if rows := columnValues(m, 2); rows != "[z w x y v]" {
+		t.Errorf("expected invalid keys to keep the rows, got %s", rows)
+	}
+}
+
+func TestSortFilterTableModelStableSort(t *testing.T) {
+	_, m := newTestMultiColumnModel(t)
+
+	// Equal rows keep the order of the source, in either direction.
+	m.Sort(0, SortAscending)
+	if rows := columnValues(m, 2); rows != "[y w v x z]" {
+		t.Errorf("ascending: expected rows [y w v x z], got %s", rows)
+	}
+
+	m.Sort(0, SortDescending)
+	if rows := columnValues(m, 2); rows != "[x z y w v]" {
+		t.Errorf("descending: expected rows [x z y w v], got %s", rows)
+	}
+}
+
+// newTestSortableListView returns a list view with three columns showing the
+// rows of newTestMultiColumnModel.
+func newTestSortableListView(t *testing.T) (*MemoryBackend, *ListView, *SortFilterTableModel) {
+	b, lv := newTestListView(t)
+
+	for _, title := range []string{"Name", "Count", "Id"} {
+		col := NewListViewColumn()
+		col.SetTitle(title)
+		if _, err := lv.Columns().Add(col); err != nil {
+			t.Fatalf("Add failed: %s", err)
+		}
+	}
+
+	_, m := newTestMultiColumnModel(t)
+	if err := lv.SetModel(m); err != nil {
+		t.Fatalf("SetModel failed: %s", err)
+	}
+
+	return b, lv, m
+}
+
+func TestListViewColumnClickSorts(t *testing.T) {
+	b, lv, m := newTestSortableListView(t)
+
+	clicks := []struct {
+		column int
+		keys   string
+		rows   string
+	}{
+		{0, "[{0 0}]", "[y w v x z]"},
+		// Clicking the primary sort column again reverses the order.
+		{0, "[{0 1}]", "[x z y w v]"},
+		{0, "[{0 0}]", "[y w v x z]"},
+		// Another column becomes the primary key, the others follow.
+		{1, "[{1 0} {0 0}]", "[w z y v x]"},
+		{0, "[{0 0} {1 0}]", "[w y v z x]"},
+		// A secondary key starts out ascending when promoted.
+		{1, "[{1 0} {0 0}]", "[w z y v x]"},
+		{1, "[{1 1} {0 0}]", "[y v x w z]"},
+	}
+
+	for _, click := range clicks {
+		if err := b.ClickListViewColumn(lv, click.column); err != nil {
+			t.Fatalf("ClickListViewColumn failed: %s", err)
+		}
+
+		if keys := fmt.Sprint(m.SortKeys()); keys != click.keys {
+			t.Errorf("click on %d: expected keys %s, got %s", click.column, click.keys, keys)
+		}
+		if rows := columnValues(m, 2); rows != click.rows {
+			t.Errorf("click on %d: expected rows %s, got %s", click.column, click.rows, rows)
+		}
+	}
+
+	// Columns that are not sortable ignore clicks.
+	lv.Columns().At(2).SetSortable(false)
+	b.ClickListViewColumn(lv, 2)
+	if keys := fmt.Sprint(m.SortKeys()); keys != "[{1 1} {0 0}]" {
+		t.Errorf("expected a click on an unsortable column to keep the keys, got %s", keys)
+	}
+}
+
+func TestListViewSortIndicators(t *testing.T) {
+	b, lv, m := newTestSortableListView(t)
+
+	columns := b.windows[lv.hWnd].listView.columns
+
+	checkIndicators := func(name string, expected ...int) {
+		for i, col := range columns {
+			if indicator := col.fmt & (HDF_SORTUP | HDF_SORTDOWN); indicator != expected[i] {
+				t.Errorf("%s: expected format %x for column %d, got %x", name, expected[i], i, indicator)
+			}
+		}
+	}
+
+	checkIndicators("unsorted", 0, 0, 0)
+
+	m.SetSortKeys([]SortKey{{1, SortAscending}, {0, SortDescending}})
+	checkIndicators("ascending", 0, HDF_SORTUP, 0)
+
+	m.Sort(2, SortDescending)
+	checkIndicators("descending", 0, 0, HDF_SORTDOWN)
+
+	m.SetSortKeys(nil)
+	checkIndicators("no keys", 0, 0, 0)
+}
@@ -5,11 +5,15 @@
 package gui
 
 import (
+	"bytes"
 	"os"
-	"syscall"
+	"strconv"
+	"strings"
 )
 
 import (
+	"walk/drawing"
+	. "walk/winapi"
 	. "walk/winapi/user32"
 )
 
@@ -22,24 +26,166 @@ const (
 
 const splitterWindowClass = `\o/ Walk_Splitter_Class \o/`
 
-var splitterWndProcCallback *syscall.Callback
-
-func splitterWndProc(args *uintptr) uintptr {
-	msg := msgFromCallbackArgs(args)
-
+func splitterWndProc(msg *MSG) uintptr {
 	s, ok := widgetsByHWnd[msg.HWnd].(*Splitter)
 	if !ok {
 		// Before CreateWindowEx returns, among others, WM_GETMINMAXINFO is sent.
 		// FIXME: Find a way to properly handle this.
-		return DefWindowProc(msg.HWnd, msg.Message, msg.WParam, msg.LParam)
+		return backend.DefWindowProc(msg, 0)
 	}
 
 	return s.wndProc(msg, 0)
 }
 
+// SplitterPane describes a pane for the sizing functions of Splitter.
+//
+// Size and MinSize are measured along the orientation of the Splitter, i.e.
+// they are widths for a horizontal and heights for a vertical Splitter.
+type SplitterPane struct {
+	Size          int
+	MinSize       int
+	StretchFactor int
+	Collapsed     bool
+}
+
+// ResizeSplitterPanes returns the sizes of panes after the space available
+// for them, i.e. the length of the Splitter minus its handles, has changed.
+//
+// Space is added to or taken from the panes as BoxLayout does: extra space
+// goes to the panes with a stretch factor, or to all panes if none has one.
+// Missing space is taken from all panes, but not below their MinSize, unless
+// there is no other way to fit them. Collapsed panes always have size 0.
+func ResizeSplitterPanes(panes []SplitterPane, available int) []int {
+	lines := make([]layoutLine, len(panes))
+
+	for i, pane := range panes {
+		lines[i] = layoutLine{
+			used:    !pane.Collapsed,
+			min:     pane.MinSize,
+			pref:    pane.Size,
+			grow:    true,
+			stretch: pane.StretchFactor,
+		}
+
+		if lines[i].pref < 0 {
+			lines[i].pref = 0
+		}
+		if lines[i].min > lines[i].pref {
+			lines[i].min = lines[i].pref
+		}
+	}
+
+	sizes := distributeLayoutSpace(lines, available)
+
+	// If the min sizes do not fit, cut off the last panes.
+	excess := -available
+	for _, size := range sizes {
+		excess += size
+	}
+
+	for i := len(sizes) - 1; i >= 0 && excess > 0; i-- {
+		d := sizes[i]
+		if d > excess {
+			d = excess
+		}
+
+		sizes[i] -= d
+		excess -= d
+	}
+
+	return sizes
+}
+
+// MoveSplitterHandle returns the sizes of panes after the handle with the
+// specified index, i.e. the handle between panes[handle] and panes[handle+1],
+// has been dragged by delta pixels.
+//
+// The nearest pane on the side the handle moves away from grows. The panes on
+// the other side shrink one after another, nearest first, down to their
+// MinSize. Collapsed panes are skipped and keep size 0.
+func MoveSplitterHandle(panes []SplitterPane, handle, delta int) []int {
+	sizes := make([]int, len(panes))
+
+	for i, pane := range panes {
+		if !pane.Collapsed {
+			sizes[i] = pane.Size
+		}
+	}
+
+	if handle < 0 || handle >= len(panes)-1 || delta == 0 {
+		return sizes
+	}
+
+	grow, shrink, step := handle, handle+1, 1
+	if delta < 0 {
+		grow, shrink, step, delta = handle+1, handle, -1, -delta
+	}
+
+	for grow >= 0 && grow < len(panes) && panes[grow].Collapsed {
+		grow -= step
+	}
+	if grow < 0 || grow >= len(panes) {
+		return sizes
+	}
+
+	moved := 0
+	for i := shrink; i >= 0 && i < len(panes) && moved < delta; i += step {
+		if panes[i].Collapsed {
+			continue
+		}
+
+		room := sizes[i] - panes[i].MinSize
+		if room <= 0 {
+			continue
+		}
+
+		d := delta - moved
+		if d > room {
+			d = room
+		}
+
+		sizes[i] -= d
+		moved += d
+	}
+
+	sizes[grow] += moved
+
+	return sizes
+}
+
+// splitterHandleAt returns the index of the handle at pos, if panes with the
+// specified sizes are separated by handles of handleWidth, or -1 if there is
+// no handle at pos.
+func splitterHandleAt(sizes []int, handleWidth, pos int) int {
+	start := 0
+
+	for i := 0; i < len(sizes)-1; i++ {
+		start += sizes[i]
+
+		if pos >= start && pos < start+handleWidth {
+			return i
+		}
+
+		start += handleWidth
+	}
+
+	return -1
+}
+
+type splitterPane struct {
+	size          int
+	stretchFactor int
+	collapsed     bool
+}
+
 type Splitter struct {
 	Container
-	orientation Orientation
+	orientation   Orientation
+	handleWidth   int
+	hWnd2Pane     map[HWND]*splitterPane
+	draggedHandle int
+	dragStartPos  int
+	dragPanes     []SplitterPane
 }
 
 func NewSplitter(parent IContainer) (*Splitter, os.Error) {
@@ -47,21 +193,26 @@ func NewSplitter(parent IContainer) (*Splitter, os.Error) {
 		return nil, newError("parent cannot be nil")
 	}
 
-	ensureRegisteredWindowClass(splitterWindowClass, splitterWndProc, &splitterWndProcCallback)
+	ensureRegisteredWindowClass(splitterWindowClass, splitterWndProc)
 
-	hWnd := CreateWindowEx(
-		0, syscall.StringToUTF16Ptr(splitterWindowClass), nil,
+	hWnd, err := backend.CreateWindow(
+		0, splitterWindowClass,
 		WS_CHILD|WS_VISIBLE,
-		0, 0, 200, 100, parent.Handle(), 0, 0, nil)
-	if hWnd == 0 {
-		return nil, lastError("CreateWindowEx")
+		parent.Handle(), drawing.Rectangle{0, 0, 200, 100})
+	if err != nil {
+		return nil, err
 	}
 
-	s := &Splitter{Container: Container{Widget: Widget{hWnd: hWnd, parent: parent}}}
+	s := &Splitter{
+		Container:     Container{Widget: Widget{hWnd: hWnd, parent: parent}},
+		handleWidth:   4,
+		hWnd2Pane:     make(map[HWND]*splitterPane),
+		draggedHandle: -1,
+	}
 
 	s.children = newObservedWidgetList(s)
 
-	s.SetFont(defaultFont)
+	s.SetFont(backend.DefaultFont())
 
 	widgetsByHWnd[hWnd] = s
 
@@ -70,12 +221,469 @@ func NewSplitter(parent IContainer) (*Splitter, os.Error) {
 	return s, nil
 }
 
-func (s *Splitter) onInsertingWidget(index int, widget IWidget) (err os.Error) {
+func (s *Splitter) SetLayout(value Layout) {
+	panic("not supported")
+}
+
+func (s *Splitter) LayoutFlags() LayoutFlags {
+	return ShrinkHorz | GrowHorz | ShrinkVert | GrowVert
+}
+
+func (s *Splitter) MinSize() (drawing.Size, os.Error) {
+	minSize, err := s.Widget.MinSize()
+	if err != nil {
+		return minSize, err
+	}
+
+	var main, cross int
+
+	count := s.children.Len()
+	for i := 0; i < count; i++ {
+		widget := s.children.At(i)
+
+		if s.PaneCollapsed(widget) {
+			continue
+		}
+
+		childMinSize, err := widget.MinSize()
+		if err != nil {
+			return drawing.Size{}, err
+		}
+
+		childMain, childCross := s.mainAndCross(childMinSize)
+
+		main += childMain
+		if childCross > cross {
+			cross = childCross
+		}
+	}
+
+	if count > 1 {
+		main += (count - 1) * s.handleWidth
+	}
+
+	size := s.sizeFromMainAndCross(main, cross)
+
+	if size.Width > minSize.Width {
+		minSize.Width = size.Width
+	}
+	if size.Height > minSize.Height {
+		minSize.Height = size.Height
+	}
+
+	return minSize, nil
+}
+
+func (s *Splitter) PreferredSize() drawing.Size {
+	var main, cross int
+
+	count := s.children.Len()
+	for i := 0; i < count; i++ {
+		widget := s.children.At(i)
+
+		childMain, childCross := s.mainAndCross(widget.PreferredSize())
+
+		if !s.PaneCollapsed(widget) {
+			main += childMain
+		}
+		if childCross > cross {
+			cross = childCross
+		}
+	}
+
+	if count > 1 {
+		main += (count - 1) * s.handleWidth
+	}
+
+	return s.sizeFromMainAndCross(main, cross)
+}
+
+func (s *Splitter) Orientation() Orientation {
+	return s.orientation
+}
+
+func (s *Splitter) SetOrientation(value Orientation) os.Error {
+	if value != Horizontal && value != Vertical {
+		return newError("invalid orientation")
+	}
+
+	if value != s.orientation {
+		s.orientation = value
+
+		// The old sizes are measured along the wrong axis.
+		count := s.children.Len()
+		for i := 0; i < count; i++ {
+			widget := s.children.At(i)
+
+			s.hWnd2Pane[widget.Handle()].size, _ = s.mainAndCross(widget.PreferredSize())
+		}
+
+		return s.updatePanes()
+	}
+
 	return nil
 }
 
+func (s *Splitter) HandleWidth() int {
+	return s.handleWidth
+}
+
+func (s *Splitter) SetHandleWidth(value int) os.Error {
+	if value != s.handleWidth {
+		if value < 1 {
+			return newError("value must be positive")
+		}
+
+		s.handleWidth = value
+
+		return s.updatePanes()
+	}
+
+	return nil
+}
+
+func (s *Splitter) pane(widget IWidget) (*splitterPane, os.Error) {
+	if widget == nil {
+		return nil, newError("widget cannot be nil")
+	}
+
+	pane, ok := s.hWnd2Pane[widget.Handle()]
+	if !ok {
+		return nil, newError("widget is not a pane of the splitter")
+	}
+
+	return pane, nil
+}
+
+func (s *Splitter) StretchFactor(widget IWidget) int {
+	pane, err := s.pane(widget)
+	if err != nil {
+		return 0
+	}
+
+	return pane.stretchFactor
+}
+
+// SetStretchFactor sets how much of the space gained or lost when the Splitter
+// is resized goes to the pane of widget, relative to the other panes.
+func (s *Splitter) SetStretchFactor(widget IWidget, factor int) os.Error {
+	if factor < 0 {
+		return newError("factor cannot be negative")
+	}
+
+	pane, err := s.pane(widget)
+	if err != nil {
+		return err
+	}
+
+	pane.stretchFactor = factor
+
+	return nil
+}
+
+func (s *Splitter) PaneCollapsed(widget IWidget) bool {
+	pane, err := s.pane(widget)
+	if err != nil {
+		return false
+	}
+
+	return pane.collapsed
+}
+
+// SetPaneCollapsed collapses the pane of widget to size 0 or restores it to its
+// size before it was collapsed. The user can do the same by double clicking a
+// handle.
+func (s *Splitter) SetPaneCollapsed(widget IWidget, collapsed bool) os.Error {
+	pane, err := s.pane(widget)
+	if err != nil {
+		return err
+	}
+
+	if collapsed == pane.collapsed {
+		return nil
+	}
+
+	pane.collapsed = collapsed
+
+	return s.updatePanes()
+}
+
+// SaveState returns the sizes and collapsed states of the panes as a string,
+// that can be passed to RestoreState.
+func (s *Splitter) SaveState() (string, os.Error) {
+	buf := bytes.NewBuffer(nil)
+
+	count := s.children.Len()
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			buf.WriteString(" ")
+		}
+
+		pane := s.hWnd2Pane[s.children.At(i).Handle()]
+
+		buf.WriteString(strconv.Itoa(pane.size))
+		if pane.collapsed {
+			buf.WriteString(":c")
+		}
+	}
+
+	return buf.String(), nil
+}
+
+func (s *Splitter) RestoreState(state string) os.Error {
+	if state == "" {
+		return nil
+	}
+
+	paneStrs := strings.Split(state, " ", -1)
+
+	if len(paneStrs) != s.children.Len() {
+		return newError("state does not match the panes of the splitter")
+	}
+
+	panes := make([]splitterPane, len(paneStrs))
+
+	for i, str := range paneStrs {
+		if strings.HasSuffix(str, ":c") {
+			str = str[0 : len(str)-2]
+			panes[i].collapsed = true
+		}
+
+		size, err := strconv.Atoi(str)
+		if err != nil {
+			return err
+		}
+		if size < 0 {
+			return newError("pane size cannot be negative")
+		}
+
+		panes[i].size = size
+	}
+
+	for i, p := range panes {
+		pane := s.hWnd2Pane[s.children.At(i).Handle()]
+
+		pane.size = p.size
+		pane.collapsed = p.collapsed
+	}
+
+	return s.updatePanes()
+}
+
+func (s *Splitter) mainAndCross(size drawing.Size) (main, cross int) {
+	if s.orientation == Horizontal {
+		return size.Width, size.Height
+	}
+
+	return size.Height, size.Width
+}
+
+func (s *Splitter) sizeFromMainAndCross(main, cross int) drawing.Size {
+	if s.orientation == Horizontal {
+		return drawing.Size{main, cross}
+	}
+
+	return drawing.Size{cross, main}
+}
+
+func (s *Splitter) panes() ([]SplitterPane, os.Error) {
+	panes := make([]SplitterPane, s.children.Len())
+
+	for i := range panes {
+		widget := s.children.At(i)
+
+		minSize, err := widget.MinSize()
+		if err != nil {
+			return nil, err
+		}
+
+		pane := s.hWnd2Pane[widget.Handle()]
+
+		panes[i].Size = pane.size
+		panes[i].MinSize, _ = s.mainAndCross(minSize)
+		panes[i].StretchFactor = pane.stretchFactor
+		panes[i].Collapsed = pane.collapsed
+	}
+
+	return panes, nil
+}
+
+func (s *Splitter) availableLength(cb drawing.Rectangle) int {
+	length, _ := s.mainAndCross(cb.Size())
+
+	if count := s.children.Len(); count > 1 {
+		length -= (count - 1) * s.handleWidth
+	}
+
+	return length
+}
+
+func (s *Splitter) updatePanes() os.Error {
+	if s.children.Len() == 0 {
+		return nil
+	}
+
+	cb, err := s.ClientBounds()
+	if err != nil {
+		return err
+	}
+
+	panes, err := s.panes()
+	if err != nil {
+		return err
+	}
+
+	return s.applySizes(ResizeSplitterPanes(panes, s.availableLength(cb)), cb)
+}
+
+func (s *Splitter) applySizes(sizes []int, cb drawing.Rectangle) os.Error {
+	pos := 0
+
+	for i, size := range sizes {
+		widget := s.children.At(i)
+
+		pane := s.hWnd2Pane[widget.Handle()]
+		if !pane.collapsed {
+			pane.size = size
+		}
+
+		var bounds drawing.Rectangle
+		if s.orientation == Horizontal {
+			bounds = drawing.Rectangle{cb.X + pos, cb.Y, size, cb.Height}
+		} else {
+			bounds = drawing.Rectangle{cb.X, cb.Y + pos, cb.Width, size}
+		}
+
+		if err := widget.SetBounds(bounds); err != nil {
+			return err
+		}
+
+		pos += size + s.handleWidth
+	}
+
+	return nil
+}
+
+func (s *Splitter) paneSizes() []int {
+	sizes := make([]int, s.children.Len())
+
+	for i := range sizes {
+		if pane := s.hWnd2Pane[s.children.At(i).Handle()]; !pane.collapsed {
+			sizes[i] = pane.size
+		}
+	}
+
+	return sizes
+}
+
+func (s *Splitter) handleAt(x, y int) int {
+	pos := x
+	if s.orientation == Vertical {
+		pos = y
+	}
+
+	return splitterHandleAt(s.paneSizes(), s.handleWidth, pos)
+}
+
+func (s *Splitter) toggleCollapsed(handle int) os.Error {
+	before := s.children.At(handle)
+	after := s.children.At(handle + 1)
+
+	beforePane := s.hWnd2Pane[before.Handle()]
+	afterPane := s.hWnd2Pane[after.Handle()]
+
+	switch {
+	case beforePane.collapsed:
+		return s.SetPaneCollapsed(before, false)
+
+	case afterPane.collapsed:
+		return s.SetPaneCollapsed(after, false)
+
+	case beforePane.size < afterPane.size:
+		return s.SetPaneCollapsed(before, true)
+	}
+
+	return s.SetPaneCollapsed(after, true)
+}
+
+func (s *Splitter) wndProc(msg *MSG, origWndProcPtr uintptr) uintptr {
+	switch msg.Message {
+	case WM_SIZE, WM_SIZING:
+		s.updatePanes()
+
+	case WM_SETCURSOR:
+		if LOWORD(uint(msg.LParam)) == HTCLIENT && s.children.Len() > 1 {
+			cursor := uint16(IDC_SIZEWE)
+			if s.orientation == Vertical {
+				cursor = IDC_SIZENS
+			}
+
+			backend.SetCursor(cursor)
+			return 1
+		}
+
+	case WM_LBUTTONDOWN:
+		handle := s.handleAt(GET_X_LPARAM(msg.LParam), GET_Y_LPARAM(msg.LParam))
+		if handle == -1 {
+			break
+		}
+
+		panes, err := s.panes()
+		if err != nil {
+			break
+		}
+
+		s.draggedHandle = handle
+		s.dragPanes = panes
+		s.dragStartPos, _ = s.mainAndCross(drawing.Size{GET_X_LPARAM(msg.LParam), GET_Y_LPARAM(msg.LParam)})
+
+		backend.SetCapture(s.hWnd)
+
+	case WM_MOUSEMOVE:
+		if s.draggedHandle == -1 {
+			break
+		}
+
+		cb, err := s.ClientBounds()
+		if err != nil {
+			break
+		}
+
+		pos, _ := s.mainAndCross(drawing.Size{GET_X_LPARAM(msg.LParam), GET_Y_LPARAM(msg.LParam)})
+
+		s.applySizes(MoveSplitterHandle(s.dragPanes, s.draggedHandle, pos-s.dragStartPos), cb)
+
+	case WM_LBUTTONUP:
+		if s.draggedHandle != -1 {
+			s.draggedHandle = -1
+			s.dragPanes = nil
+
+			backend.ReleaseCapture()
+		}
+
+	case WM_LBUTTONDBLCLK:
+		if handle := s.handleAt(GET_X_LPARAM(msg.LParam), GET_Y_LPARAM(msg.LParam)); handle != -1 {
+			s.toggleCollapsed(handle)
+		}
+	}
+
+	return s.Container.wndProc(msg, origWndProcPtr)
+}
+
+func (s *Splitter) onInsertingWidget(index int, widget IWidget) (err os.Error) {
+	return s.Container.onInsertingWidget(index, widget)
+}
+
 func (s *Splitter) onInsertedWidget(index int, widget IWidget) (err os.Error) {
-	panic("not implemented")
+	if err = s.Container.onInsertedWidget(index, widget); err != nil {
+		return
+	}
+
+	size, _ := s.mainAndCross(widget.PreferredSize())
+
+	s.hWnd2Pane[widget.Handle()] = &splitterPane{size: size}
+
+	return s.updatePanes()
 }
 
 func (s *Splitter) onRemovingWidget(index int, widget IWidget) (err os.Error) {
@@ -83,13 +691,24 @@ func (s *Splitter) onRemovingWidget(index int, widget IWidget) (err os.Error) {
 }
 
 func (s *Splitter) onRemovedWidget(index int, widget IWidget) (err os.Error) {
-	panic("not implemented")
+	s.hWnd2Pane[widget.Handle()] = nil, false
+
+	return s.updatePanes()
 }
 
 func (s *Splitter) onClearingWidgets() (err os.Error) {
-	panic("not implemented")
+	// Unparenting a widget removes it from the list, so go backwards.
+	for i := s.children.Len() - 1; i >= 0; i-- {
+		if err = s.children.At(i).SetParent(nil); err != nil {
+			return
+		}
+	}
+
+	return
 }
 
 func (s *Splitter) onClearedWidgets() (err os.Error) {
-	panic("not implemented")
+	s.hWnd2Pane = make(map[HWND]*splitterPane)
+
+	return
 }
@@ -0,0 +1,286 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"testing"
+)
+
+import (
+	"walk/drawing"
+	. "walk/winapi"
+	. "walk/winapi/user32"
+)
+
+func checkPaneSizes(t *testing.T, name string, expected, actual []int) {
+	if len(actual) != len(expected) {
+		t.Errorf("%s: expected %v, got %v", name, expected, actual)
+		return
+	}
+
+	for i := range actual {
+		if actual[i] != expected[i] {
+			t.Errorf("%s: expected %v, got %v", name, expected, actual)
+			return
+		}
+	}
+}
+
+func TestResizeSplitterPanes(t *testing.T) {
+	tests := []struct {
+		name      string
+		panes     []SplitterPane
+		available int
+		expected  []int
+	}{
+		{
+			"grow evenly",
+			[]SplitterPane{{Size: 100}, {Size: 100}},
+			210,
+			[]int{105, 105},
+		},
+		{
+			"grow by stretch factor",
+			[]SplitterPane{{Size: 100}, {Size: 100, StretchFactor: 1}},
+			250,
+			[]int{100, 150},
+		},
+		{
+			"shrink to min",
+			[]SplitterPane{{Size: 100, MinSize: 90}, {Size: 100}},
+			150,
+			[]int{90, 60},
+		},
+		{
+			"collapsed",
+			[]SplitterPane{{Size: 100, Collapsed: true}, {Size: 100}},
+			150,
+			[]int{0, 150},
+		},
+		{
+			"min sizes do not fit",
+			[]SplitterPane{{Size: 50, MinSize: 50}, {Size: 50, MinSize: 50}},
+			80,
+			[]int{50, 30},
+		},
+	}
+
+	for _, test := range tests {
+		checkPaneSizes(t, test.name, test.expected, ResizeSplitterPanes(test.panes, test.available))
+	}
+}
+
+func TestMoveSplitterHandle(t *testing.T) {
+	tests := []struct {
+		name          string
+		panes         []SplitterPane
+		handle, delta int
+		expected      []int
+	}{
+		{
+			"forward",
+			[]SplitterPane{{Size: 100}, {Size: 100}},
+			0, 30,
+			[]int{130, 70},
+		},
+		{
+			"backward",
+			[]SplitterPane{{Size: 100}, {Size: 100}},
+			0, -30,
+			[]int{70, 130},
+		},
+		{
+			"stop at min",
+			[]SplitterPane{{Size: 100}, {Size: 100, MinSize: 80}},
+			0, 30,
+			[]int{120, 80},
+		},
+		{
+			"shrink nearest first",
+			[]SplitterPane{{Size: 100}, {Size: 50, MinSize: 40}, {Size: 100}},
+			0, 30,
+			[]int{130, 40, 80},
+		},
+		{
+			"skip collapsed when shrinking",
+			[]SplitterPane{{Size: 100}, {Collapsed: true}, {Size: 100}},
+			0, 20,
+			[]int{120, 0, 80},
+		},
+		{
+			"skip collapsed when growing",
+			[]SplitterPane{{Size: 100}, {Size: 100, Collapsed: true}, {Size: 100}},
+			0, -20,
+			[]int{80, 0, 120},
+		},
+		{
+			"invalid handle",
+			[]SplitterPane{{Size: 100}, {Size: 100}},
+			1, 30,
+			[]int{100, 100},
+		},
+	}
+
+	for _, test := range tests {
+		checkPaneSizes(t, test.name, test.expected, MoveSplitterHandle(test.panes, test.handle, test.delta))
+	}
+}
+
+// newTestSplitter returns a horizontal splitter with two panes, that leaves
+// 200 pixels to the panes.
+func newTestSplitter(t *testing.T) (b *MemoryBackend, s *Splitter, left, right *Composite) {
+	b, mw := newTestMainWindow(t)
+
+	s, err := NewSplitter(mw.ClientArea())
+	if err != nil {
+		t.Fatalf("NewSplitter failed: %s", err)
+	}
+	s.SetBounds(drawing.Rectangle{0, 0, 204, 100})
+
+	if left, err = NewComposite(s); err != nil {
+		t.Fatalf("NewComposite failed: %s", err)
+	}
+	if right, err = NewComposite(s); err != nil {
+		t.Fatalf("NewComposite failed: %s", err)
+	}
+
+	return b, s, left, right
+}
+
+func checkPaneBounds(t *testing.T, name string, pane IWidget, expected drawing.Rectangle) {
+	if bounds, _ := pane.Bounds(); bounds != expected {
+		t.Errorf("%s: expected bounds %v, got %v", name, expected, bounds)
+	}
+}
+
+func TestSplitterDrag(t *testing.T) {
+	b, s, left, _ := newTestSplitter(t)
+
+	before, _ := left.Bounds()
+	handle := MAKELONG(uint16(before.Width+1), 50)
+
+	b.SendMessage(s.hWnd, WM_SETCURSOR, uintptr(s.hWnd), uintptr(MAKELONG(HTCLIENT, WM_MOUSEMOVE)))
+	if c := b.Cursor(); c != IDC_SIZEWE {
+		t.Errorf("expected the IDC_SIZEWE cursor over the handle, got %d", c)
+	}
+
+	b.SendMessage(s.hWnd, WM_LBUTTONDOWN, 0, uintptr(handle))
+	if b.Capture() != s.hWnd {
+		t.Fatal("expected the splitter to capture the mouse while dragging")
+	}
+
+	b.MouseMove(s, drawing.Point{before.Width - 49, 50}, LeftButton)
+
+	after, _ := left.Bounds()
+	if after.Width != before.Width-50 {
+		t.Errorf("expected the left pane to shrink from %d to %d, got %d", before.Width, before.Width-50, after.Width)
+	}
+
+	b.MouseUp(s, drawing.Point{before.Width - 49, 50}, LeftButton)
+	if b.Capture() != 0 {
+		t.Error("expected the capture to be released")
+	}
+}
+
+func TestSplitterSaveRestoreState(t *testing.T) {
+	_, s, left, right := newTestSplitter(t)
+
+	if err := s.RestoreState("120 80"); err != nil {
+		t.Fatalf("RestoreState failed: %s", err)
+	}
+	checkPaneBounds(t, "left", left, drawing.Rectangle{0, 0, 120, 100})
+	checkPaneBounds(t, "right", right, drawing.Rectangle{124, 0, 80, 100})
+
+	if state, _ := s.SaveState(); state != "120 80" {
+		t.Errorf("expected state %q, got %q", "120 80", state)
+	}
+
+	// A collapsed pane keeps its size for when it is expanded again.
+	if err := s.RestoreState("120 80:c"); err != nil {
+		t.Fatalf("RestoreState failed: %s", err)
+	}
+	if !s.PaneCollapsed(right) {
+		t.Error("expected the right pane to be collapsed")
+	}
+	checkPaneBounds(t, "left of collapsed", left, drawing.Rectangle{0, 0, 200, 100})
+	if state, _ := s.SaveState(); state != "200 80:c" {
+		t.Errorf("expected state %q, got %q", "200 80:c", state)
+	}
+
+	for _, state := range []string{"120", "40 80 80", "a 80", "120 -80", "120 80:x", "120  80"} {
+		if err := s.RestoreState(state); err == nil {
+			t.Errorf("expected RestoreState(%q) to fail", state)
+		}
+	}
+	if state, _ := s.SaveState(); state != "200 80:c" {
+		t.Errorf("expected failed restores to keep the state, got %q", state)
+	}
+
+	// An empty state is what a splitter without panes saves.
+	if err := s.RestoreState(""); err != nil {
+		t.Errorf("expected RestoreState to ignore an empty state, got %s", err)
+	}
+}
+
+func TestSplitterDoubleClickCollapses(t *testing.T) {
+	b, s, left, right := newTestSplitter(t)
+
+	if err := s.RestoreState("120 80"); err != nil {
+		t.Fatalf("RestoreState failed: %s", err)
+	}
+
+	// The smaller pane collapses.
+	b.SendMessage(s.hWnd, WM_LBUTTONDBLCLK, 0, uintptr(MAKELONG(121, 50)))
+	if !s.PaneCollapsed(right) || s.PaneCollapsed(left) {
+		t.Fatal("expected the right pane to collapse")
+	}
+	checkPaneBounds(t, "left", left, drawing.Rectangle{0, 0, 200, 100})
+
+	// The handle is at the end now, double clicking it again expands the pane.
+	// The left pane grew to 200 meanwhile, so both panes shrink evenly to make
+	// room for the 80 pixels of the right one.
+	b.SendMessage(s.hWnd, WM_LBUTTONDBLCLK, 0, uintptr(MAKELONG(201, 50)))
+	if s.PaneCollapsed(right) {
+		t.Fatal("expected the right pane to expand")
+	}
+	checkPaneBounds(t, "left", left, drawing.Rectangle{0, 0, 160, 100})
+	checkPaneBounds(t, "right", right, drawing.Rectangle{164, 0, 40, 100})
+
+	// Away from the handle, nothing happens.
+	b.SendMessage(s.hWnd, WM_LBUTTONDBLCLK, 0, uintptr(MAKELONG(50, 50)))
+	if s.PaneCollapsed(left) || s.PaneCollapsed(right) {
+		t.Error("expected no pane to collapse")
+	}
+}
+
+func TestSplitterSetOrientation(t *testing.T) {
+	b, s, left, right := newTestSplitter(t)
+
+	if err := s.SetOrientation(Vertical); err != nil {
+		t.Fatalf("SetOrientation failed: %s", err)
+	}
+
+	leftBounds, _ := left.Bounds()
+	rightBounds, _ := right.Bounds()
+
+	if leftBounds.X != 0 || leftBounds.Y != 0 || leftBounds.Width != 204 {
+		t.Errorf("expected the first pane at the top, got %v", leftBounds)
+	}
+	if rightBounds.X != 0 || rightBounds.Y != leftBounds.Height+4 || rightBounds.Width != 204 {
+		t.Errorf("expected the second pane below the first, got %v", rightBounds)
+	}
+	if h := leftBounds.Height + rightBounds.Height; h != 96 {
+		t.Errorf("expected the panes to fill 96 pixels, got %d", h)
+	}
+
+	b.SendMessage(s.hWnd, WM_SETCURSOR, uintptr(s.hWnd), uintptr(MAKELONG(HTCLIENT, WM_MOUSEMOVE)))
+	if c := b.Cursor(); c != IDC_SIZENS {
+		t.Errorf("expected the IDC_SIZENS cursor, got %d", c)
+	}
+
+	if err := s.SetOrientation(Orientation(42)); err == nil {
+		t.Error("expected an invalid orientation to fail")
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"container/vector"
+)
+
+type tableModelObserver interface {
+	onTableModelRowsReset()
+	onTableModelRowsInserted(from, to int)
+	onTableModelRowsRemoved(from, to int)
+	onTableModelRowsChanged(from, to int)
+	onTableModelRowsReordered(newRows []int)
+}
+
+// TableModel provides the rows of a widget like ListView on demand.
+//
+// Implementations must embed TableModelBase, which implements the unexported
+// methods widgets use to observe the model, and call its Publish methods
+// whenever their rows change. Row ranges passed to the Publish methods include
+// both from and to.
+type TableModel interface {
+	// RowCount returns the number of rows in the model.
+	RowCount() int
+
+	// Value returns the value of the cell at row and col. Strings are
+	// displayed as is, other values are formatted using fmt.Sprint.
+	Value(row, col int) interface{}
+
+	addObserver(observer tableModelObserver)
+	removeObserver(observer tableModelObserver)
+}
+
+// TableModelBase implements the change notifications of a TableModel.
+type TableModelBase struct {
+	observers vector.Vector
+}
+
+func (tmb *TableModelBase) addObserver(observer tableModelObserver) {
+	tmb.observers.Push(observer)
+}
+
+func (tmb *TableModelBase) removeObserver(observer tableModelObserver) {
+	for i, o := range tmb.observers {
+		if o.(tableModelObserver) == observer {
+			tmb.observers.Delete(i)
+			break
+		}
+	}
+}
+
+// PublishRowsReset notifies observers that all rows may have changed, e.g.
+// because the model was loaded from a different source.
+func (tmb *TableModelBase) PublishRowsReset() {
+	for _, observerIface := range tmb.observers {
+		observer := observerIface.(tableModelObserver)
+		observer.onTableModelRowsReset()
+	}
+}
+
+// PublishRowsInserted notifies observers that rows from through to have been
+// inserted.
+func (tmb *TableModelBase) PublishRowsInserted(from, to int) {
+	for _, observerIface := range tmb.observers {
+		observer := observerIface.(tableModelObserver)
+		observer.onTableModelRowsInserted(from, to)
+	}
+}
+
+// PublishRowsRemoved notifies observers that the rows previously at from
+// through to have been removed.
+func (tmb *TableModelBase) PublishRowsRemoved(from, to int) {
+	for _, observerIface := range tmb.observers {
+		observer := observerIface.(tableModelObserver)
+		observer.onTableModelRowsRemoved(from, to)
+	}
+}
+
+// PublishRowsChanged notifies observers that the values of rows from through
+// to have changed.
+func (tmb *TableModelBase) PublishRowsChanged(from, to int) {
+	for _, observerIface := range tmb.observers {
+		observer := observerIface.(tableModelObserver)
+		observer.onTableModelRowsChanged(from, to)
+	}
+}
+
+// PublishRowsReordered notifies observers that the rows have been rearranged,
+// e.g. because they were sorted. The row previously at i is now at
+// newRows[i]. Unlike after PublishRowsReset, observers can keep track of rows,
+// e.g. to keep them selected.
+func (tmb *TableModelBase) PublishRowsReordered(newRows []int) {
+	for _, observerIface := range tmb.observers {
+		observer := observerIface.(tableModelObserver)
+		observer.onTableModelRowsReordered(newRows)
+	}
+}
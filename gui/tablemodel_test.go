@@ -0,0 +1,160 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"fmt"
+	"testing"
+)
+
+// tableModelRecorder records the notifications of a TableModel.
+type tableModelRecorder struct {
+	events []string
+}
+
+func (r *tableModelRecorder) onTableModelRowsReset() {
+	r.events = append(r.events, "reset")
+}
+
+func (r *tableModelRecorder) onTableModelRowsInserted(from, to int) {
+	r.events = append(r.events, fmt.Sprintf("inserted %d-%d", from, to))
+}
+
+func (r *tableModelRecorder) onTableModelRowsRemoved(from, to int) {
+	r.events = append(r.events, fmt.Sprintf("removed %d-%d", from, to))
+}
+
+func (r *tableModelRecorder) onTableModelRowsChanged(from, to int) {
+	r.events = append(r.events, fmt.Sprintf("changed %d-%d", from, to))
+}
+
+func (r *tableModelRecorder) onTableModelRowsReordered(newRows []int) {
+	r.events = append(r.events, fmt.Sprintf("reordered %v", newRows))
+}
+
+func (r *tableModelRecorder) check(t *testing.T, name string, expected ...string) {
+	if fmt.Sprint(r.events) != fmt.Sprint(expected) {
+		t.Errorf("%s: expected %v, got %v", name, expected, r.events)
+	}
+
+	r.events = nil
+}
+
+// countTableModel is implemented like a TableModel outside of this package
+// would be, by embedding TableModelBase. Its rows are numbers.
+type countTableModel struct {
+	TableModelBase
+	count int
+}
+
+func (m *countTableModel) RowCount() int {
+	return m.count
+}
+
+func (m *countTableModel) Value(row, col int) interface{} {
+	return row
+}
+
+func (m *countTableModel) SetCount(count int) {
+	old := m.count
+	m.count = count
+
+	switch {
+	case count > old:
+		m.PublishRowsInserted(old, count-1)
+
+	case count < old:
+		m.PublishRowsRemoved(count, old-1)
+	}
+}
+
+func TestMemoryTableModelNotifications(t *testing.T) {
+	m := NewMemoryTableModel()
+	r := &tableModelRecorder{}
+	m.addObserver(r)
+
+	m.AddRow([]interface{}{"a"})
+	r.check(t, "AddRow", "inserted 0-0")
+
+	m.AddRows([][]interface{}{{"b"}, {"c"}, {"d"}})
+	r.check(t, "AddRows", "inserted 1-3")
+
+	m.AddRows(nil)
+	r.check(t, "AddRows without rows")
+
+	m.InsertRow(1, []interface{}{"e"})
+	r.check(t, "InsertRow", "inserted 1-1")
+
+	m.SetValue(2, 3, "x")
+	r.check(t, "SetValue", "changed 2-2")
+
+	m.SetRow(0, []interface{}{"f"})
+	r.check(t, "SetRow", "changed 0-0")
+
+	m.RemoveRows(1, 2)
+	r.check(t, "RemoveRows", "removed 1-2")
+
+	if err := m.RemoveRows(2, 5); err == nil {
+		t.Error("expected an error for rows out of range")
+	}
+	r.check(t, "RemoveRows out of range")
+
+	m.SetRows([][]interface{}{{"g"}})
+	r.check(t, "SetRows", "reset")
+
+	m.Clear()
+	r.check(t, "Clear", "reset")
+
+	if n := m.RowCount(); n != 0 {
+		t.Errorf("expected no rows after Clear, got %d", n)
+	}
+}
+
+func TestTableModelBaseObservers(t *testing.T) {
+	m := &countTableModel{}
+	first, second := &tableModelRecorder{}, &tableModelRecorder{}
+
+	m.addObserver(first)
+	m.addObserver(second)
+
+	m.SetCount(3)
+	first.check(t, "first", "inserted 0-2")
+	second.check(t, "second", "inserted 0-2")
+
+	m.removeObserver(first)
+
+	m.SetCount(1)
+	first.check(t, "removed observer")
+	second.check(t, "remaining observer", "removed 1-2")
+
+	m.PublishRowsReset()
+	m.PublishRowsChanged(0, 0)
+	m.PublishRowsReordered([]int{0})
+	second.check(t, "reset, changed and reordered", "reset", "changed 0-0", "reordered [0]")
+}
+
+func TestListViewObservesModel(t *testing.T) {
+	b, lv := newTestListView(t)
+
+	m := &countTableModel{count: 2}
+	if err := lv.SetModel(m); err != nil {
+		t.Fatalf("SetModel failed: %s", err)
+	}
+
+	m.SetCount(5)
+	if n := listViewItemCount(b, lv); n != 5 {
+		t.Errorf("expected 5 items after inserting, got %d", n)
+	}
+
+	m.SetCount(1)
+	if n := listViewItemCount(b, lv); n != 1 {
+		t.Errorf("expected 1 item after removing, got %d", n)
+	}
+
+	lv.SetModel(nil)
+	if m.observers.Len() != 0 {
+		t.Errorf("expected the ListView to stop observing the model, got %d observers", m.observers.Len())
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"os"
+)
+
+import (
+	"walk/drawing"
+	. "walk/winapi/user32"
+)
+
+const tabPageWindowClass = `\o/ Walk_TabPage_Class \o/`
+
+func tabPageWndProc(msg *MSG) uintptr {
+	tp, ok := widgetsByHWnd[msg.HWnd].(*TabPage)
+	if !ok {
+		// Before CreateWindowEx returns, among others, WM_GETMINMAXINFO is sent.
+		// FIXME: Find a way to properly handle this.
+		return backend.DefWindowProc(msg, 0)
+	}
+
+	return tp.wndProc(msg, 0)
+}
+
+// TabPage is a page of a TabWidget. Its children are shown while its tab is
+// the current one, arranged by its own layout.
+type TabPage struct {
+	Composite
+	title      string
+	imageIndex int
+}
+
+// NewTabPage returns a new TabPage, that can be added to the Pages of a
+// TabWidget.
+//
+// Until then it is a hidden message-only window, which children can already
+// be created in.
+func NewTabPage() (*TabPage, os.Error) {
+	ensureRegisteredWindowClass(tabPageWindowClass, tabPageWndProc)
+
+	hWnd, err := backend.CreateWindow(
+		WS_EX_CONTROLPARENT, tabPageWindowClass,
+		WS_CHILD,
+		HWND_MESSAGE, drawing.Rectangle{0, 0, 0, 0})
+	if err != nil {
+		return nil, err
+	}
+
+	tp := &TabPage{
+		Composite:  Composite{Container: Container{Widget: Widget{hWnd: hWnd}}},
+		imageIndex: -1,
+	}
+
+	tp.children = newObservedWidgetList(tp)
+
+	tp.SetFont(backend.DefaultFont())
+
+	widgetsByHWnd[hWnd] = tp
+
+	return tp, nil
+}
+
+// tabWidget returns the TabWidget the page belongs to, or nil.
+func (tp *TabPage) tabWidget() *TabWidget {
+	tw, _ := tp.parent.(*TabWidget)
+
+	return tw
+}
+
+// Title returns the text of the tab of the page.
+func (tp *TabPage) Title() string {
+	return tp.title
+}
+
+func (tp *TabPage) SetTitle(value string) os.Error {
+	tp.title = value
+
+	return tp.updateTab()
+}
+
+// ImageIndex returns the index of the image of the tab in the ImageList of the
+// TabWidget, or -1 if the tab has no image.
+func (tp *TabPage) ImageIndex() int {
+	return tp.imageIndex
+}
+
+func (tp *TabPage) SetImageIndex(value int) os.Error {
+	if value < -1 {
+		return newError("value out of range")
+	}
+
+	tp.imageIndex = value
+
+	return tp.updateTab()
+}
+
+func (tp *TabPage) updateTab() os.Error {
+	tw := tp.tabWidget()
+	if tw == nil {
+		return nil
+	}
+
+	index := tw.children.IndexOf(tp)
+	if index == -1 {
+		return nil
+	}
+
+	return tw.updateTab(index)
+}
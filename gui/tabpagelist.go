@@ -0,0 +1,57 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"os"
+)
+
+// TabPageList is the list of the pages of a TabWidget.
+//
+// It is a typed view of the children of the TabWidget, which observes them to
+// add and remove the tabs, so the two always agree.
+type TabPageList struct {
+	widgets *ObservedWidgetList
+}
+
+func newTabPageList(widgets *ObservedWidgetList) *TabPageList {
+	return &TabPageList{widgets: widgets}
+}
+
+func (l *TabPageList) Add(item *TabPage) (index int, err os.Error) {
+	return l.widgets.Add(item)
+}
+
+func (l *TabPageList) At(index int) *TabPage {
+	return l.widgets.At(index).(*TabPage)
+}
+
+func (l *TabPageList) Clear() (err os.Error) {
+	return l.widgets.Clear()
+}
+
+func (l *TabPageList) IndexOf(item *TabPage) int {
+	return l.widgets.IndexOf(item)
+}
+
+func (l *TabPageList) Contains(item *TabPage) bool {
+	return l.widgets.Contains(item)
+}
+
+func (l *TabPageList) Insert(index int, item *TabPage) (err os.Error) {
+	return l.widgets.Insert(index, item)
+}
+
+func (l *TabPageList) Len() int {
+	return l.widgets.Len()
+}
+
+func (l *TabPageList) Remove(item *TabPage) (err os.Error) {
+	return l.widgets.Remove(item)
+}
+
+func (l *TabPageList) RemoveAt(index int) (err os.Error) {
+	return l.widgets.RemoveAt(index)
+}
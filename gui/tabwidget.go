@@ -0,0 +1,486 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"os"
+	"strconv"
+	"unsafe"
+)
+
+import (
+	"walk/drawing"
+	. "walk/winapi"
+	. "walk/winapi/comctl32"
+	. "walk/winapi/gdi32"
+	. "walk/winapi/user32"
+)
+
+const tabWidgetWindowClass = `\o/ Walk_TabWidget_Class \o/`
+
+func tabWidgetWndProc(msg *MSG) uintptr {
+	tw, ok := widgetsByHWnd[msg.HWnd].(*TabWidget)
+	if !ok {
+		// Before CreateWindowEx returns, among others, WM_GETMINMAXINFO is sent.
+		// FIXME: Find a way to properly handle this.
+		return backend.DefWindowProc(msg, 0)
+	}
+
+	return tw.wndProc(msg, 0)
+}
+
+// TabWidget shows one of its TabPages at a time, the user selects which one
+// with the tabs.
+//
+// The pages are the children of the TabWidget, Pages provides typed access to
+// them. The tabs are a tab control inside the TabWidget, the current page is
+// laid out in its display area.
+type TabWidget struct {
+	Container
+	hWndTab                      HWND
+	pages                        *TabPageList
+	imageList                    *ImageList
+	currentIndex                  int
+	currentIndexChangingPublisher CancelEventPublisher
+	currentIndexChangedPublisher  EventPublisher
+}
+
+func NewTabWidget(parent IContainer) (*TabWidget, os.Error) {
+	if parent == nil {
+		return nil, newError("parent cannot be nil")
+	}
+
+	ensureRegisteredWindowClass(tabWidgetWindowClass, tabWidgetWndProc)
+
+	hWnd, err := backend.CreateWindow(
+		WS_EX_CONTROLPARENT, tabWidgetWindowClass,
+		WS_CHILD|WS_VISIBLE,
+		parent.Handle(), drawing.Rectangle{0, 0, 0, 0})
+	if err != nil {
+		return nil, err
+	}
+
+	hWndTab, err := backend.CreateWindow(
+		0, "SysTabControl32",
+		WS_CHILD|WS_CLIPSIBLINGS|WS_TABSTOP|WS_VISIBLE,
+		hWnd, drawing.Rectangle{0, 0, 0, 0})
+	if err != nil {
+		backend.DestroyWindow(hWnd)
+		return nil, err
+	}
+
+	tw := &TabWidget{
+		Container:    Container{Widget: Widget{hWnd: hWnd, parent: parent}},
+		hWndTab:      hWndTab,
+		currentIndex: -1,
+	}
+
+	tw.children = newObservedWidgetList(tw)
+	tw.pages = newTabPageList(tw.children)
+
+	tw.SetFont(backend.DefaultFont())
+
+	widgetsByHWnd[hWnd] = tw
+
+	parent.Children().Add(tw)
+
+	return tw, nil
+}
+
+func (tw *TabWidget) SetFont(value *drawing.Font) {
+	backend.SetFont(tw.hWndTab, value)
+
+	tw.Container.SetFont(value)
+}
+
+func (tw *TabWidget) SetLayout(value Layout) {
+	panic("not supported")
+}
+
+func (*TabWidget) LayoutFlags() LayoutFlags {
+	return ShrinkHorz | GrowHorz | ShrinkVert | GrowVert
+}
+
+func (tw *TabWidget) MinSize() (drawing.Size, os.Error) {
+	minSize, err := tw.Widget.MinSize()
+	if err != nil {
+		return minSize, err
+	}
+
+	var pageMinSize drawing.Size
+
+	count := tw.children.Len()
+	for i := 0; i < count; i++ {
+		size, err := tw.children.At(i).MinSize()
+		if err != nil {
+			return drawing.Size{}, err
+		}
+
+		if size.Width > pageMinSize.Width {
+			pageMinSize.Width = size.Width
+		}
+		if size.Height > pageMinSize.Height {
+			pageMinSize.Height = size.Height
+		}
+	}
+
+	size := tw.sizeForPageSize(pageMinSize)
+
+	if size.Width > minSize.Width {
+		minSize.Width = size.Width
+	}
+	if size.Height > minSize.Height {
+		minSize.Height = size.Height
+	}
+
+	return minSize, nil
+}
+
+func (tw *TabWidget) PreferredSize() drawing.Size {
+	var pageSize drawing.Size
+
+	count := tw.children.Len()
+	for i := 0; i < count; i++ {
+		size := tw.children.At(i).PreferredSize()
+
+		if size.Width > pageSize.Width {
+			pageSize.Width = size.Width
+		}
+		if size.Height > pageSize.Height {
+			pageSize.Height = size.Height
+		}
+	}
+
+	return tw.sizeForPageSize(pageSize)
+}
+
+// sizeForPageSize returns the size needed for pages of the specified size,
+// i.e. it adds the size of the tabs and the border around the pages.
+func (tw *TabWidget) sizeForPageSize(pageSize drawing.Size) drawing.Size {
+	return tw.adjustRect(drawing.Rectangle{0, 0, pageSize.Width, pageSize.Height}, true).Size()
+}
+
+// adjustRect converts the display area of the tab control to its bounds, if
+// larger is true, and the other way around otherwise.
+func (tw *TabWidget) adjustRect(r drawing.Rectangle, larger bool) drawing.Rectangle {
+	rc := RECT{r.X, r.Y, r.X + r.Width, r.Y + r.Height}
+
+	backend.SendMessage(tw.hWndTab, TCM_ADJUSTRECT, uintptr(BoolToBOOL(larger)), uintptr(unsafe.Pointer(&rc)))
+
+	return drawing.Rectangle{rc.Left, rc.Top, rc.Right - rc.Left, rc.Bottom - rc.Top}
+}
+
+// Pages returns the list of pages. Adding and removing pages adds and removes
+// their tabs.
+func (tw *TabWidget) Pages() *TabPageList {
+	return tw.pages
+}
+
+// CurrentIndex returns the index of the page that is shown or -1 if there are
+// no pages.
+func (tw *TabWidget) CurrentIndex() int {
+	return tw.currentIndex
+}
+
+// SetCurrentIndex shows the page at value.
+func (tw *TabWidget) SetCurrentIndex(value int) os.Error {
+	if value < 0 || value >= tw.children.Len() {
+		return newError("value out of range")
+	}
+
+	return tw.setCurrentIndex(value, false)
+}
+
+// CurrentPage returns the page that is shown or nil if there are no pages.
+func (tw *TabWidget) CurrentPage() *TabPage {
+	if tw.currentIndex == -1 {
+		return nil
+	}
+
+	return tw.pages.At(tw.currentIndex)
+}
+
+// CurrentIndexChanging is raised when the user selects another tab, before
+// its page is shown. Handlers can cancel the change.
+func (tw *TabWidget) CurrentIndexChanging() *CancelEvent {
+	return tw.currentIndexChangingPublisher.Event()
+}
+
+// CurrentIndexChanged is raised when another page is shown, because the user
+// selected its tab, SetCurrentIndex was called or pages were added or removed.
+func (tw *TabWidget) CurrentIndexChanged() *Event {
+	return tw.currentIndexChangedPublisher.Event()
+}
+
+// CurrentIndexProperty returns a Property with an int value that reflects
+// the index of the page that is shown.
+func (tw *TabWidget) CurrentIndexProperty() Property {
+	return &funcProperty{
+		get: func() interface{} {
+			return tw.CurrentIndex()
+		},
+		set: func(value interface{}) os.Error {
+			index, ok := value.(int)
+			if !ok {
+				return newError("value must be an int")
+			}
+
+			return tw.SetCurrentIndex(index)
+		},
+		changed: tw.CurrentIndexChanged(),
+	}
+}
+
+// setCurrentIndex selects the tab at index and shows its page, hiding all
+// others. CurrentIndexChanged is raised if the index changes, or if changed
+// is true because another page moved to the index.
+func (tw *TabWidget) setCurrentIndex(index int, changed bool) os.Error {
+	changed = changed || index != tw.currentIndex
+
+	tw.currentIndex = index
+
+	backend.SendMessage(tw.hWndTab, TCM_SETCURSEL, uintptr(index), 0)
+
+	count := tw.children.Len()
+	for i := 0; i < count; i++ {
+		if err := tw.children.At(i).SetVisible(i == index); err != nil {
+			return err
+		}
+	}
+
+	if changed {
+		tw.currentIndexChangedPublisher.Publish(&eventArgs{widgetsByHWnd[tw.hWnd]})
+	}
+
+	return nil
+}
+
+func (tw *TabWidget) ImageList() *ImageList {
+	return tw.imageList
+}
+
+// SetImageList sets the ImageList the ImageIndex of each page refers to.
+func (tw *TabWidget) SetImageList(value *ImageList) {
+	var hIml HIMAGELIST
+
+	if value != nil {
+		hIml = value.hIml
+	}
+
+	backend.SendMessage(tw.hWndTab, TCM_SETIMAGELIST, 0, uintptr(hIml))
+
+	tw.imageList = value
+}
+
+// SaveState returns the index of the current page as a string, that can be
+// passed to RestoreState.
+func (tw *TabWidget) SaveState() (string, os.Error) {
+	return strconv.Itoa(tw.currentIndex), nil
+}
+
+func (tw *TabWidget) RestoreState(state string) os.Error {
+	if state == "" {
+		return nil
+	}
+
+	index, err := strconv.Atoi(state)
+	if err != nil {
+		return err
+	}
+
+	if index == -1 && tw.children.Len() == 0 {
+		return nil
+	}
+
+	if index < 0 || index >= tw.children.Len() {
+		return newError("state does not match the pages of the tab widget")
+	}
+
+	return tw.SetCurrentIndex(index)
+}
+
+func (tw *TabWidget) tcItem(page *TabPage) *TCITEM {
+	return &TCITEM{
+		Mask:    TCIF_TEXT | TCIF_IMAGE,
+		PszText: StringToUTF16Ptr(page.title),
+		IImage:  page.imageIndex,
+	}
+}
+
+// updateTab updates the text and image of the tab at index from its page.
+func (tw *TabWidget) updateTab(index int) os.Error {
+	item := tw.tcItem(tw.pages.At(index))
+
+	if FALSE == backend.SendMessage(tw.hWndTab, TCM_SETITEM, uintptr(index), uintptr(unsafe.Pointer(item))) {
+		return newError("TCM_SETITEM failed")
+	}
+
+	// The tabs may need more or less space now.
+	return tw.updatePageBounds()
+}
+
+// updatePageBounds fits the tab control into the client area and the pages
+// into its display area.
+func (tw *TabWidget) updatePageBounds() os.Error {
+	cb, err := tw.ClientBounds()
+	if err != nil {
+		return err
+	}
+
+	if err := backend.SetBounds(tw.hWndTab, cb); err != nil {
+		return err
+	}
+
+	bounds := tw.adjustRect(cb, false)
+
+	count := tw.children.Len()
+	for i := 0; i < count; i++ {
+		if err := tw.children.At(i).SetBounds(bounds); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (tw *TabWidget) wndProc(msg *MSG, origWndProcPtr uintptr) uintptr {
+	switch msg.Message {
+	case WM_NOTIFY:
+		nmh := (*NMHDR)(unsafe.Pointer(msg.LParam))
+
+		if nmh.HwndFrom != tw.hWndTab {
+			break
+		}
+
+		switch nmh.Code {
+		case TCN_SELCHANGING:
+			args := &cancelEventArgs{eventArgs: eventArgs{widgetsByHWnd[tw.hWnd]}}
+			tw.currentIndexChangingPublisher.Publish(args)
+
+			// Returning TRUE keeps the current tab.
+			return uintptr(BoolToBOOL(args.Canceled()))
+
+		case TCN_SELCHANGE:
+			tw.setCurrentIndex(int(backend.SendMessage(tw.hWndTab, TCM_GETCURSEL, 0, 0)), false)
+			return 0
+		}
+
+	case WM_SIZE, WM_SIZING:
+		tw.updatePageBounds()
+	}
+
+	return tw.Container.wndProc(msg, origWndProcPtr)
+}
+
+func (tw *TabWidget) onInsertingWidget(index int, widget IWidget) (err os.Error) {
+	page, ok := widget.(*TabPage)
+	if !ok {
+		return newError("only TabPages can be added to a TabWidget")
+	}
+
+	item := tw.tcItem(page)
+
+	if -1 == int(backend.SendMessage(tw.hWndTab, TCM_INSERTITEM, uintptr(index), uintptr(unsafe.Pointer(item)))) {
+		err = newError("TCM_INSERTITEM failed")
+	}
+
+	return
+}
+
+func (tw *TabWidget) onInsertedWidget(index int, widget IWidget) (err os.Error) {
+	page := widget.(*TabPage)
+
+	if page.parent != IContainer(tw) {
+		// Adds nothing, as the page is already in the list.
+		if err = page.SetParent(tw); err != nil {
+			return
+		}
+	}
+
+	if err = tw.updatePageBounds(); err != nil {
+		return
+	}
+
+	switch {
+	case tw.currentIndex == -1:
+		return tw.setCurrentIndex(0, false)
+
+	case index <= tw.currentIndex:
+		return tw.setCurrentIndex(tw.currentIndex+1, false)
+	}
+
+	return page.SetVisible(false)
+}
+
+func (tw *TabWidget) onRemovingWidget(index int, widget IWidget) (err os.Error) {
+	if FALSE == backend.SendMessage(tw.hWndTab, TCM_DELETEITEM, uintptr(index), 0) {
+		return newError("TCM_DELETEITEM failed")
+	}
+
+	return tw.detachPage(widget.(*TabPage))
+}
+
+// detachPage hides page and, unless it was moved to another parent already,
+// turns it back into a message-only window, so it can be added again later.
+//
+// Widget.SetParent is not used, as it would remove the page from the list
+// while that is being done.
+func (tw *TabWidget) detachPage(page *TabPage) os.Error {
+	if err := page.SetVisible(false); err != nil {
+		return err
+	}
+
+	if page.parent != IContainer(tw) {
+		return nil
+	}
+
+	if err := backend.SetParent(page.hWnd, HWND_MESSAGE); err != nil {
+		return err
+	}
+
+	page.parent = nil
+
+	return nil
+}
+
+func (tw *TabWidget) onRemovedWidget(index int, widget IWidget) (err os.Error) {
+	current := tw.currentIndex
+
+	switch {
+	case index < current:
+		return tw.setCurrentIndex(current-1, false)
+
+	case index == current:
+		if current == tw.children.Len() {
+			current--
+		}
+
+		// The page at the index, if any, is another one now.
+		return tw.setCurrentIndex(current, true)
+	}
+
+	return
+}
+
+func (tw *TabWidget) onClearingWidgets() (err os.Error) {
+	backend.SendMessage(tw.hWndTab, TCM_DELETEALLITEMS, 0, 0)
+
+	count := tw.children.Len()
+	for i := 0; i < count; i++ {
+		if err = tw.detachPage(tw.pages.At(i)); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func (tw *TabWidget) onClearedWidgets() (err os.Error) {
+	if tw.currentIndex != -1 {
+		return tw.setCurrentIndex(-1, false)
+	}
+
+	return
+}
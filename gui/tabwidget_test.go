@@ -0,0 +1,361 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"strconv"
+	"testing"
+)
+
+import (
+	. "walk/winapi/comctl32"
+)
+
+type testTabWidget struct {
+	*TabWidget
+	pages        []*TabPage
+	changedCount int
+}
+
+// newTestTabWidget returns a TabWidget with count pages, titled by their
+// initial index, that counts how often CurrentIndexChanged is raised.
+func newTestTabWidget(t *testing.T, count int) (*MemoryBackend, *testTabWidget) {
+	b, mw := newTestMainWindow(t)
+
+	tw, err := NewTabWidget(mw.ClientArea())
+	if err != nil {
+		t.Fatalf("NewTabWidget failed: %s", err)
+	}
+
+	ttw := &testTabWidget{TabWidget: tw}
+
+	for i := 0; i < count; i++ {
+		page := newTestTabPage(t, strconv.Itoa(i))
+
+		if _, err := tw.Pages().Add(page); err != nil {
+			t.Fatalf("Add failed: %s", err)
+		}
+
+		ttw.pages = append(ttw.pages, page)
+	}
+
+	tw.CurrentIndexChanged().Attach(func(args EventArgs) {
+		ttw.changedCount++
+	})
+
+	return b, ttw
+}
+
+func newTestTabPage(t *testing.T, title string) *TabPage {
+	page, err := NewTabPage()
+	if err != nil {
+		t.Fatalf("NewTabPage failed: %s", err)
+	}
+
+	if err := page.SetTitle(title); err != nil {
+		t.Fatalf("SetTitle failed: %s", err)
+	}
+
+	return page
+}
+
+// checkCurrent checks that the page with expectedTitle is the current one, at
+// expectedIndex, and the only visible one, that the tab control agrees and
+// that CurrentIndexChanged was raised expectedChanged times.
+func (tw *testTabWidget) checkCurrent(t *testing.T, b *MemoryBackend, name string, expectedIndex int, expectedTitle string, expectedChanged int) {
+	if index := tw.CurrentIndex(); index != expectedIndex {
+		t.Errorf("%s: expected the current index %d, got %d", name, expectedIndex, index)
+	}
+
+	if page := tw.CurrentPage(); expectedIndex == -1 {
+		if page != nil {
+			t.Errorf("%s: expected no current page, got %q", name, page.Title())
+		}
+	} else if page == nil || page.Title() != expectedTitle {
+		t.Errorf("%s: expected the current page %q, got %v", name, expectedTitle, page)
+	}
+
+	if curSel := int(b.SendMessage(tw.hWndTab, TCM_GETCURSEL, 0, 0)); curSel != expectedIndex {
+		t.Errorf("%s: expected the selected tab %d, got %d", name, expectedIndex, curSel)
+	}
+
+	count := tw.Pages().Len()
+	if itemCount := int(b.SendMessage(tw.hWndTab, TCM_GETITEMCOUNT, 0, 0)); itemCount != count {
+		t.Errorf("%s: expected %d tabs, got %d", name, count, itemCount)
+	}
+
+	for i := 0; i < count; i++ {
+		if visible, _ := tw.Pages().At(i).Visible(); visible != (i == expectedIndex) {
+			t.Errorf("%s: expected page %d visible %t, got %t", name, i, i == expectedIndex, visible)
+		}
+	}
+
+	if tw.changedCount != expectedChanged {
+		t.Errorf("%s: expected CurrentIndexChanged %d times, got %d", name, expectedChanged, tw.changedCount)
+	}
+}
+
+func TestTabWidgetAddFirstPage(t *testing.T) {
+	b, tw := newTestTabWidget(t, 0)
+
+	tw.checkCurrent(t, b, "empty", -1, "", 0)
+
+	if _, err := tw.Pages().Add(newTestTabPage(t, "new")); err != nil {
+		t.Fatalf("Add failed: %s", err)
+	}
+
+	tw.checkCurrent(t, b, "first page", 0, "new", 1)
+}
+
+func TestTabWidgetInsertPage(t *testing.T) {
+	tests := []struct {
+		name          string
+		index         int
+		expectedIndex int
+		// The current page stays the same, only its index may change.
+		expectedChanged int
+	}{
+		{"before the current page", 0, 2, 1},
+		{"at the current page", 1, 2, 1},
+		{"after the current page", 2, 1, 0},
+		{"at the end", 3, 1, 0},
+	}
+
+	for _, test := range tests {
+		b, tw := newTestTabWidget(t, 3)
+
+		if err := tw.SetCurrentIndex(1); err != nil {
+			t.Fatalf("SetCurrentIndex failed: %s", err)
+		}
+		tw.changedCount = 0
+
+		if err := tw.Pages().Insert(test.index, newTestTabPage(t, "new")); err != nil {
+			t.Fatalf("%s: Insert failed: %s", test.name, err)
+		}
+
+		tw.checkCurrent(t, b, test.name, test.expectedIndex, "1", test.expectedChanged)
+
+		if title := tw.Pages().At(test.index).Title(); title != "new" {
+			t.Errorf("%s: expected the new page at %d, got %q", test.name, test.index, title)
+		}
+	}
+}
+
+func TestTabWidgetRemovePage(t *testing.T) {
+	tests := []struct {
+		name          string
+		current       int
+		index         int
+		expectedIndex int
+		expectedTitle string
+		// Removing the current page shows another one at the same index.
+		expectedChanged int
+	}{
+		{"before the current page", 1, 0, 0, "1", 1},
+		{"the current page", 1, 1, 1, "2", 1},
+		{"the current last page", 2, 2, 1, "1", 1},
+		{"after the current page", 1, 2, 1, "1", 0},
+	}
+
+	for _, test := range tests {
+		b, tw := newTestTabWidget(t, 3)
+
+		if err := tw.SetCurrentIndex(test.current); err != nil {
+			t.Fatalf("SetCurrentIndex failed: %s", err)
+		}
+		tw.changedCount = 0
+
+		removed := tw.pages[test.index]
+
+		if err := tw.Pages().RemoveAt(test.index); err != nil {
+			t.Fatalf("%s: RemoveAt failed: %s", test.name, err)
+		}
+
+		tw.checkCurrent(t, b, test.name, test.expectedIndex, test.expectedTitle, test.expectedChanged)
+
+		if removed.Parent() != nil {
+			t.Errorf("%s: expected the removed page to have no parent", test.name)
+		}
+		if visible, _ := removed.Visible(); visible {
+			t.Errorf("%s: expected the removed page to be hidden", test.name)
+		}
+	}
+}
+
+func TestTabWidgetRemoveLastPage(t *testing.T) {
+	b, tw := newTestTabWidget(t, 1)
+	tw.changedCount = 0
+
+	if err := tw.Pages().RemoveAt(0); err != nil {
+		t.Fatalf("RemoveAt failed: %s", err)
+	}
+
+	tw.checkCurrent(t, b, "no pages left", -1, "", 1)
+}
+
+func TestTabWidgetClearPages(t *testing.T) {
+	b, tw := newTestTabWidget(t, 3)
+
+	if err := tw.SetCurrentIndex(2); err != nil {
+		t.Fatalf("SetCurrentIndex failed: %s", err)
+	}
+	tw.changedCount = 0
+
+	if err := tw.Pages().Clear(); err != nil {
+		t.Fatalf("Clear failed: %s", err)
+	}
+
+	tw.checkCurrent(t, b, "cleared", -1, "", 1)
+
+	for i, page := range tw.pages {
+		if page.Parent() != nil {
+			t.Errorf("expected cleared page %d to have no parent", i)
+		}
+	}
+
+	// The pages can be added again.
+	if _, err := tw.Pages().Add(tw.pages[1]); err != nil {
+		t.Fatalf("Add failed: %s", err)
+	}
+
+	tw.checkCurrent(t, b, "added again", 0, "1", 2)
+
+	if tw.pages[1].Parent() != IContainer(tw.TabWidget) {
+		t.Error("expected the page added again to have the TabWidget as parent")
+	}
+}
+
+func TestTabWidgetSetCurrentIndex(t *testing.T) {
+	b, tw := newTestTabWidget(t, 3)
+	tw.changedCount = 0
+
+	if err := tw.SetCurrentIndex(2); err != nil {
+		t.Fatalf("SetCurrentIndex failed: %s", err)
+	}
+
+	tw.checkCurrent(t, b, "SetCurrentIndex", 2, "2", 1)
+
+	for _, index := range []int{-1, 3} {
+		if tw.SetCurrentIndex(index) == nil {
+			t.Errorf("SetCurrentIndex(%d): expected an error", index)
+		}
+	}
+
+	tw.checkCurrent(t, b, "out of range", 2, "2", 1)
+}
+
+func TestTabWidgetSelectTab(t *testing.T) {
+	b, tw := newTestTabWidget(t, 3)
+	tw.changedCount = 0
+
+	var changingCount int
+	tw.CurrentIndexChanging().Attach(func(args CancelEventArgs) {
+		changingCount++
+	})
+
+	if err := b.SelectTab(tw.TabWidget, 2); err != nil {
+		t.Fatalf("SelectTab failed: %s", err)
+	}
+
+	tw.checkCurrent(t, b, "SelectTab", 2, "2", 1)
+
+	// Selecting the selected tab does nothing.
+	if err := b.SelectTab(tw.TabWidget, 2); err != nil {
+		t.Fatalf("SelectTab failed: %s", err)
+	}
+
+	tw.checkCurrent(t, b, "SelectTab again", 2, "2", 1)
+
+	if changingCount != 1 {
+		t.Errorf("expected CurrentIndexChanging once, got %d", changingCount)
+	}
+
+	if b.SelectTab(tw.TabWidget, 3) == nil {
+		t.Error("expected an error selecting a tab out of range")
+	}
+}
+
+func TestTabWidgetSelectTabCanceled(t *testing.T) {
+	b, tw := newTestTabWidget(t, 3)
+	tw.changedCount = 0
+
+	handle := tw.CurrentIndexChanging().Attach(func(args CancelEventArgs) {
+		args.SetCanceled(true)
+	})
+
+	if err := b.SelectTab(tw.TabWidget, 1); err != nil {
+		t.Fatalf("SelectTab failed: %s", err)
+	}
+
+	tw.checkCurrent(t, b, "canceled", 0, "0", 0)
+
+	tw.CurrentIndexChanging().Detach(handle)
+
+	if err := b.SelectTab(tw.TabWidget, 1); err != nil {
+		t.Fatalf("SelectTab failed: %s", err)
+	}
+
+	tw.checkCurrent(t, b, "not canceled", 1, "1", 1)
+}
+
+func TestTabWidgetSaveRestoreState(t *testing.T) {
+	b, tw := newTestTabWidget(t, 3)
+
+	if err := tw.SetCurrentIndex(2); err != nil {
+		t.Fatalf("SetCurrentIndex failed: %s", err)
+	}
+
+	state, err := tw.SaveState()
+	if err != nil {
+		t.Fatalf("SaveState failed: %s", err)
+	}
+	if state != "2" {
+		t.Errorf("expected the state %q, got %q", "2", state)
+	}
+
+	if err := tw.SetCurrentIndex(0); err != nil {
+		t.Fatalf("SetCurrentIndex failed: %s", err)
+	}
+	tw.changedCount = 0
+
+	if err := tw.RestoreState(state); err != nil {
+		t.Fatalf("RestoreState failed: %s", err)
+	}
+
+	tw.checkCurrent(t, b, "restored", 2, "2", 1)
+
+	// An empty state means nothing was saved.
+	if err := tw.RestoreState(""); err != nil {
+		t.Errorf("RestoreState(\"\") failed: %s", err)
+	}
+
+	for _, state := range []string{"x", "1.5", "-1", "3"} {
+		if tw.RestoreState(state) == nil {
+			t.Errorf("RestoreState(%q): expected an error", state)
+		}
+	}
+
+	tw.checkCurrent(t, b, "bad states", 2, "2", 1)
+}
+
+func TestTabWidgetSaveRestoreStateNoPages(t *testing.T) {
+	_, tw := newTestTabWidget(t, 0)
+
+	state, err := tw.SaveState()
+	if err != nil {
+		t.Fatalf("SaveState failed: %s", err)
+	}
+	if state != "-1" {
+		t.Errorf("expected the state %q, got %q", "-1", state)
+	}
+
+	if err := tw.RestoreState(state); err != nil {
+		t.Errorf("RestoreState(%q) failed: %s", state, err)
+	}
+
+	if tw.RestoreState("0") == nil {
+		t.Error("expected an error restoring a page that does not exist")
+	}
+}
@@ -6,7 +6,6 @@ package gui
 
 import (
 	"os"
-	"syscall"
 )
 
 import (
@@ -16,6 +15,8 @@ import (
 
 type TextEdit struct {
 	Widget
+	validation
+	textChangedPublisher EventPublisher
 }
 
 func NewTextEdit(parent IContainer) (*TextEdit, os.Error) {
@@ -23,16 +24,17 @@ func NewTextEdit(parent IContainer) (*TextEdit, os.Error) {
 		return nil, newError("parent cannot be nil")
 	}
 
-	hWnd := CreateWindowEx(
-		WS_EX_CLIENTEDGE, syscall.StringToUTF16Ptr("EDIT"), nil,
+	hWnd, err := backend.CreateWindow(
+		WS_EX_CLIENTEDGE, "EDIT",
 		ES_MULTILINE|ES_WANTRETURN|WS_CHILD|WS_TABSTOP|WS_VISIBLE|WS_VSCROLL,
-		0, 0, 160, 80, parent.Handle(), 0, 0, nil)
-	if hWnd == 0 {
-		return nil, lastError("CreateWindowEx")
+		parent.Handle(), drawing.Rectangle{0, 0, 160, 80})
+	if err != nil {
+		return nil, err
 	}
 
 	te := &TextEdit{Widget: Widget{hWnd: hWnd, parent: parent}}
-	te.SetFont(defaultFont)
+	te.validation.widget = te
+	te.SetFont(backend.DefaultFont())
 
 	widgetsByHWnd[hWnd] = te
 
@@ -48,3 +50,38 @@ func (*TextEdit) LayoutFlags() LayoutFlags {
 func (te *TextEdit) PreferredSize() drawing.Size {
 	return te.dialogBaseUnitsToPixels(drawing.Size{100, 100})
 }
+
+func (te *TextEdit) TextChanged() *Event {
+	return te.textChangedPublisher.Event()
+}
+
+func (te *TextEdit) raiseTextChanged() {
+	te.textChangedPublisher.Publish(&eventArgs{widgetsByHWnd[te.hWnd]})
+}
+
+// TextProperty returns a Property with a string value that reflects the text
+// of the TextEdit.
+func (te *TextEdit) TextProperty() Property {
+	return &funcProperty{
+		get: func() interface{} {
+			return te.Text()
+		},
+		set: func(value interface{}) os.Error {
+			text, ok := value.(string)
+			if !ok {
+				return newError("value must be a string")
+			}
+
+			return te.SetText(text)
+		},
+		changed: te.TextChanged(),
+	}
+}
+
+func (te *TextEdit) onCommand(notificationCode uint16) {
+	switch notificationCode {
+	case EN_CHANGE:
+		te.updateValidity(backend.Focus() == te.hWnd)
+		te.raiseTextChanged()
+	}
+}
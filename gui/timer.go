@@ -0,0 +1,270 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"os"
+	"time"
+)
+
+// Clock is the source of the time timers are measured in, in nanoseconds.
+type Clock interface {
+	Nanoseconds() int64
+}
+
+type systemClock struct{}
+
+func (systemClock) Nanoseconds() int64 {
+	return time.Nanoseconds()
+}
+
+var clock Clock = systemClock{}
+
+// CurrentClock returns the Clock timers are measured in.
+func CurrentClock() Clock {
+	return clock
+}
+
+// SetClock replaces the Clock timers are measured in. Running timers are
+// restarted.
+func SetClock(value Clock) os.Error {
+	if value == nil {
+		return newError("value cannot be nil")
+	}
+
+	clock = value
+
+	now := clock.Nanoseconds()
+	for _, t := range runningTimers {
+		t.due = now + t.interval
+	}
+
+	scheduleTimers()
+
+	return nil
+}
+
+// FakeClock is a Clock that only advances when told to, so tests can trigger
+// timers deterministically.
+type FakeClock struct {
+	now int64
+}
+
+func NewFakeClock() *FakeClock {
+	return &FakeClock{}
+}
+
+func (c *FakeClock) Nanoseconds() int64 {
+	return c.now
+}
+
+// Advance moves the clock forward by ns nanoseconds.
+//
+// If the clock is the current one, the timers that become due on the way are
+// triggered in order, each at its due time, as if the message loop had been
+// running in the meantime.
+func (c *FakeClock) Advance(ns int64) {
+	end := c.now + ns
+
+	for clock == c {
+		t := nextTimer()
+		if t == nil || t.due > end {
+			break
+		}
+
+		if t.due > c.now {
+			c.now = t.due
+		}
+
+		triggerDueTimers()
+	}
+
+	c.now = end
+}
+
+// timerId identifies the backend timer of the application window, which
+// elapses when the next Timer is due.
+const timerId = 1
+
+var runningTimers []*Timer
+
+// nextTimer returns the running timer that is due first, or nil.
+func nextTimer() *Timer {
+	var next *Timer
+
+	for _, t := range runningTimers {
+		if next == nil || t.due < next.due {
+			next = t
+		}
+	}
+
+	return next
+}
+
+// scheduleTimers sets the backend timer of the application window to elapse
+// when the next timer is due. Until the message loop runs, there is no
+// application window and nothing to schedule.
+func scheduleTimers() {
+	if appWindow == nil {
+		return
+	}
+
+	t := nextTimer()
+	if t == nil {
+		backend.KillTimer(appWindow.hWnd, timerId)
+		return
+	}
+
+	// Round up, so the timer is due when the backend timer elapses.
+	delay := (t.due - clock.Nanoseconds() + 999999) / 1e6
+	if delay < 0 {
+		delay = 0
+	}
+
+	backend.SetTimer(appWindow.hWnd, timerId, uint(delay))
+}
+
+// triggerDueTimers triggers all timers that are due, in order of their due
+// time, then schedules the next one.
+func triggerDueTimers() {
+	now := clock.Nanoseconds()
+
+	for {
+		t := nextTimer()
+		if t == nil || t.due > now {
+			break
+		}
+
+		if t.singleShot {
+			t.remove()
+		} else {
+			// Intervals that were missed, e.g. while a modal loop was
+			// running, are skipped.
+			t.due += t.interval
+			if t.due <= now {
+				t.due = now + t.interval
+			}
+		}
+
+		t.triggeredPublisher.Publish(&eventArgs{t})
+	}
+
+	scheduleTimers()
+}
+
+// Timer raises its Triggered event after an interval, once or repeatedly.
+//
+// Timers are driven by the message loop, so Triggered is raised by the
+// goroutine running it, like all other events, and only while it runs.
+type Timer struct {
+	interval           int64
+	singleShot         bool
+	running            bool
+	due                int64
+	triggeredPublisher EventPublisher
+}
+
+// NewTimer returns a new, stopped Timer with an interval of interval
+// nanoseconds.
+func NewTimer(interval int64) (*Timer, os.Error) {
+	t := &Timer{}
+
+	if err := t.SetInterval(interval); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// StartSingleShotTimer starts a new single shot Timer that calls f after
+// interval nanoseconds.
+func StartSingleShotTimer(interval int64, f func()) (*Timer, os.Error) {
+	t, err := NewTimer(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	t.SetSingleShot(true)
+	t.Triggered().Attach(func(args EventArgs) {
+		f()
+	})
+
+	t.Start()
+
+	return t, nil
+}
+
+func (t *Timer) Interval() int64 {
+	return t.interval
+}
+
+// SetInterval sets the interval in nanoseconds. A running timer is restarted.
+func (t *Timer) SetInterval(value int64) os.Error {
+	if value <= 0 {
+		return newError("value must be positive")
+	}
+
+	t.interval = value
+
+	if t.running {
+		t.Start()
+	}
+
+	return nil
+}
+
+// SingleShot returns whether the timer stops after it was triggered once.
+func (t *Timer) SingleShot() bool {
+	return t.singleShot
+}
+
+func (t *Timer) SetSingleShot(value bool) {
+	t.singleShot = value
+}
+
+func (t *Timer) Running() bool {
+	return t.running
+}
+
+// Start starts the timer, so it is triggered after its interval. A running
+// timer is restarted.
+func (t *Timer) Start() {
+	t.due = clock.Nanoseconds() + t.interval
+
+	if !t.running {
+		t.running = true
+		runningTimers = append(runningTimers, t)
+	}
+
+	scheduleTimers()
+}
+
+func (t *Timer) Stop() {
+	if !t.running {
+		return
+	}
+
+	t.remove()
+
+	scheduleTimers()
+}
+
+func (t *Timer) remove() {
+	t.running = false
+
+	for i, rt := range runningTimers {
+		if rt == t {
+			timers := make([]*Timer, 0, len(runningTimers)-1)
+			timers = append(timers, runningTimers[:i]...)
+			runningTimers = append(timers, runningTimers[i+1:]...)
+			break
+		}
+	}
+}
+
+// Triggered is raised when the interval has elapsed.
+func (t *Timer) Triggered() *Event {
+	return t.triggeredPublisher.Event()
+}
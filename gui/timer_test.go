@@ -0,0 +1,306 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"fmt"
+	"testing"
+)
+
+import (
+	. "walk/winapi/user32"
+)
+
+const ms = 1e6
+
+// resetTestApplication returns a new MemoryBackend and MainWindow without the
+// application window, timers and synchronized calls of the last backend.
+func resetTestApplication(t *testing.T) (*MemoryBackend, *MainWindow) {
+	b, mw := newTestMainWindow(t)
+
+	appWindow = nil
+	runningTimers = nil
+
+	synchronized.funcs = nil
+	synchronized.hWnd = 0
+	synchronized.posted = false
+
+	return b, mw
+}
+
+// newTestClock returns a new MemoryBackend with an application window, whose
+// timers are measured in the returned FakeClock.
+func newTestClock(t *testing.T) (*MemoryBackend, *FakeClock) {
+	b, _ := resetTestApplication(t)
+
+	c := NewFakeClock()
+	if err := SetClock(c); err != nil {
+		t.Fatalf("SetClock failed: %s", err)
+	}
+
+	if err := ensureApplicationWindow(); err != nil {
+		t.Fatalf("ensureApplicationWindow failed: %s", err)
+	}
+
+	return b, c
+}
+
+// checkBackendTimer checks the delay of the backend timer of the application
+// window in milliseconds, or that there is none if expected is -1.
+func checkBackendTimer(t *testing.T, name string, b *MemoryBackend, expected int) {
+	delay, ok := b.timers[memoryTimer{appWindow.hWnd, timerId}]
+
+	switch {
+	case expected == -1 && ok:
+		t.Errorf("%s: expected no backend timer, got one with %d ms", name, delay)
+
+	case expected != -1 && (!ok || int(delay) != expected):
+		t.Errorf("%s: expected a backend timer with %d ms, got %d ms", name, expected, delay)
+	}
+}
+
+func TestSingleShotTimer(t *testing.T) {
+	b, c := newTestClock(t)
+	defer SetClock(systemClock{})
+
+	var calls []int64
+	timer, err := StartSingleShotTimer(10*ms, func() {
+		calls = append(calls, c.Nanoseconds())
+	})
+	if err != nil {
+		t.Fatalf("StartSingleShotTimer failed: %s", err)
+	}
+	checkBackendTimer(t, "started", b, 10)
+
+	c.Advance(9 * ms)
+	if len(calls) != 0 {
+		t.Fatalf("expected no call before the interval has elapsed, got %v", calls)
+	}
+	// The backend timer was set when the timer started.
+	checkBackendTimer(t, "almost due", b, 10)
+
+	c.Advance(5 * ms)
+	if fmt.Sprint(calls) != fmt.Sprint([]int64{10 * ms}) {
+		t.Errorf("expected one call at 10 ms, got %v", calls)
+	}
+	if timer.Running() {
+		t.Error("expected the timer to stop")
+	}
+	checkBackendTimer(t, "stopped", b, -1)
+
+	c.Advance(100 * ms)
+	if len(calls) != 1 {
+		t.Errorf("expected no more calls, got %v", calls)
+	}
+
+	// A stopped timer can be started again.
+	timer.Start()
+	c.Advance(10 * ms)
+	if len(calls) != 2 {
+		t.Errorf("expected the restarted timer to be triggered, got %v", calls)
+	}
+}
+
+func TestRepeatingTimer(t *testing.T) {
+	b, c := newTestClock(t)
+	defer SetClock(systemClock{})
+
+	timer, err := NewTimer(10 * ms)
+	if err != nil {
+		t.Fatalf("NewTimer failed: %s", err)
+	}
+
+	var calls []int64
+	timer.Triggered().Attach(func(args EventArgs) {
+		calls = append(calls, c.Nanoseconds()/ms)
+		if args.Sender() != timer {
+			t.Error("expected the timer to be the sender")
+		}
+	})
+
+	c.Advance(50 * ms)
+	if len(calls) != 0 {
+		t.Errorf("expected a stopped timer not to be triggered, got %v", calls)
+	}
+	checkBackendTimer(t, "not started", b, -1)
+
+	timer.Start()
+	c.Advance(35 * ms)
+	if fmt.Sprint(calls) != "[60 70 80]" {
+		t.Errorf("expected calls at 60, 70 and 80 ms, got %v", calls)
+	}
+	// The backend timer was set by the last call, for the next one.
+	checkBackendTimer(t, "running", b, 10)
+
+	timer.SetInterval(20 * ms)
+	c.Advance(20 * ms)
+	if fmt.Sprint(calls) != "[60 70 80 105]" {
+		t.Errorf("expected SetInterval to restart the timer, got %v", calls)
+	}
+
+	timer.Stop()
+	c.Advance(100 * ms)
+	if len(calls) != 4 || timer.Running() {
+		t.Errorf("expected a stopped timer not to be triggered, got %v", calls)
+	}
+	checkBackendTimer(t, "stopped", b, -1)
+}
+
+func TestTimerSkipsMissedIntervals(t *testing.T) {
+	b, c := newTestClock(t)
+	defer SetClock(systemClock{})
+
+	timer, _ := NewTimer(10 * ms)
+
+	var calls []int64
+	timer.Triggered().Attach(func(args EventArgs) {
+		calls = append(calls, c.Nanoseconds()/ms)
+	})
+	timer.Start()
+
+	// The message loop was blocked for 35 ms, e.g. by a modal loop that does
+	// not dispatch WM_TIMER, and now gets to the backend timer.
+	c.now += 35 * ms
+	b.SendMessage(appWindow.hWnd, WM_TIMER, timerId, 0)
+
+	if fmt.Sprint(calls) != "[35]" {
+		t.Errorf("expected a single call for the missed intervals, got %v", calls)
+	}
+	checkBackendTimer(t, "after missed intervals", b, 10)
+
+	c.Advance(10 * ms)
+	if fmt.Sprint(calls) != "[35 45]" {
+		t.Errorf("expected the next call an interval later, got %v", calls)
+	}
+
+	// A backend timer that elapses early does not trigger the timer.
+	c.now += 5 * ms
+	b.SendMessage(appWindow.hWnd, WM_TIMER, timerId, 0)
+	if len(calls) != 2 {
+		t.Errorf("expected no call before the timer is due, got %v", calls)
+	}
+	checkBackendTimer(t, "early", b, 5)
+}
+
+func TestTimerOrder(t *testing.T) {
+	_, c := newTestClock(t)
+	defer SetClock(systemClock{})
+
+	var calls []string
+	newTimer := func(name string, interval int64) *Timer {
+		timer, _ := NewTimer(interval)
+		timer.Triggered().Attach(func(args EventArgs) {
+			calls = append(calls, fmt.Sprintf("%s%d", name, c.Nanoseconds()/ms))
+		})
+		timer.Start()
+		return timer
+	}
+
+	newTimer("a", 30*ms)
+	newTimer("b", 20*ms)
+	once := newTimer("c", 25*ms)
+	once.SetSingleShot(true)
+
+	c.Advance(60 * ms)
+
+	expected := "[b20 c25 a30 b40 a60 b60]"
+	if s := fmt.Sprint(calls); s != expected {
+		t.Errorf("expected the calls %s, got %s", expected, s)
+	}
+}
+
+func TestSetClockRestartsTimers(t *testing.T) {
+	_, c := newTestClock(t)
+	defer SetClock(systemClock{})
+
+	var calls int
+	timer, _ := NewTimer(10 * ms)
+	timer.Triggered().Attach(func(args EventArgs) {
+		calls++
+	})
+	timer.Start()
+	c.Advance(5 * ms)
+
+	other := NewFakeClock()
+	other.now = 1000 * ms
+	SetClock(other)
+
+	// The old clock does not trigger timers anymore.
+	c.Advance(100 * ms)
+	if calls != 0 {
+		t.Errorf("expected no calls by the old clock, got %d", calls)
+	}
+
+	other.Advance(9 * ms)
+	if calls != 0 {
+		t.Error("expected the timer to be restarted")
+	}
+	other.Advance(1 * ms)
+	if calls != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+
+	if err := SetClock(nil); err == nil {
+		t.Error("expected an error for a nil clock")
+	}
+	if _, err := NewTimer(0); err == nil {
+		t.Error("expected an error for a zero interval")
+	}
+}
+
+func TestSynchronize(t *testing.T) {
+	b, _ := resetTestApplication(t)
+
+	var calls []string
+	call := func(name string) func() {
+		return func() {
+			calls = append(calls, name)
+		}
+	}
+
+	// Calls queued before the message loop starts are made when it does.
+	Synchronize(call("1"))
+	Synchronize(func() {
+		calls = append(calls, "2")
+
+		// Calls queued by a call are made after the ones already queued.
+		Synchronize(call("5"))
+	})
+
+	done := make(chan bool)
+	go func() {
+		Synchronize(call("3"))
+		done <- true
+	}()
+	<-done
+
+	Synchronize(call("4"))
+
+	if b.hasMessages() {
+		t.Error("expected no message without an application window")
+	}
+
+	if err := ensureApplicationWindow(); err != nil {
+		t.Fatalf("ensureApplicationWindow failed: %s", err)
+	}
+	b.RunMessageLoop(func() bool { return true })
+
+	if s := fmt.Sprint(calls); s != "[1 2 3 4 5]" {
+		t.Errorf("expected the calls in the order they were queued, got %s", s)
+	}
+
+	// Once the message loop runs, a single message makes all queued calls.
+	calls = nil
+	Synchronize(call("6"))
+	Synchronize(call("7"))
+	if n := b.queue.Len(); n != 1 {
+		t.Errorf("expected one queued message, got %d", n)
+	}
+
+	b.RunMessageLoop(func() bool { return true })
+	if s := fmt.Sprint(calls); s != "[6 7]" {
+		t.Errorf("expected the calls in the order they were queued, got %s", s)
+	}
+}
@@ -6,7 +6,6 @@ package gui
 
 import (
 	"os"
-	"syscall"
 	"unsafe"
 )
 
@@ -30,18 +29,18 @@ func newToolBar(parent IContainer, style uint) (*ToolBar, os.Error) {
 		return nil, newError("parent cannot be nil")
 	}
 
-	hWnd := CreateWindowEx(
-		0, syscall.StringToUTF16Ptr("ToolbarWindow32"), nil,
+	hWnd, err := backend.CreateWindow(
+		0, "ToolbarWindow32",
 		WS_CHILD|style,
-		0, 0, 0, 0, parent.Handle(), 0, 0, nil)
-	if hWnd == 0 {
-		return nil, lastError("CreateWindowEx")
+		parent.Handle(), drawing.Rectangle{0, 0, 0, 0})
+	if err != nil {
+		return nil, err
 	}
 
 	tb := &ToolBar{Widget: Widget{hWnd: hWnd, parent: parent}}
 	tb.actions = newActionList(tb)
 
-	tb.SetFont(defaultFont)
+	tb.SetFont(backend.DefaultFont())
 
 	widgetsByHWnd[hWnd] = tb
 
@@ -63,7 +62,7 @@ func NewVerticalToolBar(parent IContainer) (*ToolBar, os.Error) {
 }
 
 func (tb *ToolBar) LayoutFlags() LayoutFlags {
-	style := GetWindowLong(tb.hWnd, GWL_STYLE)
+	style, _ := backend.Style(tb.hWnd)
 
 	if style&CCS_VERT > 0 {
 		return ShrinkVert | GrowVert
@@ -77,7 +76,7 @@ func (tb *ToolBar) PreferredSize() drawing.Size {
 		return drawing.Size{}
 	}
 
-	style := GetWindowLong(tb.hWnd, GWL_STYLE)
+	style, _ := backend.Style(tb.hWnd)
 
 	if style&CCS_VERT > 0 && tb.minButtonWidth > 0 {
 		return drawing.Size{int(tb.minButtonWidth), 44}
@@ -92,7 +91,7 @@ func (tb *ToolBar) ButtonWidthLimits() (min, max uint16) {
 }
 
 func (tb *ToolBar) SetButtonWidthLimits(min, max uint16) os.Error {
-	if SendMessage(tb.hWnd, TB_SETBUTTONWIDTH, 0, uintptr(MAKELONG(min, max))) == 0 {
+	if backend.SendMessage(tb.hWnd, TB_SETBUTTONWIDTH, 0, uintptr(MAKELONG(min, max))) == 0 {
 		return newError("TB_SETBUTTONWIDTH failed")
 	}
 
@@ -117,7 +116,7 @@ func (tb *ToolBar) SetImageList(value *ImageList) {
 		hIml = value.hIml
 	}
 
-	SendMessage(tb.hWnd, TB_SETIMAGELIST, 0, uintptr(hIml))
+	backend.SendMessage(tb.hWnd, TB_SETIMAGELIST, 0, uintptr(hIml))
 
 	tb.imageList = value
 }
@@ -146,7 +145,7 @@ func (tb *ToolBar) onActionChanged(action *Action) (err os.Error) {
 		IImage:  imageIndex,
 		FsState: TBSTATE_WRAP,
 		FsStyle: BTNS_BUTTON,
-		PszText: syscall.StringToUTF16Ptr(action.Text()),
+		PszText: StringToUTF16Ptr(action.Text()),
 	}
 	tbbi.CbSize = uint(unsafe.Sizeof(tbbi))
 	if action.checked {
@@ -161,9 +160,12 @@ func (tb *ToolBar) onActionChanged(action *Action) (err os.Error) {
 	if action.exclusive {
 		tbbi.FsStyle |= BTNS_GROUP
 	}
+	if action.separator {
+		tbbi.FsStyle = BTNS_SEP
+	}
 
-	if 0 == SendMessage(tb.hWnd, TB_SETBUTTONINFO, uintptr(tb.actions.IndexOf(action)), uintptr(unsafe.Pointer(&tbbi))) {
-		err = newError("SendMessage(TB_SETBUTTONINFO) failed")
+	if 0 == backend.SendMessage(tb.hWnd, TB_SETBUTTONINFO, uintptr(tb.actions.IndexOf(action)), uintptr(unsafe.Pointer(&tbbi))) {
+		err = newError("backend.SendMessage(TB_SETBUTTONINFO) failed")
 	}
 
 	return
@@ -180,7 +182,7 @@ func (tb *ToolBar) onInsertingAction(index int, action *Action) (err os.Error) {
 		IdCommand: int(action.id),
 		FsState:   TBSTATE_WRAP,
 		FsStyle:/*BTNS_AUTOSIZE |*/ BTNS_BUTTON,
-		IString: uintptr(unsafe.Pointer(syscall.StringToUTF16Ptr(action.Text()))),
+		IString: uintptr(unsafe.Pointer(StringToUTF16Ptr(action.Text()))),
 	}
 	if action.checked {
 		tbb.FsState |= TBSTATE_CHECKED
@@ -194,19 +196,22 @@ func (tb *ToolBar) onInsertingAction(index int, action *Action) (err os.Error) {
 	if action.exclusive {
 		tbb.FsStyle |= BTNS_GROUP
 	}
+	if action.separator {
+		tbb.FsStyle = BTNS_SEP
+	}
 
 	tb.SetVisible(true)
 
-	SendMessage(tb.hWnd, TB_BUTTONSTRUCTSIZE, uintptr(unsafe.Sizeof(tbb)), 0)
-	SendMessage(tb.hWnd, TB_ADDBUTTONS, 1, uintptr(unsafe.Pointer(&tbb)))
-	SendMessage(tb.hWnd, TB_AUTOSIZE, 0, 0)
+	backend.SendMessage(tb.hWnd, TB_BUTTONSTRUCTSIZE, uintptr(unsafe.Sizeof(tbb)), 0)
+	backend.SendMessage(tb.hWnd, TB_ADDBUTTONS, 1, uintptr(unsafe.Pointer(&tbb)))
+	backend.SendMessage(tb.hWnd, TB_AUTOSIZE, 0, 0)
 
 	return
 }
 
 func (tb *ToolBar) removeAt(index int) (err os.Error) {
-	if 0 == SendMessage(tb.hWnd, TB_DELETEBUTTON, uintptr(index), 0) {
-		err = newError("SendMessage(TB_DELETEBUTTON) failed")
+	if 0 == backend.SendMessage(tb.hWnd, TB_DELETEBUTTON, uintptr(index), 0) {
+		err = newError("backend.SendMessage(TB_DELETEBUTTON) failed")
 	}
 
 	return
@@ -6,7 +6,6 @@ package gui
 
 import (
 	"os"
-	"syscall"
 	"unsafe"
 )
 
@@ -19,6 +18,7 @@ import (
 
 type ToolTip struct {
 	Widget
+	balloonHWnd HWND
 }
 
 func NewToolTip(parent IContainer) (*ToolTip, os.Error) {
@@ -26,23 +26,33 @@ func NewToolTip(parent IContainer) (*ToolTip, os.Error) {
 		return nil, newError("parent cannot be nil")
 	}
 
-	hWnd := CreateWindowEx(
-		WS_EX_TOPMOST, syscall.StringToUTF16Ptr("tooltips_class32"), nil,
-		TTS_ALWAYSTIP|TTS_BALLOON|WS_POPUP,
-		CW_USEDEFAULT, CW_USEDEFAULT, CW_USEDEFAULT, CW_USEDEFAULT,
-		parent.Handle(), 0, 0, nil)
-	if hWnd == 0 {
-		return nil, lastError("CreateWindowEx")
+	tt, err := newToolTip(parent.Handle())
+	if err != nil {
+		return nil, err
 	}
 
-	tt := &ToolTip{Widget: Widget{hWnd: hWnd, parent: parent}}
-	tt.SetFont(defaultFont)
-
-	widgetsByHWnd[hWnd] = tt
+	tt.parent = parent
 
 	parent.Children().Add(tt)
 
-	SetWindowPos(hWnd, HWND_TOPMOST, 0, 0, 0, 0, SWP_NOMOVE|SWP_NOSIZE|SWP_NOACTIVATE)
+	return tt, nil
+}
+
+// newToolTip creates a ToolTip that is owned by the window owner, but is not
+// a child of any container.
+func newToolTip(owner HWND) (*ToolTip, os.Error) {
+	hWnd, err := backend.CreateWindow(
+		WS_EX_TOPMOST, "tooltips_class32",
+		TTS_ALWAYSTIP|TTS_BALLOON|WS_POPUP,
+		owner, drawing.Rectangle{CW_USEDEFAULT, CW_USEDEFAULT, CW_USEDEFAULT, CW_USEDEFAULT})
+	if err != nil {
+		return nil, err
+	}
+
+	tt := &ToolTip{Widget: Widget{hWnd: hWnd}}
+	tt.SetFont(backend.DefaultFont())
+
+	widgetsByHWnd[hWnd] = tt
 
 	return tt, nil
 }
@@ -64,13 +74,13 @@ func (tt *ToolTip) Title() string {
 	gt.Cch = uint(len(buf))
 	gt.PszTitle = &buf[0]
 
-	SendMessage(tt.hWnd, TTM_GETTITLE, 0, uintptr(unsafe.Pointer(&gt)))
+	backend.SendMessage(tt.hWnd, TTM_GETTITLE, 0, uintptr(unsafe.Pointer(&gt)))
 
-	return syscall.UTF16ToString(buf)
+	return UTF16ToString(buf)
 }
 
 func (tt *ToolTip) SetTitle(value string) os.Error {
-	if FALSE == SendMessage(tt.hWnd, TTM_SETTITLE, uintptr(TTI_INFO), uintptr(unsafe.Pointer(syscall.StringToUTF16Ptr(value)))) {
+	if FALSE == backend.SendMessage(tt.hWnd, TTM_SETTITLE, uintptr(TTI_INFO), uintptr(unsafe.Pointer(StringToUTF16Ptr(value)))) {
 		return newError("TTM_SETTITLE failed")
 	}
 
@@ -87,15 +97,76 @@ func (tt *ToolTip) AddWidget(widget IWidget, text string) os.Error {
 	}
 	ti.UFlags = TTF_IDISHWND | TTF_SUBCLASS
 	ti.UId = uintptr(widget.Handle())
-	ti.LpszText = syscall.StringToUTF16Ptr(text)
+	ti.LpszText = StringToUTF16Ptr(text)
 
-	if FALSE == SendMessage(tt.hWnd, TTM_ADDTOOL, 0, uintptr(unsafe.Pointer(&ti))) {
+	if FALSE == backend.SendMessage(tt.hWnd, TTM_ADDTOOL, 0, uintptr(unsafe.Pointer(&ti))) {
 		return newError("TTM_ADDTOOL failed")
 	}
 
 	return nil
 }
 
+// ShowBalloon shows a balloon with an error icon, title and text below
+// widget, until HideBalloon is called or a balloon is shown for another
+// widget.
+func (tt *ToolTip) ShowBalloon(widget IWidget, title, text string) os.Error {
+	tt.HideBalloon()
+
+	var ti TOOLINFO
+
+	ti.CbSize = uint(unsafe.Sizeof(ti))
+	ti.Hwnd = widget.Handle()
+	ti.UFlags = TTF_IDISHWND | TTF_TRACK | TTF_ABSOLUTE
+	ti.UId = uintptr(widget.Handle())
+	ti.LpszText = StringToUTF16Ptr(text)
+
+	if FALSE == backend.SendMessage(tt.hWnd, TTM_ADDTOOL, 0, uintptr(unsafe.Pointer(&ti))) {
+		return newError("TTM_ADDTOOL failed")
+	}
+
+	tt.balloonHWnd = widget.Handle()
+
+	if FALSE == backend.SendMessage(tt.hWnd, TTM_SETTITLE, uintptr(TTI_ERROR), uintptr(unsafe.Pointer(StringToUTF16Ptr(title)))) {
+		return newError("TTM_SETTITLE failed")
+	}
+
+	bounds, err := backend.Bounds(widget.Handle())
+	if err != nil {
+		return err
+	}
+
+	// The balloon points at the bottom center of widget, in screen coordinates.
+	pos := drawing.Point{bounds.X + bounds.Width/2, bounds.Y + bounds.Height}
+	if parent := widget.Parent(); parent != nil {
+		if pos, err = backend.ClientToScreen(parent.Handle(), pos); err != nil {
+			return err
+		}
+	}
+
+	backend.SendMessage(tt.hWnd, TTM_TRACKPOSITION, 0, uintptr(MAKELONG(uint16(pos.X), uint16(pos.Y))))
+	backend.SendMessage(tt.hWnd, TTM_TRACKACTIVATE, TRUE, uintptr(unsafe.Pointer(&ti)))
+
+	return nil
+}
+
+// HideBalloon hides the balloon shown by ShowBalloon, if any.
+func (tt *ToolTip) HideBalloon() {
+	if tt.balloonHWnd == 0 {
+		return
+	}
+
+	var ti TOOLINFO
+
+	ti.CbSize = uint(unsafe.Sizeof(ti))
+	ti.Hwnd = tt.balloonHWnd
+	ti.UId = uintptr(tt.balloonHWnd)
+
+	backend.SendMessage(tt.hWnd, TTM_TRACKACTIVATE, FALSE, uintptr(unsafe.Pointer(&ti)))
+	backend.SendMessage(tt.hWnd, TTM_DELTOOL, 0, uintptr(unsafe.Pointer(&ti)))
+
+	tt.balloonHWnd = 0
+}
+
 func (tt *ToolTip) RemoveWidget(widget IWidget) os.Error {
 	panic("not implemented")
 }
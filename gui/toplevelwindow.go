@@ -5,7 +5,6 @@
 package gui
 
 import (
-	"container/vector"
 	"fmt"
 	"os"
 	"unsafe"
@@ -13,7 +12,6 @@ import (
 
 import (
 	"walk/drawing"
-	. "walk/winapi/kernel32"
 	. "walk/winapi/user32"
 )
 
@@ -40,13 +38,52 @@ func (a *closingEventArgs) Reason() CloseReason {
 
 type ClosingEventHandler func(args ClosingEventArgs)
 
+// ClosingEvent is an event that handlers of type ClosingEventHandler can be
+// attached to.
+type ClosingEvent struct {
+	handlers eventHandlerList
+}
+
+func (e *ClosingEvent) Attach(handler ClosingEventHandler) EventHandle {
+	return e.handlers.attach(handler, false)
+}
+
+func (e *ClosingEvent) Once(handler ClosingEventHandler) EventHandle {
+	return e.handlers.attach(handler, true)
+}
+
+func (e *ClosingEvent) Detach(handle EventHandle) {
+	e.handlers.detach(handle)
+}
+
+type ClosingEventPublisher struct {
+	event ClosingEvent
+}
+
+func (p *ClosingEventPublisher) Event() *ClosingEvent {
+	return &p.event
+}
+
+func (p *ClosingEventPublisher) Publish(args ClosingEventArgs) {
+	p.event.handlers.raise(func(handler interface{}) {
+		handler.(ClosingEventHandler)(args)
+	})
+}
+
+
+type shortcutHandler interface {
+	handleShortcut(shortcut Shortcut) bool
+}
 
 type TopLevelWindow struct {
 	Container
-	owner           *MainWindow
-	clientArea      *Composite
-	closingHandlers vector.Vector
-	closeReason     CloseReason
+	owner                    *MainWindow
+	clientArea               *Composite
+	closingPublisher         ClosingEventPublisher
+	closeReason              CloseReason
+	acceleratorTable         *AcceleratorTable
+	validationToolTip        *ToolTip
+	validityChangedPublisher EventPublisher
 }
 
 func (tlw *TopLevelWindow) ClientArea() *Composite {
@@ -58,7 +95,110 @@ func (tlw *TopLevelWindow) LayoutFlags() LayoutFlags {
 }
 
 func (tlw *TopLevelWindow) PreferredSize() drawing.Size {
-	return tlw.dialogBaseUnitsToPixels(drawing.Size{252, 218})
+	if tlw.layout == nil {
+		return tlw.dialogBaseUnitsToPixels(drawing.Size{252, 218})
+	}
+
+	return tlw.sizeForClientSize(tlw.layout.PreferredSize())
+}
+
+// sizeForClientSize returns the window size needed for a client area of the
+// specified size, i.e. it adds the size of the frame, menu bar, tool bar etc.
+func (tlw *TopLevelWindow) sizeForClientSize(clientSize drawing.Size) drawing.Size {
+	var widget IWidget = tlw
+	if w, ok := widgetsByHWnd[tlw.hWnd]; ok {
+		widget = w
+	}
+
+	bounds, err := widget.Bounds()
+	if err != nil {
+		return clientSize
+	}
+
+	cb, err := widget.ClientBounds()
+	if err != nil {
+		return clientSize
+	}
+
+	return drawing.Size{
+		clientSize.Width + bounds.Width - cb.Width,
+		clientSize.Height + bounds.Height - cb.Height,
+	}
+}
+
+// AcceleratorTable returns the table of actions that are triggered when their
+// shortcut is pressed while the window is active.
+func (tlw *TopLevelWindow) AcceleratorTable() *AcceleratorTable {
+	if tlw.acceleratorTable == nil {
+		tlw.acceleratorTable = NewAcceleratorTable()
+	}
+
+	return tlw.acceleratorTable
+}
+
+func (tlw *TopLevelWindow) handleShortcut(shortcut Shortcut) bool {
+	return tlw.acceleratorTable != nil && tlw.acceleratorTable.Trigger(shortcut)
+}
+
+// IsValid returns whether the texts of all Validatable descendants of the
+// window are valid.
+func (tlw *TopLevelWindow) IsValid() bool {
+	valid := true
+
+	walkValidatables(widgetsByHWnd[tlw.hWnd], func(v Validatable) bool {
+		valid = v.IsValid()
+
+		return valid
+	})
+
+	return valid
+}
+
+// Validate validates all Validatable descendants of the window. The error of
+// the first invalid one is shown to the user and returned, and it receives
+// the keyboard focus.
+func (tlw *TopLevelWindow) Validate() os.Error {
+	var err os.Error
+
+	walkValidatables(widgetsByHWnd[tlw.hWnd], func(v Validatable) bool {
+		if err = v.Validate(); err != nil {
+			v.SetFocus()
+			return false
+		}
+
+		return true
+	})
+
+	return err
+}
+
+func (tlw *TopLevelWindow) showValidationError(widget IWidget, err os.Error) {
+	if tlw.validationToolTip == nil {
+		tt, e := newToolTip(tlw.hWnd)
+		if e != nil {
+			return
+		}
+
+		tlw.validationToolTip = tt
+	}
+
+	tlw.validationToolTip.ShowBalloon(widget, "Invalid Input", err.String())
+}
+
+func (tlw *TopLevelWindow) hideValidationError(widget IWidget) {
+	if tlw.validationToolTip != nil && tlw.validationToolTip.balloonHWnd == widget.Handle() {
+		tlw.validationToolTip.HideBalloon()
+	}
+}
+
+// ValidityChanged is raised when the text of a Validatable descendant of the
+// window becomes valid or invalid.
+func (tlw *TopLevelWindow) ValidityChanged() *Event {
+	return tlw.validityChangedPublisher.Event()
+}
+
+func (tlw *TopLevelWindow) raiseValidityChanged() {
+	tlw.validityChangedPublisher.Publish(&eventArgs{widgetsByHWnd[tlw.hWnd]})
 }
 
 func (tlw *TopLevelWindow) RunMessageLoop() os.Error {
@@ -77,23 +217,23 @@ func (tlw *TopLevelWindow) SetOwner(value *MainWindow) os.Error {
 		ownerHWnd = value.hWnd
 	}
 
-	SetLastError(0)
-	if 0 == SetWindowLong(tlw.hWnd, GWL_HWNDPARENT, int(ownerHWnd)) {
-		return lastError("SetWindowLong")
-	}
-
-	return nil
+	return backend.SetOwner(tlw.hWnd, ownerHWnd)
 }
 
 func (tlw *TopLevelWindow) Hide() {
-	ShowWindow(tlw.hWnd, SW_HIDE)
+	backend.ShowWindow(tlw.hWnd, SW_HIDE)
 }
 
 func (tlw *TopLevelWindow) Show() {
-	ShowWindow(tlw.hWnd, SW_SHOW)
+	backend.ShowWindow(tlw.hWnd, SW_SHOW)
 }
 
 func (tlw *TopLevelWindow) close() os.Error {
+	if tlw.validationToolTip != nil {
+		tlw.validationToolTip.Dispose()
+		tlw.validationToolTip = nil
+	}
+
 	// FIXME: Remove this and children from widgetsByHWnd
 	tlw.Dispose()
 
@@ -101,18 +241,15 @@ func (tlw *TopLevelWindow) close() os.Error {
 }
 
 func (tlw *TopLevelWindow) Close() os.Error {
-	SendMessage(tlw.hWnd, WM_CLOSE, 0, 0)
+	backend.SendMessage(tlw.hWnd, WM_CLOSE, 0, 0)
 
 	return nil
 }
 
 func (tlw *TopLevelWindow) SaveState() (string, os.Error) {
-	var wp WINDOWPLACEMENT
-
-	wp.Length = uint(unsafe.Sizeof(wp))
-
-	if !GetWindowPlacement(tlw.hWnd, &wp) {
-		return "", lastError("GetWindowPlacement")
+	wp, err := backend.WindowPlacement(tlw.hWnd)
+	if err != nil {
+		return "", err
 	}
 
 	return fmt.Sprint(
@@ -137,49 +274,38 @@ func (tlw *TopLevelWindow) RestoreState(s string) os.Error {
 		return err
 	}
 
-	wp.Length = uint(unsafe.Sizeof(wp))
-
-	if !SetWindowPlacement(tlw.hWnd, &wp) {
-		return lastError("SetWindowPlacement")
-	}
-
-	return nil
+	return backend.SetWindowPlacement(tlw.hWnd, &wp)
 }
 
-func (tlw *TopLevelWindow) AddClosingHandler(handler ClosingEventHandler) {
-	tlw.closingHandlers.Push(handler)
+func (tlw *TopLevelWindow) Closing() *ClosingEvent {
+	return tlw.closingPublisher.Event()
 }
 
-func (tlw *TopLevelWindow) RemoveClosingHandler(handler ClosingEventHandler) {
-	for i, h := range tlw.closingHandlers {
-		if h.(ClosingEventHandler) == handler {
-			tlw.closingHandlers.Delete(i)
-			break
-		}
-	}
+func (tlw *TopLevelWindow) raiseClosing(args *closingEventArgs) {
+	tlw.closingPublisher.Publish(args)
 }
 
-func (tlw *TopLevelWindow) raiseClosing(args *closingEventArgs) {
-	for _, handlerIface := range tlw.closingHandlers {
-		handler := handlerIface.(ClosingEventHandler)
-		handler(args)
+// confirmClose raises Closing with the reason of the pending close and
+// returns whether no handler canceled it.
+func (tlw *TopLevelWindow) confirmClose() bool {
+	args := &closingEventArgs{
+		cancelEventArgs: cancelEventArgs{
+			eventArgs: eventArgs{
+				widgetsByHWnd[tlw.hWnd],
+			},
+		},
+		reason: tlw.closeReason,
 	}
+	tlw.closeReason = CloseReasonUnknown
+	tlw.raiseClosing(args)
+
+	return !args.Canceled()
 }
 
 func (tlw *TopLevelWindow) wndProc(msg *MSG, origWndProcPtr uintptr) uintptr {
 	switch msg.Message {
 	case WM_CLOSE:
-		args := &closingEventArgs{
-			cancelEventArgs: cancelEventArgs{
-				eventArgs: eventArgs{
-					widgetsByHWnd[tlw.hWnd],
-				},
-			},
-			reason: tlw.closeReason,
-		}
-		tlw.closeReason = CloseReasonUnknown
-		tlw.raiseClosing(args)
-		if !args.Canceled() {
+		if tlw.confirmClose() {
 			tlw.close()
 		}
 		return 0
@@ -188,6 +314,28 @@ func (tlw *TopLevelWindow) wndProc(msg *MSG, origWndProcPtr uintptr) uintptr {
 		if msg.WParam == SC_CLOSE {
 			tlw.closeReason = CloseReasonUser
 		}
+
+	case WM_INITMENUPOPUP:
+		if menu, ok := menusByHMenu[HMENU(msg.WParam)]; ok {
+			menu.raiseShowing()
+			menu.updateActions()
+		}
+
+	case WM_GETMINMAXINFO:
+		if tlw.layout != nil && msg.LParam != 0 {
+			mmi := (*MINMAXINFO)(unsafe.Pointer(msg.LParam))
+
+			minSize := tlw.sizeForClientSize(tlw.layout.MinSize())
+
+			if minSize.Width > mmi.PtMinTrackSize.X {
+				mmi.PtMinTrackSize.X = minSize.Width
+			}
+			if minSize.Height > mmi.PtMinTrackSize.Y {
+				mmi.PtMinTrackSize.Y = minSize.Height
+			}
+
+			return 0
+		}
 	}
 
 	return tlw.Container.wndProc(msg, origWndProcPtr)
@@ -0,0 +1,103 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"container/vector"
+)
+
+// TreeItem is an item of a TreeModel.
+//
+// Any comparable value can be used, typically a pointer to a node of the
+// hierarchy the model presents. nil stands for the invisible root, whose
+// children are the top level items.
+type TreeItem interface{}
+
+type treeModelObserver interface {
+	onTreeModelItemsReset(parent TreeItem)
+	onTreeModelItemsInserted(parent TreeItem, from, to int)
+	onTreeModelItemsRemoved(parent TreeItem, from, to int)
+	onTreeModelItemChanged(item TreeItem)
+}
+
+// TreeModel provides the items of a widget like TreeView on demand.
+//
+// TreeView only asks for the children of an item when the item is expanded,
+// so models can present hierarchies that are too large to load completely.
+//
+// Implementations must embed TreeModelBase, which implements the unexported
+// methods widgets use to observe the model, and call its Publish methods
+// whenever their items change.
+type TreeModel interface {
+	// ChildCount returns the number of children of parent.
+	ChildCount(parent TreeItem) int
+
+	// Child returns the child of parent at index.
+	Child(parent TreeItem, index int) TreeItem
+
+	// HasChildren returns whether item has any children. It is called for
+	// every displayed item, so it should not need to load the children.
+	HasChildren(item TreeItem) bool
+
+	// Text returns the text to display for item.
+	Text(item TreeItem) string
+
+	addObserver(observer treeModelObserver)
+	removeObserver(observer treeModelObserver)
+}
+
+// TreeModelBase implements the change notifications of a TreeModel.
+type TreeModelBase struct {
+	observers vector.Vector
+}
+
+func (tmb *TreeModelBase) addObserver(observer treeModelObserver) {
+	tmb.observers.Push(observer)
+}
+
+func (tmb *TreeModelBase) removeObserver(observer treeModelObserver) {
+	for i, o := range tmb.observers {
+		if o.(treeModelObserver) == observer {
+			tmb.observers.Delete(i)
+			break
+		}
+	}
+}
+
+// PublishItemsReset notifies observers that any descendant of parent may
+// have changed. Observers discard what they loaded of the subtree.
+func (tmb *TreeModelBase) PublishItemsReset(parent TreeItem) {
+	for _, observerIface := range tmb.observers {
+		observer := observerIface.(treeModelObserver)
+		observer.onTreeModelItemsReset(parent)
+	}
+}
+
+// PublishItemsInserted notifies observers that the children of parent from
+// through to have been inserted.
+func (tmb *TreeModelBase) PublishItemsInserted(parent TreeItem, from, to int) {
+	for _, observerIface := range tmb.observers {
+		observer := observerIface.(treeModelObserver)
+		observer.onTreeModelItemsInserted(parent, from, to)
+	}
+}
+
+// PublishItemsRemoved notifies observers that the children previously at
+// from through to of parent have been removed.
+func (tmb *TreeModelBase) PublishItemsRemoved(parent TreeItem, from, to int) {
+	for _, observerIface := range tmb.observers {
+		observer := observerIface.(treeModelObserver)
+		observer.onTreeModelItemsRemoved(parent, from, to)
+	}
+}
+
+// PublishItemChanged notifies observers that the text of item, or whether it
+// has children, has changed.
+func (tmb *TreeModelBase) PublishItemChanged(item TreeItem) {
+	for _, observerIface := range tmb.observers {
+		observer := observerIface.(treeModelObserver)
+		observer.onTreeModelItemChanged(item)
+	}
+}
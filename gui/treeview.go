@@ -7,12 +7,12 @@ package gui
 import (
 	"container/vector"
 	"os"
-	"syscall"
 	"unsafe"
 )
 
 import (
 	"walk/drawing"
+	. "walk/winapi"
 	. "walk/winapi/comctl32"
 	. "walk/winapi/user32"
 )
@@ -33,13 +33,51 @@ func (a *treeViewItemEventArgs) Item() *TreeViewItem {
 
 type TreeViewItemEventHandler func(args TreeViewItemEventArgs)
 
+// TreeViewItemEvent is an event that handlers of type
+// TreeViewItemEventHandler can be attached to.
+type TreeViewItemEvent struct {
+	handlers eventHandlerList
+}
+
+func (e *TreeViewItemEvent) Attach(handler TreeViewItemEventHandler) EventHandle {
+	return e.handlers.attach(handler, false)
+}
+
+func (e *TreeViewItemEvent) Once(handler TreeViewItemEventHandler) EventHandle {
+	return e.handlers.attach(handler, true)
+}
+
+func (e *TreeViewItemEvent) Detach(handle EventHandle) {
+	e.handlers.detach(handle)
+}
+
+type TreeViewItemEventPublisher struct {
+	event TreeViewItemEvent
+}
+
+func (p *TreeViewItemEventPublisher) Event() *TreeViewItemEvent {
+	return &p.event
+}
+
+func (p *TreeViewItemEventPublisher) Publish(args TreeViewItemEventArgs) {
+	p.event.handlers.raise(func(handler interface{}) {
+		handler.(TreeViewItemEventHandler)(args)
+	})
+}
+
 type TreeView struct {
 	Widget
-	items                  *TreeViewItemList
-	itemCollapsedHandlers  vector.Vector
-	itemCollapsingHandlers vector.Vector
-	itemExpandedHandlers   vector.Vector
-	itemExpandingHandlers  vector.Vector
+	items                   *TreeViewItemList
+	itemCollapsedPublisher  TreeViewItemEventPublisher
+	itemCollapsingPublisher TreeViewItemEventPublisher
+	itemExpandedPublisher   TreeViewItemEventPublisher
+	itemExpandingPublisher  TreeViewItemEventPublisher
+	model                   TreeModel
+	handle2ModelItem        map[HTREEITEM]TreeItem
+	modelItem2Handle        map[TreeItem]HTREEITEM
+	populated               map[HTREEITEM]bool
+	dragEnabled             bool
+	dragCompletedPublisher  DragEventPublisher
 }
 
 func NewTreeView(parent IContainer) (*TreeView, os.Error) {
@@ -47,12 +85,12 @@ func NewTreeView(parent IContainer) (*TreeView, os.Error) {
 		return nil, newError("parent cannot be nil")
 	}
 
-	hWnd := CreateWindowEx(
-		WS_EX_CLIENTEDGE, syscall.StringToUTF16Ptr("SysTreeView32"), nil,
+	hWnd, err := backend.CreateWindow(
+		WS_EX_CLIENTEDGE, "SysTreeView32",
 		TVS_HASBUTTONS|TVS_HASLINES|TVS_LINESATROOT|TVS_SHOWSELALWAYS|WS_CHILD|WS_TABSTOP|WS_VISIBLE,
-		0, 0, 0, 0, parent.Handle(), 0, 0, nil)
-	if hWnd == 0 {
-		return nil, lastError("CreateWindowEx")
+		parent.Handle(), drawing.Rectangle{0, 0, 0, 0})
+	if err != nil {
+		return nil, err
 	}
 
 	tv := &TreeView{Widget: Widget{hWnd: hWnd, parent: parent}}
@@ -63,7 +101,7 @@ func NewTreeView(parent IContainer) (*TreeView, os.Error) {
 
 	tv.items = newTreeViewItemList(tv)
 
-	tv.SetFont(defaultFont)
+	tv.SetFont(backend.DefaultFont())
 
 	widgetsByHWnd[hWnd] = tv
 
@@ -84,84 +122,136 @@ func (tv *TreeView) Items() *TreeViewItemList {
 	return tv.items
 }
 
-func (tv *TreeView) AddItemCollapsedHandler(handler TreeViewItemEventHandler) {
-	tv.itemCollapsedHandlers.Push(handler)
+func (tv *TreeView) Model() TreeModel {
+	return tv.model
 }
 
-func (tv *TreeView) RemoveItemCollapsedHandler(handler TreeViewItemEventHandler) {
-	for i, h := range tv.itemCollapsedHandlers {
-		if h.(TreeViewItemEventHandler) == handler {
-			tv.itemCollapsedHandlers.Delete(i)
-			break
+// SetModel makes the TreeView display the items of model instead of Items.
+//
+// Only the top level items are loaded immediately, the children of an item
+// are requested from the model when it is expanded for the first time. The
+// item events are only raised for Items.
+func (tv *TreeView) SetModel(model TreeModel) os.Error {
+	if model != nil && tv.items.Len() > 0 {
+		return newError("Items must be empty to use a model")
+	}
+
+	if tv.model != nil {
+		tv.model.removeObserver(tv)
+
+		if err := tv.deleteModelItems(TVI_ROOT); err != nil {
+			return err
 		}
 	}
+
+	tv.model = model
+	tv.handle2ModelItem = nil
+	tv.modelItem2Handle = nil
+	tv.populated = nil
+
+	if model == nil {
+		return nil
+	}
+
+	tv.handle2ModelItem = make(map[HTREEITEM]TreeItem)
+	tv.modelItem2Handle = make(map[TreeItem]HTREEITEM)
+	tv.populated = make(map[HTREEITEM]bool)
+
+	model.addObserver(tv)
+
+	return tv.populate(nil, TVI_ROOT)
 }
 
-func (tv *TreeView) raiseItemCollapsed(item *TreeViewItem) {
-	for _, handlerIface := range tv.itemCollapsedHandlers {
-		handler := handlerIface.(TreeViewItemEventHandler)
-		handler(&treeViewItemEventArgs{eventArgs: eventArgs{widgetsByHWnd[tv.hWnd]}, item: item})
+// RefreshModelItem reloads the children of item, which must be displayed. Pass
+// nil to reload the whole tree.
+func (tv *TreeView) RefreshModelItem(item TreeItem) os.Error {
+	if tv.model == nil {
+		return newError("no model set")
 	}
+
+	return tv.refresh(item)
 }
 
-func (tv *TreeView) AddItemCollapsingHandler(handler TreeViewItemEventHandler) {
-	tv.itemCollapsingHandlers.Push(handler)
+func (tv *TreeView) ItemCollapsed() *TreeViewItemEvent {
+	return tv.itemCollapsedPublisher.Event()
 }
 
-func (tv *TreeView) RemoveItemCollapsingHandler(handler TreeViewItemEventHandler) {
-	for i, h := range tv.itemCollapsingHandlers {
-		if h.(TreeViewItemEventHandler) == handler {
-			tv.itemCollapsingHandlers.Delete(i)
-			break
-		}
-	}
+func (tv *TreeView) raiseItemCollapsed(item *TreeViewItem) {
+	tv.itemCollapsedPublisher.Publish(&treeViewItemEventArgs{eventArgs: eventArgs{widgetsByHWnd[tv.hWnd]}, item: item})
 }
 
-func (tv *TreeView) raiseItemCollapsing(item *TreeViewItem) {
-	for _, handlerIface := range tv.itemCollapsingHandlers {
-		handler := handlerIface.(TreeViewItemEventHandler)
-		handler(&treeViewItemEventArgs{eventArgs: eventArgs{widgetsByHWnd[tv.hWnd]}, item: item})
-	}
+func (tv *TreeView) ItemCollapsing() *TreeViewItemEvent {
+	return tv.itemCollapsingPublisher.Event()
 }
 
-func (tv *TreeView) AddItemExpandedHandler(handler TreeViewItemEventHandler) {
-	tv.itemExpandedHandlers.Push(handler)
+func (tv *TreeView) raiseItemCollapsing(item *TreeViewItem) {
+	tv.itemCollapsingPublisher.Publish(&treeViewItemEventArgs{eventArgs: eventArgs{widgetsByHWnd[tv.hWnd]}, item: item})
 }
 
-func (tv *TreeView) RemoveItemExpandedHandler(handler TreeViewItemEventHandler) {
-	for i, h := range tv.itemExpandedHandlers {
-		if h.(TreeViewItemEventHandler) == handler {
-			tv.itemExpandedHandlers.Delete(i)
-			break
-		}
-	}
+func (tv *TreeView) ItemExpanded() *TreeViewItemEvent {
+	return tv.itemExpandedPublisher.Event()
 }
 
 func (tv *TreeView) raiseItemExpanded(item *TreeViewItem) {
-	for _, handlerIface := range tv.itemExpandedHandlers {
-		handler := handlerIface.(TreeViewItemEventHandler)
-		handler(&treeViewItemEventArgs{eventArgs: eventArgs{widgetsByHWnd[tv.hWnd]}, item: item})
-	}
+	tv.itemExpandedPublisher.Publish(&treeViewItemEventArgs{eventArgs: eventArgs{widgetsByHWnd[tv.hWnd]}, item: item})
 }
 
-func (tv *TreeView) AddItemExpandingHandler(handler TreeViewItemEventHandler) {
-	tv.itemExpandingHandlers.Push(handler)
+func (tv *TreeView) ItemExpanding() *TreeViewItemEvent {
+	return tv.itemExpandingPublisher.Event()
 }
 
-func (tv *TreeView) RemoveItemExpandingHandler(handler TreeViewItemEventHandler) {
-	for i, h := range tv.itemExpandingHandlers {
-		if h.(TreeViewItemEventHandler) == handler {
-			tv.itemExpandingHandlers.Delete(i)
-			break
-		}
-	}
+func (tv *TreeView) raiseItemExpanding(item *TreeViewItem) {
+	tv.itemExpandingPublisher.Publish(&treeViewItemEventArgs{eventArgs: eventArgs{widgetsByHWnd[tv.hWnd]}, item: item})
 }
 
-func (tv *TreeView) raiseItemExpanding(item *TreeViewItem) {
-	for _, handlerIface := range tv.itemExpandingHandlers {
-		handler := handlerIface.(TreeViewItemEventHandler)
-		handler(&treeViewItemEventArgs{eventArgs: eventArgs{widgetsByHWnd[tv.hWnd]}, item: item})
+func (tv *TreeView) DragEnabled() bool {
+	return tv.dragEnabled
+}
+
+// SetDragEnabled sets whether items can be dragged.
+//
+// The DataObject of the drag holds the dragged item in TreeViewItemFormat,
+// which is the TreeItem of the model if there is one and the *TreeViewItem
+// otherwise, and its text in TextFormat. Items can be copied or moved, moving
+// them is up to the handlers of DragCompleted.
+func (tv *TreeView) SetDragEnabled(value bool) {
+	tv.dragEnabled = value
+}
+
+// DragCompleted is raised when a drag of an item has ended. The effect of the
+// event args is DropEffectNone if the drag was canceled.
+func (tv *TreeView) DragCompleted() *DragEvent {
+	return tv.dragCompletedPublisher.Event()
+}
+
+func (tv *TreeView) onBeginDrag(nmtv *NMTREEVIEW) {
+	if !tv.dragEnabled {
+		return
+	}
+
+	data := NewDataObject()
+
+	if tv.model != nil {
+		item, ok := tv.handle2ModelItem[nmtv.ItemNew.HItem]
+		if !ok {
+			return
+		}
+
+		data.SetData(TreeViewItemFormat, item)
+		data.SetText(tv.model.Text(item))
+	} else {
+		item := (*TreeViewItem)(unsafe.Pointer(nmtv.ItemNew.LParam))
+		if item == nil {
+			return
+		}
+
+		data.SetData(TreeViewItemFormat, item)
+		data.SetText(item.Text())
 	}
+
+	allowed := DropEffectCopy | DropEffectMove
+
+	tv.BeginDrag(data, allowed, dragCompletedPublisher(&tv.dragCompletedPublisher, tv, data, allowed))
 }
 
 func (tv *TreeView) wndProc(msg *MSG, origWndProcPtr uintptr) uintptr {
@@ -171,6 +261,11 @@ func (tv *TreeView) wndProc(msg *MSG, origWndProcPtr uintptr) uintptr {
 
 		switch nmtv.Hdr.Code {
 		case TVN_ITEMEXPANDED:
+			if tv.model != nil {
+				// The item events are only raised for Items.
+				break
+			}
+
 			item := (*TreeViewItem)(unsafe.Pointer(nmtv.ItemNew.LParam))
 
 			switch nmtv.Action {
@@ -188,6 +283,13 @@ func (tv *TreeView) wndProc(msg *MSG, origWndProcPtr uintptr) uintptr {
 			}
 
 		case TVN_ITEMEXPANDING:
+			if tv.model != nil {
+				if nmtv.Action == TVE_EXPAND {
+					tv.onExpandingModelItem(nmtv.ItemNew.HItem)
+				}
+				break
+			}
+
 			item := (*TreeViewItem)(unsafe.Pointer(nmtv.ItemNew.LParam))
 
 			switch nmtv.Action {
@@ -203,6 +305,9 @@ func (tv *TreeView) wndProc(msg *MSG, origWndProcPtr uintptr) uintptr {
 
 			case TVE_TOGGLE:
 			}
+
+		case TVN_BEGINDRAG:
+			tv.onBeginDrag(nmtv)
 		}
 	}
 
@@ -215,7 +320,7 @@ func (tv *TreeView) onInsertingTreeViewItem(parent *TreeViewItem, index int, ite
 
 	tvi.LParam = uintptr(unsafe.Pointer(item))
 	tvi.Mask = TVIF_TEXT | TVIF_PARAM
-	tvi.PszText = syscall.StringToUTF16Ptr(item.text)
+	tvi.PszText = StringToUTF16Ptr(item.text)
 
 	tvins.Item = tvi
 
@@ -237,7 +342,7 @@ func (tv *TreeView) onInsertingTreeViewItem(parent *TreeViewItem, index int, ite
 		tvins.HInsertAfter = items.At(index - 1).handle
 	}
 
-	item.handle = HTREEITEM(SendMessage(tv.hWnd, TVM_INSERTITEM, 0, uintptr(unsafe.Pointer(&tvins))))
+	item.handle = HTREEITEM(backend.SendMessage(tv.hWnd, TVM_INSERTITEM, 0, uintptr(unsafe.Pointer(&tvins))))
 	if item.handle == 0 {
 		err = newError("TVM_INSERTITEM failed")
 	} else {
@@ -254,3 +359,225 @@ func (tv *TreeView) onRemovingTreeViewItem(index int, item *TreeViewItem) (err o
 func (tv *TreeView) onClearingTreeViewItems() (err os.Error) {
 	panic("not implemented")
 }
+
+func (tv *TreeView) modelItemHandle(item TreeItem) (handle HTREEITEM, ok bool) {
+	if item == nil {
+		return TVI_ROOT, true
+	}
+
+	handle, ok = tv.modelItem2Handle[item]
+	return
+}
+
+func (tv *TreeView) childHandles(parent HTREEITEM) []HTREEITEM {
+	var handles vector.Vector
+
+	var h HTREEITEM
+	if parent == TVI_ROOT {
+		h = HTREEITEM(backend.SendMessage(tv.hWnd, TVM_GETNEXTITEM, TVGN_ROOT, 0))
+	} else {
+		h = HTREEITEM(backend.SendMessage(tv.hWnd, TVM_GETNEXTITEM, TVGN_CHILD, uintptr(parent)))
+	}
+
+	for h != 0 {
+		handles.Push(h)
+
+		h = HTREEITEM(backend.SendMessage(tv.hWnd, TVM_GETNEXTITEM, TVGN_NEXT, uintptr(h)))
+	}
+
+	result := make([]HTREEITEM, handles.Len())
+	for i, h := range handles {
+		result[i] = h.(HTREEITEM)
+	}
+
+	return result
+}
+
+func (tv *TreeView) insertModelItem(parent, insertAfter HTREEITEM, item TreeItem) (HTREEITEM, os.Error) {
+	var tvins TVINSERTSTRUCT
+
+	tvins.HParent = parent
+	tvins.HInsertAfter = insertAfter
+	tvins.Item.Mask = TVIF_TEXT | TVIF_CHILDREN
+	tvins.Item.PszText = StringToUTF16Ptr(tv.model.Text(item))
+	if tv.model.HasChildren(item) {
+		tvins.Item.CChildren = 1
+	}
+
+	handle := HTREEITEM(backend.SendMessage(tv.hWnd, TVM_INSERTITEM, 0, uintptr(unsafe.Pointer(&tvins))))
+	if handle == 0 {
+		return 0, newError("TVM_INSERTITEM failed")
+	}
+
+	tv.handle2ModelItem[handle] = item
+	tv.modelItem2Handle[item] = handle
+
+	return handle, nil
+}
+
+// populate inserts the children of parent, whose handle is parentHandle.
+func (tv *TreeView) populate(parent TreeItem, parentHandle HTREEITEM) os.Error {
+	tv.populated[parentHandle] = true
+
+	insertAfter := TVI_LAST
+
+	count := tv.model.ChildCount(parent)
+	for i := 0; i < count; i++ {
+		var err os.Error
+		if insertAfter, err = tv.insertModelItem(parentHandle, insertAfter, tv.model.Child(parent, i)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// onExpandingModelItem loads the children of the model item handle when it is
+// expanded for the first time.
+func (tv *TreeView) onExpandingModelItem(handle HTREEITEM) {
+	if tv.populated[handle] {
+		return
+	}
+
+	if item, ok := tv.handle2ModelItem[handle]; ok {
+		tv.populate(item, handle)
+	}
+}
+
+// forgetModelItem removes handle and its loaded descendants from the maps.
+func (tv *TreeView) forgetModelItem(handle HTREEITEM) {
+	for _, h := range tv.childHandles(handle) {
+		tv.forgetModelItem(h)
+	}
+
+	if item, ok := tv.handle2ModelItem[handle]; ok {
+		tv.modelItem2Handle[item] = 0, false
+		tv.handle2ModelItem[handle] = nil, false
+	}
+	tv.populated[handle] = false, false
+}
+
+func (tv *TreeView) deleteModelItem(handle HTREEITEM) os.Error {
+	tv.forgetModelItem(handle)
+
+	if 0 == backend.SendMessage(tv.hWnd, TVM_DELETEITEM, 0, uintptr(handle)) {
+		return newError("TVM_DELETEITEM failed")
+	}
+
+	return nil
+}
+
+// deleteModelItems deletes the children of parent.
+func (tv *TreeView) deleteModelItems(parent HTREEITEM) os.Error {
+	for _, h := range tv.childHandles(parent) {
+		if err := tv.deleteModelItem(h); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (tv *TreeView) updateModelItem(item TreeItem, handle HTREEITEM) os.Error {
+	var tvi TVITEM
+
+	tvi.Mask = TVIF_HANDLE | TVIF_TEXT | TVIF_CHILDREN
+	tvi.HItem = handle
+	tvi.PszText = StringToUTF16Ptr(tv.model.Text(item))
+	if tv.model.HasChildren(item) {
+		tvi.CChildren = 1
+	}
+
+	if 0 == backend.SendMessage(tv.hWnd, TVM_SETITEM, 0, uintptr(unsafe.Pointer(&tvi))) {
+		return newError("TVM_SETITEM failed")
+	}
+
+	return nil
+}
+
+// refresh discards the loaded descendants of item. If its children were
+// loaded before, they are loaded again, so an expanded item stays expanded.
+func (tv *TreeView) refresh(item TreeItem) os.Error {
+	handle, ok := tv.modelItemHandle(item)
+	if !ok {
+		// Not loaded yet, so there is nothing to discard.
+		return nil
+	}
+
+	wasPopulated := tv.populated[handle]
+
+	if err := tv.deleteModelItems(handle); err != nil {
+		return err
+	}
+	tv.populated[handle] = false, false
+
+	if handle != TVI_ROOT {
+		if err := tv.updateModelItem(item, handle); err != nil {
+			return err
+		}
+	}
+
+	if wasPopulated {
+		return tv.populate(item, handle)
+	}
+
+	return nil
+}
+
+func (tv *TreeView) onTreeModelItemsReset(parent TreeItem) {
+	tv.refresh(parent)
+}
+
+func (tv *TreeView) onTreeModelItemsInserted(parent TreeItem, from, to int) {
+	handle, ok := tv.modelItemHandle(parent)
+	if !ok {
+		return
+	}
+
+	if !tv.populated[handle] {
+		// The children are loaded on expansion, only the button may change.
+		tv.updateModelItem(parent, handle)
+		return
+	}
+
+	insertAfter := TVI_FIRST
+	if from > 0 {
+		handles := tv.childHandles(handle)
+		if from > len(handles) {
+			// The loaded children are out of sync with the model.
+			tv.refresh(parent)
+			return
+		}
+		insertAfter = handles[from-1]
+	}
+
+	for i := from; i <= to; i++ {
+		var err os.Error
+		if insertAfter, err = tv.insertModelItem(handle, insertAfter, tv.model.Child(parent, i)); err != nil {
+			return
+		}
+	}
+}
+
+func (tv *TreeView) onTreeModelItemsRemoved(parent TreeItem, from, to int) {
+	handle, ok := tv.modelItemHandle(parent)
+	if !ok {
+		return
+	}
+
+	if !tv.populated[handle] {
+		tv.updateModelItem(parent, handle)
+		return
+	}
+
+	handles := tv.childHandles(handle)
+	for i := from; i <= to && i < len(handles); i++ {
+		tv.deleteModelItem(handles[i])
+	}
+}
+
+func (tv *TreeView) onTreeModelItemChanged(item TreeItem) {
+	if handle, ok := tv.modelItemHandle(item); ok && handle != TVI_ROOT {
+		tv.updateModelItem(item, handle)
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"fmt"
+	"testing"
+)
+
+import (
+	. "walk/winapi/comctl32"
+)
+
+// newTestTreeModel returns a model with the top level items a and b, where a
+// has the children a1 and a2.
+func newTestTreeModel() (m *MemoryTreeModel, a *MemoryTreeItem) {
+	m = NewMemoryTreeModel()
+
+	a = m.AddItem(nil, "a")
+	m.AddItem(a, "a1")
+	m.AddItem(a, "a2")
+	m.AddItem(nil, "b")
+
+	return
+}
+
+func newTestModelTreeView(t *testing.T, m TreeModel) (*MemoryBackend, *TreeView) {
+	b, tv := newTestTreeView(t)
+
+	if err := tv.SetModel(m); err != nil {
+		t.Fatalf("SetModel failed: %s", err)
+	}
+
+	return b, tv
+}
+
+func checkTreeItemTexts(t *testing.T, name string, b *MemoryBackend, tv *TreeView, parent HTREEITEM, expected ...string) {
+	handles := tv.childHandles(parent)
+
+	texts := make([]string, len(handles))
+	for i, h := range handles {
+		texts[i], _ = b.TreeItemText(tv, h)
+	}
+
+	if fmt.Sprint(texts) != fmt.Sprint(expected) {
+		t.Errorf("%s: expected items %v, got %v", name, expected, texts)
+	}
+}
+
+func TestTreeViewPopulatesOnExpand(t *testing.T) {
+	m, a := newTestTreeModel()
+	b, tv := newTestModelTreeView(t, m)
+
+	checkTreeItemTexts(t, "SetModel", b, tv, TVI_ROOT, "a", "b")
+	if n := m.ChildRequests(a); n != 0 {
+		t.Errorf("expected the children of a not to be requested before expanding, got %d requests", n)
+	}
+
+	handle := tv.modelItem2Handle[a]
+	checkTreeItemTexts(t, "before expanding", b, tv, handle)
+
+	if err := b.ToggleTreeItem(tv, handle); err != nil {
+		t.Fatalf("ToggleTreeItem failed: %s", err)
+	}
+	checkTreeItemTexts(t, "expanded", b, tv, handle, "a1", "a2")
+	if n := m.ChildRequests(a); n != 2 {
+		t.Errorf("expected 2 requests for the children of a, got %d", n)
+	}
+
+	b.ToggleTreeItem(tv, handle)
+	b.ToggleTreeItem(tv, handle)
+	checkTreeItemTexts(t, "expanded again", b, tv, handle, "a1", "a2")
+	if n := m.ChildRequests(a); n != 2 {
+		t.Errorf("expected the children of a to be loaded once, got %d requests", n)
+	}
+}
+
+func TestTreeViewModelItemEvents(t *testing.T) {
+	m, a := newTestTreeModel()
+	b, tv := newTestModelTreeView(t, m)
+
+	var events int
+	handler := func(args TreeViewItemEventArgs) {
+		events++
+	}
+	tv.ItemExpanding().Attach(handler)
+	tv.ItemExpanded().Attach(handler)
+	tv.ItemCollapsing().Attach(handler)
+	tv.ItemCollapsed().Attach(handler)
+
+	handle := tv.modelItem2Handle[a]
+	b.ToggleTreeItem(tv, handle)
+	b.ToggleTreeItem(tv, handle)
+
+	if events != 0 {
+		t.Errorf("expected no item events for model items, got %d", events)
+	}
+}
+
+func TestTreeViewModelItemsInserted(t *testing.T) {
+	m, a := newTestTreeModel()
+	b, tv := newTestModelTreeView(t, m)
+
+	handle := tv.modelItem2Handle[a]
+
+	// The children of a are not loaded yet, so they are not inserted.
+	m.InsertItem(a, 0, "a0")
+	checkTreeItemTexts(t, "not loaded", b, tv, handle)
+
+	b.ToggleTreeItem(tv, handle)
+	checkTreeItemTexts(t, "expanded", b, tv, handle, "a0", "a1", "a2")
+
+	m.InsertItem(a, 0, "first")
+	m.InsertItem(a, 2, "middle")
+	m.AddItem(a, "last")
+	checkTreeItemTexts(t, "inserted", b, tv, handle, "first", "a0", "middle", "a1", "a2", "last")
+
+	// Add an item behind the back of the TreeView, so the following insertion
+	// refers to an index beyond its items.
+	m.root.children = append(m.root.children, &MemoryTreeItem{text: "c"})
+	m.AddItem(nil, "d")
+	checkTreeItemTexts(t, "out of sync", b, tv, TVI_ROOT, "a", "b", "c", "d")
+}
+
+func TestTreeViewModelItemsRemoved(t *testing.T) {
+	m, a := newTestTreeModel()
+	b, tv := newTestModelTreeView(t, m)
+
+	handle := tv.modelItem2Handle[a]
+	b.ToggleTreeItem(tv, handle)
+
+	a1 := m.Children(a)[0]
+	m.RemoveItem(a1)
+	checkTreeItemTexts(t, "removed", b, tv, handle, "a2")
+	if _, ok := tv.modelItem2Handle[a1]; ok {
+		t.Error("expected the removed item to be forgotten")
+	}
+
+	m.RemoveItem(a)
+	checkTreeItemTexts(t, "removed parent", b, tv, TVI_ROOT, "b")
+	if len(tv.populated) != 1 {
+		t.Errorf("expected only the root to stay populated, got %d populated items", len(tv.populated))
+	}
+}
@@ -0,0 +1,713 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+import (
+	"walk/drawing"
+)
+
+// A UI file describes a widget tree in XML. Elements are named after widget
+// kinds, their attributes set properties, layout parameters and the Name of
+// the widget. A layout is described by an HBoxLayout, VBoxLayout or GridLayout
+// element inside the element of its container:
+//
+//	<Dialog Name="dlg" Text="Settings" Size="400,300">
+//		<VBoxLayout Margins="9,9,9,9" Spacing="6"/>
+//		<LineEdit Name="nameEdit" CueBanner="Name" Font="Tahoma,10,bold"/>
+//		<PushButton Name="okButton" Text="OK" Alignment="End"/>
+//	</Dialog>
+//
+// Sizes are written as "width,height", margins as "left,top,right,bottom" and
+// fonts as "family,pointSize" followed by any of bold, italic, underline and
+// strikeout.
+
+// FontDesc describes a font that is created when it is assigned to a widget.
+type FontDesc struct {
+	Family    string
+	PointSize float
+	Style     drawing.FontStyle
+}
+
+// UIFileError is returned for a UI file that is malformed or does not match
+// the registered widget kinds and properties.
+type UIFileError struct {
+	Line, Column int
+	Message      string
+}
+
+func (e *UIFileError) String() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+}
+
+// UIPropertyParser converts the text of an attribute to the value of a
+// property.
+type UIPropertyParser func(text string) (interface{}, os.Error)
+
+type uiProperty struct {
+	parser UIPropertyParser
+	kinds  map[string]bool
+}
+
+var uiPropertiesByName = make(map[string]*uiProperty)
+
+// RegisterUIProperty makes the property name available to UI files. The
+// property must also be registered using RegisterWidgetProperty.
+//
+// If kinds are specified, the property can only be used on widgets of these
+// kinds, otherwise on widgets of any kind.
+func RegisterUIProperty(name string, parser UIPropertyParser, kinds ...string) {
+	prop := &uiProperty{parser: parser}
+
+	if len(kinds) > 0 {
+		prop.kinds = make(map[string]bool)
+		for _, kind := range kinds {
+			prop.kinds[kind] = true
+		}
+	}
+
+	uiPropertiesByName[name] = prop
+}
+
+// ParseUI parses a UI file into a WidgetDesc. It does not create any widgets,
+// so it can be used to check UI files.
+func ParseUI(r io.Reader) (*WidgetDesc, os.Error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &uiParser{src: string(data), line: 1, column: 1}
+
+	root, err := p.parseDocument()
+	if err != nil {
+		return nil, err
+	}
+
+	return p.widgetDesc(root)
+}
+
+// LoadUIFile parses the UI file at filePath into a WidgetDesc.
+func LoadUIFile(filePath string) (*WidgetDesc, os.Error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ParseUI(file)
+}
+
+// CreateFromUIFile creates the widget tree described by the UI file at
+// filePath as a child of parent, see Create.
+func CreateFromUIFile(filePath string, parent IContainer) (widget IWidget, widgetsByName map[string]IWidget, err os.Error) {
+	desc, err := LoadUIFile(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return Create(desc, parent)
+}
+
+type uiAttr struct {
+	name, value  string
+	line, column int
+}
+
+type uiElement struct {
+	name         string
+	attrs        []*uiAttr
+	children     []*uiElement
+	line, column int
+}
+
+type uiParser struct {
+	src          string
+	pos          int
+	line, column int
+}
+
+func (p *uiParser) errorAt(line, column int, format string, args ...interface{}) os.Error {
+	return &UIFileError{line, column, fmt.Sprintf(format, args...)}
+}
+
+func (p *uiParser) error(format string, args ...interface{}) os.Error {
+	return p.errorAt(p.line, p.column, format, args...)
+}
+
+func (p *uiParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *uiParser) hasPrefix(s string) bool {
+	return strings.HasPrefix(p.src[p.pos:], s)
+}
+
+func (p *uiParser) advance(n int) {
+	for ; n > 0 && !p.eof(); n-- {
+		if p.src[p.pos] == '\n' {
+			p.line++
+			p.column = 1
+		} else {
+			p.column++
+		}
+		p.pos++
+	}
+}
+
+func (p *uiParser) skipSpace() {
+	for !p.eof() && strings.IndexRune(" \t\r\n", int(p.src[p.pos])) != -1 {
+		p.advance(1)
+	}
+}
+
+// skipMisc skips white space, comments and processing instructions.
+func (p *uiParser) skipMisc() os.Error {
+	for {
+		p.skipSpace()
+
+		var end string
+		switch {
+		case p.hasPrefix("<!--"):
+			end = "-->"
+
+		case p.hasPrefix("<?"):
+			end = "?>"
+
+		default:
+			return nil
+		}
+
+		line, column := p.line, p.column
+
+		i := strings.Index(p.src[p.pos:], end)
+		if i == -1 {
+			return p.errorAt(line, column, "unterminated %q", p.src[p.pos:p.pos+2])
+		}
+
+		p.advance(i + len(end))
+	}
+
+	panic("unreachable")
+}
+
+func isUINameChar(c byte, first bool) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' ||
+		!first && (c >= '0' && c <= '9' || c == '-' || c == '.')
+}
+
+func (p *uiParser) parseName() (string, os.Error) {
+	start := p.pos
+
+	for !p.eof() && isUINameChar(p.src[p.pos], p.pos == start) {
+		p.advance(1)
+	}
+
+	if p.pos == start {
+		if p.eof() {
+			return "", p.error("unexpected end of file, expected a name")
+		}
+		return "", p.error("unexpected %q, expected a name", p.src[p.pos])
+	}
+
+	return p.src[start:p.pos], nil
+}
+
+func (p *uiParser) expect(s string) os.Error {
+	if !p.hasPrefix(s) {
+		if p.eof() {
+			return p.error("unexpected end of file, expected %q", s)
+		}
+		return p.error("unexpected %q, expected %q", p.src[p.pos], s)
+	}
+
+	p.advance(len(s))
+
+	return nil
+}
+
+func (p *uiParser) parseDocument() (*uiElement, os.Error) {
+	if err := p.skipMisc(); err != nil {
+		return nil, err
+	}
+
+	root, err := p.parseElement()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.skipMisc(); err != nil {
+		return nil, err
+	}
+
+	if !p.eof() {
+		return nil, p.error("unexpected content after the root element")
+	}
+
+	return root, nil
+}
+
+func (p *uiParser) parseElement() (*uiElement, os.Error) {
+	e := &uiElement{line: p.line, column: p.column}
+
+	if err := p.expect("<"); err != nil {
+		return nil, err
+	}
+
+	var err os.Error
+	if e.name, err = p.parseName(); err != nil {
+		return nil, err
+	}
+
+	for {
+		p.skipSpace()
+
+		if p.hasPrefix("/>") {
+			p.advance(2)
+			return e, nil
+		}
+
+		if p.hasPrefix(">") {
+			p.advance(1)
+			break
+		}
+
+		attr, err := p.parseAttr()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, a := range e.attrs {
+			if a.name == attr.name {
+				return nil, p.errorAt(attr.line, attr.column, "duplicate attribute: %s", attr.name)
+			}
+		}
+
+		e.attrs = append(e.attrs, attr)
+	}
+
+	for {
+		if err := p.skipMisc(); err != nil {
+			return nil, err
+		}
+
+		if p.eof() {
+			return nil, p.error("unexpected end of file, expected </%s>", e.name)
+		}
+
+		if p.hasPrefix("</") {
+			p.advance(2)
+
+			line, column := p.line, p.column
+
+			name, err := p.parseName()
+			if err != nil {
+				return nil, err
+			}
+			if name != e.name {
+				return nil, p.errorAt(line, column, "</%s> does not match <%s> at %d:%d", name, e.name, e.line, e.column)
+			}
+
+			p.skipSpace()
+
+			if err := p.expect(">"); err != nil {
+				return nil, err
+			}
+
+			return e, nil
+		}
+
+		if !p.hasPrefix("<") {
+			return nil, p.error("unexpected text, expected an element")
+		}
+
+		child, err := p.parseElement()
+		if err != nil {
+			return nil, err
+		}
+
+		e.children = append(e.children, child)
+	}
+
+	panic("unreachable")
+}
+
+func (p *uiParser) parseAttr() (*uiAttr, os.Error) {
+	a := &uiAttr{line: p.line, column: p.column}
+
+	var err os.Error
+	if a.name, err = p.parseName(); err != nil {
+		return nil, err
+	}
+
+	p.skipSpace()
+
+	if err := p.expect("="); err != nil {
+		return nil, err
+	}
+
+	p.skipSpace()
+
+	if p.eof() || p.src[p.pos] != '"' && p.src[p.pos] != '\'' {
+		return nil, p.error("expected a quoted attribute value")
+	}
+
+	quote := p.src[p.pos : p.pos+1]
+	line, column := p.line, p.column
+	p.advance(1)
+
+	i := strings.Index(p.src[p.pos:], quote)
+	if i == -1 {
+		return nil, p.errorAt(line, column, "unterminated attribute value")
+	}
+
+	raw := p.src[p.pos : p.pos+i]
+	if strings.Index(raw, "<") != -1 {
+		return nil, p.error("attribute value cannot contain '<'")
+	}
+
+	if a.value, err = unescapeUIText(raw); err != nil {
+		return nil, p.errorAt(line, column, "%s", err.String())
+	}
+
+	p.advance(i + 1)
+
+	return a, nil
+}
+
+var uiEntities = map[string]string{
+	"lt":   "<",
+	"gt":   ">",
+	"amp":  "&",
+	"quot": `"`,
+	"apos": "'",
+}
+
+func unescapeUIText(s string) (string, os.Error) {
+	if strings.Index(s, "&") == -1 {
+		return s, nil
+	}
+
+	var parts []string
+
+	for {
+		i := strings.Index(s, "&")
+		if i == -1 {
+			parts = append(parts, s)
+			break
+		}
+
+		j := strings.Index(s[i:], ";")
+		if j == -1 {
+			return "", newError("unterminated entity reference")
+		}
+
+		name := s[i+1 : i+j]
+
+		var r string
+		if strings.HasPrefix(name, "#") {
+			var n uint64
+			var err os.Error
+			if strings.HasPrefix(name, "#x") {
+				n, err = strconv.Btoui64(name[2:], 16)
+			} else {
+				n, err = strconv.Btoui64(name[1:], 10)
+			}
+			if err != nil {
+				return "", newError(fmt.Sprintf("invalid character reference: &%s;", name))
+			}
+			r = string(int(n))
+		} else {
+			var ok bool
+			if r, ok = uiEntities[name]; !ok {
+				return "", newError(fmt.Sprintf("unknown entity: &%s;", name))
+			}
+		}
+
+		parts = append(parts, s[:i], r)
+		s = s[i+j+1:]
+	}
+
+	return strings.Join(parts, ""), nil
+}
+
+// widgetDesc converts the element e and its descendants to a WidgetDesc,
+// checking widget kinds, properties and attribute values.
+func (p *uiParser) widgetDesc(e *uiElement) (*WidgetDesc, os.Error) {
+	if _, ok := widgetCreatorsByKind[e.name]; !ok {
+		return nil, p.errorAt(e.line, e.column, "unknown widget kind: %s", e.name)
+	}
+
+	desc := &WidgetDesc{Kind: e.name}
+
+	for _, a := range e.attrs {
+		if err := p.setWidgetAttr(desc, a); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, child := range e.children {
+		if strings.HasSuffix(child.name, "Layout") {
+			if desc.Layout != nil {
+				return nil, p.errorAt(child.line, child.column, "%s already has a layout", e.name)
+			}
+
+			layout, err := p.layoutDesc(child)
+			if err != nil {
+				return nil, err
+			}
+
+			desc.Layout = layout
+			continue
+		}
+
+		childDesc, err := p.widgetDesc(child)
+		if err != nil {
+			return nil, err
+		}
+
+		desc.Children = append(desc.Children, childDesc)
+	}
+
+	return desc, nil
+}
+
+func (p *uiParser) setWidgetAttr(desc *WidgetDesc, a *uiAttr) (err os.Error) {
+	switch a.name {
+	case "Name":
+		desc.Name = a.value
+
+	case "StretchFactor":
+		desc.StretchFactor, err = parseUIInt(a.value)
+
+	case "Row":
+		desc.Row, err = parseUIInt(a.value)
+
+	case "Column":
+		desc.Column, err = parseUIInt(a.value)
+
+	case "RowSpan":
+		desc.RowSpan, err = parseUIInt(a.value)
+
+	case "ColumnSpan":
+		desc.ColumnSpan, err = parseUIInt(a.value)
+
+	case "Alignment":
+		desc.Alignment, err = parseUIAlignment(a.value)
+
+	default:
+		prop, ok := uiPropertiesByName[a.name]
+		if _, registered := propertySettersByName[a.name]; !ok || !registered {
+			return p.errorAt(a.line, a.column, "unknown property: %s", a.name)
+		}
+
+		if prop.kinds != nil && !prop.kinds[desc.Kind] {
+			return p.errorAt(a.line, a.column, "%s: not supported by %s", a.name, desc.Kind)
+		}
+
+		var value interface{}
+		if value, err = prop.parser(a.value); err == nil {
+			if desc.Properties == nil {
+				desc.Properties = make(map[string]interface{})
+			}
+			desc.Properties[a.name] = value
+		}
+	}
+
+	if err != nil {
+		return p.errorAt(a.line, a.column, "%s: %s", a.name, err.String())
+	}
+
+	return nil
+}
+
+func (p *uiParser) layoutDesc(e *uiElement) (*LayoutDesc, os.Error) {
+	switch e.name {
+	case "HBoxLayout", "VBoxLayout", "GridLayout":
+
+	default:
+		return nil, p.errorAt(e.line, e.column, "unknown layout kind: %s", e.name)
+	}
+
+	desc := &LayoutDesc{Kind: e.name[:len(e.name)-len("Layout")]}
+
+	if len(e.children) > 0 {
+		child := e.children[0]
+		return nil, p.errorAt(child.line, child.column, "a layout cannot have children")
+	}
+
+	for _, a := range e.attrs {
+		var err os.Error
+
+		switch a.name {
+		case "Margins":
+			desc.Margins, err = parseUIMargins(a.value)
+
+		case "Spacing":
+			desc.Spacing, err = parseUIInt(a.value)
+
+		default:
+			return nil, p.errorAt(a.line, a.column, "unknown layout property: %s", a.name)
+		}
+
+		if err != nil {
+			return nil, p.errorAt(a.line, a.column, "%s: %s", a.name, err.String())
+		}
+	}
+
+	return desc, nil
+}
+
+func parseUIInt(s string) (int, os.Error) {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return 0, newError(fmt.Sprintf("%q is not an integer", s))
+	}
+
+	return n, nil
+}
+
+// parseUIInts parses count comma separated integers.
+func parseUIInts(s string, count int) ([]int, os.Error) {
+	fields := strings.Split(s, ",", -1)
+	if len(fields) != count {
+		return nil, newError(fmt.Sprintf("%q is not a list of %d integers", s, count))
+	}
+
+	values := make([]int, count)
+	for i, f := range fields {
+		var err os.Error
+		if values[i], err = parseUIInt(f); err != nil {
+			return nil, err
+		}
+	}
+
+	return values, nil
+}
+
+func parseUISize(s string) (interface{}, os.Error) {
+	v, err := parseUIInts(s, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	return drawing.Size{v[0], v[1]}, nil
+}
+
+func parseUIMargins(s string) (*Margins, os.Error) {
+	v, err := parseUIInts(s, 4)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Margins{v[0], v[1], v[2], v[3]}, nil
+}
+
+func parseUIAlignment(s string) (LayoutAlignment, os.Error) {
+	switch strings.TrimSpace(s) {
+	case "Fill":
+		return AlignFill, nil
+
+	case "Start":
+		return AlignStart, nil
+
+	case "Center":
+		return AlignCenter, nil
+
+	case "End":
+		return AlignEnd, nil
+	}
+
+	return AlignFill, newError(fmt.Sprintf("%q is not one of Fill, Start, Center and End", s))
+}
+
+func parseUIString(s string) (interface{}, os.Error) {
+	return s, nil
+}
+
+func parseUIBool(s string) (interface{}, os.Error) {
+	switch strings.TrimSpace(s) {
+	case "true":
+		return true, nil
+
+	case "false":
+		return false, nil
+	}
+
+	return nil, newError(fmt.Sprintf("%q is not true or false", s))
+}
+
+func parseUIFont(s string) (interface{}, os.Error) {
+	fields := strings.Split(s, ",", -1)
+	if len(fields) < 2 {
+		return nil, newError(fmt.Sprintf("%q is not a font, expected family,pointSize[,style...]", s))
+	}
+
+	fd := &FontDesc{Family: strings.TrimSpace(fields[0])}
+	if fd.Family == "" {
+		return nil, newError("font family cannot be empty")
+	}
+
+	pointSize, err := strconv.Atof(strings.TrimSpace(fields[1]))
+	if err != nil || pointSize <= 0 {
+		return nil, newError(fmt.Sprintf("%q is not a valid point size", fields[1]))
+	}
+	fd.PointSize = pointSize
+
+	for _, f := range fields[2:] {
+		switch strings.TrimSpace(f) {
+		case "bold":
+			fd.Style |= drawing.FontBold
+
+		case "italic":
+			fd.Style |= drawing.FontItalic
+
+		case "underline":
+			fd.Style |= drawing.FontUnderline
+
+		case "strikeout":
+			fd.Style |= drawing.FontStrikeOut
+
+		default:
+			return nil, newError(fmt.Sprintf("unknown font style: %s", strings.TrimSpace(f)))
+		}
+	}
+
+	return fd, nil
+}
+
+func parseUIOrientation(s string) (interface{}, os.Error) {
+	switch strings.TrimSpace(s) {
+	case "Horizontal":
+		return Horizontal, nil
+
+	case "Vertical":
+		return Vertical, nil
+	}
+
+	return nil, newError(fmt.Sprintf("%q is not Horizontal or Vertical", s))
+}
+
+func init() {
+	RegisterUIProperty("Text", parseUIString)
+	RegisterUIProperty("CueBanner", parseUIString, "LineEdit")
+	RegisterUIProperty("Title", parseUIString, "TabPage")
+	RegisterUIProperty("Enabled", parseUIBool)
+	RegisterUIProperty("Visible", parseUIBool)
+	RegisterUIProperty("Checked", parseUIBool, "CheckBox", "PushButton", "RadioButton")
+	RegisterUIProperty("MinSize", parseUISize)
+	RegisterUIProperty("MaxSize", parseUISize)
+	RegisterUIProperty("Size", parseUISize)
+	RegisterUIProperty("Font", parseUIFont)
+	RegisterUIProperty("Orientation", parseUIOrientation, "Splitter")
+}
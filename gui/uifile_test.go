@@ -0,0 +1,111 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"strings"
+	"testing"
+)
+
+import (
+	"walk/drawing"
+)
+
+func TestParseUI(t *testing.T) {
+	desc, err := ParseUI(strings.NewReader(`<?xml version="1.0"?>
+<!-- Settings -->
+<Dialog Name="dlg" Text="Tom &amp; Jerry&#33;" Size="400, 300">
+	<GridLayout Margins="9,9,9,9" Spacing="6"/>
+	<LineEdit Name="nameEdit" CueBanner='Name' Font="Tahoma,10,bold,italic" Column="1" ColumnSpan="2"/>
+	<Splitter Orientation="Vertical" Row="1">
+		<TextEdit StretchFactor="2"/>
+		<CheckBox Checked="true" Enabled="false"/>
+	</Splitter>
+	<PushButton Name="okButton" Text="OK" Alignment="End"/>
+</Dialog>
+`))
+	if err != nil {
+		t.Fatalf("ParseUI failed: %s", err)
+	}
+
+	if desc.Kind != "Dialog" || desc.Name != "dlg" || len(desc.Children) != 3 {
+		t.Fatalf("unexpected root %+v", desc)
+	}
+	if text := desc.Properties["Text"]; text != "Tom & Jerry!" {
+		t.Errorf("expected the entities to be replaced, got %q", text)
+	}
+	if size := desc.Properties["Size"]; size != (drawing.Size{400, 300}) {
+		t.Errorf("expected the size 400x300, got %v", size)
+	}
+
+	layout := desc.Layout
+	if layout == nil || layout.Kind != "Grid" || layout.Spacing != 6 || *layout.Margins != (Margins{9, 9, 9, 9}) {
+		t.Errorf("unexpected layout %+v", layout)
+	}
+
+	nameEdit := desc.Children[0]
+	if nameEdit.Column != 1 || nameEdit.ColumnSpan != 2 || nameEdit.Properties["CueBanner"] != "Name" {
+		t.Errorf("unexpected LineEdit %+v", nameEdit)
+	}
+	if fd, ok := nameEdit.Properties["Font"].(*FontDesc); !ok || *fd != (FontDesc{"Tahoma", 10, drawing.FontBold | drawing.FontItalic}) {
+		t.Errorf("unexpected font %+v", nameEdit.Properties["Font"])
+	}
+
+	splitter := desc.Children[1]
+	if splitter.Properties["Orientation"] != Vertical || splitter.Row != 1 || len(splitter.Children) != 2 {
+		t.Errorf("unexpected Splitter %+v", splitter)
+	}
+	if splitter.Children[0].StretchFactor != 2 {
+		t.Errorf("expected the stretch factor 2, got %d", splitter.Children[0].StretchFactor)
+	}
+	if checkBox := splitter.Children[1]; checkBox.Properties["Checked"] != true || checkBox.Properties["Enabled"] != false {
+		t.Errorf("unexpected CheckBox %+v", checkBox)
+	}
+
+	if okButton := desc.Children[2]; okButton.Alignment != AlignEnd {
+		t.Errorf("expected the alignment End, got %v", okButton.Alignment)
+	}
+}
+
+func TestParseUIErrors(t *testing.T) {
+	tests := []struct {
+		src          string
+		line, column int
+		message      string
+	}{
+		{`<Frobnicator/>`, 1, 1, "unknown widget kind: Frobnicator"},
+		{"<Composite>\n\t<Label Txet=\"x\"/>\n</Composite>", 2, 9, "unknown property: Txet"},
+		{`<Label Title="x"/>`, 1, 8, "Title: not supported by Label"},
+		{`<Composite>` + "\n" + `<LineEdit Checked="true"/></Composite>`, 2, 11, "Checked: not supported by LineEdit"},
+		{`<Splitter Orientation="Diagonal"/>`, 1, 11, `Orientation: "Diagonal" is not Horizontal or Vertical`},
+		{`<Label Row="one"/>`, 1, 8, `Row: "one" is not an integer`},
+		{`<Label Size="1,2,3"/>`, 1, 8, `Size: "1,2,3" is not a list of 2 integers`},
+		{`<Label Text="a" Text="b"/>`, 1, 17, "duplicate attribute: Text"},
+		{`<Label Text="&nbsp;"/>`, 1, 13, "unknown entity: &nbsp;"},
+		{`<Label Text=x/>`, 1, 13, "expected a quoted attribute value"},
+		{`<Composite></Label>`, 1, 14, "</Label> does not match <Composite> at 1:1"},
+		{"<Composite>\n<!-- ", 2, 1, `unterminated "<!"`},
+		{`<Composite>`, 1, 12, "unexpected end of file, expected </Composite>"},
+		{`<Label/><Label/>`, 1, 9, "unexpected content after the root element"},
+		{`<Composite><VBoxLayout/><HBoxLayout/></Composite>`, 1, 25, "Composite already has a layout"},
+		{`<Composite><BorderLayout/></Composite>`, 1, 12, "unknown layout kind: BorderLayout"},
+		{`<Composite><VBoxLayout Spacing="2" Gap="3"/></Composite>`, 1, 36, "unknown layout property: Gap"},
+		{`<Composite><VBoxLayout><Label/></VBoxLayout></Composite>`, 1, 24, "a layout cannot have children"},
+	}
+
+	for _, test := range tests {
+		_, err := ParseUI(strings.NewReader(test.src))
+
+		e, ok := err.(*UIFileError)
+		if !ok {
+			t.Errorf("%q: expected a *UIFileError, got %v", test.src, err)
+			continue
+		}
+
+		if e.Line != test.line || e.Column != test.column || !strings.HasPrefix(e.Message, test.message) {
+			t.Errorf("%q: expected %d:%d: %s, got %s", test.src, test.line, test.column, test.message, e)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Validator checks text entered by the user.
+//
+// Validate returns nil if text is valid, otherwise an error whose text is
+// displayed to the user.
+type Validator interface {
+	Validate(text string) os.Error
+}
+
+// Validatable is implemented by widgets whose text can be validated.
+type Validatable interface {
+	IWidget
+	Validator() Validator
+	SetValidator(value Validator)
+	IsValid() bool
+	Validate() os.Error
+}
+
+// Validators is a Validator that requires text to be accepted by all of its
+// Validators. It reports the error of the first one that rejects it.
+type Validators []Validator
+
+func (vs Validators) Validate(text string) os.Error {
+	for _, v := range vs {
+		if err := v.Validate(text); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RequiredValidator rejects text that is empty or only contains white space.
+//
+// The other validators of this package accept empty text, so they can be used
+// for optional values and combined with a RequiredValidator using Validators.
+type RequiredValidator struct{}
+
+func NewRequiredValidator() *RequiredValidator {
+	return &RequiredValidator{}
+}
+
+func (*RequiredValidator) Validate(text string) os.Error {
+	if strings.TrimSpace(text) == "" {
+		return newError("Please enter a value.")
+	}
+
+	return nil
+}
+
+// RegexpValidator accepts text that matches a regular expression completely.
+type RegexpValidator struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+func NewRegexpValidator(pattern string) (*RegexpValidator, os.Error) {
+	re, err := regexp.Compile("^(" + pattern + ")$")
+	if err != nil {
+		return nil, err
+	}
+
+	return &RegexpValidator{pattern: pattern, re: re}, nil
+}
+
+func (v *RegexpValidator) Pattern() string {
+	return v.pattern
+}
+
+func (v *RegexpValidator) Validate(text string) os.Error {
+	if text == "" || v.re.MatchString(text) {
+		return nil
+	}
+
+	return newError("The text does not have the expected format.")
+}
+
+// IntRangeValidator accepts whole numbers from Min through Max.
+type IntRangeValidator struct {
+	min, max int64
+}
+
+func NewIntRangeValidator(min, max int64) (*IntRangeValidator, os.Error) {
+	if min > max {
+		return nil, newError("min cannot be greater than max")
+	}
+
+	return &IntRangeValidator{min: min, max: max}, nil
+}
+
+func (v *IntRangeValidator) Min() int64 {
+	return v.min
+}
+
+func (v *IntRangeValidator) Max() int64 {
+	return v.max
+}
+
+func (v *IntRangeValidator) Validate(text string) os.Error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+
+	if n, err := strconv.Atoi64(text); err == nil && n >= v.min && n <= v.max {
+		return nil
+	}
+
+	return newError(fmt.Sprintf("Please enter a whole number from %d to %d.", v.min, v.max))
+}
+
+// FloatRangeValidator accepts numbers from Min through Max.
+type FloatRangeValidator struct {
+	min, max float64
+}
+
+func NewFloatRangeValidator(min, max float64) (*FloatRangeValidator, os.Error) {
+	if min > max {
+		return nil, newError("min cannot be greater than max")
+	}
+
+	return &FloatRangeValidator{min: min, max: max}, nil
+}
+
+func (v *FloatRangeValidator) Min() float64 {
+	return v.min
+}
+
+func (v *FloatRangeValidator) Max() float64 {
+	return v.max
+}
+
+func (v *FloatRangeValidator) Validate(text string) os.Error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+
+	if f, err := strconv.Atof64(text); err == nil && f >= v.min && f <= v.max {
+		return nil
+	}
+
+	return newError(fmt.Sprintf("Please enter a number from %s to %s.",
+		strconv.Ftoa64(v.min, 'g', -1), strconv.Ftoa64(v.max, 'g', -1)))
+}
+
+type validationFeedback interface {
+	showValidationError(widget IWidget, err os.Error)
+	hideValidationError(widget IWidget)
+	raiseValidityChanged()
+}
+
+type textWidget interface {
+	IWidget
+	Text() string
+}
+
+// validation implements Validatable for the widget it is embedded in, which
+// must set widget.
+type validation struct {
+	widget    textWidget
+	validator Validator
+	err       os.Error
+}
+
+func (v *validation) Validator() Validator {
+	return v.validator
+}
+
+// SetValidator sets the Validator that checks the text of the widget, nil
+// turns validation off.
+func (v *validation) SetValidator(value Validator) {
+	v.validator = value
+
+	v.updateValidity(false)
+}
+
+// IsValid returns whether the text of the widget is accepted by its
+// Validator. Unlike Validate, it does not show anything to the user.
+func (v *validation) IsValid() bool {
+	return v.check() == nil
+}
+
+// Validate validates the text of the widget and shows the error to the user.
+func (v *validation) Validate() os.Error {
+	v.updateValidity(true)
+
+	return v.err
+}
+
+func (v *validation) check() os.Error {
+	if v.validator == nil {
+		return nil
+	}
+
+	return v.validator.Validate(v.widget.Text())
+}
+
+// updateValidity validates the text of the widget and hides the validation
+// error if it is valid. Otherwise the error is only shown if show is true.
+func (v *validation) updateValidity(show bool) {
+	oldErr := v.err
+	v.err = v.check()
+
+	feedback, ok := rootWidget(v.widget).(validationFeedback)
+	if !ok {
+		return
+	}
+
+	if v.err == nil {
+		feedback.hideValidationError(v.widget)
+	} else if show {
+		feedback.showValidationError(v.widget, v.err)
+	}
+
+	if (oldErr == nil) != (v.err == nil) {
+		feedback.raiseValidityChanged()
+	}
+}
+
+// walkValidatables calls f for each Validatable descendant of widget in child
+// order, until f returns false. It returns whether the walk was completed.
+func walkValidatables(widget IWidget, f func(v Validatable) bool) bool {
+	container, ok := widget.(IContainer)
+	if !ok {
+		return true
+	}
+
+	children := container.Children()
+	for i := 0; i < children.Len(); i++ {
+		child := children.At(i)
+
+		if v, ok := child.(Validatable); ok {
+			if !f(v) {
+				return false
+			}
+		}
+
+		if !walkValidatables(child, f) {
+			return false
+		}
+	}
+
+	return true
+}
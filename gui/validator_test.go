@@ -0,0 +1,195 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"testing"
+)
+
+import (
+	"walk/drawing"
+	. "walk/winapi/user32"
+)
+
+func TestValidators(t *testing.T) {
+	re, err := NewRegexpValidator("[a-z]+[0-9]?")
+	if err != nil {
+		t.Fatalf("NewRegexpValidator failed: %s", err)
+	}
+	ints, err := NewIntRangeValidator(-5, 10)
+	if err != nil {
+		t.Fatalf("NewIntRangeValidator failed: %s", err)
+	}
+	floats, err := NewFloatRangeValidator(0, 1.5)
+	if err != nil {
+		t.Fatalf("NewFloatRangeValidator failed: %s", err)
+	}
+	required := NewRequiredValidator()
+
+	tests := []struct {
+		validator Validator
+		text      string
+		valid     bool
+	}{
+		{required, "x", true},
+		{required, "", false},
+		{required, " \t", false},
+		{re, "", true},
+		{re, "abc1", true},
+		{re, "abc12", false},
+		{re, "xabc1x!", false},
+		{ints, "", true},
+		{ints, " -5 ", true},
+		{ints, "10", true},
+		{ints, "11", false},
+		{ints, "1.0", false},
+		{floats, "1.5", true},
+		{floats, "0", true},
+		{floats, "-0.1", false},
+		{floats, "one", false},
+		{Validators{required, ints}, "", false},
+		{Validators{required, ints}, "7", true},
+		{Validators{required, ints}, "70", false},
+		{Validators{}, "", true},
+	}
+
+	for i, test := range tests {
+		if err := test.validator.Validate(test.text); (err == nil) != test.valid {
+			t.Errorf("%d: %q: expected valid %t, got error %v", i, test.text, test.valid, err)
+		}
+	}
+}
+
+func TestNewValidatorErrors(t *testing.T) {
+	if _, err := NewRegexpValidator("[a-"); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+	if _, err := NewIntRangeValidator(2, 1); err == nil {
+		t.Error("expected an error for min greater than max")
+	}
+	if _, err := NewFloatRangeValidator(2, 1); err == nil {
+		t.Error("expected an error for min greater than max")
+	}
+}
+
+// checkBalloon checks whether the validation balloon of tlw is shown at
+// expected, in screen coordinates.
+func checkBalloon(t *testing.T, name string, b *MemoryBackend, tlw *TopLevelWindow, shown bool, expected drawing.Point) {
+	var tracked *memoryTool
+	var pos drawing.Point
+	if tt := tlw.validationToolTip; tt != nil {
+		tracked = b.windows[tt.hWnd].toolTip.tracked
+		pos = b.windows[tt.hWnd].toolTip.trackPos
+	}
+
+	switch {
+	case !shown && tracked != nil:
+		t.Errorf("%s: expected no balloon, got %q", name, tracked.text)
+
+	case shown && tracked == nil:
+		t.Errorf("%s: expected a balloon", name)
+
+	case shown && pos != expected:
+		t.Errorf("%s: expected the balloon at %v, got %v", name, expected, pos)
+	}
+}
+
+func TestLineEditValidation(t *testing.T) {
+	b, mw := newTestMainWindow(t)
+	mw.SetBounds(drawing.Rectangle{100, 50, 400, 300})
+
+	le, err := NewLineEdit(mw.ClientArea())
+	if err != nil {
+		t.Fatalf("NewLineEdit failed: %s", err)
+	}
+	le.SetBounds(drawing.Rectangle{10, 20, 100, 24})
+
+	origin, _ := b.ClientToScreen(mw.ClientArea().hWnd, drawing.Point{})
+	bottomCenter := drawing.Point{origin.X + 60, origin.Y + 44}
+
+	var changes int
+	mw.ValidityChanged().Attach(func(args EventArgs) {
+		changes++
+	})
+
+	le.SetValidator(NewRequiredValidator())
+	if changes != 1 {
+		t.Errorf("expected SetValidator to change the validity, got %d changes", changes)
+	}
+	checkBalloon(t, "SetValidator", b, &mw.TopLevelWindow, false, bottomCenter)
+
+	// IsValid only looks at the text, it does not update any state.
+	b.windows[le.hWnd].text = "x"
+	if !le.IsValid() || !mw.IsValid() {
+		t.Error("expected the text to be valid")
+	}
+	if le.validation.err == nil || changes != 1 {
+		t.Error("expected IsValid not to update the validity")
+	}
+	b.windows[le.hWnd].text = ""
+
+	if err := mw.Validate(); err == nil {
+		t.Error("expected Validate to fail for an empty text")
+	}
+	if b.Focus() != le.hWnd {
+		t.Error("expected Validate to focus the invalid LineEdit")
+	}
+	checkBalloon(t, "Validate", b, &mw.TopLevelWindow, true, bottomCenter)
+
+	b.TypeText(le, "a")
+	if changes != 2 {
+		t.Errorf("expected typing to change the validity, got %d changes", changes)
+	}
+	checkBalloon(t, "TypeText", b, &mw.TopLevelWindow, false, bottomCenter)
+
+	b.SendMessage(le.hWnd, WM_CHAR, VK_BACK, 1)
+	if changes != 3 {
+		t.Errorf("expected deleting to change the validity, got %d changes", changes)
+	}
+	checkBalloon(t, "deleting", b, &mw.TopLevelWindow, true, bottomCenter)
+
+	le.SetValidator(nil)
+	if !le.IsValid() || changes != 4 {
+		t.Errorf("expected validation to be turned off, got %d changes", changes)
+	}
+	checkBalloon(t, "without validator", b, &mw.TopLevelWindow, false, bottomCenter)
+}
+
+func TestDialogOKButtonFollowsValidity(t *testing.T) {
+	b, _ := newTestMainWindow(t)
+
+	d, err := NewDialog()
+	if err != nil {
+		t.Fatalf("NewDialog failed: %s", err)
+	}
+
+	te, err := NewTextEdit(d)
+	if err != nil {
+		t.Fatalf("NewTextEdit failed: %s", err)
+	}
+	ok, err := NewPushButton(d)
+	if err != nil {
+		t.Fatalf("NewPushButton failed: %s", err)
+	}
+
+	v, _ := NewIntRangeValidator(1, 99)
+	te.SetValidator(v)
+	d.SetOKButton(ok)
+
+	checkEnabled := func(name string, expected bool) {
+		if enabled, _ := ok.Enabled(); enabled != expected {
+			t.Errorf("%s: expected the OK button to be enabled %t, got %t", name, expected, enabled)
+		}
+	}
+
+	checkEnabled("empty", true)
+
+	b.TypeText(te, "100")
+	checkEnabled("out of range", false)
+
+	te.SetText("42")
+	checkEnabled("SetText", true)
+	checkBalloon(t, "SetText", b, &d.TopLevelWindow, false, drawing.Point{})
+}
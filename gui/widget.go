@@ -5,10 +5,7 @@
 package gui
 
 import (
-	"container/vector"
-	"fmt"
 	"os"
-	"syscall"
 	"unsafe"
 )
 
@@ -16,9 +13,7 @@ import (
 	"walk/drawing"
 	. "walk/winapi"
 	. "walk/winapi/gdi32"
-	. "walk/winapi/kernel32"
 	. "walk/winapi/user32"
-	. "walk/winapi/uxtheme"
 )
 
 type LayoutFlags byte
@@ -68,10 +63,27 @@ type IWidget interface {
 	Y() (int, os.Error)
 	SetY(value int) os.Error
 	SetFocus() os.Error
-	AddSizeChangedHandler(handler EventHandler)
-	RemoveSizeChangedHandler(handler EventHandler)
+	KeyDown() *KeyEvent
+	KeyUp() *KeyEvent
+	KeyPress() *KeyEvent
+	MouseDown() *MouseEvent
+	MouseUp() *MouseEvent
+	MouseDoubleClick() *MouseEvent
+	MouseMove() *MouseEvent
+	MouseWheel() *MouseEvent
+	MouseEnter() *Event
+	MouseLeave() *Event
+	CaptureLost() *Event
+	AllowDrop() bool
+	SetAllowDrop(value bool) os.Error
+	BeginDrag(data *DataObject, allowed DropEffect, completed func(effect DropEffect)) os.Error
+	DragEnter() *DragEvent
+	DragOver() *DragEvent
+	DragLeave() *DragEvent
+	Drop() *DragEvent
+	SizeChanged() *Event
 	RootWidget() RootWidget
-	GetDrawingSurface() (*drawing.Surface, os.Error)
+	GetDrawingSurface() (*drawing.GDISurface, os.Error)
 }
 
 type widgetInternal interface {
@@ -80,70 +92,40 @@ type widgetInternal interface {
 }
 
 type Widget struct {
-	hWnd                HWND
-	parent              IContainer
-	font                *drawing.Font
-	contextMenu         *Menu
-	keyDownHandlers     vector.Vector
-	mouseDownHandlers   vector.Vector
-	sizeChangedHandlers vector.Vector
-	maxSize             drawing.Size
-	minSize             drawing.Size
+	hWnd                      HWND
+	parent                    IContainer
+	font                      *drawing.Font
+	contextMenu               *Menu
+	keyDownPublisher          KeyEventPublisher
+	keyUpPublisher            KeyEventPublisher
+	keyPressPublisher         KeyEventPublisher
+	mouseDownPublisher        MouseEventPublisher
+	mouseUpPublisher          MouseEventPublisher
+	mouseDoubleClickPublisher MouseEventPublisher
+	mouseMovePublisher        MouseEventPublisher
+	mouseWheelPublisher       MouseEventPublisher
+	mouseEnterPublisher       EventPublisher
+	mouseLeavePublisher       EventPublisher
+	captureLostPublisher      EventPublisher
+	dragEnterPublisher        DragEventPublisher
+	dragOverPublisher         DragEventPublisher
+	dragLeavePublisher        DragEventPublisher
+	dropPublisher             DragEventPublisher
+	sizeChangedPublisher      EventPublisher
+	maxSize                   drawing.Size
+	minSize                   drawing.Size
+	mouseInside               bool
+	highSurrogate             uint16
+	allowDrop                 bool
+	dragSession               *dragSession
 }
 
 var (
 	widgetsByHWnd map[HWND]widgetInternal = make(map[HWND]widgetInternal)
 )
 
-func ensureRegisteredWindowClass(className string, windowProc syscall.CallbackFunc, callback **syscall.Callback) {
-	if callback == nil {
-		panic("callback cannot be nil")
-	}
-
-	if *callback != nil {
-		return
-	}
-
-	hInst := GetModuleHandle(nil)
-	if hInst == 0 {
-		panic("GetModuleHandle failed")
-	}
-
-	hIcon := LoadIcon(0, (*uint16)(unsafe.Pointer(uintptr(IDI_APPLICATION))))
-	if hIcon == 0 {
-		panic("LoadIcon failed")
-	}
-
-	hCursor := LoadCursor(0, (*uint16)(unsafe.Pointer(uintptr(IDC_ARROW))))
-	if hCursor == 0 {
-		panic("LoadCursor failed")
-	}
-
-	*callback = syscall.NewCallback(windowProc, 4*4)
-
-	var wc WNDCLASSEX
-	wc.CbSize = uint(unsafe.Sizeof(wc))
-	wc.LpfnWndProc = uintptr((*callback).ExtFnEntry())
-	wc.HInstance = hInst
-	wc.HIcon = hIcon
-	wc.HCursor = hCursor
-	wc.HbrBackground = COLOR_BTNFACE + 1
-	wc.LpszClassName = syscall.StringToUTF16Ptr(className)
-
-	if atom := RegisterClassEx(&wc); atom == 0 {
-		panic("RegisterClassEx")
-	}
-}
-
-func msgFromCallbackArgs(args *uintptr) *MSG {
-	p := (*[4]int32)(unsafe.Pointer(args))
-
-	return &MSG{
-		HWnd:    HWND(p[0]),
-		Message: uint(p[1]),
-		WParam:  uintptr(p[2]),
-		LParam:  uintptr(p[3]),
-	}
+func ensureRegisteredWindowClass(className string, windowProc func(msg *MSG) uintptr) {
+	backend.RegisterWindowClass(className, windowProc)
 }
 
 func rootWidget(w IWidget) RootWidget {
@@ -164,7 +146,7 @@ func (w *Widget) Handle() HWND {
 
 func (w *Widget) Dispose() {
 	if w.hWnd != 0 {
-		DestroyWindow(w.hWnd)
+		backend.DestroyWindow(w.hWnd)
 		w.hWnd = 0
 	}
 }
@@ -186,18 +168,18 @@ func (w *Widget) SetContextMenu(value *Menu) {
 }
 
 func (w *Widget) Enabled() (bool, os.Error) {
-	ret := GetWindowLong(w.hWnd, GWL_STYLE)
-	if ret == 0 {
-		return false, lastError("GetWindowLong")
+	style, err := backend.Style(w.hWnd)
+	if err != nil {
+		return false, err
 	}
 
-	return (ret & WS_DISABLED) == 0, nil
+	return (style & WS_DISABLED) == 0, nil
 }
 
 func (w *Widget) SetEnabled(value bool) os.Error {
-	style := GetWindowLong(w.hWnd, GWL_STYLE)
-	if style == 0 {
-		return lastError("GetWindowLong")
+	style, err := backend.Style(w.hWnd)
+	if err != nil {
+		return err
 	}
 	if value {
 		style &^= WS_DISABLED
@@ -205,13 +187,11 @@ func (w *Widget) SetEnabled(value bool) os.Error {
 		style |= WS_DISABLED
 	}
 
-	SetLastError(0)
-	ret := SetWindowLong(w.hWnd, GWL_STYLE, style)
-	if ret == 0 {
-		return lastError("SetWindowLong")
+	if err := backend.SetStyle(w.hWnd, style); err != nil {
+		return err
 	}
 
-	SendMessage(w.hWnd, WM_ENABLE, uintptr(BoolToBOOL(value)), 0)
+	backend.SendMessage(w.hWnd, WM_ENABLE, uintptr(BoolToBOOL(value)), 0)
 
 	return nil
 }
@@ -222,9 +202,11 @@ func (w *Widget) Font() *drawing.Font {
 
 func (w *Widget) SetFont(value *drawing.Font) {
 	if value != w.font {
-		SendMessage(w.hWnd, WM_SETFONT, uintptr(value.HandleForDPI(0)), 1)
+		backend.SetFont(w.hWnd, value)
 
 		w.font = value
+
+		w.updateParentLayout()
 	}
 }
 
@@ -234,13 +216,7 @@ func (w *Widget) Invalidate() os.Error {
 		return err
 	}
 
-	r := &RECT{cb.X, cb.Y, cb.X + cb.Width, cb.Y + cb.Height}
-
-	if !InvalidateRect(w.hWnd, r, true) {
-		return newError("InvalidateRect failed")
-	}
-
-	return nil
+	return backend.Invalidate(w.hWnd, cb)
 }
 
 func (w *Widget) Parent() IContainer {
@@ -252,44 +228,15 @@ func (w *Widget) SetParent(value IContainer) (err os.Error) {
 		return nil
 	}
 
-	style := uint(GetWindowLong(w.hWnd, GWL_STYLE))
-	if style == 0 {
-		return lastError("GetWindowLong")
-	}
-
-	if value == nil {
-		style &^= WS_CHILD
-		style |= WS_POPUP
-
-		if SetParent(w.hWnd, 0) == 0 {
-			return lastError("SetParent")
-		}
-		SetLastError(0)
-		if SetWindowLong(w.hWnd, GWL_STYLE, int(style)) == 0 {
-			return lastError("SetWindowLong")
-		}
-	} else {
-		style |= WS_CHILD
-		style &^= WS_POPUP
-
-		SetLastError(0)
-		if SetWindowLong(w.hWnd, GWL_STYLE, int(style)) == 0 {
-			return lastError("SetWindowLong")
-		}
-		if SetParent(w.hWnd, value.Handle()) == 0 {
-			return lastError("SetParent")
-		}
+	var parentHWnd HWND
+	if value != nil {
+		parentHWnd = value.Handle()
 	}
 
-	b, err := w.Bounds()
-	if err != nil {
+	if err := backend.SetParent(w.hWnd, parentHWnd); err != nil {
 		return err
 	}
 
-	if !SetWindowPos(w.hWnd, HWND_BOTTOM, b.X, b.Y, b.Width, b.Height, SWP_FRAMECHANGED) {
-		return lastError("SetWindowPos")
-	}
-
 	oldParent := w.parent
 
 	w.parent = value
@@ -306,33 +253,30 @@ func (w *Widget) SetParent(value IContainer) (err os.Error) {
 }
 
 func (w *Widget) Text() string {
-	textLength := SendMessage(w.hWnd, WM_GETTEXTLENGTH, 0, 0)
-	buf := make([]uint16, textLength+1)
-	SendMessage(w.hWnd, WM_GETTEXT, uintptr(textLength+1), uintptr(unsafe.Pointer(&buf[0])))
-	return syscall.UTF16ToString(buf)
+	return backend.Text(w.hWnd)
 }
 
 func (w *Widget) SetText(value string) os.Error {
-	if TRUE != SendMessage(w.hWnd, WM_SETTEXT, 0, uintptr(unsafe.Pointer(syscall.StringToUTF16Ptr(value)))) {
-		return newError("WM_SETTEXT failed")
+	if err := backend.SetText(w.hWnd, value); err != nil {
+		return err
 	}
 
-	return nil
+	return w.updateParentLayout()
 }
 
 func (w *Widget) Visible() (bool, os.Error) {
-	style := GetWindowLong(w.hWnd, GWL_STYLE)
-	if style == 0 {
-		return false, lastError("GetWindowLong")
+	style, err := backend.Style(w.hWnd)
+	if err != nil {
+		return false, err
 	}
 
 	return (style & WS_VISIBLE) != 0, nil
 }
 
 func (w *Widget) SetVisible(value bool) os.Error {
-	style := GetWindowLong(w.hWnd, GWL_STYLE)
-	if style == 0 {
-		return lastError("GetWindowLong")
+	style, err := backend.Style(w.hWnd)
+	if err != nil {
+		return err
 	}
 
 	if value {
@@ -349,43 +293,21 @@ func (w *Widget) SetVisible(value bool) os.Error {
 		style &^= WS_VISIBLE
 	}
 
-	SetLastError(0)
-	if SetWindowLong(w.hWnd, GWL_STYLE, style) == 0 {
-		return lastError("SetWindowLong")
+	if err := backend.SetStyle(w.hWnd, style); err != nil {
+		return err
 	}
 
-	SendMessage(w.hWnd, WM_SHOWWINDOW, uintptr(BoolToBOOL(value)), 0)
+	backend.SendMessage(w.hWnd, WM_SHOWWINDOW, uintptr(BoolToBOOL(value)), 0)
 
-	return nil
+	return w.updateParentLayout()
 }
 
 func (w *Widget) Bounds() (drawing.Rectangle, os.Error) {
-	var r RECT
-
-	if !GetWindowRect(w.hWnd, &r) {
-		return drawing.Rectangle{}, lastError("GetWindowRect")
-	}
-
-	b := drawing.Rectangle{X: r.Left, Y: r.Top, Width: r.Right - r.Left, Height: r.Bottom - r.Top}
-
-	if w.parent != nil {
-		p := POINT{b.X, b.Y}
-		if !ScreenToClient(w.hWnd, &p) {
-			return drawing.Rectangle{}, newError("ScreenToClient failed")
-		}
-		b.X = p.X
-		b.Y = p.Y
-	}
-
-	return b, nil
+	return backend.Bounds(w.hWnd)
 }
 
 func (w *Widget) SetBounds(bounds drawing.Rectangle) os.Error {
-	if !MoveWindow(w.hWnd, bounds.X, bounds.Y, bounds.Width, bounds.Height, true) {
-		return lastError("MoveWindow")
-	}
-
-	return nil
+	return backend.SetBounds(w.hWnd, bounds)
 }
 
 func (w *Widget) MaxSize() (drawing.Size, os.Error) {
@@ -395,7 +317,7 @@ func (w *Widget) MaxSize() (drawing.Size, os.Error) {
 func (w *Widget) SetMaxSize(value drawing.Size) os.Error {
 	w.maxSize = value
 
-	return nil
+	return w.updateParentLayout()
 }
 
 func (w *Widget) MinSize() (drawing.Size, os.Error) {
@@ -405,36 +327,85 @@ func (w *Widget) MinSize() (drawing.Size, os.Error) {
 func (w *Widget) SetMinSize(value drawing.Size) os.Error {
 	w.minSize = value
 
-	return nil
+	return w.updateParentLayout()
 }
 
-func (w *Widget) dialogBaseUnits() drawing.Size {
-	// FIXME: Error handling
-	hFont := HFONT(SendMessage(w.hWnd, WM_GETFONT, 0, 0))
-	hdc := GetDC(w.hWnd)
-	hFontOld := SelectObject(hdc, HGDIOBJ(hFont))
+// updateParentLayout relayouts the ancestors of the widget, because a change to
+// its size hints may change theirs as well.
+//
+// The ancestors are invalidated up to the first one without a layout, whose
+// size does not depend on its children, and a single relayout starts from the
+// topmost invalid one. Containers resized by it arrange their children in
+// response to WM_SIZE, the others are updated afterwards, so each layout runs
+// once.
+func (w *Widget) updateParentLayout() os.Error {
+	if w.parent == nil || !w.parent.Children().ContainsHandle(w.hWnd) {
+		return nil
+	}
+
+	var invalid []IContainer
+	var sizes []drawing.Size
+
+	for container := w.parent; container != nil && container.Layout() != nil; container = container.Parent() {
+		cb, err := container.ClientBounds()
+		if err != nil {
+			return err
+		}
 
-	var tm TEXTMETRIC
-	GetTextMetrics(hdc, &tm)
+		invalid = append(invalid, container)
+		sizes = append(sizes, cb.Size())
+	}
+
+	if len(invalid) == 0 {
+		return nil
+	}
 
-	var size SIZE
-	GetTextExtentPoint32(
-		hdc,
-		syscall.StringToUTF16Ptr("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"),
-		52,
-		&size)
+	top := len(invalid) - 1
+	if err := invalid[top].Layout().Update(false); err != nil {
+		return err
+	}
+
+	for i := top - 1; i >= 0; i-- {
+		cb, err := invalid[i].ClientBounds()
+		if err != nil {
+			return err
+		}
+
+		if cb.Size() == sizes[i] {
+			if err := invalid[i].Layout().Update(false); err != nil {
+				return err
+			}
+		}
+	}
 
-	SelectObject(hdc, HGDIOBJ(hFontOld))
-	ReleaseDC(w.hWnd, hdc)
+	return nil
+}
 
-	return drawing.Size{(size.CX/26 + 1) / 2, int(tm.TmHeight)}
+func (w *Widget) dialogBaseUnits() drawing.Size {
+	return backend.DialogBaseUnits(w.hWnd)
 }
 
 func (w *Widget) dialogBaseUnitsToPixels(dlus drawing.Size) (pixels drawing.Size) {
 	// FIXME: Cache dialog base units on font change.
 	base := w.dialogBaseUnits()
 
-	return drawing.Size{MulDiv(dlus.Width, base.Width, 4), MulDiv(dlus.Height, base.Height, 8)}
+	return drawing.Size{mulDiv(dlus.Width, base.Width, 4), mulDiv(dlus.Height, base.Height, 8)}
+}
+
+// mulDiv returns a * b / c, rounded like MulDiv of kernel32, which cannot be
+// called with the MemoryBackend.
+func mulDiv(a, b, c int) int {
+	if c == 0 {
+		return -1
+	}
+
+	p := int64(a) * int64(b)
+	half := int64(c) / 2
+	if (p < 0) != (c < 0) {
+		half = -half
+	}
+
+	return int((p + half) / int64(c))
 }
 
 func (w *Widget) LayoutFlags() LayoutFlags {
@@ -551,36 +522,26 @@ func (w *Widget) SetHeight(value int) (err os.Error) {
 }
 
 func (w *Widget) ClientBounds() (drawing.Rectangle, os.Error) {
-	var r RECT
-
-	if !GetClientRect(w.hWnd, &r) {
-		return drawing.Rectangle{}, lastError("GetClientRect")
-	}
-
-	return drawing.Rectangle{X: r.Left, Y: r.Top, Width: r.Right - r.Left, Height: r.Bottom - r.Top}, nil
+	return backend.ClientBounds(w.hWnd)
 }
 
 func (w *Widget) SetFocus() os.Error {
-	if SetFocus(w.hWnd) == 0 {
-		return lastError("SetFocus")
-	}
-
-	return nil
+	return backend.SetFocus(w.hWnd)
 }
 
 func (w *Widget) GroupStart() (bool, os.Error) {
-	style := GetWindowLong(w.hWnd, GWL_STYLE)
-	if style == 0 {
-		return false, lastError("GetWindowLong")
+	style, err := backend.Style(w.hWnd)
+	if err != nil {
+		return false, err
 	}
 
 	return (style & WS_GROUP) != 0, nil
 }
 
 func (w *Widget) SetGroupStart(value bool) os.Error {
-	style := GetWindowLong(w.hWnd, GWL_STYLE)
-	if style == 0 {
-		return lastError("GetWindowLong")
+	style, err := backend.Style(w.hWnd)
+	if err != nil {
+		return err
 	}
 
 	if value {
@@ -589,79 +550,383 @@ func (w *Widget) SetGroupStart(value bool) os.Error {
 		style &^= WS_GROUP
 	}
 
-	SetLastError(0)
-	if SetWindowLong(w.hWnd, GWL_STYLE, style) == 0 {
-		return lastError("SetWindowLong")
-	}
-
-	return nil
+	return backend.SetStyle(w.hWnd, style)
 }
 
-func (w *Widget) GetDrawingSurface() (*drawing.Surface, os.Error) {
+func (w *Widget) GetDrawingSurface() (*drawing.GDISurface, os.Error) {
 	return drawing.NewSurfaceFromHWND(w.hWnd)
 }
 
 func (w *Widget) setTheme(appName string) os.Error {
-	if hr := SetWindowTheme(w.hWnd, syscall.StringToUTF16Ptr(appName), nil); FAILED(hr) {
-		return errorFromHRESULT("SetWindowTheme", hr)
+	return backend.SetTheme(w.hWnd, appName)
+}
+
+func (w *Widget) KeyDown() *KeyEvent {
+	return w.keyDownPublisher.Event()
+}
+
+func (w *Widget) KeyUp() *KeyEvent {
+	return w.keyUpPublisher.Event()
+}
+
+// KeyPress is raised for each character typed while the widget has the
+// keyboard focus.
+func (w *Widget) KeyPress() *KeyEvent {
+	return w.keyPressPublisher.Event()
+}
+
+// handleKeyMessage raises the key event for msg and returns whether a handler
+// marked it as handled.
+func (w *Widget) handleKeyMessage(msg *MSG) bool {
+	kind, args, ok := translateKeyMessage(msg, widgetsByHWnd[w.hWnd], backend.ModifiersDown(), &w.highSurrogate)
+	if !ok {
+		return false
 	}
 
-	return nil
+	switch kind {
+	case keyDownEvent:
+		w.keyDownPublisher.Publish(args)
+
+	case keyUpEvent:
+		w.keyUpPublisher.Publish(args)
+
+	case keyPressEvent:
+		w.keyPressPublisher.Publish(args)
+	}
+
+	return args.handled
+}
+
+func (w *Widget) MouseDown() *MouseEvent {
+	return w.mouseDownPublisher.Event()
+}
+
+func (w *Widget) MouseUp() *MouseEvent {
+	return w.mouseUpPublisher.Event()
+}
+
+// MouseDoubleClick is raised instead of MouseDown for the second press of a
+// double click.
+func (w *Widget) MouseDoubleClick() *MouseEvent {
+	return w.mouseDoubleClickPublisher.Event()
+}
+
+func (w *Widget) MouseMove() *MouseEvent {
+	return w.mouseMovePublisher.Event()
+}
+
+// MouseWheel is raised when the mouse wheel is rotated while the widget has
+// the keyboard focus.
+func (w *Widget) MouseWheel() *MouseEvent {
+	return w.mouseWheelPublisher.Event()
 }
 
-func (w *Widget) AddKeyDownHandler(handler KeyEventHandler) {
-	w.keyDownHandlers.Push(handler)
+// MouseEnter is raised when the mouse moves into the widget.
+func (w *Widget) MouseEnter() *Event {
+	return w.mouseEnterPublisher.Event()
 }
 
-func (w *Widget) RemoveKeyDownHandler(handler KeyEventHandler) {
-	for i, h := range w.keyDownHandlers {
-		if h.(KeyEventHandler) == handler {
-			w.keyDownHandlers.Delete(i)
-			break
+// MouseLeave is raised when the mouse leaves the widget.
+func (w *Widget) MouseLeave() *Event {
+	return w.mouseLeavePublisher.Event()
+}
+
+// CaptureLost is raised when the widget loses the mouse capture, because
+// ReleaseCapture was called or another window took the capture.
+func (w *Widget) CaptureLost() *Event {
+	return w.captureLostPublisher.Event()
+}
+
+// SetCapture makes the widget receive all mouse input, even while the mouse
+// is outside of it, until ReleaseCapture is called. This is typically done
+// during drags.
+func (w *Widget) SetCapture() os.Error {
+	return backend.SetCapture(w.hWnd)
+}
+
+func (w *Widget) ReleaseCapture() os.Error {
+	if !w.HasCapture() {
+		return nil
+	}
+
+	return backend.ReleaseCapture()
+}
+
+func (w *Widget) HasCapture() bool {
+	return w.hWnd != 0 && backend.Capture() == w.hWnd
+}
+
+func (w *Widget) handleMouseMessage(msg *MSG) {
+	widget := widgetsByHWnd[w.hWnd]
+
+	kind, args, ok := translateMouseMessage(msg, widget, backend.ModifiersDown()&ModAlt)
+	if !ok {
+		return
+	}
+
+	switch kind {
+	case mouseDownEvent:
+		w.mouseDownPublisher.Publish(args)
+
+	case mouseUpEvent:
+		w.mouseUpPublisher.Publish(args)
+
+	case mouseDoubleClickEvent:
+		w.mouseDoubleClickPublisher.Publish(args)
+
+	case mouseMoveEvent:
+		if !w.mouseInside {
+			w.mouseInside = true
+			backend.TrackMouseLeave(w.hWnd)
+			w.mouseEnterPublisher.Publish(&eventArgs{widget})
+		}
+
+		w.mouseMovePublisher.Publish(args)
+
+	case mouseWheelEvent:
+		if pt, err := backend.ScreenToClient(w.hWnd, drawing.Point{args.x, args.y}); err == nil {
+			args.x, args.y = pt.X, pt.Y
 		}
+
+		w.mouseWheelPublisher.Publish(args)
 	}
 }
 
-func (w *Widget) raiseKeyDown(args KeyEventArgs) {
-	for _, handlerIface := range w.keyDownHandlers {
-		handler := handlerIface.(KeyEventHandler)
-		handler(args)
+func (w *Widget) AllowDrop() bool {
+	return w.allowDrop
+}
+
+// SetAllowDrop sets whether data can be dropped on the widget, both from
+// other widgets and as files from the shell.
+func (w *Widget) SetAllowDrop(value bool) os.Error {
+	if err := backend.AcceptFiles(w.hWnd, value); err != nil {
+		return err
 	}
+
+	w.allowDrop = value
+
+	return nil
 }
 
-func (w *Widget) AddMouseDownHandler(handler MouseEventHandler) {
-	w.mouseDownHandlers.Push(handler)
+// DragEnter is raised when data is dragged into the widget. Handlers that
+// cannot accept the data set the effect to DropEffectNone.
+func (w *Widget) DragEnter() *DragEvent {
+	return w.dragEnterPublisher.Event()
 }
 
-func (w *Widget) AddSizeChangedHandler(handler EventHandler) {
-	w.sizeChangedHandlers.Push(handler)
+// DragOver is raised when data is dragged within the widget or the modifier
+// keys change.
+func (w *Widget) DragOver() *DragEvent {
+	return w.dragOverPublisher.Event()
 }
 
-func (w *Widget) RemoveSizeChangedHandler(handler EventHandler) {
-	for i, h := range w.sizeChangedHandlers {
-		if h.(EventHandler) == handler {
-			w.sizeChangedHandlers.Delete(i)
-			break
+// DragLeave is raised when data is dragged out of the widget or the drag is
+// canceled.
+func (w *Widget) DragLeave() *DragEvent {
+	return w.dragLeavePublisher.Event()
+}
+
+// Drop is raised when data is dropped on the widget. Files dropped from the
+// shell are only copied, they do not raise DragEnter before.
+func (w *Widget) Drop() *DragEvent {
+	return w.dropPublisher.Event()
+}
+
+func (w *Widget) publishDragEvent(kind dragEventKind, args *dragEventArgs) {
+	switch kind {
+	case dragEnterEvent:
+		w.dragEnterPublisher.Publish(args)
+
+	case dragOverEvent:
+		w.dragOverPublisher.Publish(args)
+
+	case dragLeaveEvent:
+		w.dragLeavePublisher.Publish(args)
+
+	case dropEvent:
+		w.dropPublisher.Publish(args)
+	}
+}
+
+// BeginDrag starts dragging data from the widget, typically in response to a
+// MouseMove event with the left button down.
+//
+// The widget captures the mouse until the button is released, which drops the
+// data on the widget under the mouse if that allows drops and accepts one of
+// the allowed effects. Escape or losing the capture cancels the drag. Then
+// completed, if not nil, is called with the effect of the drop, which is
+// DropEffectNone if nothing was dropped. A source that allows moving removes
+// the data itself when the effect is DropEffectMove.
+func (w *Widget) BeginDrag(data *DataObject, allowed DropEffect, completed func(effect DropEffect)) os.Error {
+	if data == nil {
+		return newError("data cannot be nil")
+	}
+	if allowed&(DropEffectCopy|DropEffectMove|DropEffectLink) == 0 {
+		return newError("allowed must contain at least one effect")
+	}
+	if w.dragSession != nil {
+		return newError("already dragging")
+	}
+
+	session := newDragSession(widgetsByHWnd[w.hWnd], data, allowed, completed)
+
+	if err := backend.SetCapture(w.hWnd); err != nil {
+		return err
+	}
+
+	w.dragSession = session
+
+	return nil
+}
+
+// handleDragMessage drives the drag started by BeginDrag with the messages the
+// widget receives while it has captured the mouse. It returns whether msg was
+// consumed by the drag.
+func (w *Widget) handleDragMessage(msg *MSG) bool {
+	switch msg.Message {
+	case WM_MOUSEMOVE:
+		target, x, y, modifiers := w.dragTarget(msg)
+		w.dragSession.moveTo(target, x, y, modifiers)
+		return true
+
+	case WM_LBUTTONUP:
+		target, x, y, modifiers := w.dragTarget(msg)
+		session := w.endDrag()
+		session.moveTo(target, x, y, modifiers)
+		session.drop(x, y, modifiers)
+		return true
+
+	case WM_KEYDOWN:
+		if Key(msg.WParam) == KeyEscape {
+			w.endDrag().cancel()
+			return true
+		}
+
+	case WM_CAPTURECHANGED:
+		if HWND(msg.LParam) != w.hWnd {
+			session := w.dragSession
+			w.dragSession = nil
+			session.cancel()
 		}
 	}
+
+	return false
 }
 
-func (w *Widget) raiseSizeChanged() {
-	for _, handlerIface := range w.sizeChangedHandlers {
-		handler := handlerIface.(EventHandler)
-		handler(&eventArgs{widgetsByHWnd[w.hWnd]})
+func (w *Widget) endDrag() *dragSession {
+	session := w.dragSession
+	w.dragSession = nil
+
+	w.ReleaseCapture()
+
+	return session
+}
+
+// dragTarget returns the widget that allows drops under the mouse position of
+// msg, along with the position in its client coordinates and the modifier
+// keys held down.
+func (w *Widget) dragTarget(msg *MSG) (target dropTarget, x, y int, modifiers Modifiers) {
+	_, args, _ := translateMouseMessage(msg, nil, backend.ModifiersDown()&ModAlt)
+	modifiers = args.modifiers
+
+	screen, err := backend.ClientToScreen(w.hWnd, drawing.Point{args.x, args.y})
+	if err != nil {
+		return
+	}
+
+	var widget IWidget
+	if wi, ok := widgetsByHWnd[backend.WindowFromPoint(screen)]; ok {
+		widget = wi
+	}
+
+	for widget != nil && !widget.AllowDrop() {
+		widget = widget.Parent()
+	}
+
+	if widget == nil {
+		return
+	}
+
+	pt, err := backend.ScreenToClient(widget.Handle(), screen)
+	if err != nil {
+		return
 	}
+
+	target, _ = widget.(dropTarget)
+
+	return target, pt.X, pt.Y, modifiers
+}
+
+// handleDropFiles raises Drop for files dropped from the shell.
+func (w *Widget) handleDropFiles(hDrop uintptr) {
+	// The files must be queried even if they are not wanted, which releases
+	// the drop handle.
+	paths, point, err := backend.DroppedFiles(hDrop)
+	if err != nil || !w.allowDrop {
+		return
+	}
+
+	data := NewDataObject()
+	data.SetFilePaths(paths)
+
+	w.dropPublisher.Publish(&dragEventArgs{
+		eventArgs: eventArgs{widgetsByHWnd[w.hWnd]},
+		data:      data,
+		x:         point.X,
+		y:         point.Y,
+		modifiers: backend.ModifiersDown(),
+		allowed:   DropEffectCopy,
+		effect:    DropEffectCopy,
+	})
+}
+
+func (w *Widget) SizeChanged() *Event {
+	return w.sizeChangedPublisher.Event()
+}
+
+// handleShortcut passes the key press to the root widget of w, which triggers
+// the action that has the resulting shortcut, if any.
+func (w *Widget) handleShortcut(key Key) bool {
+	switch key {
+	case VK_SHIFT, VK_CONTROL, VK_MENU:
+		return false
+	}
+
+	widget, ok := widgetsByHWnd[w.hWnd]
+	if !ok {
+		return false
+	}
+
+	handler, ok := rootWidget(widget).(shortcutHandler)
+	if !ok {
+		return false
+	}
+
+	return handler.handleShortcut(Shortcut{backend.ModifiersDown(), key})
 }
 
 func (w *Widget) wndProc(msg *MSG, origWndProcPtr uintptr) uintptr {
 	//	widget := widgetsByHWnd[w.hWnd]
 	//	fmt.Printf("*Widget.wndProc: type: %T, msg: %+v\n", widget, msg)
 
+	if w.dragSession != nil && w.handleDragMessage(msg) {
+		return 0
+	}
+
 	switch msg.Message {
-	case WM_LBUTTONDOWN:
-		for _, handlerIface := range w.mouseDownHandlers {
-			handler := handlerIface.(MouseEventHandler)
-			handler(&mouseEventArgs{eventArgs: eventArgs{sender: widgetsByHWnd[w.hWnd]}})
+	case WM_LBUTTONDOWN, WM_LBUTTONUP, WM_LBUTTONDBLCLK,
+		WM_RBUTTONDOWN, WM_RBUTTONUP, WM_RBUTTONDBLCLK,
+		WM_MBUTTONDOWN, WM_MBUTTONUP, WM_MBUTTONDBLCLK,
+		WM_MOUSEMOVE, WM_MOUSEWHEEL:
+		w.handleMouseMessage(msg)
+
+	case WM_MOUSELEAVE:
+		w.mouseInside = false
+		w.mouseLeavePublisher.Publish(&eventArgs{widgetsByHWnd[w.hWnd]})
+
+	case WM_CAPTURECHANGED:
+		if HWND(msg.LParam) != w.hWnd {
+			w.captureLostPublisher.Publish(&eventArgs{widgetsByHWnd[w.hWnd]})
 		}
 
 	case WM_CONTEXTMENU:
@@ -672,15 +937,30 @@ func (w *Widget) wndProc(msg *MSG, origWndProcPtr uintptr) uintptr {
 		contextMenu := sourceWidget.ContextMenu()
 
 		if contextMenu != nil {
-			TrackPopupMenuEx(contextMenu.hMenu, TPM_NOANIMATION, x, y, rootWidget(sourceWidget).Handle(), nil)
+			backend.TrackPopupMenu(contextMenu.hMenu, rootWidget(sourceWidget).Handle(), drawing.Point{x, y})
 		}
 		return 0
 
-	case WM_KEYDOWN:
-		w.raiseKeyDown(&keyEventArgs{eventArgs: eventArgs{widgetsByHWnd[w.hWnd]}, key: int(msg.WParam)})
+	case WM_KEYDOWN, WM_SYSKEYDOWN:
+		if w.handleShortcut(Key(msg.WParam)) {
+			return 0
+		}
+
+		if w.handleKeyMessage(msg) {
+			return 0
+		}
+
+	case WM_KEYUP, WM_SYSKEYUP, WM_CHAR, WM_SYSCHAR:
+		if w.handleKeyMessage(msg) {
+			return 0
+		}
+
+	case WM_DROPFILES:
+		w.handleDropFiles(uintptr(msg.WParam))
+		return 0
 
 	case WM_SIZE, WM_SIZING:
-		w.raiseSizeChanged()
+		w.sizeChangedPublisher.Publish(&eventArgs{widgetsByHWnd[w.hWnd]})
 
 	case WM_GETMINMAXINFO:
 		mmi := (*MINMAXINFO)(unsafe.Pointer(msg.LParam))
@@ -688,40 +968,15 @@ func (w *Widget) wndProc(msg *MSG, origWndProcPtr uintptr) uintptr {
 		return 0
 	}
 
-	if origWndProcPtr != 0 {
-		return CallWindowProc(origWndProcPtr, msg.HWnd, msg.Message, msg.WParam, msg.LParam)
-	}
-
-	return DefWindowProc(msg.HWnd, msg.Message, msg.WParam, msg.LParam)
+	return backend.DefWindowProc(msg, origWndProcPtr)
 }
 
 func (w *Widget) runMessageLoop() os.Error {
-	var msg MSG
-
-	for w.hWnd != 0 {
-		ret := GetMessage(&msg, 0, 0, 0)
-
-		//		fmt.Printf("*Widget.runMessageLoop: msg: %+v\n", msg)
-
-		switch ret {
-		case 0:
-			fmt.Println("Widget.runMessageLoop: GetMessage returned 0, exiting")
-			return nil
-
-		case -1:
-			return newError("GetMessage returned -1")
-		}
-
-		rootHWnd := GetAncestor(msg.HWnd, GA_ROOT)
-		if rootHWnd == 0 {
-			rootHWnd = msg.HWnd
-		}
-
-		if !IsDialogMessage(rootHWnd, &msg) {
-			TranslateMessage(&msg)
-			DispatchMessage(&msg)
-		}
+	if err := ensureApplicationWindow(); err != nil {
+		return err
 	}
 
-	return nil
+	return backend.RunMessageLoop(func() bool {
+		return w.hWnd != 0
+	})
 }
@@ -0,0 +1,161 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"os"
+	"testing"
+)
+
+import (
+	"walk/drawing"
+)
+
+// countingLayout resizes all children to childSize and counts its updates.
+type countingLayout struct {
+	container IContainer
+	childSize drawing.Size
+	updates   int
+}
+
+func (l *countingLayout) Container() IContainer {
+	return l.container
+}
+
+func (l *countingLayout) SetContainer(value IContainer) {
+	l.container = value
+
+	if value != nil && value.Layout() != Layout(l) {
+		value.SetLayout(l)
+	}
+}
+
+func (l *countingLayout) Margins() *Margins {
+	return &Margins{}
+}
+
+func (l *countingLayout) SetMargins(value *Margins) os.Error {
+	return nil
+}
+
+func (l *countingLayout) Spacing() int {
+	return 0
+}
+
+func (l *countingLayout) SetSpacing(value int) os.Error {
+	return nil
+}
+
+func (l *countingLayout) Update(reset bool) os.Error {
+	l.updates++
+
+	children := l.container.Children()
+	for i := 0; i < children.Len(); i++ {
+		if err := children.At(i).SetBounds(drawing.Rectangle{0, 0, l.childSize.Width, l.childSize.Height}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (l *countingLayout) MinSize() drawing.Size {
+	return l.childSize
+}
+
+func (l *countingLayout) PreferredSize() drawing.Size {
+	return l.childSize
+}
+
+// newTestCompositeChain creates count nested Composites with a countingLayout
+// each, inside a Composite without a layout, and a Label in the innermost one.
+func newTestCompositeChain(t *testing.T, count int) ([]*countingLayout, *Label) {
+	_, mw := newTestMainWindow(t)
+
+	outer, err := NewComposite(mw.ClientArea())
+	if err != nil {
+		t.Fatalf("NewComposite failed: %s", err)
+	}
+
+	var parent IContainer = outer
+
+	layouts := make([]*countingLayout, count)
+	for i := range layouts {
+		c, err := NewComposite(parent)
+		if err != nil {
+			t.Fatalf("NewComposite failed: %s", err)
+		}
+
+		layouts[i] = &countingLayout{childSize: drawing.Size{100, 50}}
+		c.SetLayout(layouts[i])
+		c.SetBounds(drawing.Rectangle{0, 0, 100, 50})
+
+		parent = c
+	}
+
+	label, err := NewLabel(parent)
+	if err != nil {
+		t.Fatalf("NewLabel failed: %s", err)
+	}
+
+	for _, l := range layouts {
+		l.updates = 0
+	}
+
+	return layouts, label
+}
+
+func checkLayoutUpdates(t *testing.T, name string, layouts []*countingLayout, expected []int) {
+	for i, l := range layouts {
+		if l.updates != expected[i] {
+			t.Errorf("%s: layout %d: expected %d updates, got %d", name, i, expected[i], l.updates)
+		}
+	}
+}
+
+func TestUpdateParentLayoutOncePerContainer(t *testing.T) {
+	layouts, label := newTestCompositeChain(t, 3)
+
+	if err := label.SetMinSize(drawing.Size{10, 10}); err != nil {
+		t.Fatalf("SetMinSize failed: %s", err)
+	}
+
+	checkLayoutUpdates(t, "unchanged sizes", layouts, []int{1, 1, 1})
+}
+
+func TestUpdateParentLayoutResizedContainers(t *testing.T) {
+	layouts, label := newTestCompositeChain(t, 3)
+
+	// The outermost layout resizes the middle container, which arranges its
+	// children in response to WM_SIZE and must not be updated again.
+	layouts[0].childSize = drawing.Size{80, 40}
+
+	label.SetMinSize(drawing.Size{10, 10})
+
+	checkLayoutUpdates(t, "resized", layouts, []int{1, 1, 1})
+}
+
+func TestUpdateParentLayoutStopsWithoutLayout(t *testing.T) {
+	layouts, label := newTestCompositeChain(t, 2)
+
+	// Without a layout, the size of a container does not depend on its
+	// children, so its ancestors are not affected.
+	layouts[1].container.SetLayout(nil)
+
+	label.SetMinSize(drawing.Size{10, 10})
+
+	checkLayoutUpdates(t, "without layout", layouts, []int{0, 0})
+}
+
+func TestUpdateParentLayoutRemovedWidget(t *testing.T) {
+	layouts, label := newTestCompositeChain(t, 1)
+
+	layouts[0].container.Children().Remove(label)
+	layouts[0].updates = 0
+
+	label.SetMinSize(drawing.Size{10, 10})
+
+	checkLayoutUpdates(t, "removed", layouts, []int{0})
+}
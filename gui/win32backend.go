@@ -0,0 +1,871 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gui
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+import (
+	"walk/drawing"
+	. "walk/winapi"
+	. "walk/winapi/comctl32"
+	. "walk/winapi/gdi32"
+	. "walk/winapi/kernel32"
+	. "walk/winapi/shell32"
+	. "walk/winapi/user32"
+	. "walk/winapi/uxtheme"
+)
+
+type win32Subclass struct {
+	windowProc     func(msg *MSG, origWndProcPtr uintptr) uintptr
+	origWndProcPtr uintptr
+}
+
+type win32Backend struct {
+	clipboardOwner   HWND
+	defaultFont      *drawing.Font
+	classCallbacks   map[string]*syscall.Callback
+	subclassCallback *syscall.Callback
+	subclasses       map[HWND]*win32Subclass
+	loopDepth        int
+}
+
+func newDefaultBackend() Backend {
+	return &win32Backend{
+		classCallbacks: make(map[string]*syscall.Callback),
+		subclasses:     make(map[HWND]*win32Subclass),
+	}
+}
+
+func msgFromCallbackArgs(args *uintptr) *MSG {
+	p := (*[4]int32)(unsafe.Pointer(args))
+
+	return &MSG{
+		HWnd:    HWND(p[0]),
+		Message: uint(p[1]),
+		WParam:  uintptr(p[2]),
+		LParam:  uintptr(p[3]),
+	}
+}
+
+// RegisterWindowClass registers the class once, with a callback that passes
+// the messages on to windowProc. The callbacks are kept forever, because
+// windows of the class may exist as long as the process does.
+func (b *win32Backend) RegisterWindowClass(className string, windowProc func(msg *MSG) uintptr) {
+	if _, ok := b.classCallbacks[className]; ok {
+		return
+	}
+
+	hInst := GetModuleHandle(nil)
+	if hInst == 0 {
+		panic("GetModuleHandle failed")
+	}
+
+	hIcon := LoadIcon(0, (*uint16)(unsafe.Pointer(uintptr(IDI_APPLICATION))))
+	if hIcon == 0 {
+		panic("LoadIcon failed")
+	}
+
+	hCursor := LoadCursor(0, (*uint16)(unsafe.Pointer(uintptr(IDC_ARROW))))
+	if hCursor == 0 {
+		panic("LoadCursor failed")
+	}
+
+	wndProc := func(args *uintptr) uintptr {
+		return windowProc(msgFromCallbackArgs(args))
+	}
+	callback := syscall.NewCallback(wndProc, 4*4)
+
+	b.classCallbacks[className] = callback
+
+	var wc WNDCLASSEX
+	wc.CbSize = uint(unsafe.Sizeof(wc))
+	wc.Style = CS_DBLCLKS
+	wc.LpfnWndProc = uintptr(callback.ExtFnEntry())
+	wc.HInstance = hInst
+	wc.HIcon = hIcon
+	wc.HCursor = hCursor
+	wc.HbrBackground = COLOR_BTNFACE + 1
+	wc.LpszClassName = StringToUTF16Ptr(className)
+
+	if atom := RegisterClassEx(&wc); atom == 0 {
+		panic("RegisterClassEx")
+	}
+}
+
+func (*win32Backend) CreateWindow(exStyle uint, className string, style uint, parent HWND, bounds drawing.Rectangle) (HWND, os.Error) {
+	hWnd := CreateWindowEx(
+		exStyle, StringToUTF16Ptr(className), nil,
+		style,
+		bounds.X, bounds.Y, bounds.Width, bounds.Height, parent, 0, 0, nil)
+	if hWnd == 0 {
+		return 0, lastError("CreateWindowEx")
+	}
+
+	return hWnd, nil
+}
+
+func (*win32Backend) DestroyWindow(hWnd HWND) os.Error {
+	if !DestroyWindow(hWnd) {
+		return lastError("DestroyWindow")
+	}
+
+	return nil
+}
+
+// SubclassWindow replaces the window procedure of hWnd by windowProc, which
+// receives the original one with each message. All subclassed windows share a
+// single callback, which looks up the windowProc by handle.
+func (b *win32Backend) SubclassWindow(hWnd HWND, windowProc func(msg *MSG, origWndProcPtr uintptr) uintptr) os.Error {
+	if b.subclassCallback == nil {
+		wndProc := func(args *uintptr) uintptr {
+			return b.subclassWndProc(msgFromCallbackArgs(args))
+		}
+		b.subclassCallback = syscall.NewCallback(wndProc, 4*4)
+	}
+
+	sc := &win32Subclass{windowProc: windowProc}
+	b.subclasses[hWnd] = sc
+
+	sc.origWndProcPtr = uintptr(SetWindowLong(hWnd, GWL_WNDPROC, int(b.subclassCallback.ExtFnEntry())))
+	if sc.origWndProcPtr == 0 {
+		b.subclasses[hWnd] = nil, false
+
+		return lastError("SetWindowLong")
+	}
+
+	return nil
+}
+
+func (b *win32Backend) subclassWndProc(msg *MSG) uintptr {
+	sc := b.subclasses[msg.HWnd]
+
+	if msg.Message == WM_NCDESTROY {
+		// The last message the window receives.
+		b.subclasses[msg.HWnd] = nil, false
+	}
+
+	return sc.windowProc(msg, sc.origWndProcPtr)
+}
+
+func (b *win32Backend) SetParent(hWnd, parent HWND) os.Error {
+	style, err := b.Style(hWnd)
+	if err != nil {
+		return err
+	}
+
+	if parent == 0 {
+		style &^= WS_CHILD
+		style |= WS_POPUP
+
+		if SetParent(hWnd, 0) == 0 {
+			return lastError("SetParent")
+		}
+		if err := b.SetStyle(hWnd, style); err != nil {
+			return err
+		}
+	} else {
+		style |= WS_CHILD
+		style &^= WS_POPUP
+
+		if err := b.SetStyle(hWnd, style); err != nil {
+			return err
+		}
+		if SetParent(hWnd, parent) == 0 {
+			return lastError("SetParent")
+		}
+	}
+
+	bounds, err := b.Bounds(hWnd)
+	if err != nil {
+		return err
+	}
+
+	if !SetWindowPos(hWnd, HWND_BOTTOM, bounds.X, bounds.Y, bounds.Width, bounds.Height, SWP_FRAMECHANGED) {
+		return lastError("SetWindowPos")
+	}
+
+	return nil
+}
+
+func (*win32Backend) SetOwner(hWnd, owner HWND) os.Error {
+	SetLastError(0)
+	if 0 == SetWindowLong(hWnd, GWL_HWNDPARENT, int(owner)) {
+		return lastError("SetWindowLong")
+	}
+
+	return nil
+}
+
+func (*win32Backend) Style(hWnd HWND) (uint, os.Error) {
+	style := GetWindowLong(hWnd, GWL_STYLE)
+	if style == 0 {
+		return 0, lastError("GetWindowLong")
+	}
+
+	return uint(style), nil
+}
+
+func (*win32Backend) SetStyle(hWnd HWND, style uint) os.Error {
+	SetLastError(0)
+	if SetWindowLong(hWnd, GWL_STYLE, int(style)) == 0 {
+		return lastError("SetWindowLong")
+	}
+
+	return nil
+}
+
+func (*win32Backend) Bounds(hWnd HWND) (drawing.Rectangle, os.Error) {
+	var r RECT
+
+	if !GetWindowRect(hWnd, &r) {
+		return drawing.Rectangle{}, lastError("GetWindowRect")
+	}
+
+	b := drawing.Rectangle{X: r.Left, Y: r.Top, Width: r.Right - r.Left, Height: r.Bottom - r.Top}
+
+	if GetWindowLong(hWnd, GWL_STYLE)&WS_CHILD != 0 {
+		// Child windows are positioned in the client area of their parent.
+		p := POINT{b.X, b.Y}
+		if !ScreenToClient(GetAncestor(hWnd, GA_PARENT), &p) {
+			return drawing.Rectangle{}, newError("ScreenToClient failed")
+		}
+		b.X = p.X
+		b.Y = p.Y
+	}
+
+	return b, nil
+}
+
+func (*win32Backend) SetBounds(hWnd HWND, bounds drawing.Rectangle) os.Error {
+	if !MoveWindow(hWnd, bounds.X, bounds.Y, bounds.Width, bounds.Height, true) {
+		return lastError("MoveWindow")
+	}
+
+	return nil
+}
+
+func (*win32Backend) ClientBounds(hWnd HWND) (drawing.Rectangle, os.Error) {
+	var r RECT
+
+	if !GetClientRect(hWnd, &r) {
+		return drawing.Rectangle{}, lastError("GetClientRect")
+	}
+
+	return drawing.Rectangle{X: r.Left, Y: r.Top, Width: r.Right - r.Left, Height: r.Bottom - r.Top}, nil
+}
+
+func (*win32Backend) ShowWindow(hWnd HWND, cmdShow int) os.Error {
+	ShowWindow(hWnd, cmdShow)
+
+	return nil
+}
+
+func (*win32Backend) WindowPlacement(hWnd HWND) (*WINDOWPLACEMENT, os.Error) {
+	wp := &WINDOWPLACEMENT{}
+	wp.Length = uint(unsafe.Sizeof(*wp))
+
+	if !GetWindowPlacement(hWnd, wp) {
+		return nil, lastError("GetWindowPlacement")
+	}
+
+	return wp, nil
+}
+
+func (*win32Backend) SetWindowPlacement(hWnd HWND, wp *WINDOWPLACEMENT) os.Error {
+	wp.Length = uint(unsafe.Sizeof(*wp))
+
+	if !SetWindowPlacement(hWnd, wp) {
+		return lastError("SetWindowPlacement")
+	}
+
+	return nil
+}
+
+func (*win32Backend) Text(hWnd HWND) string {
+	textLength := SendMessage(hWnd, WM_GETTEXTLENGTH, 0, 0)
+	buf := make([]uint16, textLength+1)
+	SendMessage(hWnd, WM_GETTEXT, uintptr(textLength+1), uintptr(unsafe.Pointer(&buf[0])))
+	return UTF16ToString(buf)
+}
+
+func (*win32Backend) SetText(hWnd HWND, value string) os.Error {
+	if TRUE != SendMessage(hWnd, WM_SETTEXT, 0, uintptr(unsafe.Pointer(StringToUTF16Ptr(value)))) {
+		return newError("WM_SETTEXT failed")
+	}
+
+	return nil
+}
+
+// DefaultFont returns the menu font of the system, which is created on first
+// use.
+func (b *win32Backend) DefaultFont() *drawing.Font {
+	if b.defaultFont != nil {
+		return b.defaultFont
+	}
+
+	var ncm NONCLIENTMETRICS
+	ncm.CbSize = uint(unsafe.Sizeof(ncm))
+
+	if !SystemParametersInfo(SPI_GETNONCLIENTMETRICS, ncm.CbSize, unsafe.Pointer(&ncm), 0) {
+		panic("SystemParametersInfo failed")
+	}
+
+	hdc := GetDC(0)
+	defer ReleaseDC(0, hdc)
+	dpi := GetDeviceCaps(hdc, LOGPIXELSY)
+
+	// FIXME: Find out how to get dialog item font and use that.
+	font, err := drawing.NewFontFromLOGFONT(&ncm.LfMenuFont, dpi)
+	if err != nil {
+		panic("failed to create default font")
+	}
+
+	b.defaultFont = font
+
+	return font
+}
+
+func (*win32Backend) SetFont(hWnd HWND, font *drawing.Font) {
+	SendMessage(hWnd, WM_SETFONT, uintptr(font.HandleForDPI(0)), 1)
+}
+
+func (*win32Backend) Focus() HWND {
+	return GetFocus()
+}
+
+func (*win32Backend) SetFocus(hWnd HWND) os.Error {
+	if SetFocus(hWnd) == 0 {
+		return lastError("SetFocus")
+	}
+
+	return nil
+}
+
+func (*win32Backend) ModifiersDown() Modifiers {
+	var m Modifiers
+
+	if GetKeyState(VK_SHIFT)&0x8000 != 0 {
+		m |= ModShift
+	}
+	if GetKeyState(VK_CONTROL)&0x8000 != 0 {
+		m |= ModControl
+	}
+	if GetKeyState(VK_MENU)&0x8000 != 0 {
+		m |= ModAlt
+	}
+
+	return m
+}
+
+func (*win32Backend) Capture() HWND {
+	return GetCapture()
+}
+
+func (*win32Backend) SetCapture(hWnd HWND) os.Error {
+	SetCapture(hWnd)
+
+	return nil
+}
+
+func (*win32Backend) ReleaseCapture() os.Error {
+	if !ReleaseCapture() {
+		return lastError("ReleaseCapture")
+	}
+
+	return nil
+}
+
+func (*win32Backend) SetCursor(id uint16) os.Error {
+	hCursor := LoadCursor(0, (*uint16)(unsafe.Pointer(uintptr(id))))
+	if hCursor == 0 {
+		return lastError("LoadCursor")
+	}
+
+	SetCursor(hCursor)
+
+	return nil
+}
+
+func (*win32Backend) TrackMouseLeave(hWnd HWND) os.Error {
+	tme := TRACKMOUSEEVENT{DwFlags: TME_LEAVE, HwndTrack: hWnd}
+	tme.CbSize = uint(unsafe.Sizeof(tme))
+
+	if !TrackMouseEvent(&tme) {
+		return lastError("TrackMouseEvent")
+	}
+
+	return nil
+}
+
+func (*win32Backend) ScreenToClient(hWnd HWND, point drawing.Point) (drawing.Point, os.Error) {
+	pt := POINT{point.X, point.Y}
+
+	if !ScreenToClient(hWnd, &pt) {
+		return point, lastError("ScreenToClient")
+	}
+
+	return drawing.Point{pt.X, pt.Y}, nil
+}
+
+func (*win32Backend) ClientToScreen(hWnd HWND, point drawing.Point) (drawing.Point, os.Error) {
+	pt := POINT{point.X, point.Y}
+
+	if !ClientToScreen(hWnd, &pt) {
+		return point, newError("ClientToScreen failed")
+	}
+
+	return drawing.Point{pt.X, pt.Y}, nil
+}
+
+func (*win32Backend) WindowFromPoint(point drawing.Point) HWND {
+	return WindowFromPoint(POINT{point.X, point.Y})
+}
+
+func (*win32Backend) AcceptFiles(hWnd HWND, accept bool) os.Error {
+	DragAcceptFiles(hWnd, accept)
+
+	return nil
+}
+
+func (*win32Backend) DroppedFiles(hDrop uintptr) (paths []string, point drawing.Point, err os.Error) {
+	h := HDROP(hDrop)
+	defer DragFinish(h)
+
+	count := DragQueryFile(h, 0xFFFFFFFF, nil, 0)
+	paths = make([]string, count)
+
+	for i := uint(0); i < count; i++ {
+		buf := make([]uint16, DragQueryFile(h, i, nil, 0)+1)
+		if DragQueryFile(h, i, &buf[0], uint(len(buf))) == 0 {
+			return nil, point, newError("DragQueryFile failed")
+		}
+
+		paths[i] = UTF16ToString(buf)
+	}
+
+	var pt POINT
+	DragQueryPoint(h, &pt)
+
+	return paths, drawing.Point{pt.X, pt.Y}, nil
+}
+
+// withClipboard calls f with the clipboard opened. The clipboard is owned by
+// a message-only window, so it can be set without any widget.
+func (b *win32Backend) withClipboard(f func() os.Error) os.Error {
+	if b.clipboardOwner == 0 {
+		hWnd := CreateWindowEx(0, StringToUTF16Ptr("STATIC"), nil, 0, 0, 0, 0, 0, HWND_MESSAGE, 0, 0, nil)
+		if hWnd == 0 {
+			return lastError("CreateWindowEx")
+		}
+
+		b.clipboardOwner = hWnd
+	}
+
+	if !OpenClipboard(b.clipboardOwner) {
+		return lastError("OpenClipboard")
+	}
+	defer CloseClipboard()
+
+	return f()
+}
+
+// clipboardFormatId returns the id of the standard clipboard format that
+// format maps to, or registers it as custom format.
+func clipboardFormatId(format string) (uint, os.Error) {
+	switch format {
+	case TextFormat:
+		return CF_UNICODETEXT, nil
+
+	case ImageFormat:
+		return CF_DIB, nil
+	}
+
+	id := RegisterClipboardFormat(StringToUTF16Ptr(format))
+	if id == 0 {
+		return 0, lastError("RegisterClipboardFormat")
+	}
+
+	return id, nil
+}
+
+func (*win32Backend) ClipboardSequenceNumber() uint {
+	return GetClipboardSequenceNumber()
+}
+
+func (*win32Backend) ClipboardContains(format string) bool {
+	id, err := clipboardFormatId(format)
+	if err != nil {
+		return false
+	}
+
+	return IsClipboardFormatAvailable(id)
+}
+
+func (b *win32Backend) ClipboardData(format string) (value interface{}, err os.Error) {
+	id, err := clipboardFormatId(format)
+	if err != nil {
+		return nil, err
+	}
+
+	err = b.withClipboard(func() os.Error {
+		hMem := HGLOBAL(GetClipboardData(id))
+		if hMem == 0 {
+			return lastError("GetClipboardData")
+		}
+
+		p := GlobalLock(hMem)
+		if p == nil {
+			return lastError("GlobalLock")
+		}
+		defer GlobalUnlock(hMem)
+
+		size := int(GlobalSize(hMem))
+
+		if format == TextFormat {
+			value = UTF16ToString((*[1 << 29]uint16)(p)[0 : size/2])
+			return nil
+		}
+
+		data := make([]byte, size)
+		if size > 0 {
+			MoveMemory(unsafe.Pointer(&data[0]), p, uintptr(size))
+		}
+
+		if format == ImageFormat {
+			var err os.Error
+			value, err = drawing.NewBitmapFromPackedDIB(data)
+			return err
+		}
+
+		value = data
+
+		return nil
+	})
+
+	return
+}
+
+func (b *win32Backend) SetClipboardData(values map[string]interface{}) os.Error {
+	return b.withClipboard(func() os.Error {
+		if !EmptyClipboard() {
+			return lastError("EmptyClipboard")
+		}
+
+		for format, value := range values {
+			id, err := clipboardFormatId(format)
+			if err != nil {
+				return err
+			}
+
+			var data []byte
+
+			switch v := value.(type) {
+			case string:
+				text := StringToUTF16(v)
+				data = (*[1 << 30]byte)(unsafe.Pointer(&text[0]))[0 : len(text)*2]
+
+			case *drawing.Bitmap:
+				if data, err = v.PackedDIB(); err != nil {
+					return err
+				}
+
+			case []byte:
+				data = v
+
+			default:
+				return newError(fmt.Sprintf("cannot put %T on the clipboard", value))
+			}
+
+			if len(data) == 0 {
+				continue
+			}
+
+			hMem := GlobalAlloc(GMEM_MOVEABLE, uintptr(len(data)))
+			if hMem == 0 {
+				return lastError("GlobalAlloc")
+			}
+
+			p := GlobalLock(hMem)
+			if p == nil {
+				GlobalFree(hMem)
+				return lastError("GlobalLock")
+			}
+
+			MoveMemory(p, unsafe.Pointer(&data[0]), uintptr(len(data)))
+			GlobalUnlock(hMem)
+
+			// On success, the clipboard owns the memory.
+			if SetClipboardData(id, HANDLE(hMem)) == 0 {
+				GlobalFree(hMem)
+				return lastError("SetClipboardData")
+			}
+		}
+
+		return nil
+	})
+}
+
+// CreateMenu creates a menu bar or a popup menu. Popup menus show check marks
+// and bitmaps in the same column.
+func (*win32Backend) CreateMenu(popup bool) (HMENU, os.Error) {
+	if !popup {
+		hMenu := CreateMenu()
+		if hMenu == 0 {
+			return 0, lastError("CreateMenu")
+		}
+
+		return hMenu, nil
+	}
+
+	hMenu := CreatePopupMenu()
+	if hMenu == 0 {
+		return 0, lastError("CreatePopupMenu")
+	}
+
+	var mi MENUINFO
+	mi.CbSize = uint(unsafe.Sizeof(mi))
+
+	if !GetMenuInfo(hMenu, &mi) {
+		DestroyMenu(hMenu)
+		return 0, lastError("GetMenuInfo")
+	}
+
+	mi.FMask |= MIM_STYLE
+	mi.DwStyle = MNS_CHECKORBMP
+
+	if !SetMenuInfo(hMenu, &mi) {
+		DestroyMenu(hMenu)
+		return 0, lastError("SetMenuInfo")
+	}
+
+	return hMenu, nil
+}
+
+func (*win32Backend) DestroyMenu(hMenu HMENU) os.Error {
+	if !DestroyMenu(hMenu) {
+		return lastError("DestroyMenu")
+	}
+
+	return nil
+}
+
+func (*win32Backend) SetMenu(hWnd HWND, hMenu HMENU) os.Error {
+	if !SetMenu(hWnd, hMenu) {
+		return lastError("SetMenu")
+	}
+
+	return nil
+}
+
+func (*win32Backend) InsertMenuItem(hMenu HMENU, position int, mii *MENUITEMINFO) os.Error {
+	if !InsertMenuItem(hMenu, uint(position), true, mii) {
+		return newError("InsertMenuItem failed")
+	}
+
+	return nil
+}
+
+func (*win32Backend) SetMenuItemInfo(hMenu HMENU, position int, mii *MENUITEMINFO) os.Error {
+	if !SetMenuItemInfo(hMenu, uint(position), true, mii) {
+		return newError("SetMenuItemInfo failed")
+	}
+
+	return nil
+}
+
+func (*win32Backend) RemoveMenuItem(hMenu HMENU, position int) os.Error {
+	if !RemoveMenu(hMenu, uint(position), MF_BYPOSITION) {
+		return lastError("RemoveMenu")
+	}
+
+	return nil
+}
+
+func (*win32Backend) DrawMenuBar(hWnd HWND) os.Error {
+	if !DrawMenuBar(hWnd) {
+		return lastError("DrawMenuBar")
+	}
+
+	return nil
+}
+
+func (*win32Backend) TrackPopupMenu(hMenu HMENU, owner HWND, point drawing.Point) os.Error {
+	if FALSE == TrackPopupMenuEx(hMenu, TPM_NOANIMATION, point.X, point.Y, owner, nil) {
+		return lastError("TrackPopupMenuEx")
+	}
+
+	return nil
+}
+
+func (*win32Backend) CreateImageList(imageSize drawing.Size) (HIMAGELIST, os.Error) {
+	hIml := ImageList_Create(imageSize.Width, imageSize.Height, ILC_MASK|ILC_COLOR24, 8, 8)
+	if hIml == 0 {
+		return 0, newError("ImageList_Create failed")
+	}
+
+	return hIml, nil
+}
+
+func (*win32Backend) DestroyImageList(hIml HIMAGELIST) os.Error {
+	if !ImageList_Destroy(hIml) {
+		return newError("ImageList_Destroy failed")
+	}
+
+	return nil
+}
+
+func (*win32Backend) AddImage(hIml HIMAGELIST, bitmap, maskBitmap *drawing.Bitmap) (int, os.Error) {
+	var maskHandle HBITMAP
+	if maskBitmap != nil {
+		maskHandle = maskBitmap.Handle()
+	}
+
+	index := ImageList_Add(hIml, bitmap.Handle(), maskHandle)
+	if index == -1 {
+		return 0, newError("ImageList_Add failed")
+	}
+
+	return index, nil
+}
+
+func (*win32Backend) AddMaskedImage(hIml HIMAGELIST, bitmap *drawing.Bitmap, maskColor drawing.Color) (int, os.Error) {
+	index := ImageList_AddMasked(hIml, bitmap.Handle(), COLORREF(maskColor))
+	if index == -1 {
+		return 0, newError("ImageList_AddMasked failed")
+	}
+
+	return index, nil
+}
+
+func (*win32Backend) MessageBox(owner HWND, title, message string, style uint) int {
+	return MessageBox(owner, StringToUTF16Ptr(message), StringToUTF16Ptr(title), style)
+}
+
+func (*win32Backend) SetTheme(hWnd HWND, appName string) os.Error {
+	if hr := SetWindowTheme(hWnd, StringToUTF16Ptr(appName), nil); FAILED(hr) {
+		return errorFromHRESULT("SetWindowTheme", hr)
+	}
+
+	return nil
+}
+
+func (*win32Backend) DialogBaseUnits(hWnd HWND) drawing.Size {
+	// FIXME: Error handling
+	hFont := HFONT(SendMessage(hWnd, WM_GETFONT, 0, 0))
+	hdc := GetDC(hWnd)
+	hFontOld := SelectObject(hdc, HGDIOBJ(hFont))
+
+	var tm TEXTMETRIC
+	GetTextMetrics(hdc, &tm)
+
+	var size SIZE
+	GetTextExtentPoint32(
+		hdc,
+		StringToUTF16Ptr("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"),
+		52,
+		&size)
+
+	SelectObject(hdc, HGDIOBJ(hFontOld))
+	ReleaseDC(hWnd, hdc)
+
+	return drawing.Size{(size.CX/26 + 1) / 2, int(tm.TmHeight)}
+}
+
+func (*win32Backend) Invalidate(hWnd HWND, bounds drawing.Rectangle) os.Error {
+	r := &RECT{bounds.X, bounds.Y, bounds.X + bounds.Width, bounds.Y + bounds.Height}
+
+	if !InvalidateRect(hWnd, r, true) {
+		return newError("InvalidateRect failed")
+	}
+
+	return nil
+}
+
+func (*win32Backend) SendMessage(hWnd HWND, msg uint, wParam, lParam uintptr) uintptr {
+	return SendMessage(hWnd, msg, wParam, lParam)
+}
+
+func (*win32Backend) PostMessage(hWnd HWND, msg uint, wParam, lParam uintptr) os.Error {
+	if 0 == PostMessage(hWnd, msg, wParam, lParam) {
+		return lastError("PostMessage")
+	}
+
+	return nil
+}
+
+func (*win32Backend) SetTimer(hWnd HWND, id uintptr, elapse uint) os.Error {
+	if SetTimer(hWnd, id, elapse, 0) == 0 {
+		return lastError("SetTimer")
+	}
+
+	return nil
+}
+
+func (*win32Backend) KillTimer(hWnd HWND, id uintptr) os.Error {
+	if !KillTimer(hWnd, id) {
+		return lastError("KillTimer")
+	}
+
+	return nil
+}
+
+func (*win32Backend) DefWindowProc(msg *MSG, origWndProcPtr uintptr) uintptr {
+	if origWndProcPtr != 0 {
+		return CallWindowProc(origWndProcPtr, msg.HWnd, msg.Message, msg.WParam, msg.LParam)
+	}
+
+	return DefWindowProc(msg.HWnd, msg.Message, msg.WParam, msg.LParam)
+}
+
+func (b *win32Backend) RunMessageLoop(running func() bool) os.Error {
+	var msg MSG
+
+	b.loopDepth++
+	defer func() {
+		b.loopDepth--
+	}()
+
+	for running() {
+		ret := GetMessage(&msg, 0, 0, 0)
+
+		switch ret {
+		case 0:
+			// A nested loop, e.g. the one of a modal Dialog, passes WM_QUIT on
+			// to the loop it was started from, so that one ends as well.
+			if b.loopDepth > 1 {
+				PostQuitMessage(int(msg.WParam))
+			}
+			return nil
+
+		case -1:
+			return newError("GetMessage returned -1")
+		}
+
+		rootHWnd := GetAncestor(msg.HWnd, GA_ROOT)
+		if rootHWnd == 0 {
+			rootHWnd = msg.HWnd
+		}
+
+		if !IsDialogMessage(rootHWnd, &msg) {
+			TranslateMessage(&msg)
+			DispatchMessage(&msg)
+		}
+
+		if !PeekMessage(&msg, 0, 0, 0, PM_NOREMOVE) {
+			onIdle()
+		}
+	}
+
+	return nil
+}
+
+func (*win32Backend) PostQuitMessage(exitCode int) {
+	PostQuitMessage(exitCode)
+}
@@ -18,7 +18,7 @@ import (
 
 type part interface {
 	Bounds() drawing.Rectangle
-	Draw(surface *drawing.Surface) os.Error
+	Draw(surface drawing.Surface) os.Error
 }
 
 type item interface {
@@ -26,7 +26,7 @@ type item interface {
 	Part(i int) part
 	NextPartMinSize() drawing.Size
 	PreferredSize() drawing.Size
-	AddNewPart(surface *drawing.Surface, bounds drawing.Rectangle) (part part, more bool, err os.Error)
+	AddNewPart(surface drawing.Surface, bounds drawing.Rectangle) (part part, more bool, err os.Error)
 	Dispose()
 }
 
@@ -135,7 +135,7 @@ func (doc *Document) Page(i int) *Page {
 	return doc.pages[i]
 }
 
-func (doc *Document) withSurface(f func(surface *drawing.Surface) os.Error) os.Error {
+func (doc *Document) withSurface(f func(surface drawing.Surface) os.Error) os.Error {
 	hdc := doc.nextPageInfo.createDC()
 	defer DeleteDC(hdc)
 
@@ -149,7 +149,7 @@ func (doc *Document) withSurface(f func(surface *drawing.Surface) os.Error) os.E
 }
 
 func (doc *Document) pageBounds() (bounds drawing.Rectangle, err os.Error) {
-	err = doc.withSurface(func(surface *drawing.Surface) os.Error {
+	err = doc.withSurface(func(surface drawing.Surface) os.Error {
 		bounds = surface.Bounds()
 
 		return nil
@@ -159,7 +159,7 @@ func (doc *Document) pageBounds() (bounds drawing.Rectangle, err os.Error) {
 }
 
 func (doc *Document) paginateItem(item item) (err os.Error) {
-	err = doc.withSurface(func(surface *drawing.Surface) os.Error {
+	err = doc.withSurface(func(surface drawing.Surface) os.Error {
 		pageBounds, err := doc.pageBounds()
 		if err != nil {
 			return err
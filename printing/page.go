@@ -33,7 +33,7 @@ func (page *Page) Info() *PageInfo {
 	return page.info
 }
 
-func (page *Page) Draw(surface *drawing.Surface) os.Error {
+func (page *Page) Draw(surface drawing.Surface) os.Error {
 	for _, part := range page.parts {
 		err := part.Draw(surface)
 		if err != nil {
@@ -24,7 +24,7 @@ func (part *simpleTextPart) Bounds() drawing.Rectangle {
 	return part.bounds
 }
 
-func (part *simpleTextPart) Draw(surface *drawing.Surface) os.Error {
+func (part *simpleTextPart) Draw(surface drawing.Surface) os.Error {
 	item := part.item
 	text := item.text.Slice(part.offset, part.offset+part.length)
 
@@ -52,7 +52,7 @@ func (item *simpleTextItem) Part(i int) part {
 	return item.parts[i]
 }
 
-func (item *simpleTextItem) AddNewPart(surface *drawing.Surface, bounds drawing.Rectangle) (part part, more bool, err os.Error) {
+func (item *simpleTextItem) AddNewPart(surface drawing.Surface, bounds drawing.Rectangle) (part part, more bool, err os.Error) {
 	partCount := len(item.parts)
 	var offset int
 	if partCount > 0 {
@@ -5,7 +5,6 @@
 package advapi32
 
 import (
-	"syscall"
 	"unsafe"
 )
 
@@ -53,7 +52,7 @@ func init() {
 }
 
 func RegCloseKey(hKey HKEY) int {
-	ret, _, _ := syscall.Syscall(uintptr(regCloseKey),
+	ret, _, _ := Syscall(uintptr(regCloseKey),
 		uintptr(hKey),
 		0,
 		0)
@@ -62,7 +61,7 @@ func RegCloseKey(hKey HKEY) int {
 }
 
 func RegOpenKeyEx(hKey HKEY, lpSubKey *uint16, ulOptions uint, samDesired REGSAM, phkResult *HKEY) int {
-	ret, _, _ := syscall.Syscall6(uintptr(regOpenKeyEx),
+	ret, _, _ := Syscall6(uintptr(regOpenKeyEx),
 		uintptr(hKey),
 		uintptr(unsafe.Pointer(lpSubKey)),
 		uintptr(ulOptions),
@@ -74,7 +73,7 @@ func RegOpenKeyEx(hKey HKEY, lpSubKey *uint16, ulOptions uint, samDesired REGSAM
 }
 
 func RegQueryValueEx(hKey HKEY, lpValueName *uint16, lpReserved, lpType *uint, lpData *byte, lpcbData *uint) int {
-	ret, _, _ := syscall.Syscall6(uintptr(regQueryValueEx),
+	ret, _, _ := Syscall6(uintptr(regQueryValueEx),
 		uintptr(hKey),
 		uintptr(unsafe.Pointer(lpValueName)),
 		uintptr(unsafe.Pointer(lpReserved)),
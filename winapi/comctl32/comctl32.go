@@ -5,7 +5,6 @@
 package comctl32
 
 import (
-	"syscall"
 	"unsafe"
 )
 
@@ -166,16 +165,21 @@ func init() {
 	imageList_Destroy = MustGetProcAddress(lib, "ImageList_Destroy")
 	initCommonControlsEx = MustGetProcAddress(lib, "InitCommonControlsEx")
 
+	if lib == 0 {
+		// Not on Windows, see winapi.MustLoadLibrary.
+		return
+	}
+
 	// Initialize the common controls we support
 	var initCtrls INITCOMMONCONTROLSEX
 	initCtrls.DwSize = uint(unsafe.Sizeof(initCtrls))
-	initCtrls.DwICC = ICC_LISTVIEW_CLASSES | ICC_PROGRESS_CLASS
+	initCtrls.DwICC = ICC_LISTVIEW_CLASSES | ICC_PROGRESS_CLASS | ICC_TAB_CLASSES
 
 	InitCommonControlsEx(&initCtrls)
 }
 
 func ImageList_Add(himl HIMAGELIST, hbmImage, hbmMask HBITMAP) int {
-	ret, _, _ := syscall.Syscall(uintptr(imageList_Add),
+	ret, _, _ := Syscall(uintptr(imageList_Add),
 		uintptr(himl),
 		uintptr(hbmImage),
 		uintptr(hbmMask))
@@ -184,7 +188,7 @@ func ImageList_Add(himl HIMAGELIST, hbmImage, hbmMask HBITMAP) int {
 }
 
 func ImageList_AddMasked(himl HIMAGELIST, hbmImage HBITMAP, crMask COLORREF) int {
-	ret, _, _ := syscall.Syscall(uintptr(imageList_AddMasked),
+	ret, _, _ := Syscall(uintptr(imageList_AddMasked),
 		uintptr(himl),
 		uintptr(hbmImage),
 		uintptr(crMask))
@@ -193,7 +197,7 @@ func ImageList_AddMasked(himl HIMAGELIST, hbmImage HBITMAP, crMask COLORREF) int
 }
 
 func ImageList_Create(cx, cy int, flags uint, cInitial, cGrow int) HIMAGELIST {
-	ret, _, _ := syscall.Syscall6(uintptr(imageList_Create),
+	ret, _, _ := Syscall6(uintptr(imageList_Create),
 		uintptr(cx),
 		uintptr(cy),
 		uintptr(flags),
@@ -205,7 +209,7 @@ func ImageList_Create(cx, cy int, flags uint, cInitial, cGrow int) HIMAGELIST {
 }
 
 func ImageList_Destroy(hIml HIMAGELIST) bool {
-	ret, _, _ := syscall.Syscall(uintptr(imageList_Destroy),
+	ret, _, _ := Syscall(uintptr(imageList_Destroy),
 		uintptr(hIml),
 		0,
 		0)
@@ -214,7 +218,7 @@ func ImageList_Destroy(hIml HIMAGELIST) bool {
 }
 
 func InitCommonControlsEx(lpInitCtrls *INITCOMMONCONTROLSEX) bool {
-	ret, _, _ := syscall.Syscall(uintptr(initCommonControlsEx),
+	ret, _, _ := Syscall(uintptr(initCommonControlsEx),
 		uintptr(unsafe.Pointer(lpInitCtrls)),
 		0,
 		0)
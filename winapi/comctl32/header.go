@@ -0,0 +1,57 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package comctl32
+
+import (
+	. "walk/winapi/gdi32"
+)
+
+// Header messages
+const (
+	HDM_FIRST   = 0x1200
+	HDM_GETITEM = HDM_FIRST + 11
+	HDM_SETITEM = HDM_FIRST + 12
+)
+
+// Header item mask
+const (
+	HDI_WIDTH  = 0x0001
+	HDI_TEXT   = 0x0002
+	HDI_FORMAT = 0x0004
+	HDI_LPARAM = 0x0008
+	HDI_BITMAP = 0x0010
+	HDI_IMAGE  = 0x0020
+	HDI_ORDER  = 0x0080
+)
+
+// Header item format
+const (
+	HDF_LEFT            = 0x0000
+	HDF_RIGHT           = 0x0001
+	HDF_CENTER          = 0x0002
+	HDF_JUSTIFYMASK     = 0x0003
+	HDF_SORTDOWN        = 0x0200
+	HDF_SORTUP          = 0x0400
+	HDF_IMAGE           = 0x0800
+	HDF_BITMAP_ON_RIGHT = 0x1000
+	HDF_BITMAP          = 0x2000
+	HDF_STRING          = 0x4000
+	HDF_OWNERDRAW       = 0x8000
+)
+
+type HDITEM struct {
+	Mask       uint
+	Cxy        int
+	PszText    *uint16
+	Hbm        HBITMAP
+	CchTextMax int
+	Fmt        int
+	LParam     uintptr
+	IImage     int
+	IOrder     int
+	Type       uint
+	PvFilter   uintptr
+	State      uint
+}
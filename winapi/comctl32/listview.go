@@ -12,6 +12,7 @@ import (
 // ListView messages
 const (
 	LVM_FIRST                    = 0x1000
+	LVM_GETITEMCOUNT             = LVM_FIRST + 4
 	LVM_GETITEM                  = LVM_FIRST + 75
 	LVM_SETITEM                  = LVM_FIRST + 76
 	LVM_INSERTITEM               = LVM_FIRST + 77
@@ -126,7 +127,7 @@ const (
 
 // ListView notifications
 const (
-	LVN_FIRST = -100
+	LVN_FIRST = ^uint(99)
 
 	LVN_ITEMCHANGING      = LVN_FIRST - 0
 	LVN_ITEMCHANGED       = LVN_FIRST - 1
@@ -217,6 +218,25 @@ const (
 	LVS_EX_SIMPLESELECT     = 0x00100000
 )
 
+// ListView hit test flags
+const (
+	LVHT_NOWHERE         = 0x0001
+	LVHT_ONITEMICON      = 0x0002
+	LVHT_ONITEMLABEL     = 0x0004
+	LVHT_ONITEMSTATEICON = 0x0008
+	LVHT_ONITEM          = LVHT_ONITEMICON | LVHT_ONITEMLABEL | LVHT_ONITEMSTATEICON
+	LVHT_ABOVE           = 0x0008
+	LVHT_BELOW           = 0x0010
+	LVHT_TORIGHT         = 0x0020
+	LVHT_TOLEFT          = 0x0040
+)
+
+// LVM_SETITEMCOUNT flags
+const (
+	LVSICF_NOINVALIDATEALL = 0x0001
+	LVSICF_NOSCROLL        = 0x0002
+)
+
 // ListView column flags
 const (
 	LVCF_FMT     = 0x0001
@@ -298,3 +318,45 @@ type NMITEMACTIVATE struct {
 	LParam    uintptr
 	UKeyFlags uint
 }
+
+type NMLVDISPINFO struct {
+	Hdr  NMHDR
+	Item LVITEM
+}
+
+type NMLISTVIEW struct {
+	Hdr       NMHDR
+	IItem     int
+	ISubItem  int
+	UNewState uint
+	UOldState uint
+	UChanged  uint
+	PtAction  POINT
+	LParam    uintptr
+}
+
+type NMLVODSTATECHANGE struct {
+	Hdr       NMHDR
+	IFrom     int
+	ITo       int
+	UNewState uint
+	UOldState uint
+}
+
+type NMLVKEYDOWN struct {
+	Hdr   NMHDR
+	WVKey uint16
+	Flags uint
+}
+
+type LVHITTESTINFO struct {
+	Pt       POINT
+	Flags    uint
+	IItem    int
+	ISubItem int
+	IGroup   int
+}
+
+func INDEXTOSTATEIMAGEMASK(i int) uint {
+	return uint(i) << 12
+}
@@ -69,6 +69,21 @@ const (
 	TVI_SORT  = ^HTREEITEM(0xfffc)
 )
 
+// TVM_GETNEXTITEM flags
+const (
+	TVGN_ROOT            = 0x0000
+	TVGN_NEXT            = 0x0001
+	TVGN_PREVIOUS        = 0x0002
+	TVGN_PARENT          = 0x0003
+	TVGN_CHILD           = 0x0004
+	TVGN_FIRSTVISIBLE    = 0x0005
+	TVGN_NEXTVISIBLE     = 0x0006
+	TVGN_PREVIOUSVISIBLE = 0x0007
+	TVGN_DROPHILITE      = 0x0008
+	TVGN_CARET           = 0x0009
+	TVGN_LASTVISIBLE     = 0x000A
+)
+
 // TVM_EXPAND action flags
 const (
 	TVE_COLLAPSE      = 0x0001
@@ -5,7 +5,6 @@
 package comdlg32
 
 import (
-	"syscall"
 	"unsafe"
 )
 
@@ -233,7 +232,7 @@ func init() {
 }
 
 func CommDlgExtendedError() uint {
-	ret, _, _ := syscall.Syscall(uintptr(commDlgExtendedError),
+	ret, _, _ := Syscall(uintptr(commDlgExtendedError),
 		0,
 		0,
 		0)
@@ -242,7 +241,7 @@ func CommDlgExtendedError() uint {
 }
 
 func GetOpenFileName(lpofn *OPENFILENAME) bool {
-	ret, _, _ := syscall.Syscall(uintptr(getOpenFileName),
+	ret, _, _ := Syscall(uintptr(getOpenFileName),
 		uintptr(unsafe.Pointer(lpofn)),
 		0,
 		0)
@@ -251,7 +250,7 @@ func GetOpenFileName(lpofn *OPENFILENAME) bool {
 }
 
 func GetSaveFileName(lpofn *OPENFILENAME) bool {
-	ret, _, _ := syscall.Syscall(uintptr(getSaveFileName),
+	ret, _, _ := Syscall(uintptr(getSaveFileName),
 		uintptr(unsafe.Pointer(lpofn)),
 		0,
 		0)
@@ -260,7 +259,7 @@ func GetSaveFileName(lpofn *OPENFILENAME) bool {
 }
 
 func PrintDlgEx(lppd *PRINTDLGEX) HRESULT {
-	ret, _, _ := syscall.Syscall(uintptr(printDlgEx),
+	ret, _, _ := Syscall(uintptr(printDlgEx),
 		uintptr(unsafe.Pointer(lppd)),
 		0,
 		0)
@@ -5,7 +5,6 @@
 package gdiplus
 
 import (
-	"syscall"
 	"unsafe"
 )
 
@@ -160,6 +159,11 @@ func init() {
 	gdiplusShutdown = MustGetProcAddress(lib, "GdiplusShutdown")
 	gdiplusStartup = MustGetProcAddress(lib, "GdiplusStartup")
 
+	if lib == 0 {
+		// Not on Windows, see winapi.MustLoadLibrary.
+		return
+	}
+
 	// Startup and remember token for shutdown.
 	var si GdiplusStartupInput
 	si.GdiplusVersion = 1
@@ -170,7 +174,7 @@ func init() {
 
 
 func GdipCreateBitmapFromFile(filename *uint16, bitmap **GpBitmap) GpStatus {
-	ret, _, _ := syscall.Syscall(uintptr(gdipCreateBitmapFromFile),
+	ret, _, _ := Syscall(uintptr(gdipCreateBitmapFromFile),
 		uintptr(unsafe.Pointer(filename)),
 		uintptr(unsafe.Pointer(bitmap)),
 		0)
@@ -179,7 +183,7 @@ func GdipCreateBitmapFromFile(filename *uint16, bitmap **GpBitmap) GpStatus {
 }
 
 func GdipCreateBitmapFromHBITMAP(hbm HBITMAP, hpal HPALETTE, bitmap **GpBitmap) GpStatus {
-	ret, _, _ := syscall.Syscall(uintptr(gdipCreateBitmapFromHBITMAP),
+	ret, _, _ := Syscall(uintptr(gdipCreateBitmapFromHBITMAP),
 		uintptr(hbm),
 		uintptr(hpal),
 		uintptr(unsafe.Pointer(bitmap)))
@@ -188,7 +192,7 @@ func GdipCreateBitmapFromHBITMAP(hbm HBITMAP, hpal HPALETTE, bitmap **GpBitmap)
 }
 
 func GdipCreateHBITMAPFromBitmap(bitmap *GpBitmap, hbmReturn *HBITMAP, background ARGB) GpStatus {
-	ret, _, _ := syscall.Syscall(uintptr(gdipCreateHBITMAPFromBitmap),
+	ret, _, _ := Syscall(uintptr(gdipCreateHBITMAPFromBitmap),
 		uintptr(unsafe.Pointer(bitmap)),
 		uintptr(unsafe.Pointer(hbmReturn)),
 		uintptr(background))
@@ -197,7 +201,7 @@ func GdipCreateHBITMAPFromBitmap(bitmap *GpBitmap, hbmReturn *HBITMAP, backgroun
 }
 
 func GdipDisposeImage(image *GpImage) GpStatus {
-	ret, _, _ := syscall.Syscall(uintptr(gdipDisposeImage),
+	ret, _, _ := Syscall(uintptr(gdipDisposeImage),
 		uintptr(unsafe.Pointer(image)),
 		0,
 		0)
@@ -206,14 +210,14 @@ func GdipDisposeImage(image *GpImage) GpStatus {
 }
 
 func GdiplusShutdown(token uintptr) {
-	syscall.Syscall(uintptr(gdiplusShutdown),
+	Syscall(uintptr(gdiplusShutdown),
 		token,
 		0,
 		0)
 }
 
 func GdiplusStartup(token *uintptr, input *GdiplusStartupInput, output *GdiplusStartupOutput) GpStatus {
-	ret, _, _ := syscall.Syscall(uintptr(gdiplusStartup),
+	ret, _, _ := Syscall(uintptr(gdiplusStartup),
 		uintptr(unsafe.Pointer(token)),
 		uintptr(unsafe.Pointer(input)),
 		uintptr(unsafe.Pointer(output)))
@@ -222,7 +226,7 @@ func GdiplusStartup(token *uintptr, input *GdiplusStartupInput, output *GdiplusS
 }
 
 /*GdipSaveImageToFile(image *GpImage, filename *uint16, clsidEncoder *CLSID, encoderParams *EncoderParameters) GpStatus {
-	ret, _, _ := syscall.Syscall6(uintptr(gdipSaveImageToFile),
+	ret, _, _ := Syscall6(uintptr(gdipSaveImageToFile),
 		uintptr(unsafe.Pointer(image)),
 		uintptr(unsafe.Pointer(filename)),
 		uintptr(unsafe.Pointer(clsidEncoder)),
@@ -5,7 +5,6 @@
 package kernel32
 
 import (
-	"syscall"
 	"unsafe"
 )
 
@@ -44,6 +43,7 @@ var (
 	globalAlloc     uint32
 	globalFree      uint32
 	globalLock      uint32
+	globalSize      uint32
 	globalUnlock    uint32
 	moveMemory      uint32
 	mulDiv          uint32
@@ -69,6 +69,7 @@ func init() {
 	globalAlloc = MustGetProcAddress(lib, "GlobalAlloc")
 	globalFree = MustGetProcAddress(lib, "GlobalFree")
 	globalLock = MustGetProcAddress(lib, "GlobalLock")
+	globalSize = MustGetProcAddress(lib, "GlobalSize")
 	globalUnlock = MustGetProcAddress(lib, "GlobalUnlock")
 	moveMemory = MustGetProcAddress(lib, "RtlMoveMemory")
 	mulDiv = MustGetProcAddress(lib, "MulDiv")
@@ -76,7 +77,7 @@ func init() {
 }
 
 func GetLastError() uint {
-	ret, _, _ := syscall.Syscall(uintptr(setLastError),
+	ret, _, _ := Syscall(uintptr(setLastError),
 		0,
 		0,
 		0)
@@ -85,7 +86,7 @@ func GetLastError() uint {
 }
 
 func GetModuleHandle(lpModuleName *uint16) HINSTANCE {
-	ret, _, _ := syscall.Syscall(uintptr(getModuleHandle),
+	ret, _, _ := Syscall(uintptr(getModuleHandle),
 		uintptr(unsafe.Pointer(lpModuleName)),
 		0,
 		0)
@@ -94,7 +95,7 @@ func GetModuleHandle(lpModuleName *uint16) HINSTANCE {
 }
 
 func GetThreadLocale() LCID {
-	ret, _, _ := syscall.Syscall(uintptr(getThreadLocale),
+	ret, _, _ := Syscall(uintptr(getThreadLocale),
 		0,
 		0,
 		0)
@@ -103,7 +104,7 @@ func GetThreadLocale() LCID {
 }
 
 func GlobalAlloc(uFlags uint, dwBytes uintptr) HGLOBAL {
-	ret, _, _ := syscall.Syscall(uintptr(globalAlloc),
+	ret, _, _ := Syscall(uintptr(globalAlloc),
 		uintptr(uFlags),
 		dwBytes,
 		0)
@@ -112,7 +113,7 @@ func GlobalAlloc(uFlags uint, dwBytes uintptr) HGLOBAL {
 }
 
 func GlobalFree(hMem HGLOBAL) HGLOBAL {
-	ret, _, _ := syscall.Syscall(uintptr(globalFree),
+	ret, _, _ := Syscall(uintptr(globalFree),
 		uintptr(hMem),
 		0,
 		0)
@@ -121,7 +122,7 @@ func GlobalFree(hMem HGLOBAL) HGLOBAL {
 }
 
 func GlobalLock(hMem HGLOBAL) unsafe.Pointer {
-	ret, _, _ := syscall.Syscall(uintptr(globalLock),
+	ret, _, _ := Syscall(uintptr(globalLock),
 		uintptr(hMem),
 		0,
 		0)
@@ -129,8 +130,17 @@ func GlobalLock(hMem HGLOBAL) unsafe.Pointer {
 	return unsafe.Pointer(ret)
 }
 
+func GlobalSize(hMem HGLOBAL) uintptr {
+	ret, _, _ := Syscall(uintptr(globalSize),
+		uintptr(hMem),
+		0,
+		0)
+
+	return ret
+}
+
 func GlobalUnlock(hMem HGLOBAL) bool {
-	ret, _, _ := syscall.Syscall(uintptr(globalUnlock),
+	ret, _, _ := Syscall(uintptr(globalUnlock),
 		uintptr(hMem),
 		0,
 		0)
@@ -139,14 +149,14 @@ func GlobalUnlock(hMem HGLOBAL) bool {
 }
 
 func MoveMemory(destination, source unsafe.Pointer, length uintptr) {
-	syscall.Syscall(uintptr(moveMemory),
+	Syscall(uintptr(moveMemory),
 		uintptr(unsafe.Pointer(destination)),
 		uintptr(source),
 		uintptr(length))
 }
 
 func MulDiv(nNumber, nNumerator, nDenominator int) int {
-	ret, _, _ := syscall.Syscall(uintptr(mulDiv),
+	ret, _, _ := Syscall(uintptr(mulDiv),
 		uintptr(nNumber),
 		uintptr(nNumerator),
 		uintptr(nDenominator))
@@ -155,7 +165,7 @@ func MulDiv(nNumber, nNumerator, nDenominator int) int {
 }
 
 func SetLastError(dwErrorCode uint) {
-	syscall.Syscall(uintptr(setLastError),
+	Syscall(uintptr(setLastError),
 		uintptr(dwErrorCode),
 		0,
 		0)
@@ -5,17 +5,20 @@
 package shell32
 
 import (
-	"syscall"
 	"unsafe"
 )
 
 import (
 	. "walk/winapi"
+	. "walk/winapi/gdi32"
+	. "walk/winapi/kernel32"
 	. "walk/winapi/user32"
 )
 
 type CSIDL uint32
 
+type HDROP HANDLE
+
 const (
 	CSIDL_DESKTOP                 = 0x00
 	CSIDL_INTERNET                = 0x01
@@ -86,6 +89,10 @@ var (
 	lib uint32
 
 	// Functions
+	dragAcceptFiles        uint32
+	dragFinish             uint32
+	dragQueryFile          uint32
+	dragQueryPoint         uint32
 	shGetSpecialFolderPath uint32
 )
 
@@ -94,11 +101,50 @@ func init() {
 	lib = MustLoadLibrary("shell32.dll")
 
 	// Functions
+	dragAcceptFiles = MustGetProcAddress(lib, "DragAcceptFiles")
+	dragFinish = MustGetProcAddress(lib, "DragFinish")
+	dragQueryFile = MustGetProcAddress(lib, "DragQueryFileW")
+	dragQueryPoint = MustGetProcAddress(lib, "DragQueryPoint")
 	shGetSpecialFolderPath = MustGetProcAddress(lib, "SHGetSpecialFolderPathW")
 }
 
+func DragAcceptFiles(hWnd HWND, fAccept bool) {
+	Syscall(uintptr(dragAcceptFiles),
+		uintptr(hWnd),
+		uintptr(BoolToBOOL(fAccept)),
+		0)
+}
+
+func DragQueryFile(hDrop HDROP, iFile uint, lpszFile *uint16, cch uint) uint {
+	ret, _, _ := Syscall6(uintptr(dragQueryFile),
+		uintptr(hDrop),
+		uintptr(iFile),
+		uintptr(unsafe.Pointer(lpszFile)),
+		uintptr(cch),
+		0,
+		0)
+
+	return uint(ret)
+}
+
+func DragQueryPoint(hDrop HDROP, lppt *POINT) bool {
+	ret, _, _ := Syscall(uintptr(dragQueryPoint),
+		uintptr(hDrop),
+		uintptr(unsafe.Pointer(lppt)),
+		0)
+
+	return ret != 0
+}
+
+func DragFinish(hDrop HDROP) {
+	Syscall(uintptr(dragFinish),
+		uintptr(hDrop),
+		0,
+		0)
+}
+
 func ShGetSpecialFolderPath(hwndOwner HWND, lpszPath *uint16, csidl CSIDL, fCreate bool) bool {
-	ret, _, _ := syscall.Syscall6(uintptr(shGetSpecialFolderPath),
+	ret, _, _ := Syscall6(uintptr(shGetSpecialFolderPath),
 		uintptr(hwndOwner),
 		uintptr(unsafe.Pointer(lpszPath)),
 		uintptr(csidl),
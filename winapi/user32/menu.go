@@ -8,6 +8,12 @@ import (
 	. "walk/winapi/gdi32"
 )
 
+// RemoveMenu flags
+const (
+	MF_BYCOMMAND  = 0x00000000
+	MF_BYPOSITION = 0x00000400
+)
+
 // Constants for MENUITEMINFO.fMask
 const (
 	MIIM_STATE      = 1
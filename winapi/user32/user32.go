@@ -5,7 +5,6 @@
 package user32
 
 import (
-	"syscall"
 	"unsafe"
 )
 
@@ -276,6 +275,11 @@ const (
 	IDC_SIZE        = 32640
 )
 
+// WM_NCHITTEST and WM_SETCURSOR hit test codes
+const (
+	HTCLIENT = 1
+)
+
 // ShowWindow constants
 const (
 	SW_HIDE            = 0
@@ -734,6 +738,38 @@ const (
 	WM_MOUSELEAVE             = 0X2A3
 )
 
+// Mouse message key state flags
+const (
+	MK_LBUTTON  = 0x0001
+	MK_RBUTTON  = 0x0002
+	MK_SHIFT    = 0x0004
+	MK_CONTROL  = 0x0008
+	MK_MBUTTON  = 0x0010
+	MK_XBUTTON1 = 0x0020
+	MK_XBUTTON2 = 0x0040
+)
+
+const WHEEL_DELTA = 120
+
+// TrackMouseEvent flags
+const (
+	TME_HOVER     = 0x00000001
+	TME_LEAVE     = 0x00000002
+	TME_NONCLIENT = 0x00000010
+	TME_QUERY     = 0x40000000
+	TME_CANCEL    = 0x80000000
+)
+
+// Standard clipboard formats
+const (
+	CF_TEXT        = 1
+	CF_BITMAP      = 2
+	CF_DIB         = 8
+	CF_UNICODETEXT = 13
+	CF_HDROP       = 15
+	CF_DIBV5       = 17
+)
+
 // TrackPopupMenu[Ex] flags
 const (
 	TPM_CENTERALIGN     = 0x0004
@@ -755,6 +791,13 @@ const (
 	TPM_VERTICAL        = 0x0040
 )
 
+// PeekMessage wRemoveMsg value
+const (
+	PM_NOREMOVE = 0x0000
+	PM_REMOVE   = 0x0001
+	PM_NOYIELD  = 0x0002
+)
+
 // WINDOWPLACEMENT flags
 const (
 	WPF_ASYNCWINDOWPLACEMENT = 0x0004
@@ -906,6 +949,13 @@ type MINMAXINFO struct {
 	PtMaxTrackSize POINT
 }
 
+type TRACKMOUSEEVENT struct {
+	CbSize      uint
+	DwFlags     uint
+	HwndTrack   HWND
+	DwHoverTime uint
+}
+
 type NONCLIENTMETRICS struct {
 	CbSize           uint
 	IBorderWidth     int
@@ -937,52 +987,73 @@ var (
 	lib uint32
 
 	// Functions
-	beginPaint           uint32
-	callWindowProc       uint32
-	createMenu           uint32
-	createPopupMenu      uint32
-	createWindowEx       uint32
-	defWindowProc        uint32
-	destroyMenu          uint32
-	destroyWindow        uint32
-	dispatchMessage      uint32
-	drawMenuBar          uint32
-	drawTextEx           uint32
-	endPaint             uint32
-	getAncestor          uint32
-	getClientRect        uint32
-	getDC                uint32
-	getMenuInfo          uint32
-	getMessage           uint32
-	getWindowLong        uint32
-	getWindowPlacement   uint32
-	getWindowRect        uint32
-	insertMenuItem       uint32
-	invalidateRect       uint32
-	isDialogMessage      uint32
-	loadCursor           uint32
-	loadIcon             uint32
-	loadImage            uint32
-	messageBox           uint32
-	moveWindow           uint32
-	postMessage          uint32
-	postQuitMessage      uint32
-	registerClassEx      uint32
-	releaseDC            uint32
-	screenToClient       uint32
-	sendMessage          uint32
-	setFocus             uint32
-	setMenu              uint32
-	setMenuInfo          uint32
-	setMenuItemInfo      uint32
-	setParent            uint32
-	setWindowLong        uint32
-	setWindowPlacement   uint32
-	setWindowPos         uint32
-	showWindow           uint32
-	systemParametersInfo uint32
-	trackPopupMenuEx     uint32
-	translateMessage     uint32
+	beginPaint                 uint32
+	callWindowProc             uint32
+	clientToScreen             uint32
+	closeClipboard             uint32
+	createMenu                 uint32
+	createPopupMenu            uint32
+	createWindowEx             uint32
+	defWindowProc              uint32
+	destroyMenu                uint32
+	destroyWindow              uint32
+	dispatchMessage            uint32
+	drawMenuBar                uint32
+	drawTextEx                 uint32
+	emptyClipboard             uint32
+	endPaint                   uint32
+	getAncestor                uint32
+	getCapture                 uint32
+	getClientRect              uint32
+	getClipboardData           uint32
+	getClipboardSequenceNumber uint32
+	getDC                      uint32
+	getFocus                   uint32
+	getKeyState                uint32
+	getMenuInfo                uint32
+	getMessage                 uint32
+	getWindowLong              uint32
+	getWindowPlacement         uint32
+	getWindowRect              uint32
+	insertMenuItem             uint32
+	invalidateRect             uint32
+	isClipboardFormatAvailable uint32
+	isDialogMessage            uint32
+	killTimer                  uint32
+	loadCursor                 uint32
+	loadIcon                   uint32
+	loadImage                  uint32
+	messageBox                 uint32
+	moveWindow                 uint32
+	openClipboard              uint32
+	peekMessage                uint32
+	postMessage                uint32
+	postQuitMessage            uint32
+	registerClassEx            uint32
+	registerClipboardFormat    uint32
+	releaseCapture             uint32
+	releaseDC                  uint32
+	removeMenu                 uint32
+	screenToClient             uint32
+	sendMessage                uint32
+	setCapture                 uint32
+	setClipboardData           uint32
+	setCursor                  uint32
+	setFocus                   uint32
+	setMenu                    uint32
+	setMenuInfo                uint32
+	setMenuItemInfo            uint32
+	setParent                  uint32
+	setTimer                   uint32
+	setWindowLong              uint32
+	setWindowPlacement         uint32
+	setWindowPos               uint32
+	showWindow                 uint32
+	systemParametersInfo       uint32
+	trackMouseEvent            uint32
+	trackPopupMenuEx           uint32
+	translateMessage           uint32
+	windowFromPoint            uint32
 )
 
 func init() {
@@ -992,6 +1063,8 @@ func init() {
 	// Functions
 	beginPaint = MustGetProcAddress(lib, "BeginPaint")
 	callWindowProc = MustGetProcAddress(lib, "CallWindowProcW")
+	clientToScreen = MustGetProcAddress(lib, "ClientToScreen")
+	closeClipboard = MustGetProcAddress(lib, "CloseClipboard")
 	createMenu = MustGetProcAddress(lib, "CreateMenu")
 	createPopupMenu = MustGetProcAddress(lib, "CreatePopupMenu")
 	createWindowEx = MustGetProcAddress(lib, "CreateWindowExW")
@@ -1001,10 +1074,16 @@ func init() {
 	dispatchMessage = MustGetProcAddress(lib, "DispatchMessageW")
 	drawMenuBar = MustGetProcAddress(lib, "DrawMenuBar")
 	drawTextEx = MustGetProcAddress(lib, "DrawTextExW")
+	emptyClipboard = MustGetProcAddress(lib, "EmptyClipboard")
 	endPaint = MustGetProcAddress(lib, "EndPaint")
 	getAncestor = MustGetProcAddress(lib, "GetAncestor")
+	getCapture = MustGetProcAddress(lib, "GetCapture")
 	getClientRect = MustGetProcAddress(lib, "GetClientRect")
+	getClipboardData = MustGetProcAddress(lib, "GetClipboardData")
+	getClipboardSequenceNumber = MustGetProcAddress(lib, "GetClipboardSequenceNumber")
 	getDC = MustGetProcAddress(lib, "GetDC")
+	getFocus = MustGetProcAddress(lib, "GetFocus")
+	getKeyState = MustGetProcAddress(lib, "GetKeyState")
 	getMenuInfo = MustGetProcAddress(lib, "GetMenuInfo")
 	getMessage = MustGetProcAddress(lib, "GetMessageW")
 	getWindowLong = MustGetProcAddress(lib, "GetWindowLongW")
@@ -1012,34 +1091,47 @@ func init() {
 	getWindowRect = MustGetProcAddress(lib, "GetWindowRect")
 	insertMenuItem = MustGetProcAddress(lib, "InsertMenuItemW")
 	invalidateRect = MustGetProcAddress(lib, "InvalidateRect")
+	isClipboardFormatAvailable = MustGetProcAddress(lib, "IsClipboardFormatAvailable")
 	isDialogMessage = MustGetProcAddress(lib, "IsDialogMessageW")
+	killTimer = MustGetProcAddress(lib, "KillTimer")
 	loadCursor = MustGetProcAddress(lib, "LoadCursorW")
 	loadIcon = MustGetProcAddress(lib, "LoadIconW")
 	loadImage = MustGetProcAddress(lib, "LoadImageW")
 	messageBox = MustGetProcAddress(lib, "MessageBoxW")
 	moveWindow = MustGetProcAddress(lib, "MoveWindow")
+	openClipboard = MustGetProcAddress(lib, "OpenClipboard")
+	peekMessage = MustGetProcAddress(lib, "PeekMessageW")
 	postMessage = MustGetProcAddress(lib, "PostMessageW")
 	postQuitMessage = MustGetProcAddress(lib, "PostQuitMessage")
 	registerClassEx = MustGetProcAddress(lib, "RegisterClassExW")
+	registerClipboardFormat = MustGetProcAddress(lib, "RegisterClipboardFormatW")
+	releaseCapture = MustGetProcAddress(lib, "ReleaseCapture")
 	releaseDC = MustGetProcAddress(lib, "ReleaseDC")
+	removeMenu = MustGetProcAddress(lib, "RemoveMenu")
 	screenToClient = MustGetProcAddress(lib, "ScreenToClient")
 	sendMessage = MustGetProcAddress(lib, "SendMessageW")
+	setCapture = MustGetProcAddress(lib, "SetCapture")
+	setClipboardData = MustGetProcAddress(lib, "SetClipboardData")
+	setCursor = MustGetProcAddress(lib, "SetCursor")
 	setFocus = MustGetProcAddress(lib, "SetFocus")
 	setMenu = MustGetProcAddress(lib, "SetMenu")
 	setMenuInfo = MustGetProcAddress(lib, "SetMenuInfo")
 	setMenuItemInfo = MustGetProcAddress(lib, "SetMenuItemInfoW")
 	setParent = MustGetProcAddress(lib, "SetParent")
+	setTimer = MustGetProcAddress(lib, "SetTimer")
 	setWindowLong = MustGetProcAddress(lib, "SetWindowLongW")
 	setWindowPlacement = MustGetProcAddress(lib, "SetWindowPlacement")
 	setWindowPos = MustGetProcAddress(lib, "SetWindowPos")
 	showWindow = MustGetProcAddress(lib, "ShowWindow")
 	systemParametersInfo = MustGetProcAddress(lib, "SystemParametersInfoW")
+	trackMouseEvent = MustGetProcAddress(lib, "TrackMouseEvent")
 	trackPopupMenuEx = MustGetProcAddress(lib, "TrackPopupMenuEx")
 	translateMessage = MustGetProcAddress(lib, "TranslateMessage")
+	windowFromPoint = MustGetProcAddress(lib, "WindowFromPoint")
 }
 
 func BeginPaint(hwnd HWND, lpPaint *PAINTSTRUCT) HDC {
-	ret, _, _ := syscall.Syscall(uintptr(beginPaint),
+	ret, _, _ := Syscall(uintptr(beginPaint),
 		uintptr(hwnd),
 		uintptr(unsafe.Pointer(lpPaint)),
 		0)
@@ -1048,7 +1140,7 @@ func BeginPaint(hwnd HWND, lpPaint *PAINTSTRUCT) HDC {
 }
 
 func CallWindowProc(lpPrevWndFunc uintptr, hWnd HWND, Msg uint, wParam, lParam uintptr) uintptr {
-	ret, _, _ := syscall.Syscall6(uintptr(callWindowProc),
+	ret, _, _ := Syscall6(uintptr(callWindowProc),
 		lpPrevWndFunc,
 		uintptr(hWnd),
 		uintptr(Msg),
@@ -1059,8 +1151,26 @@ func CallWindowProc(lpPrevWndFunc uintptr, hWnd HWND, Msg uint, wParam, lParam u
 	return ret
 }
 
+func ClientToScreen(hWnd HWND, point *POINT) bool {
+	ret, _, _ := Syscall(uintptr(clientToScreen),
+		uintptr(hWnd),
+		uintptr(unsafe.Pointer(point)),
+		0)
+
+	return ret != 0
+}
+
+func CloseClipboard() bool {
+	ret, _, _ := Syscall(uintptr(closeClipboard),
+		0,
+		0,
+		0)
+
+	return ret != 0
+}
+
 func CreateMenu() HMENU {
-	ret, _, _ := syscall.Syscall(uintptr(createMenu),
+	ret, _, _ := Syscall(uintptr(createMenu),
 		0,
 		0,
 		0)
@@ -1069,7 +1179,7 @@ func CreateMenu() HMENU {
 }
 
 func CreatePopupMenu() HMENU {
-	ret, _, _ := syscall.Syscall(uintptr(createPopupMenu),
+	ret, _, _ := Syscall(uintptr(createPopupMenu),
 		0,
 		0,
 		0)
@@ -1078,7 +1188,7 @@ func CreatePopupMenu() HMENU {
 }
 
 func CreateWindowEx(dwExStyle uint, lpClassName, lpWindowName *uint16, dwStyle uint, x, y, nWidth, nHeight int, hWndParent HWND, hMenu HMENU, hInstance HINSTANCE, lpParam unsafe.Pointer) HWND {
-	ret, _, _ := syscall.Syscall12(uintptr(createWindowEx),
+	ret, _, _ := Syscall12(uintptr(createWindowEx),
 		uintptr(dwExStyle),
 		uintptr(unsafe.Pointer(lpClassName)),
 		uintptr(unsafe.Pointer(lpWindowName)),
@@ -1096,7 +1206,7 @@ func CreateWindowEx(dwExStyle uint, lpClassName, lpWindowName *uint16, dwStyle u
 }
 
 func DefWindowProc(hWnd HWND, Msg uint, wParam, lParam uintptr) uintptr {
-	ret, _, _ := syscall.Syscall6(uintptr(defWindowProc),
+	ret, _, _ := Syscall6(uintptr(defWindowProc),
 		uintptr(hWnd),
 		uintptr(Msg),
 		wParam,
@@ -1108,7 +1218,7 @@ func DefWindowProc(hWnd HWND, Msg uint, wParam, lParam uintptr) uintptr {
 }
 
 func DestroyMenu(hMenu HMENU) bool {
-	ret, _, _ := syscall.Syscall(uintptr(destroyMenu),
+	ret, _, _ := Syscall(uintptr(destroyMenu),
 		uintptr(hMenu),
 		0,
 		0)
@@ -1117,7 +1227,7 @@ func DestroyMenu(hMenu HMENU) bool {
 }
 
 func DestroyWindow(hWnd HWND) bool {
-	ret, _, _ := syscall.Syscall(uintptr(destroyWindow),
+	ret, _, _ := Syscall(uintptr(destroyWindow),
 		uintptr(hWnd),
 		0,
 		0)
@@ -1126,7 +1236,7 @@ func DestroyWindow(hWnd HWND) bool {
 }
 
 func DispatchMessage(msg *MSG) uintptr {
-	ret, _, _ := syscall.Syscall(uintptr(dispatchMessage),
+	ret, _, _ := Syscall(uintptr(dispatchMessage),
 		uintptr(unsafe.Pointer(msg)),
 		0,
 		0)
@@ -1135,7 +1245,7 @@ func DispatchMessage(msg *MSG) uintptr {
 }
 
 func DrawMenuBar(hWnd HWND) bool {
-	ret, _, _ := syscall.Syscall(uintptr(drawMenuBar),
+	ret, _, _ := Syscall(uintptr(drawMenuBar),
 		uintptr(hWnd),
 		0,
 		0)
@@ -1144,7 +1254,7 @@ func DrawMenuBar(hWnd HWND) bool {
 }
 
 func DrawTextEx(hdc HDC, lpchText *uint16, cchText int, lprc *RECT, dwDTFormat uint, lpDTParams *DRAWTEXTPARAMS) int {
-	ret, _, _ := syscall.Syscall6(uintptr(drawTextEx),
+	ret, _, _ := Syscall6(uintptr(drawTextEx),
 		uintptr(hdc),
 		uintptr(unsafe.Pointer(lpchText)),
 		uintptr(cchText),
@@ -1155,8 +1265,17 @@ func DrawTextEx(hdc HDC, lpchText *uint16, cchText int, lprc *RECT, dwDTFormat u
 	return int(ret)
 }
 
+func EmptyClipboard() bool {
+	ret, _, _ := Syscall(uintptr(emptyClipboard),
+		0,
+		0,
+		0)
+
+	return ret != 0
+}
+
 func EndPaint(hwnd HWND, lpPaint *PAINTSTRUCT) bool {
-	ret, _, _ := syscall.Syscall(uintptr(endPaint),
+	ret, _, _ := Syscall(uintptr(endPaint),
 		uintptr(hwnd),
 		uintptr(unsafe.Pointer(lpPaint)),
 		0)
@@ -1165,7 +1284,7 @@ func EndPaint(hwnd HWND, lpPaint *PAINTSTRUCT) bool {
 }
 
 func GetAncestor(hWnd HWND, gaFlags uint) HWND {
-	ret, _, _ := syscall.Syscall(uintptr(getAncestor),
+	ret, _, _ := Syscall(uintptr(getAncestor),
 		uintptr(hWnd),
 		uintptr(gaFlags),
 		0)
@@ -1173,8 +1292,17 @@ func GetAncestor(hWnd HWND, gaFlags uint) HWND {
 	return HWND(ret)
 }
 
+func GetCapture() HWND {
+	ret, _, _ := Syscall(uintptr(getCapture),
+		0,
+		0,
+		0)
+
+	return HWND(ret)
+}
+
 func GetClientRect(hWnd HWND, rect *RECT) bool {
-	ret, _, _ := syscall.Syscall(uintptr(getClientRect),
+	ret, _, _ := Syscall(uintptr(getClientRect),
 		uintptr(hWnd),
 		uintptr(unsafe.Pointer(rect)),
 		0)
@@ -1182,8 +1310,26 @@ func GetClientRect(hWnd HWND, rect *RECT) bool {
 	return ret != 0
 }
 
+func GetClipboardData(uFormat uint) HANDLE {
+	ret, _, _ := Syscall(uintptr(getClipboardData),
+		uintptr(uFormat),
+		0,
+		0)
+
+	return HANDLE(ret)
+}
+
+func GetClipboardSequenceNumber() uint {
+	ret, _, _ := Syscall(uintptr(getClipboardSequenceNumber),
+		0,
+		0,
+		0)
+
+	return uint(ret)
+}
+
 func GetDC(hWnd HWND) HDC {
-	ret, _, _ := syscall.Syscall(uintptr(getDC),
+	ret, _, _ := Syscall(uintptr(getDC),
 		uintptr(hWnd),
 		0,
 		0)
@@ -1191,8 +1337,26 @@ func GetDC(hWnd HWND) HDC {
 	return HDC(ret)
 }
 
+func GetFocus() HWND {
+	ret, _, _ := Syscall(uintptr(getFocus),
+		0,
+		0,
+		0)
+
+	return HWND(ret)
+}
+
+func GetKeyState(nVirtKey int) uint16 {
+	ret, _, _ := Syscall(uintptr(getKeyState),
+		uintptr(nVirtKey),
+		0,
+		0)
+
+	return uint16(ret)
+}
+
 func GetMenuInfo(hmenu HMENU, lpcmi *MENUINFO) bool {
-	ret, _, _ := syscall.Syscall(uintptr(getMenuInfo),
+	ret, _, _ := Syscall(uintptr(getMenuInfo),
 		uintptr(hmenu),
 		uintptr(unsafe.Pointer(lpcmi)),
 		0)
@@ -1201,7 +1365,7 @@ func GetMenuInfo(hmenu HMENU, lpcmi *MENUINFO) bool {
 }
 
 func GetMessage(msg *MSG, hWnd HWND, msgFilterMin, msgFilterMax uint) BOOL {
-	ret, _, _ := syscall.Syscall6(uintptr(getMessage),
+	ret, _, _ := Syscall6(uintptr(getMessage),
 		uintptr(unsafe.Pointer(msg)),
 		uintptr(hWnd),
 		uintptr(msgFilterMin),
@@ -1213,7 +1377,7 @@ func GetMessage(msg *MSG, hWnd HWND, msgFilterMin, msgFilterMax uint) BOOL {
 }
 
 func GetWindowLong(hWnd HWND, index int) int {
-	ret, _, _ := syscall.Syscall(uintptr(getWindowLong),
+	ret, _, _ := Syscall(uintptr(getWindowLong),
 		uintptr(hWnd),
 		uintptr(index),
 		0)
@@ -1222,7 +1386,7 @@ func GetWindowLong(hWnd HWND, index int) int {
 }
 
 func GetWindowPlacement(hWnd HWND, lpwndpl *WINDOWPLACEMENT) bool {
-	ret, _, _ := syscall.Syscall(uintptr(getWindowPlacement),
+	ret, _, _ := Syscall(uintptr(getWindowPlacement),
 		uintptr(hWnd),
 		uintptr(unsafe.Pointer(lpwndpl)),
 		0)
@@ -1231,7 +1395,7 @@ func GetWindowPlacement(hWnd HWND, lpwndpl *WINDOWPLACEMENT) bool {
 }
 
 func GetWindowRect(hWnd HWND, rect *RECT) bool {
-	ret, _, _ := syscall.Syscall(uintptr(getWindowRect),
+	ret, _, _ := Syscall(uintptr(getWindowRect),
 		uintptr(hWnd),
 		uintptr(unsafe.Pointer(rect)),
 		0)
@@ -1240,7 +1404,7 @@ func GetWindowRect(hWnd HWND, rect *RECT) bool {
 }
 
 func InsertMenuItem(hMenu HMENU, uItem uint, fByPosition bool, lpmii *MENUITEMINFO) bool {
-	ret, _, _ := syscall.Syscall6(uintptr(insertMenuItem),
+	ret, _, _ := Syscall6(uintptr(insertMenuItem),
 		uintptr(hMenu),
 		uintptr(uItem),
 		uintptr(BoolToBOOL(fByPosition)),
@@ -1252,7 +1416,7 @@ func InsertMenuItem(hMenu HMENU, uItem uint, fByPosition bool, lpmii *MENUITEMIN
 }
 
 func InvalidateRect(hWnd HWND, lpRect *RECT, bErase bool) bool {
-	ret, _, _ := syscall.Syscall(uintptr(invalidateRect),
+	ret, _, _ := Syscall(uintptr(invalidateRect),
 		uintptr(hWnd),
 		uintptr(unsafe.Pointer(lpRect)),
 		uintptr(BoolToBOOL(bErase)))
@@ -1260,8 +1424,17 @@ func InvalidateRect(hWnd HWND, lpRect *RECT, bErase bool) bool {
 	return ret != 0
 }
 
+func IsClipboardFormatAvailable(format uint) bool {
+	ret, _, _ := Syscall(uintptr(isClipboardFormatAvailable),
+		uintptr(format),
+		0,
+		0)
+
+	return ret != 0
+}
+
 func IsDialogMessage(hWnd HWND, msg *MSG) bool {
-	ret, _, _ := syscall.Syscall(uintptr(isDialogMessage),
+	ret, _, _ := Syscall(uintptr(isDialogMessage),
 		uintptr(hWnd),
 		uintptr(unsafe.Pointer(msg)),
 		0)
@@ -1269,8 +1442,17 @@ func IsDialogMessage(hWnd HWND, msg *MSG) bool {
 	return ret != 0
 }
 
+func KillTimer(hWnd HWND, uIDEvent uintptr) bool {
+	ret, _, _ := Syscall(uintptr(killTimer),
+		uintptr(hWnd),
+		uIDEvent,
+		0)
+
+	return ret != 0
+}
+
 func LoadCursor(hInstance HINSTANCE, lpCursorName *uint16) HCURSOR {
-	ret, _, _ := syscall.Syscall(uintptr(loadCursor),
+	ret, _, _ := Syscall(uintptr(loadCursor),
 		uintptr(hInstance),
 		uintptr(unsafe.Pointer(lpCursorName)),
 		0)
@@ -1279,7 +1461,7 @@ func LoadCursor(hInstance HINSTANCE, lpCursorName *uint16) HCURSOR {
 }
 
 func LoadIcon(hInstance HINSTANCE, lpIconName *uint16) HICON {
-	ret, _, _ := syscall.Syscall(uintptr(loadIcon),
+	ret, _, _ := Syscall(uintptr(loadIcon),
 		uintptr(hInstance),
 		uintptr(unsafe.Pointer(lpIconName)),
 		0)
@@ -1288,7 +1470,7 @@ func LoadIcon(hInstance HINSTANCE, lpIconName *uint16) HICON {
 }
 
 func LoadImage(hinst HINSTANCE, lpszName *uint16, uType uint, cxDesired, cyDesired int, fuLoad uint) HANDLE {
-	ret, _, _ := syscall.Syscall6(uintptr(loadImage),
+	ret, _, _ := Syscall6(uintptr(loadImage),
 		uintptr(hinst),
 		uintptr(unsafe.Pointer(lpszName)),
 		uintptr(uType),
@@ -1300,7 +1482,7 @@ func LoadImage(hinst HINSTANCE, lpszName *uint16, uType uint, cxDesired, cyDesir
 }
 
 func MessageBox(hWnd HWND, lpText, lpCaption *uint16, uType uint) int {
-	ret, _, _ := syscall.Syscall6(uintptr(messageBox),
+	ret, _, _ := Syscall6(uintptr(messageBox),
 		uintptr(hWnd),
 		uintptr(unsafe.Pointer(lpText)),
 		uintptr(unsafe.Pointer(lpCaption)),
@@ -1312,7 +1494,7 @@ func MessageBox(hWnd HWND, lpText, lpCaption *uint16, uType uint) int {
 }
 
 func MoveWindow(hWnd HWND, x, y, width, height int, repaint bool) bool {
-	ret, _, _ := syscall.Syscall6(uintptr(moveWindow),
+	ret, _, _ := Syscall6(uintptr(moveWindow),
 		uintptr(hWnd),
 		uintptr(x),
 		uintptr(y),
@@ -1323,8 +1505,29 @@ func MoveWindow(hWnd HWND, x, y, width, height int, repaint bool) bool {
 	return ret != 0
 }
 
+func OpenClipboard(hWndNewOwner HWND) bool {
+	ret, _, _ := Syscall(uintptr(openClipboard),
+		uintptr(hWndNewOwner),
+		0,
+		0)
+
+	return ret != 0
+}
+
+func PeekMessage(lpMsg *MSG, hWnd HWND, wMsgFilterMin, wMsgFilterMax, wRemoveMsg uint) bool {
+	ret, _, _ := Syscall6(uintptr(peekMessage),
+		uintptr(unsafe.Pointer(lpMsg)),
+		uintptr(hWnd),
+		uintptr(wMsgFilterMin),
+		uintptr(wMsgFilterMax),
+		uintptr(wRemoveMsg),
+		0)
+
+	return ret != 0
+}
+
 func PostMessage(hWnd HWND, msg uint, wParam, lParam uintptr) uintptr {
-	ret, _, _ := syscall.Syscall6(uintptr(postMessage),
+	ret, _, _ := Syscall6(uintptr(postMessage),
 		uintptr(hWnd),
 		uintptr(msg),
 		wParam,
@@ -1336,14 +1539,23 @@ func PostMessage(hWnd HWND, msg uint, wParam, lParam uintptr) uintptr {
 }
 
 func PostQuitMessage(exitCode int) {
-	syscall.Syscall(uintptr(postQuitMessage),
+	Syscall(uintptr(postQuitMessage),
 		uintptr(exitCode),
 		0,
 		0)
 }
 
+func RegisterClipboardFormat(lpszFormat *uint16) uint {
+	ret, _, _ := Syscall(uintptr(registerClipboardFormat),
+		uintptr(unsafe.Pointer(lpszFormat)),
+		0,
+		0)
+
+	return uint(ret)
+}
+
 func RegisterClassEx(windowClass *WNDCLASSEX) ATOM {
-	ret, _, _ := syscall.Syscall(uintptr(registerClassEx),
+	ret, _, _ := Syscall(uintptr(registerClassEx),
 		uintptr(unsafe.Pointer(windowClass)),
 		0,
 		0)
@@ -1351,8 +1563,17 @@ func RegisterClassEx(windowClass *WNDCLASSEX) ATOM {
 	return ATOM(ret)
 }
 
+func ReleaseCapture() bool {
+	ret, _, _ := Syscall(uintptr(releaseCapture),
+		0,
+		0,
+		0)
+
+	return ret != 0
+}
+
 func ReleaseDC(hWnd HWND, hDC HDC) bool {
-	ret, _, _ := syscall.Syscall(uintptr(releaseDC),
+	ret, _, _ := Syscall(uintptr(releaseDC),
 		uintptr(hWnd),
 		uintptr(hDC),
 		0)
@@ -1360,8 +1581,17 @@ func ReleaseDC(hWnd HWND, hDC HDC) bool {
 	return ret != 0
 }
 
+func RemoveMenu(hMenu HMENU, uPosition, uFlags uint) bool {
+	ret, _, _ := Syscall(uintptr(removeMenu),
+		uintptr(hMenu),
+		uintptr(uPosition),
+		uintptr(uFlags))
+
+	return ret != 0
+}
+
 func ScreenToClient(hWnd HWND, point *POINT) bool {
-	ret, _, _ := syscall.Syscall(uintptr(screenToClient),
+	ret, _, _ := Syscall(uintptr(screenToClient),
 		uintptr(hWnd),
 		uintptr(unsafe.Pointer(point)),
 		0)
@@ -1370,7 +1600,7 @@ func ScreenToClient(hWnd HWND, point *POINT) bool {
 }
 
 func SendMessage(hWnd HWND, msg uint, wParam, lParam uintptr) uintptr {
-	ret, _, _ := syscall.Syscall6(uintptr(sendMessage),
+	ret, _, _ := Syscall6(uintptr(sendMessage),
 		uintptr(hWnd),
 		uintptr(msg),
 		wParam,
@@ -1381,8 +1611,35 @@ func SendMessage(hWnd HWND, msg uint, wParam, lParam uintptr) uintptr {
 	return ret
 }
 
+func SetCapture(hWnd HWND) HWND {
+	ret, _, _ := Syscall(uintptr(setCapture),
+		uintptr(hWnd),
+		0,
+		0)
+
+	return HWND(ret)
+}
+
+func SetCursor(hCursor HCURSOR) HCURSOR {
+	ret, _, _ := Syscall(uintptr(setCursor),
+		uintptr(hCursor),
+		0,
+		0)
+
+	return HCURSOR(ret)
+}
+
+func SetClipboardData(uFormat uint, hMem HANDLE) HANDLE {
+	ret, _, _ := Syscall(uintptr(setClipboardData),
+		uintptr(uFormat),
+		uintptr(hMem),
+		0)
+
+	return HANDLE(ret)
+}
+
 func SetFocus(hWnd HWND) HWND {
-	ret, _, _ := syscall.Syscall(uintptr(setFocus),
+	ret, _, _ := Syscall(uintptr(setFocus),
 		uintptr(hWnd),
 		0,
 		0)
@@ -1391,7 +1648,7 @@ func SetFocus(hWnd HWND) HWND {
 }
 
 func SetMenu(hWnd HWND, hMenu HMENU) bool {
-	ret, _, _ := syscall.Syscall(uintptr(setMenu),
+	ret, _, _ := Syscall(uintptr(setMenu),
 		uintptr(hWnd),
 		uintptr(hMenu),
 		0)
@@ -1400,7 +1657,7 @@ func SetMenu(hWnd HWND, hMenu HMENU) bool {
 }
 
 func SetMenuInfo(hmenu HMENU, lpcmi *MENUINFO) bool {
-	ret, _, _ := syscall.Syscall(uintptr(setMenuInfo),
+	ret, _, _ := Syscall(uintptr(setMenuInfo),
 		uintptr(hmenu),
 		uintptr(unsafe.Pointer(lpcmi)),
 		0)
@@ -1409,7 +1666,7 @@ func SetMenuInfo(hmenu HMENU, lpcmi *MENUINFO) bool {
 }
 
 func SetMenuItemInfo(hMenu HMENU, uItem uint, fByPosition bool, lpmii *MENUITEMINFO) bool {
-	ret, _, _ := syscall.Syscall6(uintptr(setMenuItemInfo),
+	ret, _, _ := Syscall6(uintptr(setMenuItemInfo),
 		uintptr(hMenu),
 		uintptr(uItem),
 		uintptr(BoolToBOOL(fByPosition)),
@@ -1421,7 +1678,7 @@ func SetMenuItemInfo(hMenu HMENU, uItem uint, fByPosition bool, lpmii *MENUITEMI
 }
 
 func SetParent(hWnd HWND, parentHWnd HWND) HWND {
-	ret, _, _ := syscall.Syscall(uintptr(setParent),
+	ret, _, _ := Syscall(uintptr(setParent),
 		uintptr(hWnd),
 		uintptr(parentHWnd),
 		0)
@@ -1429,8 +1686,20 @@ func SetParent(hWnd HWND, parentHWnd HWND) HWND {
 	return HWND(ret)
 }
 
+func SetTimer(hWnd HWND, nIDEvent uintptr, uElapse uint, lpTimerFunc uintptr) uintptr {
+	ret, _, _ := Syscall6(uintptr(setTimer),
+		uintptr(hWnd),
+		nIDEvent,
+		uintptr(uElapse),
+		lpTimerFunc,
+		0,
+		0)
+
+	return ret
+}
+
 func SetWindowLong(hWnd HWND, index, value int) int {
-	ret, _, _ := syscall.Syscall(uintptr(setWindowLong),
+	ret, _, _ := Syscall(uintptr(setWindowLong),
 		uintptr(hWnd),
 		uintptr(index),
 		uintptr(value))
@@ -1439,7 +1708,7 @@ func SetWindowLong(hWnd HWND, index, value int) int {
 }
 
 func SetWindowPlacement(hWnd HWND, lpwndpl *WINDOWPLACEMENT) bool {
-	ret, _, _ := syscall.Syscall(uintptr(setWindowPlacement),
+	ret, _, _ := Syscall(uintptr(setWindowPlacement),
 		uintptr(hWnd),
 		uintptr(unsafe.Pointer(lpwndpl)),
 		0)
@@ -1448,7 +1717,7 @@ func SetWindowPlacement(hWnd HWND, lpwndpl *WINDOWPLACEMENT) bool {
 }
 
 func SetWindowPos(hWnd, hWndInsertAfter HWND, x, y, width, height int, flags uint) bool {
-	ret, _, _ := syscall.Syscall9(uintptr(setWindowPos),
+	ret, _, _ := Syscall9(uintptr(setWindowPos),
 		uintptr(hWnd),
 		uintptr(hWndInsertAfter),
 		uintptr(x),
@@ -1463,7 +1732,7 @@ func SetWindowPos(hWnd, hWndInsertAfter HWND, x, y, width, height int, flags uin
 }
 
 func ShowWindow(hWnd HWND, nCmdShow int) bool {
-	ret, _, _ := syscall.Syscall(uintptr(showWindow),
+	ret, _, _ := Syscall(uintptr(showWindow),
 		uintptr(hWnd),
 		uintptr(nCmdShow),
 		0)
@@ -1472,7 +1741,7 @@ func ShowWindow(hWnd HWND, nCmdShow int) bool {
 }
 
 func SystemParametersInfo(uiAction, uiParam uint, pvParam unsafe.Pointer, fWinIni uint) bool {
-	ret, _, _ := syscall.Syscall6(uintptr(systemParametersInfo),
+	ret, _, _ := Syscall6(uintptr(systemParametersInfo),
 		uintptr(uiAction),
 		uintptr(uiParam),
 		uintptr(pvParam),
@@ -1483,8 +1752,17 @@ func SystemParametersInfo(uiAction, uiParam uint, pvParam unsafe.Pointer, fWinIn
 	return ret != 0
 }
 
+func TrackMouseEvent(lpEventTrack *TRACKMOUSEEVENT) bool {
+	ret, _, _ := Syscall(uintptr(trackMouseEvent),
+		uintptr(unsafe.Pointer(lpEventTrack)),
+		0,
+		0)
+
+	return ret != 0
+}
+
 func TrackPopupMenuEx(hMenu HMENU, fuFlags uint, x, y int, hWnd HWND, lptpm *TPMPARAMS) BOOL {
-	ret, _, _ := syscall.Syscall6(uintptr(trackPopupMenuEx),
+	ret, _, _ := Syscall6(uintptr(trackPopupMenuEx),
 		uintptr(hMenu),
 		uintptr(fuFlags),
 		uintptr(x),
@@ -1496,10 +1774,19 @@ func TrackPopupMenuEx(hMenu HMENU, fuFlags uint, x, y int, hWnd HWND, lptpm *TPM
 }
 
 func TranslateMessage(msg *MSG) bool {
-	ret, _, _ := syscall.Syscall(uintptr(translateMessage),
+	ret, _, _ := Syscall(uintptr(translateMessage),
 		uintptr(unsafe.Pointer(msg)),
 		0,
 		0)
 
 	return ret != 0
 }
+
+func WindowFromPoint(point POINT) HWND {
+	ret, _, _ := Syscall(uintptr(windowFromPoint),
+		uintptr(point.X),
+		uintptr(point.Y),
+		0)
+
+	return HWND(ret)
+}
@@ -5,7 +5,6 @@
 package uxtheme
 
 import (
-	"syscall"
 	"unsafe"
 )
 
@@ -31,7 +30,7 @@ func init() {
 }
 
 func SetWindowTheme(hwnd HWND, pszSubAppName, pszSubIdList *uint16) HRESULT {
-	ret, _, _ := syscall.Syscall(uintptr(setWindowTheme),
+	ret, _, _ := Syscall(uintptr(setWindowTheme),
 		uintptr(hwnd),
 		uintptr(unsafe.Pointer(pszSubAppName)),
 		uintptr(unsafe.Pointer(pszSubIdList)))
@@ -5,9 +5,8 @@
 package winapi
 
 import (
-	"fmt"
-	"syscall"
 	"unsafe"
+	"utf16"
 )
 
 const (
@@ -36,24 +35,6 @@ type (
 	HRESULT int32
 )
 
-func MustLoadLibrary(name string) uint32 {
-	lib, errno := syscall.LoadLibrary(name)
-	if errno != 0 {
-		panic(fmt.Sprintf(`syscall.LoadLibrary("%s") failed: %s`, name, syscall.Errstr(errno)))
-	}
-
-	return lib
-}
-
-func MustGetProcAddress(lib uint32, name string) uint32 {
-	addr, errno := syscall.GetProcAddress(lib, name)
-	if errno != 0 {
-		panic(fmt.Sprintf(`syscall.GetProcAddress(%d, "%s") failed: %s`, lib, name, syscall.Errstr(errno)))
-	}
-
-	return addr
-}
-
 func SUCCEEDED(hr HRESULT) bool {
 	return hr >= 0
 }
@@ -75,7 +56,32 @@ func HIWORD(dw uint) uint16 {
 }
 
 func UTF16PtrToString(s *uint16) string {
-	return syscall.UTF16ToString((*[1 << 30]uint16)(unsafe.Pointer(s))[0:])
+	return UTF16ToString((*[1 << 30]uint16)(unsafe.Pointer(s))[0:])
+}
+
+// StringToUTF16 returns the UTF-16 encoding of s, with a terminating NUL
+// added. Unlike its counterpart in package syscall, it is available on all
+// platforms.
+func StringToUTF16(s string) []uint16 {
+	return utf16.Encode([]int(s + "\x00"))
+}
+
+// StringToUTF16Ptr returns a pointer to the UTF-16 encoding of s, with a
+// terminating NUL added.
+func StringToUTF16Ptr(s string) *uint16 {
+	return &StringToUTF16(s)[0]
+}
+
+// UTF16ToString returns the string that s, up to the first NUL, encodes.
+func UTF16ToString(s []uint16) string {
+	for i, v := range s {
+		if v == 0 {
+			s = s[0:i]
+			break
+		}
+	}
+
+	return string(utf16.Decode(s))
 }
 
 func BoolToBOOL(value bool) BOOL {
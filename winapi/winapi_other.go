@@ -0,0 +1,43 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+// Outside of Windows there are no DLLs to load. Libraries and functions get
+// the handle 0 and calling any of them panics, so the packages importing
+// winapi can be built and initialized everywhere, as long as only the code
+// paths that do not call the Windows API are used. The gui MemoryBackend is
+// such a path.
+
+func MustLoadLibrary(name string) uint32 {
+	return 0
+}
+
+func MustGetProcAddress(lib uint32, name string) uint32 {
+	return 0
+}
+
+func notAvailable() {
+	panic("winapi: the Windows API is not available on this platform")
+}
+
+func Syscall(trap, a1, a2, a3 uintptr) (r1, r2, err uintptr) {
+	notAvailable()
+	return
+}
+
+func Syscall6(trap, a1, a2, a3, a4, a5, a6 uintptr) (r1, r2, err uintptr) {
+	notAvailable()
+	return
+}
+
+func Syscall9(trap, a1, a2, a3, a4, a5, a6, a7, a8, a9 uintptr) (r1, r2, err uintptr) {
+	notAvailable()
+	return
+}
+
+func Syscall12(trap, a1, a2, a3, a4, a5, a6, a7, a8, a9, a10, a11, a12 uintptr) (r1, r2, err uintptr) {
+	notAvailable()
+	return
+}
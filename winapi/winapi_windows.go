@@ -0,0 +1,44 @@
+// Copyright 2010 The Walk Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"fmt"
+	"syscall"
+)
+
+func MustLoadLibrary(name string) uint32 {
+	lib, errno := syscall.LoadLibrary(name)
+	if errno != 0 {
+		panic(fmt.Sprintf(`syscall.LoadLibrary("%s") failed: %s`, name, syscall.Errstr(errno)))
+	}
+
+	return lib
+}
+
+func MustGetProcAddress(lib uint32, name string) uint32 {
+	addr, errno := syscall.GetProcAddress(lib, name)
+	if errno != 0 {
+		panic(fmt.Sprintf(`syscall.GetProcAddress(%d, "%s") failed: %s`, lib, name, syscall.Errstr(errno)))
+	}
+
+	return addr
+}
+
+func Syscall(trap, a1, a2, a3 uintptr) (r1, r2, err uintptr) {
+	return syscall.Syscall(trap, a1, a2, a3)
+}
+
+func Syscall6(trap, a1, a2, a3, a4, a5, a6 uintptr) (r1, r2, err uintptr) {
+	return syscall.Syscall6(trap, a1, a2, a3, a4, a5, a6)
+}
+
+func Syscall9(trap, a1, a2, a3, a4, a5, a6, a7, a8, a9 uintptr) (r1, r2, err uintptr) {
+	return syscall.Syscall9(trap, a1, a2, a3, a4, a5, a6, a7, a8, a9)
+}
+
+func Syscall12(trap, a1, a2, a3, a4, a5, a6, a7, a8, a9, a10, a11, a12 uintptr) (r1, r2, err uintptr) {
+	return syscall.Syscall12(trap, a1, a2, a3, a4, a5, a6, a7, a8, a9, a10, a11, a12)
+}